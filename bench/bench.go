@@ -0,0 +1,302 @@
+// Package bench load-tests a running playground: many concurrent clients,
+// each with its own cookie jar (so its own visitor session), either
+// requesting a page over and over or holding an SSE stream open, reporting
+// latency percentiles and event rates.
+package bench
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	URL         string
+	Connections int           // concurrent clients (default 10)
+	Duration    time.Duration // how long to run (default 10s)
+	SSE         bool          // hold Datastar SSE streams open instead of requesting pages
+}
+
+// Result summarises a run.
+type Result struct {
+	Connections int
+	SSE         bool
+	Elapsed     time.Duration
+
+	Requests int64         // completed requests (HTML) or streams opened (SSE)
+	Errors   int64         // failed requests, including non-2xx responses
+	Statuses map[int]int64 // responses by status code
+	Latency  Stats         // HTML: full response time; SSE: time to first event
+	LastErr  error         // the most recent error, for the report
+
+	Events       int64   // SSE events received
+	EventsPerSec float64 // across all streams
+	Reconnects   int64   // streams reopened after the server closed them
+}
+
+// Stats are latency percentiles over a set of samples.
+type Stats struct {
+	Count          int
+	Min, Mean, Max time.Duration
+	P50, P90, P99  time.Duration
+}
+
+// Run drives opts.Connections clients against opts.URL until opts.Duration
+// is up or ctx is cancelled.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if opts.URL == "" {
+		return nil, errors.New("bench: no URL")
+	}
+	if opts.Connections <= 0 {
+		opts.Connections = 10
+	}
+	if opts.Duration <= 0 {
+		opts.Duration = 10 * time.Second
+	}
+	if _, err := http.NewRequest(http.MethodGet, opts.URL, nil); err != nil {
+		return nil, fmt.Errorf("bench: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: opts.Connections,
+	}
+	defer transport.CloseIdleConnections()
+
+	res := &Result{Connections: opts.Connections, SSE: opts.SSE, Statuses: map[int]int64{}}
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for range opts.Connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jar, _ := cookiejar.New(nil)
+			w := &worker{client: &http.Client{Transport: transport, Jar: jar}, url: opts.URL, statuses: map[int]int64{}}
+			if opts.SSE {
+				w.stream(ctx)
+			} else {
+				w.poll(ctx)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, w.latencies...)
+			res.Requests += w.completed
+			res.Errors += w.errors
+			res.Events += w.events
+			res.Reconnects += w.reconnects
+			for status, n := range w.statuses {
+				res.Statuses[status] += n
+			}
+			if w.lastErr != nil {
+				res.LastErr = w.lastErr
+			}
+		}()
+	}
+	wg.Wait()
+
+	res.Elapsed = time.Since(start)
+	res.Latency = summarize(latencies)
+	if secs := res.Elapsed.Seconds(); secs > 0 {
+		res.EventsPerSec = float64(res.Events) / secs
+	}
+	return res, nil
+}
+
+// worker is one simulated visitor.
+type worker struct {
+	client *http.Client
+	url    string
+
+	latencies  []time.Duration
+	completed  int64
+	errors     int64
+	events     int64
+	reconnects int64
+	statuses   map[int]int64
+	lastErr    error
+}
+
+func (w *worker) fail(err error) {
+	w.errors++
+	w.lastErr = err
+}
+
+// poll fetches the page in a loop, timing each complete response.
+func (w *worker) poll(ctx context.Context) {
+	for ctx.Err() == nil {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, w.url, nil)
+		began := time.Now()
+		resp, err := w.client.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.fail(err)
+			}
+			continue
+		}
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			if ctx.Err() == nil {
+				w.fail(err)
+			}
+			continue
+		}
+		w.completed++
+		w.statuses[resp.StatusCode]++
+		if resp.StatusCode >= 300 {
+			w.fail(fmt.Errorf("GET %s: %s", w.url, resp.Status))
+			continue
+		}
+		w.latencies = append(w.latencies, time.Since(began))
+	}
+}
+
+// stream holds a Datastar SSE stream open, counting events and reopening
+// it if the server closes it.
+func (w *worker) stream(ctx context.Context) {
+	for opened := 0; ctx.Err() == nil; opened++ {
+		if opened > 0 {
+			w.reconnects++
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, w.url, nil)
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("datastar-request", "true")
+		began := time.Now()
+		resp, err := w.client.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.fail(err)
+				pause(ctx)
+			}
+			continue
+		}
+		w.completed++
+		w.statuses[resp.StatusCode]++
+		if resp.StatusCode >= 300 {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			w.fail(fmt.Errorf("GET %s: %s", w.url, resp.Status))
+			pause(ctx)
+			continue
+		}
+
+		first := true
+		inEvent := false
+		sc := bufio.NewScanner(resp.Body)
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		for sc.Scan() {
+			if sc.Text() != "" {
+				inEvent = true
+				continue
+			}
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			w.events++
+			if first {
+				w.latencies = append(w.latencies, time.Since(began))
+				first = false
+			}
+		}
+		if err := sc.Err(); err != nil && ctx.Err() == nil {
+			w.fail(err)
+		}
+		resp.Body.Close()
+	}
+}
+
+// pause backs off briefly after a failure so a refusing server isn't
+// hammered in a tight loop.
+func pause(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func summarize(samples []time.Duration) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	slices.Sort(samples)
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	at := func(p float64) time.Duration {
+		return samples[min(int(p*float64(len(samples))), len(samples)-1)]
+	}
+	return Stats{
+		Count: len(samples),
+		Min:   samples[0],
+		Mean:  total / time.Duration(len(samples)),
+		Max:   samples[len(samples)-1],
+		P50:   at(0.50),
+		P90:   at(0.90),
+		P99:   at(0.99),
+	}
+}
+
+// Report writes a human-readable summary of r.
+func (r *Result) Report(w io.Writer) {
+	mode := "HTML"
+	if r.SSE {
+		mode = "SSE"
+	}
+	fmt.Fprintf(w, "%s, %d connections, %s\n\n", mode, r.Connections, r.Elapsed.Round(time.Millisecond))
+
+	if r.SSE {
+		fmt.Fprintf(w, "  streams opened  %d (%d reconnects)\n", r.Requests, r.Reconnects)
+		fmt.Fprintf(w, "  events          %d (%.1f/s total, %.2f/s per connection)\n", r.Events, r.EventsPerSec, r.EventsPerSec/float64(max(r.Connections, 1)))
+	} else {
+		rate := 0.0
+		if secs := r.Elapsed.Seconds(); secs > 0 {
+			rate = float64(r.Requests) / secs
+		}
+		fmt.Fprintf(w, "  requests        %d (%.1f/s)\n", r.Requests, rate)
+	}
+	for _, s := range slices.Sorted(maps.Keys(r.Statuses)) {
+		fmt.Fprintf(w, "    %d           %d\n", s, r.Statuses[s])
+	}
+	fmt.Fprintf(w, "  errors          %d\n", r.Errors)
+	if r.LastErr != nil {
+		fmt.Fprintf(w, "    last: %v\n", r.LastErr)
+	}
+
+	label := "latency"
+	if r.SSE {
+		label = "first event"
+	}
+	if l := r.Latency; l.Count > 0 {
+		fmt.Fprintf(w, "\n  %-15s min %s  mean %s  p50 %s  p90 %s  p99 %s  max %s\n", label,
+			round(l.Min), round(l.Mean), round(l.P50), round(l.P90), round(l.P99), round(l.Max))
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
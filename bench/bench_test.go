@@ -0,0 +1,88 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<h1>hello</h1>")
+	})
+	mux.HandleFunc("/missing/", http.NotFound)
+	mux.HandleFunc("/clock/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("datastar-request") == "" {
+			http.Error(w, "not a datastar request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			fmt.Fprintf(w, "event: datastar-patch-elements\ndata: elements <div id=\"n\">%d</div>\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		opts       Options
+		wantErrors bool
+	}{
+		{"html", Options{URL: srv.URL + "/page/", Connections: 4}, false},
+		{"sse", Options{URL: srv.URL + "/clock/", Connections: 4, SSE: true}, false},
+		{"not found", Options{URL: srv.URL + "/missing/", Connections: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Duration = 200 * time.Millisecond
+			res, err := Run(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Requests == 0 {
+				t.Fatalf("no requests completed: %+v", res)
+			}
+			if (res.Errors > 0) != tt.wantErrors {
+				t.Errorf("errors = %d (last %v), want errors %v", res.Errors, res.LastErr, tt.wantErrors)
+			}
+			if tt.opts.SSE {
+				if res.Events < 3*int64(tt.opts.Connections) {
+					t.Errorf("events = %d, want at least %d", res.Events, 3*tt.opts.Connections)
+				}
+				if res.Reconnects == 0 {
+					t.Errorf("streams closed by the server were not reopened")
+				}
+			}
+			if !tt.wantErrors && res.Latency.Count == 0 {
+				t.Errorf("no latency samples")
+			}
+
+			var b strings.Builder
+			res.Report(&b)
+			if !strings.Contains(b.String(), fmt.Sprintf("%d connections", tt.opts.Connections)) {
+				t.Errorf("report lacks the connection count:\n%s", b.String())
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	s := summarize(samples)
+	want := Stats{Count: 100, Min: time.Millisecond, Mean: 50500 * time.Microsecond, Max: 100 * time.Millisecond,
+		P50: 51 * time.Millisecond, P90: 91 * time.Millisecond, P99: 100 * time.Millisecond}
+	if s != want {
+		t.Errorf("summarize = %+v, want %+v", s, want)
+	}
+}
@@ -0,0 +1,193 @@
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveExt is the extension dsplay pack gives playground archives.
+const ArchiveExt = ".dsplay.zip"
+
+// MaxArchiveSize caps what an archive may unpack to, and how much of one
+// is downloaded, since archives from URLs are untrusted.
+const MaxArchiveSize = 256 << 20
+
+// Pack writes the playground in dir to out as a zip archive, returning the
+// number of files packed.
+func Pack(out, dir string, opts Options) (int, error) {
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := zipDir(f, dir, opts)
+	if err == nil && n == 0 {
+		err = fmt.Errorf("no files found in %s", dir)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(out)
+		return 0, err
+	}
+	return n, nil
+}
+
+// IsArchive reports whether name looks like a playground archive: a zip, or
+// a tar that may be gzipped.
+func IsArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractArchiveTemp unpacks the archive at src, a local path or an http(s)
+// URL, into a new temporary directory, which the caller removes when done.
+// It also returns the playground's directory within it: the archive's
+// single top-level directory when it has one, as in a repository download,
+// and otherwise the temporary directory itself.
+func ExtractArchiveTemp(ctx context.Context, src string) (tmpDir, playgroundDir string, err error) {
+	tmpDir, err = os.MkdirTemp("", "dsplay-archive-*")
+	if err != nil {
+		return "", "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpDir)
+		}
+	}()
+
+	path := src
+	if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+		path = filepath.Join(tmpDir, ".download")
+		if err := download(ctx, src, path); err != nil {
+			return "", "", err
+		}
+	}
+	root := filepath.Join(tmpDir, "playground")
+	if err := extractArchive(path, root); err != nil {
+		return "", "", fmt.Errorf("unpacking %s: %w", src, err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return tmpDir, filepath.Join(root, entries[0].Name()), nil
+	}
+	return tmpDir, root, nil
+}
+
+// download saves url to path, up to MaxArchiveSize.
+func download(ctx context.Context, url, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(resp.Body, MaxArchiveSize+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > MaxArchiveSize {
+		err = fmt.Errorf("%s is larger than %d MB", url, MaxArchiveSize>>20)
+	}
+	return err
+}
+
+// extractArchive unpacks the zip or tar archive at path into dir, telling
+// them apart by content rather than name, since URLs needn't end in one.
+func extractArchive(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if zr, err := zip.NewReader(f, info.Size()); err == nil {
+		var total uint64
+		for _, zf := range zr.File {
+			total += zf.UncompressedSize64
+		}
+		if total > MaxArchiveSize {
+			return fmt.Errorf("archive unpacks to more than %d MB", MaxArchiveSize>>20)
+		}
+		return extractZip(zr, dir)
+	}
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return extractTar(tar.NewReader(r), dir)
+}
+
+// extractTar writes the regular files in tr under dir, skipping any whose
+// path would land outside it.
+func extractTar(tr *tar.Reader, dir string) error {
+	var total int64
+	found := false
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !found {
+				return errors.New("not a zip or tar archive")
+			}
+			return err
+		}
+		found = true
+		name := filepath.FromSlash(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !filepath.IsLocal(name) {
+			continue
+		}
+		if total += hdr.Size; total > MaxArchiveSize {
+			return fmt.Errorf("archive unpacks to more than %d MB", MaxArchiveSize>>20)
+		}
+		if err := extractFile(tr, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("extracting %s: %w", hdr.Name, err)
+		}
+	}
+	if !found {
+		return errors.New("empty or unrecognised archive")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackAndServeArchive(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "playground")
+	writeFiles(t, src, map[string]string{
+		"index.html":       "<h1>Home</h1>",
+		"static/app.css":   "body{}",
+		"todos/index.html": "<ul></ul>",
+		".git/config":      "[core]",
+	})
+	out := filepath.Join(tmp, "demo"+ArchiveExt)
+	n, err := Pack(out, src, Options{Skip: func(rel string) bool { return rel == ".git" }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("packed %d files, want 3", n)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	for _, from := range []string{out, srv.URL + "/share?id=1"} {
+		tmpDir, dir, err := ExtractArchiveTemp(context.Background(), from)
+		if err != nil {
+			t.Fatalf("%s: %v", from, err)
+		}
+		defer os.RemoveAll(tmpDir)
+		if got, _ := os.ReadFile(filepath.Join(dir, "static", "app.css")); string(got) != "body{}" {
+			t.Errorf("%s: static/app.css = %q", from, got)
+		}
+	}
+}
+
+func TestExtractTarball(t *testing.T) {
+	// A repository download: one top-level directory, plus an entry
+	// trying to escape it
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"demos-main/index.html": "<h1>Home</h1>",
+		"../escape.html":        "nope",
+	} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	path := filepath.Join(t.TempDir(), "demos.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsArchive(path) {
+		t.Errorf("IsArchive(%q) = false", path)
+	}
+
+	tmpDir, dir, err := ExtractArchiveTemp(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if filepath.Base(dir) != "demos-main" {
+		t.Errorf("playground dir = %s, want the archive's top-level directory", dir)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(got) != "<h1>Home</h1>" {
+		t.Errorf("index.html = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "escape.html")); err == nil {
+		t.Error("../escape.html was extracted")
+	}
+}
@@ -0,0 +1,227 @@
+// Package bundle ships a playground inside a copy of the dsplay executable:
+// the playground's files are zipped onto the end of the binary, followed by
+// a trailer that lets the copy find them again when it runs. Executables
+// load fine with data after them, so the result needs no Go toolchain and
+// no setup to run. It also packs playgrounds into standalone zip archives,
+// and unpacks those and tarballs for serving.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/ignore"
+)
+
+// magic ends a bundled executable, after the zip's length.
+var magic = []byte("dsplay-playground")
+
+const trailerSize = 8 // big-endian zip length, before magic
+
+// ErrNoPlayground is returned for executables without a playground.
+var ErrNoPlayground = errors.New("no playground bundled")
+
+// Options controls which files are bundled, besides those the playground's
+// .dsplayignore lists, which never are.
+type Options struct {
+	Skip func(rel string) bool // leave out the file or directory at this slash-separated path
+}
+
+// Write copies the executable exe to out and appends the playground in dir,
+// returning the number of files bundled. A playground already bundled into
+// exe is replaced rather than kept.
+func Write(out, exe, dir string, opts Options) (int, error) {
+	src, err := os.Open(exe)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	binSize, _, err := payload(src)
+	if err != nil && !errors.Is(err, ErrNoPlayground) {
+		return 0, err
+	}
+
+	var zipped bytes.Buffer
+	n, err := zipDir(&zipped, dir, opts)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("no files found in %s", dir)
+	}
+
+	dst, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o755)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(dst, io.NewSectionReader(src, 0, binSize)); err != nil {
+		dst.Close()
+		return 0, err
+	}
+	trailer := binary.BigEndian.AppendUint64(nil, uint64(zipped.Len()))
+	for _, b := range [][]byte{zipped.Bytes(), trailer, magic} {
+		if _, err := dst.Write(b); err != nil {
+			dst.Close()
+			return 0, err
+		}
+	}
+	return n, dst.Close()
+}
+
+func zipDir(w io.Writer, dir string, opts Options) (int, error) {
+	ignored, err := ignore.Load(os.DirFS(dir))
+	if err != nil {
+		return 0, err
+	}
+	zw := zip.NewWriter(w)
+	n := 0
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if (opts.Skip != nil && opts.Skip(rel)) || ignored.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := zw.Create(rel)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(content); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("walking playground dir: %w", err)
+	}
+	return n, zw.Close()
+}
+
+// payload locates the bundled zip in f: the executable is binSize bytes and
+// the zip follows it. Without a playground, binSize is the file's size and
+// the error is ErrNoPlayground.
+func payload(f *os.File) (binSize, zipSize int64, err error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	size := info.Size()
+	end := int64(trailerSize + len(magic))
+	if size < end {
+		return size, 0, ErrNoPlayground
+	}
+	buf := make([]byte, end)
+	if _, err := f.ReadAt(buf, size-end); err != nil {
+		return 0, 0, err
+	}
+	if !bytes.Equal(buf[trailerSize:], magic) {
+		return size, 0, ErrNoPlayground
+	}
+	zipSize = int64(binary.BigEndian.Uint64(buf[:trailerSize]))
+	if zipSize > size-end {
+		return 0, 0, fmt.Errorf("corrupt playground bundle: %d bytes claimed, %d available", zipSize, size-end)
+	}
+	return size - end - zipSize, zipSize, nil
+}
+
+// Extract writes the playground bundled into exe under dir.
+func Extract(exe, dir string) error {
+	f, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	binSize, zipSize, err := payload(f)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(io.NewSectionReader(f, binSize, zipSize), zipSize)
+	if err != nil {
+		return fmt.Errorf("reading playground bundle: %w", err)
+	}
+	return extractZip(zr, dir)
+}
+
+// extractZip writes the files in zr under dir, skipping any whose path
+// would land outside it.
+func extractZip(zr *zip.Reader, dir string) error {
+	for _, zf := range zr.File {
+		name := filepath.FromSlash(zf.Name)
+		if !filepath.IsLocal(name) || strings.HasSuffix(zf.Name, "/") || !zf.Mode().IsRegular() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", zf.Name, err)
+		}
+		err = extractFile(rc, filepath.Join(dir, name))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", zf.Name, err)
+		}
+	}
+	return nil
+}
+
+func extractFile(r io.Reader, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ExtractTemp extracts the playground bundled into exe into a new temporary
+// directory, which the caller removes when done.
+func ExtractTemp(exe string) (string, error) {
+	f, err := os.Open(exe)
+	if err != nil {
+		return "", err
+	}
+	_, _, err = payload(f)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dsplay-bundle-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	if err := Extract(exe, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	return tmpDir, nil
+}
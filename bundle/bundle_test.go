@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tmp := t.TempDir()
+	exe := filepath.Join(tmp, "dsplay")
+	binary := []byte("\x7fELF not really a binary")
+	if err := os.WriteFile(exe, binary, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(tmp, "playground")
+	writeFiles(t, src, map[string]string{
+		"index.html":       "<h1>Home</h1>",
+		"todos/index.html": "<ul></ul>",
+		"dsplay.yaml":      "secret: hunter2",
+		".git/HEAD":        "ref: refs/heads/main",
+	})
+
+	if _, err := ExtractTemp(exe); !errors.Is(err, ErrNoPlayground) {
+		t.Fatalf("ExtractTemp(plain binary) = %v, want ErrNoPlayground", err)
+	}
+
+	skip := Options{Skip: func(rel string) bool { return rel == ".git" || rel == "dsplay.yaml" }}
+	out := filepath.Join(tmp, "demo")
+	n, err := Write(out, exe, src, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("bundled %d files, want 2", n)
+	}
+
+	// Rebundling a bundled binary replaces its playground
+	writeFiles(t, src, map[string]string{"index.html": "<h1>Updated</h1>"})
+	again := filepath.Join(tmp, "demo2")
+	if _, err := Write(again, out, src, skip); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, binary) || bytes.Count(data, magic) != 1 {
+		t.Errorf("rebundled binary should be the original plus one playground")
+	}
+
+	dir, err := ExtractTemp(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, want := range map[string]string{"index.html": "<h1>Updated</h1>", "todos/index.html": "<ul></ul>"} {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, name := range []string{"dsplay.yaml", ".git/HEAD"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s was bundled despite Skip", name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package docker writes the files that package a playground as a container
+// image: a multi-stage Dockerfile that builds dsplay from source and copies
+// the playground in, and a .dockerignore to go with it.
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Repo is where the build stage fetches dsplay from.
+const Repo = "https://github.com/dataSPA/dataSPA-playground"
+
+// Options controls the generated files.
+type Options struct {
+	Version       string // dsplay git branch or tag to build (default "main")
+	IncludeConfig bool   // copy dsplay.yaml into the image (it may hold credentials)
+	Force         bool   // overwrite existing files
+}
+
+const dockerfile = `# Generated by dsplay dockerize. Build and run with:
+#
+#   docker build -t my-playground .
+#   docker run -p 8080:8080 -e DSPLAY_SECRET=change-me my-playground
+#
+# The server reads PORT (set by Fly, Render, Cloud Run and others) or
+# DSPLAY_PORT, and its session secret from DSPLAY_SECRET.
+
+FROM golang:1-alpine AS build
+RUN apk add --no-cache git
+ARG DSPLAY_VERSION=%s
+RUN git clone --depth 1 --branch "$DSPLAY_VERSION" %s /src
+WORKDIR /src
+RUN CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o /dsplay .
+
+FROM gcr.io/distroless/static-debian12:nonroot
+COPY --from=build /dsplay /usr/local/bin/dsplay
+COPY . /playground
+ENV PORT=8080
+EXPOSE 8080
+ENTRYPOINT ["/usr/local/bin/dsplay", "--addr", "0.0.0.0"]
+CMD ["serve", "/playground"]
+`
+
+// Files returns the generated files by name.
+func Files(opts Options) map[string]string {
+	version := opts.Version
+	if version == "" {
+		version = "main"
+	}
+	ignore := []string{".git", "Dockerfile", ".dockerignore", "_tests"}
+	if !opts.IncludeConfig {
+		ignore = append(ignore, "dsplay.yaml")
+	}
+	return map[string]string{
+		"Dockerfile":    fmt.Sprintf(dockerfile, version, Repo),
+		".dockerignore": strings.Join(ignore, "\n") + "\n",
+	}
+}
+
+// Write writes the generated files into dir, returning their names. Existing
+// files are kept unless opts.Force is set.
+func Write(dir string, opts Options) ([]string, error) {
+	files := Files(opts)
+	names := []string{"Dockerfile", ".dockerignore"}
+	if !opts.Force {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --force to override)", name)
+			}
+		}
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(files[name]), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
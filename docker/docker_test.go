@@ -0,0 +1,38 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	files := Files(Options{Version: "v1.2.0"})
+	if !strings.Contains(files["Dockerfile"], "ARG DSPLAY_VERSION=v1.2.0") {
+		t.Errorf("Dockerfile doesn't pin the version:\n%s", files["Dockerfile"])
+	}
+	if !strings.Contains(files[".dockerignore"], "dsplay.yaml\n") {
+		t.Errorf(".dockerignore should leave out dsplay.yaml by default")
+	}
+	if strings.Contains(Files(Options{IncludeConfig: true})[".dockerignore"], "dsplay.yaml") {
+		t.Errorf(".dockerignore should keep dsplay.yaml with IncludeConfig")
+	}
+}
+
+func TestWriteKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(dir, Options{}); err == nil {
+		t.Fatal("Write overwrote an existing Dockerfile without Force")
+	}
+	if _, err := Write(dir, Options{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	if !strings.Contains(string(data), "dsplay dockerize") {
+		t.Errorf("Force didn't replace the Dockerfile")
+	}
+}
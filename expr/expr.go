@@ -0,0 +1,109 @@
+// Package expr compiles the expressions used in playground frontmatter
+// (section when: conditions and computed vars) with expr-lang
+// (github.com/expr-lang/expr). They are evaluated against an environment of
+// named values:
+//
+//	signals.email == "" || !(signals.email contains "@")
+//	signals.price * signals.qty
+//	len(signals.items) > 3 and method == "POST"
+//
+// On top of the expr-lang language, names missing from the environment are
+// nil, the operands of ! && || (not and or) may be any value and count by
+// Truthy, and number() converts a string such as a form field to a float.
+package expr
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Program is a compiled expression, safe for concurrent evaluation.
+type Program struct {
+	source string
+	prog   *vm.Program
+}
+
+// truthyFunc is the function logical operands are wrapped in.
+const truthyFunc = "truthy"
+
+var options = []expr.Option{
+	expr.AllowUndefinedVariables(),
+	expr.Function(truthyFunc, func(args ...any) (any, error) {
+		return Truthy(args[0]), nil
+	}, new(func(any) bool)),
+	expr.Function("number", func(args ...any) (any, error) {
+		f, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("number: cannot convert %v", args[0])
+		}
+		return f, nil
+	}, new(func(any) float64)),
+	expr.Patch(logicPatch{}),
+}
+
+// Compile parses and type-checks an expression.
+func Compile(source string) (*Program, error) {
+	prog, err := expr.Compile(source, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", source, err)
+	}
+	return &Program{source: source, prog: prog}, nil
+}
+
+// Eval evaluates the program against env.
+func (p *Program) Eval(env map[string]any) (any, error) {
+	v, err := expr.Run(p.prog, env)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", p.source, err)
+	}
+	return v, nil
+}
+
+// String returns the source the program was compiled from.
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval compiles and evaluates source in one step.
+func Eval(source string, env map[string]any) (any, error) {
+	p, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(env)
+}
+
+// EvalBool compiles and evaluates source, reporting whether the result is truthy.
+func EvalBool(source string, env map[string]any) (bool, error) {
+	v, err := Eval(source, env)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+// logicPatch wraps the operands of the logical operators in truthy(), so
+// !signals.agree holds for a signal that was never sent rather than failing
+// on nil.
+type logicPatch struct{}
+
+func (logicPatch) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.UnaryNode:
+		if n.Operator == "!" || n.Operator == "not" {
+			n.Node = truthy(n.Node)
+		}
+	case *ast.BinaryNode:
+		switch n.Operator {
+		case "&&", "||", "and", "or":
+			n.Left, n.Right = truthy(n.Left), truthy(n.Right)
+		}
+	}
+}
+
+func truthy(n ast.Node) ast.Node {
+	return &ast.CallNode{Callee: &ast.IdentifierNode{Value: truthyFunc}, Arguments: []ast.Node{n}}
+}
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := map[string]any{
+		"signals": map[string]any{
+			"email": "ada@example.com",
+			"price": 2.5,
+			"qty":   4.0,
+			"tags":  []any{"a", "b"},
+		},
+		"form":   map[string]any{"qty": "4"},
+		"method": "POST",
+		"user":   struct{ Login string }{Login: "ada"},
+	}
+
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`1 + 2 * 3`, 7},
+		{`-4 % 3`, -1},
+		{`signals.price * signals.qty`, 10.0},
+		{`signals.email == "ada@example.com"`, true},
+		{`signals.missing == nil`, true},
+		{`!signals.missing`, true},
+		{`not signals.tags`, false},
+		{`signals.email && method`, true},
+		{`signals.missing || "fallback"`, true},
+		{`signals.email contains "@" && method == "POST"`, true},
+		{`len(signals.tags) > 1 and "b" in signals.tags`, true},
+		{`signals.tags[0]`, "a"},
+		{`user.Login`, "ada"},
+		{`signals.email matches "^[a-z]+@"`, true},
+		{`number(form.qty) == signals.qty`, true},
+		{`form.qty == 4`, false},
+		{`{"total": signals.price * 2}.total`, 5.0},
+		{`upper(trim(" a "))`, "A"},
+		{`undefinedName`, nil},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.src, env)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", tt.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Eval(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{`1 +`, `(1`, `"open`, `f(1,`, `1 2`, `{"a" 1}`, `{"a": 1`, `number()`} {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("Compile(%q) succeeded, want error", src)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, src := range []string{`1 % 0`, `"a" * 2`, `nope(1)`, `number("x")`} {
+		if _, err := Eval(src, nil); err == nil {
+			t.Errorf("Eval(%q) succeeded, want error", src)
+		}
+	}
+}
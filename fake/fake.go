@@ -0,0 +1,119 @@
+// Package fake generates plausible placeholder content — names, emails,
+// sentences, prices — for playground demos, on top of gofakeit. A Faker
+// seeded with the same value always produces the same sequence.
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v6"
+)
+
+// Faker produces fake values from its own random source.
+type Faker struct {
+	gf *gofakeit.Faker
+}
+
+// New returns a Faker with a random seed.
+func New() *Faker {
+	return &Faker{gf: gofakeit.New(0)}
+}
+
+// Seeded returns a Faker whose output is determined by seed.
+func Seeded(seed string) *Faker {
+	f := &Faker{}
+	f.Seed(seed)
+	return f
+}
+
+// Seed restarts the Faker's sequence from seed.
+func (f *Faker) Seed(seed string) {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	// gofakeit treats 0 as "pick a random seed"
+	f.gf = gofakeit.New(int64(h.Sum64() | 1))
+}
+
+// FirstName returns a given name.
+func (f *Faker) FirstName() string { return f.gf.FirstName() }
+
+// LastName returns a family name.
+func (f *Faker) LastName() string { return f.gf.LastName() }
+
+// Name returns a full name.
+func (f *Faker) Name() string { return f.FirstName() + " " + f.LastName() }
+
+// Username returns a handle like "ada.lovelace42".
+func (f *Faker) Username() string {
+	return fmt.Sprintf("%s.%s%d", strings.ToLower(f.FirstName()), strings.ToLower(f.LastName()), f.gf.IntRange(0, 99))
+}
+
+// Email returns an address at a reserved example domain, so demos never
+// show a real mailbox.
+func (f *Faker) Email() string {
+	return f.Username() + "@" + f.gf.RandomString(domains)
+}
+
+// Company returns a company name.
+func (f *Faker) Company() string { return f.gf.Company() }
+
+// City returns a city name.
+func (f *Faker) City() string { return f.gf.City() }
+
+// Phone returns a number in the fictional 555-01xx range. gofakeit's own
+// numbers can belong to real people.
+func (f *Faker) Phone() string {
+	return fmt.Sprintf("(%03d) 555-01%02d", f.gf.IntRange(200, 999), f.gf.IntRange(0, 99))
+}
+
+// Word returns a single lorem ipsum word.
+func (f *Faker) Word() string { return f.gf.LoremIpsumWord() }
+
+// Sentence returns a capitalized lorem ipsum sentence of n words (default
+// 6–12 when n <= 0).
+func (f *Faker) Sentence(n int) string {
+	if n <= 0 {
+		n = f.gf.IntRange(6, 12)
+	}
+	return f.gf.LoremIpsumSentence(n)
+}
+
+// Paragraph returns n sentences (default 3–5 when n <= 0).
+func (f *Faker) Paragraph(n int) string {
+	if n <= 0 {
+		n = f.gf.IntRange(3, 5)
+	}
+	ss := make([]string, n)
+	for i := range ss {
+		ss[i] = f.Sentence(0)
+	}
+	return strings.Join(ss, " ")
+}
+
+// Int returns a number in [min, max].
+func (f *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return f.gf.IntRange(min, max)
+}
+
+// Price returns an amount in [min, max] formatted with two decimals, ending
+// in .99 or .49 most of the time like real price tags.
+func (f *Faker) Price(min, max float64) string {
+	if max <= min {
+		return fmt.Sprintf("%.2f", min)
+	}
+	p := f.gf.Price(min, max)
+	if whole := float64(int(p)); f.gf.IntRange(0, 3) > 0 && whole+0.99 <= max && whole+0.49 >= min {
+		if f.gf.Bool() {
+			return fmt.Sprintf("%.2f", whole+0.99)
+		}
+		return fmt.Sprintf("%.2f", whole+0.49)
+	}
+	return fmt.Sprintf("%.2f", p)
+}
+
+var domains = []string{"example.com", "example.org", "example.net"}
@@ -0,0 +1,79 @@
+package fake
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSeededIsReproducible(t *testing.T) {
+	a, b := Seeded("s-123"), Seeded("s-123")
+	for range 20 {
+		if x, y := a.Name(), b.Name(); x != y {
+			t.Fatalf("Name() = %q and %q for the same seed", x, y)
+		}
+		if x, y := a.Sentence(0), b.Sentence(0); x != y {
+			t.Fatalf("Sentence() = %q and %q for the same seed", x, y)
+		}
+	}
+}
+
+func TestSeedRestartsSequence(t *testing.T) {
+	f := Seeded("demo")
+	first := f.Email()
+	f.Email()
+	f.Seed("demo")
+	if got := f.Email(); got != first {
+		t.Errorf("after Seed, Email() = %q, want %q", got, first)
+	}
+}
+
+func TestSentence(t *testing.T) {
+	f := Seeded("x")
+	s := f.Sentence(5)
+	if n := len(strings.Fields(s)); n != 5 {
+		t.Errorf("Sentence(5) has %d words: %q", n, s)
+	}
+	if !strings.HasSuffix(s, ".") || strings.ToUpper(s[:1]) != s[:1] {
+		t.Errorf("Sentence(5) = %q, want capitalized with a period", s)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	f := New()
+	for range 50 {
+		e := f.Email()
+		if !strings.Contains(e, "@example.") {
+			t.Fatalf("Email() = %q, want an example domain", e)
+		}
+	}
+}
+
+func TestPriceInRange(t *testing.T) {
+	f := Seeded("prices")
+	for range 200 {
+		s := f.Price(5, 50)
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			t.Fatalf("Price() = %q: %v", s, err)
+		}
+		if p < 5 || p > 50 {
+			t.Fatalf("Price(5, 50) = %s, out of range", s)
+		}
+		if i := strings.IndexByte(s, '.'); i < 0 || len(s)-i != 3 {
+			t.Fatalf("Price() = %q, want two decimals", s)
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	f := Seeded("ints")
+	for range 200 {
+		if n := f.Int(3, 7); n < 3 || n > 7 {
+			t.Fatalf("Int(3, 7) = %d", n)
+		}
+	}
+	if n := f.Int(4, 4); n != 4 {
+		t.Errorf("Int(4, 4) = %d", n)
+	}
+}
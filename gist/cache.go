@@ -0,0 +1,67 @@
+package gist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// cacheEntry is a fetched gist kept on disk, so the next load can ask
+// GitHub whether it changed instead of downloading it again. Conditional
+// requests answered with 304 Not Modified don't count against the API
+// rate limit.
+type cacheEntry struct {
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	Revision     string            `json:"revision,omitempty"` // when the gist was last updated
+	Files        map[string]string `json:"files"`              // relative path → content
+}
+
+// cacheablePattern matches cache keys safe to use as file names: a gist ID,
+// with @revision for a pinned version.
+var cacheablePattern = regexp.MustCompile(`^[0-9A-Za-z]+(@[0-9a-f]+)?$`)
+
+func (c *Client) cacheFile(key string) string {
+	if c.cacheDir == "" || !cacheablePattern.MatchString(key) {
+		return ""
+	}
+	return filepath.Join(c.cacheDir, key+".json")
+}
+
+// cached returns the cached copy of a gist, or nil.
+func (c *Client) cached(key string) *cacheEntry {
+	file := c.cacheFile(key)
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil || e.Files == nil {
+		return nil
+	}
+	return &e
+}
+
+// store saves a gist to the cache. Failures only cost a refetch, so they
+// are ignored.
+func (c *Client) store(key string, e *cacheEntry) {
+	file := c.cacheFile(key)
+	if file == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return
+	}
+	tmp := file + ".tmp"
+	if os.WriteFile(tmp, data, 0o644) == nil {
+		os.Rename(tmp, file)
+	}
+}
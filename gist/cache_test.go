@@ -0,0 +1,48 @@
+package gist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestLoadPlaygroundRevalidates(t *testing.T) {
+	fetches, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gists/abc123" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"abc123","files":{"home__index.html":{"content":"<h1>Hi</h1>"}}}`))
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	c := &Client{gh: gh, cacheDir: t.TempDir()}
+
+	for i := range 2 {
+		files, err := c.LoadPlayground(context.Background(), "abc123", "")
+		if err != nil {
+			t.Fatalf("load %d: %v", i+1, err)
+		}
+		if files["home/index.html"] != "<h1>Hi</h1>" {
+			t.Errorf("load %d: files = %v", i+1, files)
+		}
+	}
+	if fetches != 1 || notModified != 1 {
+		t.Errorf("got %d full fetches and %d 304s, want 1 of each", fetches, notModified)
+	}
+}
@@ -1,7 +1,10 @@
 package gist
 
 import (
-	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/google/go-github/v68/github"
 	githubauth "github.com/jferrl/go-githubauth"
@@ -10,21 +13,55 @@ import (
 
 // Client wraps a GitHub API client for gist operations.
 type Client struct {
-	gh    *github.Client
-	token string // raw token, used for authenticated git clone URLs
+	gh       *github.Client
+	tokens   oauth2.TokenSource // nil when unauthenticated; also used for authenticated git clone URLs
+	cacheDir string             // where fetched gists are kept for conditional requests ("" = no cache)
 }
 
 // NewClient creates a new gist Client. If token is empty, the client is
 // unauthenticated (only public gist reads will work).
 func NewClient(token string) *Client {
 	if token == "" {
-		return &Client{gh: github.NewClient(nil)}
+		return newClient(nil)
 	}
+	return newClient(githubauth.NewPersonalAccessTokenSource(token))
+}
+
+// AppAuth identifies a GitHub App installation to act as.
+type AppAuth struct {
+	AppID          string // the app's client ID, or its numeric app ID
+	InstallationID int64
+	PrivateKey     []byte // the app's private key, PEM encoded
+}
 
-	tokenSource := githubauth.NewPersonalAccessTokenSource(token)
-	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+// NewAppClient creates a gist Client authenticated as a GitHub App
+// installation, so a shared server needn't hold a long-lived personal
+// token. Installation tokens last an hour and are renewed when they run
+// out. GitHub doesn't let apps own gists, so the client reads public gists
+// under the app's rate limit but can't read secret gists or share.
+func NewAppClient(app AppAuth) (*Client, error) {
+	if app.InstallationID == 0 {
+		return nil, fmt.Errorf("github app installation ID is required")
+	}
+	jwt, err := githubauth.NewApplicationTokenSource(app.AppID, app.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("github app key: %w", err)
+	}
+	return newClient(githubauth.NewInstallationTokenSource(app.InstallationID, jwt)), nil
+}
+
+func newClient(tokens oauth2.TokenSource) *Client {
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "dsplay", "gists")
+	}
+	var transport http.RoundTripper = &retryTransport{base: http.DefaultTransport}
+	if tokens != nil {
+		transport = &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, tokens), Base: transport}
+	}
 	return &Client{
-		gh:    github.NewClient(httpClient),
-		token: token,
+		gh:       github.NewClient(&http.Client{Transport: transport}),
+		tokens:   tokens,
+		cacheDir: cacheDir,
 	}
 }
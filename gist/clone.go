@@ -1,87 +1,183 @@
 package gist
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v68/github"
 )
 
-// ClonePlayground clones a gist's git repo to destDir, then expands the
-// flat __ -encoded filenames back into a proper directory structure.
-func (c *Client) ClonePlayground(ctx context.Context, gistID string, destDir string) error {
-	// Fetch gist to get the clone URL
-	g, _, err := c.gh.Gists.Get(ctx, gistID)
-	if err != nil {
-		return fmt.Errorf("fetching gist %s: %w", gistID, err)
+// CloneOptions controls what ClonePlayground does with files already in
+// the destination. Files there that the gist doesn't have are left alone.
+type CloneOptions struct {
+	Merge bool // skip files identical to the gist's instead of refusing
+	Force bool // overwrite files that differ from the gist's (implies Merge)
+}
+
+// CloneReport lists the files a clone wrote or left, by slash-separated
+// path.
+type CloneReport struct {
+	Created []string
+	Updated []string
+	Skipped []string // already identical
+}
+
+// ConflictError is returned when files in the destination would be
+// overwritten. Nothing is written.
+type ConflictError struct {
+	Existing  []string // identical to the gist's, refused without Merge
+	Differing []string // refused without Force
+}
+
+func (e *ConflictError) Error() string {
+	var parts []string
+	if len(e.Differing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d files differ from the gist's (%s)", len(e.Differing), strings.Join(e.Differing, ", ")))
 	}
+	if len(e.Existing) > 0 {
+		parts = append(parts, fmt.Sprintf("%d files already exist (%s)", len(e.Existing), strings.Join(e.Existing, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
 
-	cloneURL := g.GetGitPullURL()
-	if cloneURL == "" {
-		return fmt.Errorf("gist %s has no git pull URL", gistID)
+// ClonePlayground clones a gist's git repo to destDir, checked out at
+// revision unless it is "", then expands the flat __ -encoded filenames
+// back into a proper directory structure. Every file is checked against
+// destDir before anything is written, so a clone that conflicts with
+// existing files leaves destDir untouched.
+func (c *Client) ClonePlayground(ctx context.Context, gistID, revision, destDir string, opts CloneOptions) (*CloneReport, error) {
+	if revision != "" && !ValidRevision(revision) {
+		return nil, fmt.Errorf("gist %s: %q is not a revision SHA", gistID, revision)
 	}
 
-	// Embed token for authenticated access to secret gists
-	if c.token != "" {
-		cloneURL = injectTokenInURL(cloneURL, c.token)
+	// Fetch gist to get the clone URL
+	g, _, err := c.gh.Gists.Get(ctx, gistID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching gist %s: %w", gistID, withRate(err))
 	}
 
 	// Clone into a temp dir first, then reorganize into destDir
-	tmpClone, err := os.MkdirTemp("", "ds-play-clone-*")
+	tmpClone, err := c.gitClone(ctx, g, revision)
 	if err != nil {
-		return fmt.Errorf("creating temp clone dir: %w", err)
+		return nil, err
 	}
 	defer os.RemoveAll(tmpClone)
 
-	cmd := exec.CommandContext(ctx, "git", "clone", cloneURL, tmpClone)
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git clone: %w", err)
-	}
-
 	// Walk the cloned files, decode paths, write to destDir
 	entries, err := os.ReadDir(tmpClone)
 	if err != nil {
-		return fmt.Errorf("reading cloned dir: %w", err)
-	}
-
-	if err := os.MkdirAll(destDir, 0o755); err != nil {
-		return fmt.Errorf("creating dest dir: %w", err)
+		return nil, fmt.Errorf("reading cloned dir: %w", err)
 	}
-
+	files := make(map[string][]byte)
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue // skip .git etc.
 		}
-
-		name := entry.Name()
-		relPath := DecodePath(name)
-
-		srcPath := filepath.Join(tmpClone, name)
-		dstPath := filepath.FromSlash(relPath)
-		dstRoot, err := os.OpenRoot(destDir)
+		content, err := os.ReadFile(filepath.Join(tmpClone, entry.Name()))
 		if err != nil {
-			return fmt.Errorf("opening root for %s: %w", relPath, err)
+			return nil, fmt.Errorf("reading %s: %w", entry.Name(), err)
+		}
+		relPath := DecodePath(entry.Name())
+		if err := ValidatePath(relPath); err != nil {
+			return nil, fmt.Errorf("gist %s: file %q: %w", gistID, entry.Name(), err)
 		}
-		defer dstRoot.Close()
+		files[relPath] = content
+	}
 
-		content, err := os.ReadFile(srcPath)
-		if err != nil {
-			return fmt.Errorf("reading %s: %w", name, err)
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating dest dir: %w", err)
+	}
+	dstRoot, err := os.OpenRoot(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", destDir, err)
+	}
+	defer dstRoot.Close()
+
+	// Plan every write first, so conflicts are all reported at once
+	report := &CloneReport{}
+	conflict := &ConflictError{}
+	for _, relPath := range slices.Sorted(maps.Keys(files)) {
+		existing, err := dstRoot.ReadFile(filepath.FromSlash(relPath))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			report.Created = append(report.Created, relPath)
+		case err != nil:
+			return nil, fmt.Errorf("reading %s: %w", relPath, err)
+		case bytes.Equal(existing, files[relPath]):
+			if !opts.Merge && !opts.Force {
+				conflict.Existing = append(conflict.Existing, relPath)
+			}
+			report.Skipped = append(report.Skipped, relPath)
+		case opts.Force:
+			report.Updated = append(report.Updated, relPath)
+		default:
+			conflict.Differing = append(conflict.Differing, relPath)
 		}
+	}
+	if len(conflict.Existing) > 0 || len(conflict.Differing) > 0 {
+		return nil, conflict
+	}
 
+	for _, relPath := range slices.Concat(report.Created, report.Updated) {
+		dstPath := filepath.FromSlash(relPath)
 		if err := dstRoot.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
-			return fmt.Errorf("creating dir for %s: %w", relPath, err)
+			return report, fmt.Errorf("creating dir for %s: %w", relPath, err)
+		}
+		if err := dstRoot.WriteFile(dstPath, files[relPath], 0o644); err != nil {
+			return report, fmt.Errorf("writing %s: %w", relPath, err)
 		}
+	}
+	return report, nil
+}
 
-		if err := dstRoot.WriteFile(dstPath, content, 0o644); err != nil {
-			return fmt.Errorf("writing %s: %w", relPath, err)
+// gitClone clones a gist's git repo into a new temporary directory,
+// checked out at revision unless it is "". The caller removes the directory.
+func (c *Client) gitClone(ctx context.Context, g *github.Gist, revision string) (string, error) {
+	cloneURL := g.GetGitPullURL()
+	if cloneURL == "" {
+		return "", fmt.Errorf("gist %s has no git pull URL", g.GetID())
+	}
+
+	// Embed token for authenticated access to secret gists
+	if c.tokens != nil {
+		tok, err := c.tokens.Token()
+		if err != nil {
+			return "", fmt.Errorf("github token: %w", err)
 		}
+		cloneURL = injectTokenInURL(cloneURL, tok.AccessToken)
+	}
+
+	tmpClone, err := os.MkdirTemp("", "ds-play-clone-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp clone dir: %w", err)
 	}
 
-	return nil
+	cmd := exec.CommandContext(ctx, "git", "clone", cloneURL, tmpClone)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(tmpClone)
+		return "", fmt.Errorf("git clone: %w", err)
+	}
+	if revision != "" {
+		cmd := exec.CommandContext(ctx, "git", "-C", tmpClone, "checkout", "--quiet", revision)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			os.RemoveAll(tmpClone)
+			return "", fmt.Errorf("git checkout %s: %w", revision, err)
+		}
+	}
+	return tmpClone, nil
 }
 
 // injectTokenInURL rewrites an HTTPS URL to include an oauth2 token for
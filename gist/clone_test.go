@@ -0,0 +1,91 @@
+package gist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// fakeGistRepo serves a gist whose git pull URL is a local repository
+// holding files.
+func fakeGistRepo(t *testing.T, files map[string]string) *Client {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "gist"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"abc123","git_pull_url":%q}`, repo)
+	}))
+	t.Cleanup(srv.Close)
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	return &Client{gh: gh}
+}
+
+func TestCloneIntoExistingDir(t *testing.T) {
+	c := fakeGistRepo(t, map[string]string{
+		"index.html":        "<h1>Home</h1>",
+		"todos__index.html": "<ul></ul>",
+		"chat__index.html":  "<div></div>",
+	})
+	ctx := context.Background()
+	dest := t.TempDir()
+	writeFile(dest, "index.html", "<h1>Home</h1>")
+	writeFile(dest, "todos/index.html", "<ul>mine</ul>")
+	writeFile(dest, "notes.txt", "keep me")
+
+	_, err := c.ClonePlayground(ctx, "abc123", "", dest, CloneOptions{})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("clone into a non-empty dir = %v, want a ConflictError", err)
+	}
+	if !slices.Equal(conflict.Existing, []string{"index.html"}) || !slices.Equal(conflict.Differing, []string{"todos/index.html"}) {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "chat", "index.html")); err == nil {
+		t.Error("a refused clone wrote files")
+	}
+
+	if _, err := c.ClonePlayground(ctx, "abc123", "", dest, CloneOptions{Merge: true}); !errors.As(err, &conflict) || len(conflict.Existing) != 0 {
+		t.Fatalf("merge with a differing file = %v, want a ConflictError for it alone", err)
+	}
+
+	report, err := c.ClonePlayground(ctx, "abc123", "", dest, CloneOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CloneReport{Created: []string{"chat/index.html"}, Updated: []string{"todos/index.html"}, Skipped: []string{"index.html"}}
+	if fmt.Sprint(*report) != fmt.Sprint(want) {
+		t.Errorf("report = %+v, want %+v", *report, want)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dest, "notes.txt")); string(got) != "keep me" {
+		t.Error("clone touched a file the gist doesn't have")
+	}
+}
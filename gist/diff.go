@@ -0,0 +1,249 @@
+package gist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// OriginFile records, in a playground directory, the gist it was shared to
+// or cloned from, so dsplay diff knows what to compare with. It is one of
+// LocalOnlyFiles.
+const OriginFile = ".dsplay-gist"
+
+// SaveOrigin records gistURL as the origin of the playground in dir.
+func SaveOrigin(dir, gistURL string) error {
+	return os.WriteFile(filepath.Join(dir, OriginFile), []byte(gistURL+"\n"), 0o644)
+}
+
+// LoadOrigin returns the gist recorded as the origin of the playground in
+// dir, or "" when there isn't one.
+func LoadOrigin(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, OriginFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+// Change statuses of a FileDiff, from the local playground's side.
+const (
+	OnlyInGist = "added"   // pulling would add the file
+	OnlyLocal  = "removed" // the gist doesn't have it
+	Changed    = "changed"
+)
+
+// FileDiff is one file that differs between a local playground and a gist.
+type FileDiff struct {
+	Path    string
+	Status  string // OnlyInGist, OnlyLocal or Changed
+	Unified string // unified diff from the local file to the gist's
+}
+
+// Diff compares the playground in dir with a gist, at revision unless it
+// is "", and returns the files that differ, sorted by path. Local files
+// that are never shared (see ListFiles) are left out.
+func (c *Client) Diff(ctx context.Context, gistID, revision, dir string) ([]FileDiff, error) {
+	remote, err := c.LoadPlayground(ctx, gistID, revision)
+	if err != nil {
+		return nil, err
+	}
+	list, err := ListFiles(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	local := make(map[string]string, len(list))
+	for _, f := range list {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, err
+		}
+		local[f.Path] = string(data)
+	}
+
+	paths := slices.Sorted(maps.Keys(local))
+	for p := range remote {
+		if _, ok := local[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	var diffs []FileDiff
+	for _, p := range paths {
+		l, inLocal := local[p]
+		r, inRemote := remote[p]
+		switch {
+		case !inLocal:
+			diffs = append(diffs, FileDiff{p, OnlyInGist, unifiedDiff("/dev/null", "b/"+p, "", r)})
+		case !inRemote:
+			diffs = append(diffs, FileDiff{p, OnlyLocal, unifiedDiff("a/"+p, "/dev/null", l, "")})
+		case l != r:
+			diffs = append(diffs, FileDiff{p, Changed, unifiedDiff("a/"+p, "b/"+p, l, r)})
+		}
+	}
+	return diffs, nil
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the line-matching table; changes between files too
+// big for it are shown as a whole-file replacement.
+const maxDiffCells = 1 << 24
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff returns a unified diff turning a into b, under the names
+// aName and bName, or "" when they are equal.
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for start := 0; start < len(lines); {
+		// Find the next change and extend the hunk while changes are
+		// close enough for their context to touch
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(lines))
+
+		aLine, bLine := 1, 1
+		for _, l := range lines[:from] {
+			if l.op != '+' {
+				aLine++
+			}
+			if l.op != '-' {
+				bLine++
+			}
+		}
+		var aCount, bCount int
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, l := range lines[from:to] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange formats one side of a hunk header. An empty range names the
+// line before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits s after each newline, keeping them.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns an edit script turning a into b, found by longest
+// common subsequence after trimming the lines both start and end with.
+func diffLines(a, b []string) []diffLine {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, l := range a[:prefix] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(am)+1)*(len(bm)+1) > maxDiffCells {
+		for _, l := range am {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range bm {
+			lines = append(lines, diffLine{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of
+		// am[i:] and bm[j:]
+		w := len(bm) + 1
+		lcs := make([]int32, (len(am)+1)*w)
+		for i := len(am) - 1; i >= 0; i-- {
+			for j := len(bm) - 1; j >= 0; j-- {
+				if am[i] == bm[j] {
+					lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+				} else {
+					lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(am) || j < len(bm) {
+			switch {
+			case i < len(am) && j < len(bm) && am[i] == bm[j]:
+				lines = append(lines, diffLine{' ', am[i]})
+				i++
+				j++
+			case j == len(bm) || (i < len(am) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+				lines = append(lines, diffLine{'-', am[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', bm[j]})
+				j++
+			}
+		}
+	}
+	for _, l := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', l})
+	}
+	return lines
+}
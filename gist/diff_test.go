@@ -0,0 +1,81 @@
+package gist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11"
+	want := `--- a/x
++++ b/x
+@@ -2,9 +2,10 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+ 9
+ 10
++11
+\ No newline at end of file
+`
+	if got := unifiedDiff("a/x", "b/x", a, b); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("a/x", "b/x", a, a); got != "" {
+		t.Errorf("unifiedDiff of equal files = %q, want none", got)
+	}
+	if got, want := unifiedDiff("/dev/null", "b/x", "", "hi\n"), "--- /dev/null\n+++ b/x\n@@ -0,0 +1 @@\n+hi\n"; got != want {
+		t.Errorf("unifiedDiff of a new file = %q, want %q", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"abc123","files":{"index.html":{"content":"<h1>v2</h1>\n"},"same.html":{"content":"="},"todos__index.html":{"content":"new"}}}`))
+	}))
+	defer srv.Close()
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	c := &Client{gh: gh}
+
+	dir := t.TempDir()
+	for rel, content := range map[string]string{
+		"index.html": "<h1>v1</h1>\n",
+		"same.html":  "=",
+		"local.html": "mine",
+		OriginFile:   "abc123\n",
+	} {
+		if err := writeFile(dir, rel, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diffs, err := c.Diff(context.Background(), "abc123", "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileDiff{
+		{"index.html", Changed, "--- a/index.html\n+++ b/index.html\n@@ -1 +1 @@\n-<h1>v1</h1>\n+<h1>v2</h1>\n"},
+		{"local.html", OnlyLocal, "--- a/local.html\n+++ /dev/null\n@@ -1 +0,0 @@\n-mine\n\\ No newline at end of file\n"},
+		{"todos/index.html", OnlyInGist, "--- /dev/null\n+++ b/todos/index.html\n@@ -0,0 +1 @@\n+new\n\\ No newline at end of file\n"},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("Diff = %+v, want %+v", diffs, want)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diff %d = %+v, want %+v", i, diffs[i], want[i])
+		}
+	}
+}
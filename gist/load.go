@@ -2,46 +2,228 @@ package gist
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
 )
 
 // ParseGistID extracts a gist ID from either a raw ID string or a full
 // gist URL (e.g. "https://gist.github.com/user/abc123" → "abc123").
 func ParseGistID(input string) string {
+	id, _ := ParseGistRef(input)
+	return id
+}
+
+// revisionPattern matches a gist revision: a git commit SHA, which may be
+// abbreviated.
+var revisionPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// ValidRevision reports whether rev looks like a gist revision SHA.
+func ValidRevision(rev string) bool {
+	return revisionPattern.MatchString(rev)
+}
+
+// ParseGistRef extracts a gist ID and, when the URL pins one, a revision:
+//
+//	abc123                                    → abc123
+//	https://gist.github.com/user/abc123       → abc123
+//	https://gist.github.com/user/abc123/<sha> → abc123, <sha>
+//	abc123/<sha>                              → abc123, <sha>
+//
+// Only full 40-character SHAs are taken as revisions from a URL, since gist
+// IDs are hex too.
+func ParseGistRef(input string) (id, revision string) {
 	input = strings.TrimSpace(input)
-	input = strings.TrimSuffix(input, "/")
-	// If it looks like a URL, take the last path segment
-	if strings.Contains(input, "/") {
-		parts := strings.Split(input, "/")
-		return parts[len(parts)-1]
+	input, _, _ = strings.Cut(input, "#")
+	input, _, _ = strings.Cut(input, "?")
+	input = strings.TrimSuffix(strings.TrimSuffix(input, "/"), ".git")
+	if !strings.Contains(input, "/") {
+		return input, ""
+	}
+	parts := strings.Split(input, "/")
+	last := parts[len(parts)-1]
+	if len(parts) >= 2 && len(last) == 40 && ValidRevision(last) {
+		return parts[len(parts)-2], last
 	}
-	return input
+	return last, ""
 }
 
 // LoadPlayground fetches a gist by ID and returns a map of relative file
-// paths to their content (decoded from the flat gist filenames).
-func (c *Client) LoadPlayground(ctx context.Context, gistID string) (map[string]string, error) {
-	g, _, err := c.gh.Gists.Get(ctx, gistID)
+// paths to their content (decoded from the flat gist filenames). revision
+// pins a past version of the gist; "" loads the latest. A gist fetched
+// before is revalidated with a conditional request and served from the
+// on-disk cache when unchanged, or when GitHub's rate limit is used up.
+// Revisions never change, so a cached one is served without asking.
+func (c *Client) LoadPlayground(ctx context.Context, gistID, revision string) (map[string]string, error) {
+	endpoint := "gists/" + url.PathEscape(gistID)
+	cacheKey := gistID
+	if revision != "" {
+		if !ValidRevision(revision) {
+			return nil, fmt.Errorf("gist %s: %q is not a revision SHA", gistID, revision)
+		}
+		endpoint += "/" + revision
+		cacheKey += "@" + revision
+	}
+
+	cached := c.cached(cacheKey)
+	if cached != nil && revision != "" {
+		return cached.Files, nil
+	}
+	req, err := c.gh.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, fmt.Errorf("fetching gist %s: %w", gistID, err)
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
 	}
 
+	g := new(gistResponse)
+	resp, err := c.gh.Do(ctx, req, g)
+	if cached != nil && resp != nil && resp.StatusCode == http.StatusNotModified {
+		return cached.Files, nil
+	}
+	if err != nil {
+		var rateErr *github.RateLimitError
+		if cached != nil && errors.As(err, &rateErr) {
+			return cached.Files, nil
+		}
+		if revision != "" {
+			return nil, fmt.Errorf("fetching gist %s at revision %s: %w", gistID, revision, withRate(err))
+		}
+		return nil, fmt.Errorf("fetching gist %s: %w", gistID, withRate(err))
+	}
+
+	var files map[string]string
+	if g.Truncated {
+		// The API lists at most 300 files; the git repo has them all
+		files, err = c.cloneFiles(ctx, &g.Gist, revision)
+	} else {
+		files, err = c.gistFiles(ctx, &g.Gist)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(cacheKey, &cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Revision:     g.GetUpdatedAt().UTC().Format(time.RFC3339),
+		Files:        files,
+	})
+	return files, nil
+}
+
+// gistResponse is a gist as the API returns it, with the flag go-github
+// leaves out for gists whose file list was cut short.
+type gistResponse struct {
+	github.Gist
+	Truncated bool `json:"truncated"`
+}
+
+// addFile decodes a gist filename into files. Gists are untrusted: a name
+// like ..__x must not escape the playground.
+func addFile(files map[string]string, g *github.Gist, name, content string) error {
+	relPath := DecodePath(name)
+	if err := ValidatePath(relPath); err != nil {
+		return fmt.Errorf("gist %s: file %q: %w", g.GetID(), name, err)
+	}
+	files[relPath] = content
+	return nil
+}
+
+// gistFiles returns the files in an API response, downloading any whose
+// content the API truncated (those over about 1 MB) from their raw URL.
+func (c *Client) gistFiles(ctx context.Context, g *github.Gist) (map[string]string, error) {
 	files := make(map[string]string, len(g.Files))
 	for name, file := range g.Files {
-		relPath := DecodePath(string(name))
-		files[relPath] = file.GetContent()
+		content := file.GetContent()
+		if len(content) < file.GetSize() {
+			var err error
+			if content, err = c.fetchRaw(ctx, file); err != nil {
+				return nil, fmt.Errorf("gist %s: %s: %w", g.GetID(), name, err)
+			}
+		}
+		if err := addFile(files, g, string(name), content); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// fetchRaw downloads the full content of a truncated gist file.
+func (c *Client) fetchRaw(ctx context.Context, file github.GistFile) (string, error) {
+	if file.GetRawURL() == "" {
+		return "", fmt.Errorf("content truncated and no raw URL to fetch it from")
 	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.GetRawURL(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.gh.Client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching raw content: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(file.GetSize())+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) != file.GetSize() {
+		return "", fmt.Errorf("raw content is %d bytes, want %d", len(data), file.GetSize())
+	}
+	return string(data), nil
+}
 
+// cloneFiles reads every file of a gist from a git clone of it.
+func (c *Client) cloneFiles(ctx context.Context, g *github.Gist, revision string) (map[string]string, error) {
+	tmpClone, err := c.gitClone(ctx, g, revision)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpClone)
+
+	entries, err := os.ReadDir(tmpClone)
+	if err != nil {
+		return nil, fmt.Errorf("reading cloned dir: %w", err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue // skip .git
+		}
+		content, err := os.ReadFile(filepath.Join(tmpClone, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := addFile(files, g, entry.Name(), string(content)); err != nil {
+			return nil, err
+		}
+	}
 	return files, nil
 }
 
-// LoadToTempDir fetches a gist and writes its files into a temporary directory,
-// recreating the directory structure. Returns the temp dir path.
-func (c *Client) LoadToTempDir(ctx context.Context, gistID string) (string, error) {
-	files, err := c.LoadPlayground(ctx, gistID)
+// LoadToTempDir fetches a gist, at revision if it isn't "", and writes its
+// files into a temporary directory, recreating the directory structure.
+// Returns the temp dir path.
+func (c *Client) LoadToTempDir(ctx context.Context, gistID, revision string) (string, error) {
+	files, err := c.LoadPlayground(ctx, gistID, revision)
 	if err != nil {
 		return "", err
 	}
@@ -52,16 +234,26 @@ func (c *Client) LoadToTempDir(ctx context.Context, gistID string) (string, erro
 	}
 
 	for relPath, content := range files {
-		fullPath := filepath.Join(tmpDir, filepath.FromSlash(relPath))
-		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
-			os.RemoveAll(tmpDir)
-			return "", fmt.Errorf("creating dir for %s: %w", relPath, err)
-		}
-		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+		if err := writeFile(tmpDir, relPath, content); err != nil {
 			os.RemoveAll(tmpDir)
-			return "", fmt.Errorf("writing %s: %w", relPath, err)
+			return "", err
 		}
 	}
 
 	return tmpDir, nil
 }
+
+// writeFile writes one playground file under dir, creating its directories.
+func writeFile(dir, relPath, content string) error {
+	if err := ValidatePath(relPath); err != nil {
+		return err
+	}
+	fullPath := filepath.Join(dir, filepath.FromSlash(relPath))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return fmt.Errorf("creating dir for %s: %w", relPath, err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", relPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestLoadPlaygroundFetchesTruncatedFiles(t *testing.T) {
+	big := strings.Repeat("<p>row</p>\n", 1000)
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gists/abc123":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"abc123","files":{"big__index.html":{"size":%d,"content":%q,"raw_url":%q}}}`,
+				len(big), big[:100], srv.URL+"/raw/big__index.html")
+		case "/raw/big__index.html":
+			w.Write([]byte(big))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	c := &Client{gh: gh}
+
+	files, err := c.LoadPlayground(context.Background(), "abc123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["big/index.html"] != big {
+		t.Errorf("got %d bytes of big/index.html, want %d", len(files["big/index.html"]), len(big))
+	}
+}
+
+func TestSync(t *testing.T) {
+	body := `{"id":"abc123","files":{"index.html":{"content":"<h1>v1</h1>"},"old.html":{"content":"bye"}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	c := &Client{gh: gh}
+	ctx := context.Background()
+
+	dir, err := c.LoadToTempDir(ctx, "abc123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if changed, err := c.Sync(ctx, "abc123", dir); err != nil || len(changed) != 0 {
+		t.Fatalf("Sync(unchanged) = %v, %v; want nothing changed", changed, err)
+	}
+
+	body = `{"id":"abc123","files":{"index.html":{"content":"<h1>v2</h1>"},"todos__index.html":{"content":"<ul></ul>"}}}`
+	changed, err := c.Sync(ctx, "abc123", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"index.html", "old.html", "todos/index.html"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(got) != "<h1>v2</h1>" {
+		t.Errorf("index.html = %q after sync", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.html")); err == nil {
+		t.Error("old.html survived the sync")
+	}
+}
@@ -0,0 +1,35 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// LoginScopes are the permissions a login asks for: gist to read secret
+// gists and create new ones.
+var LoginScopes = []string{"gist"}
+
+// Login signs in to GitHub with the device authorization flow of the OAuth
+// app clientID, which must have device flow enabled. prompt is given the
+// code the user enters at verificationURL; Login then waits for them to
+// approve it and returns the access token.
+func Login(ctx context.Context, clientID string, prompt func(userCode, verificationURL string)) (string, error) {
+	cfg := &oauth2.Config{
+		ClientID: clientID,
+		Endpoint: endpoints.GitHub,
+		Scopes:   LoginScopes,
+	}
+	da, err := cfg.DeviceAuth(ctx)
+	if err != nil {
+		return "", fmt.Errorf("requesting device code: %w", err)
+	}
+	prompt(da.UserCode, da.VerificationURI)
+	tok, err := cfg.DeviceAccessToken(ctx, da)
+	if err != nil {
+		return "", fmt.Errorf("waiting for approval: %w", err)
+	}
+	return tok.AccessToken, nil
+}
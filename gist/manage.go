@@ -0,0 +1,104 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// Marker tags a gist's description as a dsplay playground, so it can be
+// told apart from the owner's other gists. SavePlayground adds it.
+const Marker = "#dsplay"
+
+// defaultDescription is what SavePlayground names gists without a
+// description; gists shared before Marker existed still carry it.
+const defaultDescription = "ds-play playground"
+
+// playgroundFiles mark a gist as a playground without the marker: its
+// manifest or a home page.
+var playgroundFiles = []string{"playground.yaml", "index.html", "index.md"}
+
+// Summary describes one of the user's gists.
+type Summary struct {
+	ID          string
+	Description string
+	URL         string
+	Public      bool
+	Files       int
+	Updated     time.Time
+	Playground  bool // has Marker, or a manifest or home page
+}
+
+// IsPlayground reports whether a gist looks like a dsplay playground.
+func IsPlayground(g *github.Gist) bool {
+	desc := g.GetDescription()
+	if strings.Contains(desc, Marker) || desc == defaultDescription {
+		return true
+	}
+	for _, name := range playgroundFiles {
+		if _, ok := g.Files[github.GistFilename(name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// withMarker adds Marker to a gist description that lacks it.
+func withMarker(desc string) string {
+	if strings.Contains(desc, Marker) {
+		return desc
+	}
+	return strings.TrimSpace(strings.TrimSpace(desc) + " " + Marker)
+}
+
+// List returns the authenticated user's gists, newest first. Unless all is
+// set, only playgrounds are included.
+func (c *Client) List(ctx context.Context, all bool) ([]Summary, error) {
+	var out []Summary
+	opts := &github.GistListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		gists, resp, err := c.gh.Gists.List(ctx, "", opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing gists: %w", withRate(err))
+		}
+		for _, g := range gists {
+			s := Summary{
+				ID:          g.GetID(),
+				Description: g.GetDescription(),
+				URL:         g.GetHTMLURL(),
+				Public:      g.GetPublic(),
+				Files:       len(g.Files),
+				Updated:     g.GetUpdatedAt().Time,
+				Playground:  IsPlayground(g),
+			}
+			if all || s.Playground {
+				out = append(out, s)
+			}
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// Delete deletes a gist. Only its owner can.
+func (c *Client) Delete(ctx context.Context, gistID string) error {
+	if _, err := c.gh.Gists.Delete(ctx, gistID); err != nil {
+		return fmt.Errorf("deleting gist: %w", withRate(err))
+	}
+	return nil
+}
+
+// Fork copies someone's gist into the authenticated user's account and
+// returns the fork's ID and HTML URL.
+func (c *Client) Fork(ctx context.Context, gistID string) (forkID, htmlURL string, err error) {
+	fork, _, err := c.gh.Gists.Fork(ctx, gistID)
+	if err != nil {
+		return "", "", fmt.Errorf("forking gist: %w", withRate(err))
+	}
+	return fork.GetID(), fork.GetHTMLURL(), nil
+}
@@ -0,0 +1,62 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v68/github"
+)
+
+func TestListPlaygrounds(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"id":"c3","description":"ds-play playground","files":{"x.html":{}}}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/gists?page=2>; rel="next"`, srv.URL))
+		fmt.Fprint(w, `[
+			{"id":"a1","description":"Todo demo #dsplay","files":{"todos__index.html":{}}},
+			{"id":"b2","description":"dotfiles","files":{".vimrc":{}}},
+			{"id":"d4","description":"untagged","files":{"playground.yaml":{},"index.html":{}}}
+		]`)
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+	c := &Client{gh: gh}
+
+	got, err := c.List(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range got {
+		ids = append(ids, s.ID)
+	}
+	if fmt.Sprint(ids) != "[a1 d4 c3]" {
+		t.Errorf("playgrounds = %v, want [a1 d4 c3]", ids)
+	}
+	if all, _ := c.List(context.Background(), true); len(all) != 4 {
+		t.Errorf("List(all) returned %d gists, want 4", len(all))
+	}
+}
+
+func TestWithMarker(t *testing.T) {
+	for desc, want := range map[string]string{
+		"":             "#dsplay",
+		"Chat demo":    "Chat demo #dsplay",
+		"#dsplay chat": "#dsplay chat",
+		"Chat demo ":   "Chat demo #dsplay",
+	} {
+		if got := withMarker(desc); got != want {
+			t.Errorf("withMarker(%q) = %q, want %q", desc, got, want)
+		}
+	}
+}
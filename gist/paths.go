@@ -1,22 +1,82 @@
 package gist
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 )
 
-const pathSeparator = "__"
+// Gist filenames are flat, so a path's slashes are encoded as "__". An
+// underscore that would run into one, or into another underscore, is
+// escaped as "~_", and "~" itself as "~~". Lone underscores, as in
+// snake_case names, are left alone, so gists saved before escaping
+// existed decode the same.
+const (
+	pathSeparator = "__"
+	escapeChar    = '~'
+)
 
 // EncodePath converts a relative file path (e.g. "home/greeting/sse.html")
 // into a flat gist filename (e.g. "home__greeting__sse.html").
 func EncodePath(relPath string) string {
 	// Normalize to forward slashes
 	relPath = filepath.ToSlash(relPath)
-	return strings.ReplaceAll(relPath, "/", pathSeparator)
+	var b strings.Builder
+	for i := 0; i < len(relPath); i++ {
+		switch c := relPath[i]; {
+		case c == '/':
+			b.WriteString(pathSeparator)
+		case c == escapeChar:
+			b.WriteString("~~")
+		case c == '_' && (joins(relPath, i-1) || joins(relPath, i+1)):
+			b.WriteString("~_")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// joins reports whether the byte at i would merge with an underscore next
+// to it into a separator.
+func joins(s string, i int) bool {
+	return i >= 0 && i < len(s) && (s[i] == '_' || s[i] == '/')
 }
 
 // DecodePath converts a flat gist filename (e.g. "home__greeting__sse.html")
-// back into a relative file path (e.g. "home/greeting/sse.html").
+// back into a relative file path (e.g. "home/greeting/sse.html"). The
+// result comes from an untrusted gist; check it with ValidatePath before
+// writing it anywhere.
 func DecodePath(gistFilename string) string {
-	return strings.ReplaceAll(gistFilename, pathSeparator, "/")
+	var b strings.Builder
+	for i := 0; i < len(gistFilename); i++ {
+		c := gistFilename[i]
+		switch {
+		case c == escapeChar && i+1 < len(gistFilename) && (gistFilename[i+1] == escapeChar || gistFilename[i+1] == '_'):
+			i++
+			b.WriteByte(gistFilename[i])
+		case strings.HasPrefix(gistFilename[i:], pathSeparator):
+			i++
+			b.WriteByte('/')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// ValidatePath reports an error for a decoded path that isn't a plain
+// relative file path inside the playground: absolute, or with an empty,
+// "." or ".." segment. A malicious gist could use those to write
+// elsewhere.
+func ValidatePath(relPath string) error {
+	if !filepath.IsLocal(filepath.FromSlash(relPath)) {
+		return fmt.Errorf("%q is not a path inside the playground", relPath)
+	}
+	for seg := range strings.SplitSeq(relPath, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("%q has a %q segment", relPath, seg)
+		}
+	}
+	return nil
 }
@@ -1,6 +1,10 @@
 package gist
 
-import "testing"
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestEncodePath(t *testing.T) {
 	tests := []struct {
@@ -11,6 +15,11 @@ func TestEncodePath(t *testing.T) {
 		{"home/greeting/sse.html", "home__greeting__sse.html"},
 		{"home/counter/live/sse.html", "home__counter__live__sse.html"},
 		{"index.html", "index.html"},
+		{"snake_case.html", "snake_case.html"},
+		{"__init__.py", "~_~_init~_~_.py"},
+		{"a_/b", "a~___b"},
+		{"home/_partial.html", "home__~_partial.html"},
+		{"~backup", "~~backup"},
 	}
 	for _, tt := range tests {
 		got := EncodePath(tt.input)
@@ -29,6 +38,10 @@ func TestDecodePath(t *testing.T) {
 		{"home__greeting__sse.html", "home/greeting/sse.html"},
 		{"home__counter__live__sse.html", "home/counter/live/sse.html"},
 		{"index.html", "index.html"},
+		{"~_~_init~_~_.py", "__init__.py"},
+		// Names saved before escaping decode as they always did
+		{"home___partial.html", "home/_partial.html"},
+		{"odd~name", "odd~name"},
 	}
 	for _, tt := range tests {
 		got := DecodePath(tt.input)
@@ -44,6 +57,9 @@ func TestRoundTrip(t *testing.T) {
 		"home/greeting/sse.html",
 		"home/counter/step/sse.html",
 		"home/action/post.html",
+		"lib/__init__.py",
+		"a___b/c_",
+		"tilde~/~_x",
 	}
 	for _, p := range paths {
 		got := DecodePath(EncodePath(p))
@@ -52,3 +68,78 @@ func TestRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func FuzzPathRoundTrip(f *testing.F) {
+	for _, seed := range []string{"home/index.html", "__init__.py", "a_/b", "~_", "x___y", "/_/"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, p string) {
+		enc := EncodePath(p)
+		if strings.Contains(enc, "/") {
+			t.Fatalf("EncodePath(%q) = %q contains a slash", p, enc)
+		}
+		if got := DecodePath(enc); got != p {
+			t.Fatalf("DecodePath(EncodePath(%q)) = %q (encoded %q)", p, got, enc)
+		}
+	})
+}
+
+func FuzzDecodePathSafe(f *testing.F) {
+	for _, seed := range []string{"..__..__etc__passwd", "__etc__passwd", "a__..__..__b", "~_..", "index.html"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		rel := DecodePath(name)
+		if ValidatePath(rel) != nil {
+			return
+		}
+		// Anything ValidatePath accepts stays inside the directory
+		dir := filepath.FromSlash("/playground")
+		joined := filepath.Join(dir, filepath.FromSlash(rel))
+		if r, err := filepath.Rel(dir, joined); err != nil || !filepath.IsLocal(r) || r == "." {
+			t.Fatalf("ValidatePath accepted %q (from %q), which resolves to %s", rel, name, joined)
+		}
+	})
+}
+
+func TestValidatePath(t *testing.T) {
+	for p, ok := range map[string]bool{
+		"index.html":        true,
+		"todos/index.html":  true,
+		"":                  false,
+		"/etc/passwd":       false,
+		"../escape.html":    false,
+		"a/../../escape":    false,
+		"a/../b":            false,
+		"..":                false,
+		".":                 false,
+		"a//b":              false,
+		"static/../../x.js": false,
+	} {
+		if err := ValidatePath(p); (err == nil) != ok {
+			t.Errorf("ValidatePath(%q) = %v, want ok=%v", p, err, ok)
+		}
+	}
+}
+
+func TestParseGistRef(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		input, id, revision string
+	}{
+		{"abc123", "abc123", ""},
+		{"https://gist.github.com/user/abc123", "abc123", ""},
+		{"https://gist.github.com/user/abc123/", "abc123", ""},
+		{"https://gist.github.com/abc123", "abc123", ""},
+		{"https://gist.github.com/user/abc123/" + sha, "abc123", sha},
+		{"https://gist.github.com/user/abc123#file-index-html", "abc123", ""},
+		{"https://gist.github.com/abc123.git", "abc123", ""},
+		{"abc123/" + sha, "abc123", sha},
+	}
+	for _, tt := range tests {
+		id, rev := ParseGistRef(tt.input)
+		if id != tt.id || rev != tt.revision {
+			t.Errorf("ParseGistRef(%q) = %q, %q, want %q, %q", tt.input, id, rev, tt.id, tt.revision)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gist
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v68/github"
+)
+
+// Retry policy for GitHub API calls. Variables so tests can shorten them.
+var (
+	retryAttempts = 4                // tries per request, the first included
+	retryBackoff  = time.Second      // wait before the first retry, doubled after each
+	retryMaxWait  = 60 * time.Second // longest wait worth retrying after
+)
+
+// retryTransport retries GitHub API requests that failed for reasons that
+// tend to pass: network errors, 5xx responses and secondary rate limits.
+// Requests that aren't safe to repeat, like creating a gist, are only
+// retried when GitHub says it didn't process them (a rate limit or a 503),
+// so a flaky connection can't share the same playground twice.
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		req.Method == http.MethodPut || req.Method == http.MethodDelete
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt == retryAttempts || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if !idempotent || req.Context().Err() != nil {
+				return resp, err
+			}
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden:
+			var ok bool
+			if wait, ok = secondaryLimitWait(resp); !ok {
+				return resp, err
+			}
+		case resp.StatusCode == http.StatusServiceUnavailable:
+		case resp.StatusCode >= 500 && idempotent:
+		default:
+			return resp, err
+		}
+		// Jitter spreads out clients retrying in step
+		backoff := retryBackoff << (attempt - 1)
+		wait = max(wait, backoff/2+rand.N(backoff/2+1))
+		if wait > retryMaxWait {
+			return resp, err
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// secondaryLimitWait reports whether a 403 or 429 is GitHub's secondary
+// rate limit, which lifts after a short wait, and how long to wait. The
+// primary limit (no requests left until the hourly reset) isn't retried.
+func secondaryLimitWait(resp *http.Response) (time.Duration, bool) {
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		secs, err := strconv.Atoi(s)
+		return time.Duration(secs) * time.Second, err == nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, false
+	}
+	// GitHub names secondary limits in the message; peek without consuming
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), resp.Body))
+	if resp.StatusCode == http.StatusTooManyRequests || strings.Contains(string(data), "secondary rate limit") {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// withRate adds what's left of the GitHub rate limit to a failed API call's
+// error, so a failure caused by, or close to, the limit says so.
+func withRate(err error) error {
+	var rateErr *github.RateLimitError
+	if err == nil || errors.As(err, &rateErr) {
+		return err // already names the limit and its reset
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return fmt.Errorf("%w (GitHub secondary rate limit; try again in %s)", err, abuseErr.RetryAfter.Round(time.Second))
+		}
+		return fmt.Errorf("%w (GitHub secondary rate limit; try again in a minute)", err)
+	}
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return err
+	}
+	h := respErr.Response.Header
+	remaining, limit := h.Get("X-RateLimit-Remaining"), h.Get("X-RateLimit-Limit")
+	if remaining == "" || limit == "" {
+		return err
+	}
+	note := fmt.Sprintf("GitHub rate limit: %s of %s requests left", remaining, limit)
+	if reset, perr := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); perr == nil {
+		note += ", resets at " + time.Unix(reset, 0).Format(time.Kitchen)
+	}
+	return fmt.Errorf("%w (%s)", err, note)
+}
@@ -0,0 +1,65 @@
+package gist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	var gets, posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "7")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		switch {
+		case r.Method == http.MethodGet && gets.Add(1) < 3:
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"id":"abc123","files":{"index.html":{"content":"<h1>hi</h1>"}}}`))
+		case posts.Add(1) == 1:
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"message":"You have exceeded a secondary rate limit"}`, http.StatusForbidden)
+		default:
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	c := newClient(nil)
+	c.cacheDir = ""
+	c.gh.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	files, err := c.LoadPlayground(context.Background(), "abc123", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["index.html"] != "<h1>hi</h1>" || gets.Load() != 3 {
+		t.Errorf("got %v after %d GETs, want the gist after 3", files, gets.Load())
+	}
+
+	// A secondary rate limit is retried even when creating a gist, but a
+	// 502 isn't: the gist may have been created anyway
+	dir := t.TempDir()
+	if err := writeFile(dir, "index.html", "<h1>hi</h1>"); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.SavePlayground(context.Background(), dir, SaveOptions{Public: true})
+	if err == nil {
+		t.Fatal("SavePlayground succeeded, want the 502")
+	}
+	if posts.Load() != 2 {
+		t.Errorf("made %d POSTs, want 2", posts.Load())
+	}
+	if !strings.Contains(err.Error(), "7 of 60 requests left") {
+		t.Errorf("error %q doesn't give the remaining rate limit", err)
+	}
+}
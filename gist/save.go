@@ -3,9 +3,14 @@ package gist
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/dataSPA/dataSPA-playground/ignore"
 	"github.com/google/go-github/v68/github"
 )
 
@@ -13,60 +18,136 @@ import (
 type SaveOptions struct {
 	Public      bool
 	Description string
+	Exclude     []string // glob patterns of files to leave out, see Excluded
+	// Progress, if set, is called as each file is read, before the upload
+	Progress func(f File, done, total int)
 }
 
-// SavePlayground walks a playground directory, encodes all files into
-// flat gist filenames, and creates a new GitHub gist. Returns the gist ID and
-// HTML URL.
-func (c *Client) SavePlayground(ctx context.Context, dir string, opts SaveOptions) (gistID string, htmlURL string, err error) {
-	files := make(map[github.GistFilename]github.GistFile)
+// LocalOnlyFiles are never published: dsplay.yaml holds server settings,
+// including credentials, and OriginFile only means something locally.
+var LocalOnlyFiles = []string{"dsplay.yaml", OriginFile}
 
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if info.IsDir() {
-			return nil
+func localOnly(rel string) bool {
+	for _, name := range LocalOnlyFiles {
+		if rel == name {
+			return true
 		}
+	}
+	return false
+}
 
-		rel, relErr := filepath.Rel(dir, path)
-		if relErr != nil {
-			return relErr
-		}
+// File is a playground file as it will be published.
+type File struct {
+	Path string // slash-separated, relative to the playground
+	Name string // flat gist filename
+	Size int64
+}
 
-		content, readErr := os.ReadFile(path)
-		if readErr != nil {
-			return readErr
+// Excluded reports whether the slash-separated path rel matches any of
+// patterns. A pattern matches the whole path (drafts/*.html), any
+// directory it is in (drafts), or, when it has no slash, any single name
+// along it (*.psd, node_modules).
+func Excluded(rel string, patterns []string) bool {
+	segments := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		for i := range segments {
+			if ok, _ := path.Match(pattern, strings.Join(segments[:i+1], "/")); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, segments[i]); ok && !strings.Contains(pattern, "/") {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		gistName := EncodePath(filepath.ToSlash(rel))
-		files[github.GistFilename(gistName)] = github.GistFile{
-			Content: github.Ptr(string(content)),
+// ListFiles returns the files in dir that would be published, sorted by
+// path: everything but git metadata, LocalOnlyFiles, what the playground's
+// .dsplayignore lists and files matching exclude.
+func ListFiles(dir string, exclude []string) ([]File, error) {
+	for _, pattern := range exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("exclude pattern %q: %w", pattern, err)
+		}
+	}
+	ignored, err := ignore.Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == ".git" || Excluded(rel, exclude) || ignored.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || localOnly(rel) || Excluded(rel, exclude) || ignored.Match(rel, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: rel, Name: EncodePath(rel), Size: info.Size()})
 		return nil
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("walking playground dir: %w", err)
+		return nil, fmt.Errorf("walking playground dir: %w", err)
 	}
-
 	if len(files) == 0 {
-		return "", "", fmt.Errorf("no files found in %s", dir)
+		return nil, fmt.Errorf("no files found in %s", dir)
+	}
+	slices.SortFunc(files, func(a, b File) int { return strings.Compare(a.Path, b.Path) })
+	return files, nil
+}
+
+// SavePlayground walks a playground directory, encodes all files into
+// flat gist filenames, and creates a new GitHub gist, tagged with Marker.
+// Returns the gist ID and HTML URL.
+func (c *Client) SavePlayground(ctx context.Context, dir string, opts SaveOptions) (gistID string, htmlURL string, err error) {
+	list, err := ListFiles(dir, opts.Exclude)
+	if err != nil {
+		return "", "", err
+	}
+	files := make(map[github.GistFilename]github.GistFile, len(list))
+	for i, f := range list {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return "", "", err
+		}
+		files[github.GistFilename(f.Name)] = github.GistFile{
+			Content: github.Ptr(string(content)),
+		}
+		if opts.Progress != nil {
+			opts.Progress(f, i+1, len(list))
+		}
 	}
 
 	desc := opts.Description
 	if desc == "" {
-		desc = "ds-play playground"
+		desc = defaultDescription
 	}
 
 	g := &github.Gist{
-		Description: github.Ptr(desc),
+		Description: github.Ptr(withMarker(desc)),
 		Public:      github.Ptr(opts.Public),
 		Files:       files,
 	}
 
 	created, _, apiErr := c.gh.Gists.Create(ctx, g)
 	if apiErr != nil {
-		return "", "", fmt.Errorf("creating gist: %w", apiErr)
+		return "", "", fmt.Errorf("creating gist: %w", withRate(apiErr))
 	}
 
 	return created.GetID(), created.GetHTMLURL(), nil
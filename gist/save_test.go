@@ -0,0 +1,57 @@
+package gist
+
+import "testing"
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{
+		"index.html", "todos/index.html", "dsplay.yaml", ".git/HEAD",
+		"drafts/wip.html", "art/logo.psd", "node_modules/x/y.js", "todos/_row.html",
+	} {
+		if err := writeFile(dir, rel, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := ListFiles(dir, []string{"drafts", "*.psd", "node_modules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Path+"="+f.Name)
+	}
+	want := []string{"index.html=index.html", "todos/_row.html=todos__~_row.html", "todos/index.html=todos__index.html"}
+	if len(got) != len(want) {
+		t.Fatalf("ListFiles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ListFiles = %v, want %v", got, want)
+			break
+		}
+	}
+
+	if _, err := ListFiles(dir, []string{"[x"}); err == nil {
+		t.Error("ListFiles accepted a malformed pattern")
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	for _, tt := range []struct {
+		rel     string
+		pattern string
+		want    bool
+	}{
+		{"drafts/wip.html", "drafts", true},
+		{"drafts/wip.html", "drafts/*.html", true},
+		{"a/drafts/wip.html", "drafts/*.html", false},
+		{"a/b/big.mp4", "*.mp4", true},
+		{"notes.md", "*.html", false},
+		{"todos/index.html", "/todos/", true},
+	} {
+		if got := Excluded(tt.rel, []string{tt.pattern}); got != tt.want {
+			t.Errorf("Excluded(%q, %q) = %v, want %v", tt.rel, tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The keychain entry a login is stored under.
+const (
+	keychainService = "dsplay"
+	keychainAccount = "github"
+)
+
+// ErrNoToken is returned by LoadToken when nobody has logged in.
+var ErrNoToken = errors.New("not logged in")
+
+// TokenFile is where a login is kept when there's no usable OS keychain:
+// github-token in the dsplay config directory, readable only by the user.
+func TokenFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dsplay", "github-token"), nil
+}
+
+// SaveToken stores a GitHub token in the OS keychain (the macOS Keychain,
+// or the Secret Service via secret-tool on Linux), falling back to
+// TokenFile. It returns where the token went.
+func SaveToken(token string) (string, error) {
+	if err := keychainSave(token); err == nil {
+		return "the system keychain", nil
+	}
+	path, err := TokenFile()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// LoadToken returns the token saved by SaveToken, or ErrNoToken.
+func LoadToken() (string, error) {
+	if token, err := keychainLoad(); err == nil && token != "" {
+		return token, nil
+	}
+	path, err := TokenFile()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoToken
+	}
+	if err != nil {
+		return "", err
+	}
+	if token := strings.TrimSpace(string(data)); token != "" {
+		return token, nil
+	}
+	return "", ErrNoToken
+}
+
+// DeleteToken removes a saved token from the keychain and the token file.
+func DeleteToken() error {
+	keychainDelete()
+	path, err := TokenFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// errNoKeychain means this system has no keychain dsplay knows how to use.
+var errNoKeychain = errors.New("no keychain available")
+
+// keychain runs the OS keychain tool with the action's arguments, feeding
+// it stdin, and returns its output.
+func keychain(action, stdin string) (string, error) {
+	var args []string
+	switch runtime.GOOS + " " + action {
+	case "darwin save":
+		// security reads -w's prompt from the terminal, not stdin, so the
+		// token goes on the command line as go-keyring does
+		args = []string{"security", "add-generic-password", "-U", "-s", keychainService, "-a", keychainAccount, "-w", stdin}
+		stdin = ""
+	case "darwin load":
+		args = []string{"security", "find-generic-password", "-s", keychainService, "-a", keychainAccount, "-w"}
+	case "darwin delete":
+		args = []string{"security", "delete-generic-password", "-s", keychainService, "-a", keychainAccount}
+	case "linux save":
+		args = []string{"secret-tool", "store", "--label=dsplay GitHub token", "service", keychainService, "account", keychainAccount}
+	case "linux load":
+		args = []string{"secret-tool", "lookup", "service", keychainService, "account", keychainAccount}
+	case "linux delete":
+		args = []string{"secret-tool", "clear", "service", keychainService, "account", keychainAccount}
+	default:
+		return "", errNoKeychain
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", errNoKeychain
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keychainSave(token string) error {
+	_, err := keychain("save", token)
+	return err
+}
+
+func keychainLoad() (string, error) {
+	return keychain("load", "")
+}
+
+func keychainDelete() {
+	keychain("delete", "")
+}
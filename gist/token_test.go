@@ -0,0 +1,39 @@
+package gist
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestTokenFileFallback(t *testing.T) {
+	// No keychain tools on PATH, and a private config directory
+	t.Setenv("PATH", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if _, err := LoadToken(); !errors.Is(err, ErrNoToken) {
+		t.Fatalf("LoadToken() before login = %v, want ErrNoToken", err)
+	}
+	path, err := SaveToken("gho_secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+	if token, err := LoadToken(); err != nil || token != "gho_secret" {
+		t.Errorf("LoadToken() = %q, %v; want the saved token", token, err)
+	}
+
+	if err := DeleteToken(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadToken(); !errors.Is(err, ErrNoToken) {
+		t.Errorf("LoadToken() after logout = %v, want ErrNoToken", err)
+	}
+}
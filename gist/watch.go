@@ -0,0 +1,70 @@
+package gist
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Sync brings dir, loaded earlier by LoadToTempDir, up to date with the
+// latest version of the gist: changed and new files are written and files
+// the gist no longer has are removed. It returns the paths that changed,
+// sorted. An unchanged gist costs one conditional request.
+func (c *Client) Sync(ctx context.Context, gistID, dir string) ([]string, error) {
+	files, err := c.LoadPlayground(ctx, gistID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := files[rel]; ok {
+			return nil
+		}
+		changed = append(changed, rel)
+		return os.Remove(path)
+	})
+	if err != nil {
+		return changed, err
+	}
+	for rel, content := range files {
+		if old, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel))); err == nil && string(old) == content {
+			continue
+		}
+		if err := writeFile(dir, rel, content); err != nil {
+			return changed, err
+		}
+		changed = append(changed, rel)
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// Watch calls Sync every interval until ctx ends, reporting each round
+// that changed something, or failed, to report.
+func (c *Client) Watch(ctx context.Context, gistID, dir string, interval time.Duration, report func(changed []string, err error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			changed, err := c.Sync(ctx, gistID, dir)
+			if (len(changed) > 0 || err != nil) && ctx.Err() == nil {
+				report(changed, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package ignore reads .dsplayignore files: gitignore-style lists of the
+// files in a playground that are neither served as routes nor shared.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// File is the name of the ignore file, read from the playground root.
+const File = ".dsplayignore"
+
+// Matcher decides which playground paths an ignore file leaves out. The
+// zero Matcher, and a nil one, ignore nothing.
+type Matcher struct {
+	rules []rule
+}
+
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool // a ! pattern, re-including what an earlier one left out
+	dirOnly bool // a pattern ending in /, matching only directories
+}
+
+// Load reads File from the root of fsys. A playground without one gets a
+// Matcher that ignores nothing.
+func Load(fsys fs.FS) (*Matcher, error) {
+	data, err := fs.ReadFile(fsys, File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Matcher{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(data))
+}
+
+// Parse reads patterns in gitignore syntax:
+//
+//	# a comment
+//	*.swp          any file so named, in any directory
+//	node_modules/  any directory so named, with everything in it
+//	/notes.md      only at the playground root
+//	drafts/*.html  a slash makes a pattern relative to the root
+//	docs/**/old    ** matches any number of directories
+//	!keep.swp      re-include what an earlier pattern left out
+//
+// As in git, a file can't be re-included when a directory above it is
+// ignored.
+func Parse(src string) (*Matcher, error) {
+	m := &Matcher{}
+	sc := bufio.NewScanner(strings.NewReader(src))
+	for n := 1; sc.Scan(); n++ {
+		line := trimTrailingSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r rule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// A slash anywhere but the end anchors the pattern to the root
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		expr := translate(line)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "(^|/)" + expr + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: bad pattern %q", File, n, sc.Text())
+		}
+		r.re = re
+		m.rules = append(m.rules, r)
+	}
+	return m, sc.Err()
+}
+
+// Match reports whether the slash-separated path rel, a directory if isDir
+// is set, is ignored, either itself or through a directory above it.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	for i := range len(rel) {
+		if rel[i] == '/' && m.match(rel[:i], true) {
+			return true
+		}
+	}
+	return m.match(rel, isDir)
+}
+
+// match applies the rules to rel alone. The last rule to match decides.
+func (m *Matcher) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if (!r.dirOnly || isDir) && r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// translate turns a gitignore glob into a regular expression.
+func translate(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString("(.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// trimTrailingSpace drops trailing spaces, except one escaped with a
+// backslash.
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
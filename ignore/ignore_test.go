@@ -0,0 +1,84 @@
+package ignore
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatch(t *testing.T) {
+	m, err := Parse(`
+# editor and tool leftovers
+*.swp
+.DS_Store
+node_modules/
+/notes.md
+drafts/*.html
+docs/**/old
+build/**
+secret?.txt
+[Tt]mp
+!keep.swp
+\#hash
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"index.swp", false, true},
+		{"todos/.index.html.swp", false, true},
+		{"keep.swp", false, false},
+		{"a/.DS_Store", false, true},
+		{"node_modules", true, true},
+		{"node_modules/x/y.js", false, true},
+		{"node_modules", false, false}, // a file, and the pattern wants a directory
+		{"notes.md", false, true},
+		{"todos/notes.md", false, false},
+		{"drafts/wip.html", false, true},
+		{"drafts/deep/wip.html", false, false},
+		{"x/drafts/wip.html", false, false},
+		{"docs/old", true, true},
+		{"docs/a/b/old", false, true},
+		{"build/out/app.js", false, true},
+		{"secret1.txt", false, true},
+		{"secret10.txt", false, false},
+		{"tmp", true, true},
+		{"Tmp/x.html", false, true},
+		{"#hash", false, true},
+		{"index.html", false, false},
+		{"todos/index.html", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestNoReincludeUnderIgnoredDir(t *testing.T) {
+	m, err := Parse("vendor/\n!vendor/keep.js\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("vendor/keep.js", false) {
+		t.Error("vendor/keep.js re-included inside an ignored directory")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load(fstest.MapFS{})
+	if err != nil || m.Match("anything.swp", false) {
+		t.Fatalf("Load without %s = %v, %v; want a matcher that ignores nothing", File, m, err)
+	}
+	m, err = Load(fstest.MapFS{File: {Data: []byte("*.swp\n")}})
+	if err != nil || !m.Match("a/b.swp", false) {
+		t.Fatalf("Load(%s) = %v, %v; want *.swp ignored", File, m, err)
+	}
+	var nilMatcher *Matcher
+	if nilMatcher.Match("x", false) {
+		t.Error("nil Matcher ignored a path")
+	}
+}
@@ -0,0 +1,256 @@
+// Package importer converts a plain static site into a playground: pages are
+// mapped onto the directory-per-route convention, other files move under
+// static/, links are rewritten to match, and the Datastar script is injected.
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DatastarScript is injected into pages that don't load Datastar already. It
+// expands to the bundle dsplay serves locally.
+const DatastarScript = `{{datastarScript}}`
+
+// Options controls an import.
+type Options struct {
+	Force bool // overwrite files that already exist in the destination
+}
+
+// Report describes what an import did and what needs a human.
+type Report struct {
+	Pages  []Mapping
+	Assets []Mapping
+	Notes  []Note
+}
+
+// Mapping records where a source file was written.
+type Mapping struct {
+	From string // relative to the site
+	To   string // relative to the playground
+}
+
+// Note flags something that needs manual attention.
+type Note struct {
+	File    string // source file, relative to the site
+	Line    int    // 0 if not specific to a line
+	Message string
+}
+
+func (n Note) String() string {
+	if n.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", n.File, n.Line, n.Message)
+	}
+	return fmt.Sprintf("%s: %s", n.File, n.Message)
+}
+
+// ImportSite converts the static site in src into a playground in dst.
+func ImportSite(src, dst string, opts Options) (*Report, error) {
+	site := os.DirFS(src)
+	rep := &Report{}
+	written := map[string]string{} // destination → source, to catch collisions
+
+	err := fs.WalkDir(site, ".", func(rel string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if rel != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(site, rel)
+		if err != nil {
+			return err
+		}
+
+		var to string
+		var notes []Note
+		if isPage(rel) {
+			to = PagePath(rel)
+			var out string
+			out, notes = ConvertPage(rel, string(data))
+			data = []byte(out)
+		} else {
+			to = AssetPath(rel)
+		}
+
+		if prev, ok := written[to]; ok {
+			rep.Notes = append(rep.Notes, Note{File: rel, Message: fmt.Sprintf("skipped: %s already maps to %s", prev, to)})
+			return nil
+		}
+		dest := filepath.Join(dst, filepath.FromSlash(to))
+		if _, err := os.Stat(dest); err == nil && !opts.Force {
+			rep.Notes = append(rep.Notes, Note{File: rel, Message: fmt.Sprintf("skipped: %s already exists (use --force to overwrite)", to)})
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			return err
+		}
+		written[to] = rel
+
+		m := Mapping{From: rel, To: to}
+		if isPage(rel) {
+			rep.Pages = append(rep.Pages, m)
+		} else {
+			rep.Assets = append(rep.Assets, m)
+		}
+		rep.Notes = append(rep.Notes, notes...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rep.Notes, func(i, j int) bool { return rep.Notes[i].File < rep.Notes[j].File })
+	return rep, nil
+}
+
+func isPage(rel string) bool {
+	ext := strings.ToLower(path.Ext(rel))
+	return ext == ".html" || ext == ".htm"
+}
+
+// PagePath maps a page to the playground file serving its URL:
+// about.html → about/index.html, blog/index.html stays put.
+func PagePath(rel string) string {
+	dir, file := path.Split(rel)
+	stem := strings.TrimSuffix(file, path.Ext(file))
+	if strings.EqualFold(stem, "index") {
+		return dir + "index.html"
+	}
+	return dir + stem + "/index.html"
+}
+
+// PageURL is the URL the playground serves a page at.
+func PageURL(rel string) string {
+	dir := path.Dir(PagePath(rel))
+	if dir == "." {
+		return "/"
+	}
+	return "/" + dir + "/"
+}
+
+// AssetPath maps a non-page file under static/, which the server serves at /static/.
+func AssetPath(rel string) string {
+	return "static/" + rel
+}
+
+var (
+	linkAttr     = regexp.MustCompile(`(?i)\b(href|src|action)\s*=\s*("([^"]*)"|'([^']*)')`)
+	headClose    = regexp.MustCompile(`(?i)</head\s*>`)
+	formTag      = regexp.MustCompile(`(?i)<form\b[^>]*>`)
+	formMethod   = regexp.MustCompile(`(?i)\bmethod\s*=\s*["']?(\w+)`)
+	inlineScript = regexp.MustCompile(`(?i)<script\b[^>]*>`)
+	srcsetAttr   = regexp.MustCompile(`(?i)\bsrcset\s*=`)
+	externalURL  = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*:|//|#)`)
+)
+
+// ConvertPage rewrites one page for the playground and returns notes on
+// anything that needs manual attention.
+func ConvertPage(rel, content string) (string, []Note) {
+	var notes []Note
+	note := func(offset int, format string, args ...any) {
+		notes = append(notes, Note{File: rel, Line: lineAt(content, offset), Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Report before rewriting so line numbers match the source file
+	for _, loc := range formTag.FindAllStringIndex(content, -1) {
+		tag := content[loc[0]:loc[1]]
+		method := "GET"
+		if m := formMethod.FindStringSubmatch(tag); m != nil {
+			method = strings.ToUpper(m[1])
+		}
+		if method != "GET" {
+			note(loc[0], "form submits with %s: add a %s.html (or %s_sse.html) next to the target route to handle it", method, strings.ToLower(method), strings.ToLower(method))
+		} else {
+			note(loc[0], "form submits with GET: consider a Datastar @get instead of a full page load")
+		}
+	}
+	for _, loc := range inlineScript.FindAllStringIndex(content, -1) {
+		if !strings.Contains(strings.ToLower(content[loc[0]:loc[1]]), "src") {
+			note(loc[0], "inline script: review for DOM changes that Datastar morphing will undo")
+		}
+	}
+	for _, loc := range srcsetAttr.FindAllStringIndex(content, -1) {
+		note(loc[0], "srcset URLs are not rewritten")
+	}
+	for i, line := range strings.Split(content, "\n") {
+		if isMarker := strings.TrimRight(line, " \t\r") == "==="; isMarker || strings.HasPrefix(line, "=== ") {
+			notes = append(notes, Note{File: rel, Line: i + 1, Message: `line looks like a section separator ("==="); change it or the page will be split`})
+		}
+	}
+	if n := strings.Count(content, "{{"); n > 0 {
+		notes = append(notes, Note{File: rel, Message: fmt.Sprintf("escaped %d literal {{ so they aren't parsed as template actions", n)})
+	}
+
+	out := linkAttr.ReplaceAllStringFunc(content, func(attr string) string {
+		m := linkAttr.FindStringSubmatch(attr)
+		quote, value := `"`, m[3]
+		if strings.HasPrefix(m[2], "'") {
+			quote, value = "'", m[4]
+		}
+		return m[1] + "=" + quote + rewriteURL(rel, value) + quote
+	})
+
+	out = strings.ReplaceAll(out, "{{", `{{"{{"}}`)
+
+	if !strings.Contains(strings.ToLower(out), "datastar") {
+		if loc := headClose.FindStringIndex(out); loc != nil {
+			out = out[:loc[0]] + "    " + DatastarScript + "\n" + out[loc[0]:]
+		} else {
+			notes = append(notes, Note{File: rel, Message: "no </head>: Datastar script not injected"})
+		}
+	}
+
+	// Empty frontmatter keeps a page that starts with "---" from being read as YAML
+	return "---\n---\n\n" + out, notes
+}
+
+// rewriteURL maps a link from page rel onto the playground layout. External
+// URLs, fragments, and directory or extensionless links are left alone.
+func rewriteURL(rel, u string) string {
+	if u == "" || externalURL.MatchString(u) || strings.Contains(u, "{{") {
+		return u
+	}
+	target, suffix := u, ""
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		target, suffix = u[:i], u[i:]
+	}
+	if target == "" || strings.HasSuffix(target, "/") || path.Ext(target) == "" {
+		return u
+	}
+
+	var abs string
+	if strings.HasPrefix(target, "/") {
+		abs = strings.TrimPrefix(path.Clean(target), "/")
+	} else {
+		abs = path.Join(path.Dir(rel), target)
+	}
+	if abs == "" || abs == "." || strings.HasPrefix(abs, "../") {
+		return u
+	}
+	if isPage(abs) {
+		return PageURL(abs) + suffix
+	}
+	return "/" + AssetPath(abs) + suffix
+}
+
+// lineAt returns the 1-based line number of offset in s.
+func lineAt(s string, offset int) int {
+	return strings.Count(s[:offset], "\n") + 1
+}
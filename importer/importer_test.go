@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPagePath(t *testing.T) {
+	tests := []struct{ in, path, url string }{
+		{"index.html", "index.html", "/"},
+		{"about.html", "about/index.html", "/about/"},
+		{"blog/index.html", "blog/index.html", "/blog/"},
+		{"blog/first-post.htm", "blog/first-post/index.html", "/blog/first-post/"},
+		{"post.html", "post/index.html", "/post/"},
+	}
+	for _, tt := range tests {
+		if got := PagePath(tt.in); got != tt.path {
+			t.Errorf("PagePath(%q) = %q, want %q", tt.in, got, tt.path)
+		}
+		if got := PageURL(tt.in); got != tt.url {
+			t.Errorf("PageURL(%q) = %q, want %q", tt.in, got, tt.url)
+		}
+	}
+}
+
+func TestRewriteURL(t *testing.T) {
+	tests := []struct{ page, in, want string }{
+		{"index.html", "about.html", "/about/"},
+		{"index.html", "about.html#team", "/about/#team"},
+		{"blog/post.html", "../index.html", "/"},
+		{"blog/post.html", "other.html?x=1", "/blog/other/?x=1"},
+		{"blog/post.html", "../css/site.css", "/static/css/site.css"},
+		{"blog/post.html", "/img/logo.png", "/static/img/logo.png"},
+		{"index.html", "https://example.com/a.html", "https://example.com/a.html"},
+		{"index.html", "//cdn.example.com/x.js", "//cdn.example.com/x.js"},
+		{"index.html", "mailto:me@example.com", "mailto:me@example.com"},
+		{"index.html", "#top", "#top"},
+		{"index.html", "docs/", "docs/"},
+		{"index.html", "docs", "docs"},
+		{"index.html", "../outside.css", "../outside.css"},
+	}
+	for _, tt := range tests {
+		if got := rewriteURL(tt.page, tt.in); got != tt.want {
+			t.Errorf("rewriteURL(%q, %q) = %q, want %q", tt.page, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPage(t *testing.T) {
+	in := `<html>
+<head><link href='style.css' rel="stylesheet"></head>
+<body>
+<a href="about.html">About {{name}}</a>
+<form method="post" action="contact.html"></form>
+<script>document.title = "x"</script>
+===
+</body>
+</html>`
+	out, notes := ConvertPage("index.html", in)
+
+	for _, want := range []string{
+		"---\n---\n",
+		`href='/static/style.css'`,
+		`href="/about/"`,
+		`action="/contact/"`,
+		`{{"{{"}}name}}`,
+		DatastarScript + "\n</head>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	wantNotes := map[int]string{5: "POST", 6: "inline script", 7: "section separator"}
+	for line, substr := range wantNotes {
+		found := false
+		for _, n := range notes {
+			if n.Line == line && strings.Contains(n.Message, substr) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no note mentioning %q on line %d; notes: %v", substr, line, notes)
+		}
+	}
+}
+
+func TestConvertPageKeepsExistingDatastar(t *testing.T) {
+	in := `<head><script type="module" src="/js/datastar.js"></script></head>`
+	out, _ := ConvertPage("index.html", in)
+	if strings.Contains(out, DatastarScript) {
+		t.Error("Datastar injected into a page that already loads it")
+	}
+}
+
+func TestImportSite(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"index.html":         "<head></head><a href=\"about.html\">About</a>",
+		"about.html":         "<head></head><img src=\"img/me.png\">",
+		"img/me.png":         "png",
+		".git/config":        "ignored",
+		"contact.htm":        "<head></head>contact",
+		"contact/index.html": "<head></head>collides",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		os.WriteFile(p, []byte(content), 0o644)
+	}
+
+	rep, err := ImportSite(src, dst, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Pages) != 3 || len(rep.Assets) != 1 {
+		t.Errorf("pages=%v assets=%v", rep.Pages, rep.Assets)
+	}
+	for _, p := range []string{"index.html", "about/index.html", "static/img/me.png"} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Errorf("missing %s", p)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "static/.git")); err == nil {
+		t.Error("dot directory was imported")
+	}
+	about, _ := os.ReadFile(filepath.Join(dst, "about/index.html"))
+	if !strings.Contains(string(about), `src="/static/img/me.png"`) {
+		t.Errorf("about page links not rewritten:\n%s", about)
+	}
+	collided := false
+	for _, n := range rep.Notes {
+		if strings.Contains(n.Message, "already maps to") {
+			collided = true
+		}
+	}
+	if !collided {
+		t.Errorf("collision not reported: %v", rep.Notes)
+	}
+}
@@ -1,47 +1,369 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"maps"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/dataSPA/dataSPA-playground/bench"
+	"github.com/dataSPA/dataSPA-playground/bundle"
+	"github.com/dataSPA/dataSPA-playground/docker"
 	"github.com/dataSPA/dataSPA-playground/gist"
+	"github.com/dataSPA/dataSPA-playground/importer"
 	"github.com/dataSPA/dataSPA-playground/server"
+	"github.com/dataSPA/dataSPA-playground/share"
+	"github.com/dataSPA/dataSPA-playground/tui"
+	"github.com/mdp/qrterminal/v3"
 	"github.com/urfave/cli/v3"
 )
 
 //go:embed skeleton
 var skeletonFS embed.FS
 
+// version is this dsplay's release. Release builds set it with
+// -ldflags "-X main.version=<tag>"; go install records it in the build info.
+var version string
+
+// dsplayVersion returns the release this binary was built from, or "dev".
+func dsplayVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// githubClientID is the OAuth app dsplay login signs in with. Release builds
+// set it with -ldflags "-X main.githubClientID=<client id>".
+var githubClientID string
+
 func main() {
 	app := &cli.Command{
 		Name:  "dsplay",
 		Usage: "Datastar Playground Engine",
 		Flags: []cli.Flag{
 			&cli.IntFlag{
-				Name:  "port",
-				Value: 8080,
-				Usage: "port to listen on",
+				Name:    "port",
+				Value:   8080,
+				Usage:   "port to listen on",
+				Sources: cli.EnvVars("DSPLAY_PORT", "PORT"),
+			},
+			&cli.StringFlag{
+				Name:  "addr",
+				Usage: "interface to bind, e.g. 127.0.0.1 or 0.0.0.0 (default: all interfaces)",
+			},
+			&cli.StringFlag{
+				Name:  "unix-socket",
+				Usage: "listen on a unix socket instead of a TCP port",
+			},
+			&cli.StringFlag{
+				Name:  "base-path",
+				Usage: "URL prefix to serve the playground under, e.g. /play (for reverse proxies)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "trusted-proxies",
+				Usage: "believe X-Forwarded-For/-Proto/-Host from these proxy IPs or CIDR ranges, or \"unix\" for --unix-socket peers (repeatable)",
+			},
+			&cli.DurationFlag{
+				Name:  "read-timeout",
+				Value: 30 * time.Second,
+				Usage: "maximum time to read a request, including its body (0 = none)",
+			},
+			&cli.DurationFlag{
+				Name:  "write-timeout",
+				Value: 30 * time.Second,
+				Usage: "maximum time to write a non-streaming response; SSE and WebSocket streams are exempt (0 = none)",
+			},
+			&cli.DurationFlag{
+				Name:  "idle-timeout",
+				Value: 2 * time.Minute,
+				Usage: "how long idle keep-alive connections stay open",
+			},
+			&cli.Int64Flag{
+				Name:  "max-body",
+				Value: 1 << 20,
+				Usage: "maximum request body size in bytes, e.g. POSTed signals (0 = unlimited)",
+			},
+			&cli.Int64Flag{
+				Name:  "max-upload",
+				Value: 10 << 20,
+				Usage: "maximum multipart form size in bytes, uploaded files included (0 = unlimited)",
+			},
+			&cli.StringFlag{
+				Name:  "upload-dir",
+				Usage: "directory uploaded files are saved in, one subdirectory per session (default: a temp directory removed on exit)",
+			},
+			&cli.IntFlag{
+				Name:  "compress-level",
+				Value: 5,
+				Usage: "brotli/gzip level (1-9) for HTML and static assets; SSE and WebSocket streams are never compressed (0 = off)",
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "server settings file (default: dsplay.yaml in the playground directory)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "basic-auth",
+				Usage: "require HTTP basic auth with this user:pass (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:    "auth-token",
+				Usage:   "accept this bearer token, or ?token= on a shared link (repeatable)",
+				Sources: cli.EnvVars("DSPLAY_AUTH_TOKENS"),
+			},
+			&cli.BoolFlag{
+				Name:  "csrf",
+				Usage: "require a {{csrfField}} token on form posts that aren't Datastar requests",
+			},
+			&cli.StringFlag{
+				Name:    "secret",
+				Value:   "ds-play-dev-secret-change-me",
+				Usage:   "session cookie secret",
+				Sources: cli.EnvVars("DSPLAY_SECRET"),
+			},
+			&cli.StringSliceFlag{
+				Name:  "previous-secret",
+				Usage: "retired session secret still accepted, for rotating --secret without logging everyone out (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "session-cookie",
+				Value: "ds-play",
+				Usage: "session cookie name",
+			},
+			&cli.DurationFlag{
+				Name:  "session-max-age",
+				Value: time.Hour,
+				Usage: "session cookie lifetime (negative = until the browser closes)",
+			},
+			&cli.BoolFlag{
+				Name:  "session-secure",
+				Usage: "only send the session cookie over HTTPS",
+			},
+			&cli.StringFlag{
+				Name:  "session-samesite",
+				Value: "lax",
+				Usage: "session cookie SameSite: lax, strict, or none (for demos embedded in cross-site iframes; implies --session-secure)",
+			},
+			&cli.StringFlag{
+				Name:  "session-domain",
+				Usage: "session cookie domain",
 			},
 			&cli.StringFlag{
-				Name:  "secret",
-				Value: "ds-play-dev-secret-change-me",
-				Usage: "session cookie secret",
+				Name:  "session-store",
+				Value: "cookie",
+				Usage: "where session data lives: cookie, fs (files on disk), or nats (embedded JetStream key-value); fs and nats keep only the session ID in the cookie",
+			},
+			&cli.StringFlag{
+				Name:  "session-dir",
+				Usage: "directory for the fs session store, or JetStream storage for nats (default: a temp directory)",
+			},
+			&cli.StringFlag{
+				Name:    "nats-url",
+				Usage:   "connect to this NATS server instead of starting an embedded one, so instances share nats sessions and live updates (it needs JetStream for --session-store nats)",
+				Sources: cli.EnvVars("DSPLAY_NATS_URL"),
 			},
 			&cli.StringFlag{
 				Name:    "github-token",
-				Usage:   "GitHub personal access token",
+				Usage:   "GitHub personal access token (default: the one saved by dsplay login)",
 				Sources: cli.EnvVars("GITHUB_TOKEN"),
 			},
+			&cli.StringFlag{
+				Name:    "github-app-id",
+				Usage:   "load gists as a GitHub App installation instead of with a token: the app's client ID or app ID",
+				Sources: cli.EnvVars("DSPLAY_GITHUB_APP_ID"),
+			},
+			&cli.Int64Flag{
+				Name:    "github-app-installation",
+				Usage:   "installation ID of the GitHub App",
+				Sources: cli.EnvVars("DSPLAY_GITHUB_APP_INSTALLATION"),
+			},
+			&cli.StringFlag{
+				Name:    "github-app-key",
+				Usage:   "PEM private key file of the GitHub App",
+				Sources: cli.EnvVars("DSPLAY_GITHUB_APP_KEY"),
+			},
+			&cli.StringFlag{
+				Name:    "gitlab-token",
+				Usage:   "GitLab personal access token with the api scope, for sharing snippets",
+				Sources: cli.EnvVars("GITLAB_TOKEN"),
+			},
+			&cli.StringFlag{
+				Name:    "gitlab-url",
+				Value:   share.DefaultGitLabURL,
+				Usage:   "GitLab instance to share snippets on",
+				Sources: cli.EnvVars("GITLAB_URL"),
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: 2 * time.Minute,
+				Usage: "time limit for sharing, loading or cloning a playground, and for gist commands, retries included (0 = none)",
+			},
+			&cli.BoolFlag{
+				Name:  "qr",
+				Value: true,
+				Usage: "print a QR code of a shared playground's link, and of the address phones on your network can open when serving beyond localhost",
+			},
+			&cli.BoolFlag{
+				Name:  "short-link",
+				Usage: "also register a short link to a shared playground, or to the address it is served on, with --shortener",
+			},
+			&cli.StringFlag{
+				Name:  "shortener",
+				Value: share.DefaultShortener,
+				Usage: "link shortener for --short-link: a URL with %s where the long URL goes, answering with the short link as text",
+			},
 			&cli.BoolFlag{
 				Name:  "debug",
 				Usage: "enable debug logging for route resolution, request handling, and template rendering",
 			},
+			&cli.BoolFlag{
+				Name:  "debug-endpoints",
+				Usage: "mount net/http/pprof and expvar under /_debug/ for profiling",
+			},
+			&cli.BoolFlag{
+				Name:  "dev",
+				Usage: "development mode: show template errors as an in-page overlay instead of a bare 500",
+			},
+			&cli.BoolFlag{
+				Name:  "live-reload",
+				Usage: "reload open pages in the browser when playground files change",
+			},
+			&cli.BoolFlag{
+				Name:  "tui",
+				Usage: "show a live terminal dashboard of requests, streams, counters and NATS traffic instead of the request log",
+			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "append every request and its response (including SSE events) to this file, for dsplay replay",
+			},
+			&cli.BoolFlag{
+				Name:  "strict-templates",
+				Usage: "fail templates that reference missing keys (e.g. unset signals) instead of rendering <no value>",
+			},
+			&cli.BoolFlag{
+				Name:  "admin",
+				Usage: "mount the admin dashboard at /_admin/",
+			},
+			&cli.StringSliceFlag{
+				Name:    "admin-token",
+				Usage:   "token required to use the admin dashboard, as a bearer token or ?token= (repeatable)",
+				Sources: cli.EnvVars("DSPLAY_ADMIN_TOKEN"),
+			},
+			&cli.BoolFlag{
+				Name:  "source",
+				Value: true,
+				Usage: "mount the playground source viewer at /_source/",
+			},
+			&cli.IntFlag{
+				Name:  "max-sse",
+				Usage: "maximum simultaneous SSE connections (0 = unlimited)",
+			},
+			&cli.IntFlag{
+				Name:  "max-sse-per-session",
+				Usage: "maximum simultaneous SSE connections per session (0 = unlimited)",
+			},
+			&cli.IntFlag{
+				Name:  "max-sse-per-ip",
+				Usage: "maximum simultaneous SSE connections per client IP (0 = unlimited)",
+			},
+			&cli.FloatFlag{
+				Name:  "rate-limit",
+				Usage: "requests per second allowed per client IP (0 = unlimited)",
+			},
+			&cli.FloatFlag{
+				Name:  "rate-limit-session",
+				Usage: "requests per second allowed per session (0 = unlimited)",
+			},
+			&cli.IntFlag{
+				Name:  "rate-burst",
+				Value: 20,
+				Usage: "requests a client may make in a burst before --rate-limit applies",
+			},
+			&cli.IntFlag{
+				Name:  "job-workers",
+				Value: 2,
+				Usage: "number of workers processing simulated jobs",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow-tail",
+				Usage: "allow tail: routes to stream files or commands matching this pattern (repeatable, e.g. /var/log/*.log or \"journalctl -f -u *\"; commands must match every argument)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "set a template global, available as .Globals.key (repeatable, key=value)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow-env",
+				Usage: "allow the env template function to read this variable (repeatable; DSPLAY_VAR_* are always allowed)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow-fetch",
+				Usage: "allow fetchJSON and fetchText to reach hosts matching this pattern (repeatable, e.g. api.github.com or *.example.com)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "ws-origin",
+				Usage: "also accept WebSocket connections from pages on hosts matching this pattern (repeatable, e.g. *.example.com)",
+			},
+			&cli.DurationFlag{
+				Name:  "fetch-timeout",
+				Usage: "timeout for each fetchJSON / fetchText request (default 5s)",
+			},
+			&cli.StringFlag{
+				Name:  "prefs-file",
+				Usage: "JSON file persisting visitor preferences across restarts (default: memory only)",
+			},
+			&cli.DurationFlag{
+				Name:  "prefs-ttl",
+				Usage: "forget a visitor's preferences once their session goes unseen this long (default 720h)",
+			},
+			&cli.StringFlag{
+				Name:  "public-url",
+				Usage: "externally visible URL of the playground root including any base path, used for login callbacks (default: http://localhost:<port><base-path>)",
+			},
+			&cli.StringFlag{
+				Name:    "auth-github-client-id",
+				Usage:   "GitHub OAuth app client ID (enables login at /_auth/login/github)",
+				Sources: cli.EnvVars("DSPLAY_GITHUB_CLIENT_ID"),
+			},
+			&cli.StringFlag{
+				Name:    "auth-github-client-secret",
+				Usage:   "GitHub OAuth app client secret",
+				Sources: cli.EnvVars("DSPLAY_GITHUB_CLIENT_SECRET"),
+			},
+			&cli.StringFlag{
+				Name:    "auth-oidc-issuer",
+				Usage:   "OpenID Connect issuer URL (enables login at /_auth/login/oidc)",
+				Sources: cli.EnvVars("DSPLAY_OIDC_ISSUER"),
+			},
+			&cli.StringFlag{
+				Name:    "auth-oidc-client-id",
+				Usage:   "OpenID Connect client ID",
+				Sources: cli.EnvVars("DSPLAY_OIDC_CLIENT_ID"),
+			},
+			&cli.StringFlag{
+				Name:    "auth-oidc-client-secret",
+				Usage:   "OpenID Connect client secret",
+				Sources: cli.EnvVars("DSPLAY_OIDC_CLIENT_SECRET"),
+			},
 		},
 		Action: func(ctx context.Context, c *cli.Command) error {
 			return runServe(ctx, c, "")
@@ -56,6 +378,11 @@ func main() {
 						Name:  "force",
 						Usage: "create files even if directory exists and is not empty",
 					},
+					&cli.StringFlag{
+						Name:  "template",
+						Usage: "skeleton to start from: counter, chat, todo, dashboard or form-validation (asked for when run without flags or arguments in a terminal)",
+						Value: defaultTemplate,
+					},
 				},
 				Action: func(ctx context.Context, c *cli.Command) error {
 					return runInit(ctx, c)
@@ -63,142 +390,1330 @@ func main() {
 			},
 			{
 				Name:  "share",
-				Usage: "Publish the current playground directory to a GitHub gist",
+				Usage: "Publish the current playground directory to a GitHub gist, GitLab snippet or git repository",
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name:  "secret",
-						Usage: "make the gist secret (default: public)",
+						Usage: "make the gist or snippet secret (default: public)",
 					},
 					&cli.StringFlag{
 						Name:  "description",
-						Usage: "description for the gist",
+						Usage: "description for the gist or snippet, or the git commit message",
 					},
 					&cli.StringFlag{
 						Name:  "dir",
 						Usage: "playground directory to share (default: current directory)",
 					},
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "where to publish: github, gitlab or git (default: github, or git with --remote)",
+					},
+					&cli.StringFlag{
+						Name:  "remote",
+						Usage: "git repository URL to push the playground to",
+					},
+					&cli.StringFlag{
+						Name:  "branch",
+						Usage: "branch to push to (default: the repository's default branch)",
+					},
+					&cli.StringSliceFlag{
+						Name:  "exclude",
+						Usage: "leave out files matching this glob, e.g. *.psd, drafts or drafts/*.html (repeatable)",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "list the files that would be published, with their sizes and names, without publishing",
+					},
 				},
 				Action: func(ctx context.Context, c *cli.Command) error {
 					return runShare(ctx, c)
 				},
 			},
 			{
-				Name:      "serve",
-				Usage:     "Serve a playground from a directory or GitHub gist URL",
-				ArgsUsage: "[directory or gist URL]",
+				Name:      "diff",
+				Usage:     "Show how the local playground differs from its gist, as a unified diff",
+				ArgsUsage: "[gist ID or URL]",
 				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory to compare (default: current directory)",
+					},
 					&cli.BoolFlag{
-						Name:  "clone",
-						Usage: "clone gist to disk instead of serving from memory",
+						Name:  "stat",
+						Usage: "only list the files that differ",
 					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runDiff(ctx, c)
+				},
+			},
+			{
+				Name:  "version",
+				Usage: "Print the dsplay version and the Datastar release it serves",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					fmt.Printf("dsplay %s (Datastar %s)\n", dsplayVersion(), server.DatastarVersion)
+					return nil
+				},
+			},
+			{
+				Name:  "login",
+				Usage: "Sign in to GitHub in the browser and save the token for share and secret gists",
+				Flags: []cli.Flag{
 					&cli.StringFlag{
-						Name:  "clone-dir",
-						Usage: "directory to clone gist into (default: current directory)",
+						Name:    "client-id",
+						Value:   githubClientID,
+						Usage:   "client ID of a GitHub OAuth app with device flow enabled",
+						Sources: cli.EnvVars("DSPLAY_GITHUB_CLIENT_ID"),
 					},
 				},
 				Action: func(ctx context.Context, c *cli.Command) error {
-					return runServe(ctx, c, c.Args().First())
+					return runLogin(ctx, c)
 				},
 			},
-		},
+			{
+				Name:  "gist",
+				Usage: "Manage your shared playground gists",
+				Commands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List your playground gists",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "list every gist, not only playgrounds",
+							},
+						},
+						Action: func(ctx context.Context, c *cli.Command) error {
+							return runGistList(ctx, c)
+						},
+					},
+					{
+						Name:      "delete",
+						Usage:     "Delete one of your gists",
+						ArgsUsage: "<gist id or URL>",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "yes",
+								Usage: "delete without asking for confirmation",
+							},
+						},
+						Action: func(ctx context.Context, c *cli.Command) error {
+							return runGistDelete(ctx, c)
+						},
+					},
+					{
+						Name:      "fork",
+						Usage:     "Fork someone's playground gist into your account",
+						ArgsUsage: "<gist id or URL>",
+						Action: func(ctx context.Context, c *cli.Command) error {
+							return runGistFork(ctx, c)
+						},
+					},
+				},
+			},
+			{
+				Name:  "logout",
+				Usage: "Forget the GitHub token saved by dsplay login",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if err := gist.DeleteToken(); err != nil {
+						return err
+					}
+					fmt.Println("Logged out of GitHub.")
+					return nil
+				},
+			},
+			{
+				Name:      "render",
+				Usage:     "Render a route's section to stdout without starting the server",
+				ArgsUsage: "<route>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "method",
+						Value: "GET",
+						Usage: "HTTP method to render",
+					},
+					&cli.StringFlag{
+						Name:  "signals",
+						Usage: `Datastar signals as JSON, e.g. '{"foo":1}'`,
+					},
+					&cli.IntFlag{
+						Name:  "section",
+						Usage: "section index to render (default: the section the server would pick first)",
+					},
+					&cli.BoolFlag{
+						Name:  "sse",
+						Usage: "render the route's SSE template instead of its HTML template",
+					},
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory (default: current directory)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runRender(ctx, c)
+				},
+			},
+			{
+				Name:      "build",
+				Usage:     "Package the playground and dsplay into one executable that serves it",
+				ArgsUsage: "<output file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory to package (default: current directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "include-config",
+						Usage: "bundle dsplay.yaml too (it may hold credentials)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite the output file if it exists",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runBuild(ctx, c)
+				},
+			},
+			{
+				Name:      "pack",
+				Usage:     "Pack the playground into one .dsplay.zip file that dsplay serve accepts",
+				ArgsUsage: "[output file]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory to pack (default: current directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "include-config",
+						Usage: "pack dsplay.yaml too (it may hold credentials)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite the output file if it exists",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runPack(ctx, c)
+				},
+			},
+			{
+				Name:  "dockerize",
+				Usage: "Write a Dockerfile that packages the playground and dsplay as a container image",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory to write into (default: current directory)",
+					},
+					&cli.StringFlag{
+						Name:  "version",
+						Value: "main",
+						Usage: "dsplay git branch or tag the image builds",
+					},
+					&cli.BoolFlag{
+						Name:  "include-config",
+						Usage: "copy dsplay.yaml into the image too (it may hold credentials)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite an existing Dockerfile and .dockerignore",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runDockerize(ctx, c)
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "Render the playground's GET routes into a static site, e.g. for GitHub Pages",
+				ArgsUsage: "<output directory>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory (default: current directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "write into the output directory even if it isn't empty",
+					},
+					&cli.IntFlag{
+						Name:  "events",
+						Value: 20,
+						Usage: "SSE events to record for each stream",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runExport(ctx, c)
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "Convert existing content into a playground",
+				Commands: []*cli.Command{
+					{
+						Name:      "site",
+						Usage:     "Convert a plain static site into a playground",
+						ArgsUsage: "<site directory>",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "dir",
+								Usage: "playground directory to write (default: current directory)",
+							},
+							&cli.BoolFlag{
+								Name:  "force",
+								Usage: "overwrite files that already exist in the playground",
+							},
+						},
+						Action: func(ctx context.Context, c *cli.Command) error {
+							return runImportSite(ctx, c)
+						},
+					},
+				},
+			},
+			{
+				Name:  "test",
+				Usage: "Run the playground's _tests/*.yaml specs against golden files",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "rewrite golden files with the current output",
+					},
+					&cli.StringFlag{
+						Name:  "dir",
+						Usage: "playground directory (default: current directory)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runTest(ctx, c)
+				},
+			},
+			{
+				Name:      "replay",
+				Usage:     "Re-send a --record recording against a server, or export it as HAR or .http",
+				ArgsUsage: "<recording>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "target",
+						Value: "http://localhost:8080",
+						Usage: "server to replay against (include any base path the recording doesn't)",
+					},
+					&cli.FloatFlag{
+						Name:  "speed",
+						Value: 1,
+						Usage: "replay speed multiplier (0 = send everything without waiting)",
+					},
+					&cli.StringFlag{
+						Name:  "export",
+						Usage: "write the recording as har or http instead of replaying it",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Usage: "file to write the export to (default: stdout)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runReplay(ctx, c)
+				},
+			},
+			{
+				Name:      "bench",
+				Usage:     "Load-test a running playground with many concurrent HTML or SSE clients",
+				ArgsUsage: "<url>",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "connections",
+						Value: 50,
+						Usage: "number of concurrent clients, each its own visitor session",
+					},
+					&cli.DurationFlag{
+						Name:  "duration",
+						Value: 10 * time.Second,
+						Usage: "how long to run",
+					},
+					&cli.BoolFlag{
+						Name:  "sse",
+						Usage: "hold Datastar SSE streams open instead of requesting the page",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runBench(ctx, c)
+				},
+			},
+			{
+				Name:      "serve",
+				Usage:     "Serve a playground from a directory, GitHub gist, GitLab snippet or git repository",
+				ArgsUsage: "[directory or URL]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "provider",
+						Usage: "where the URL points: github, gitlab or git (default: guessed from the URL)",
+					},
+					&cli.BoolFlag{
+						Name:  "clone",
+						Usage: "clone gist to disk instead of serving from memory",
+					},
+					&cli.StringFlag{
+						Name:  "clone-dir",
+						Usage: "directory to clone gist into (default: current directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "merge",
+						Usage: "with --clone, skip files already identical to the gist's instead of refusing",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "with --clone, overwrite files that differ from the gist's",
+					},
+					&cli.StringFlag{
+						Name:  "revision",
+						Usage: "gist revision SHA to serve (default: the one in the URL, or the latest)",
+					},
+					&cli.DurationFlag{
+						Name:  "gist-poll",
+						Value: 30 * time.Second,
+						Usage: "check a gist served from memory for edits this often and load them (0 = never)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runServe(ctx, c, c.Args().First())
+				},
+			},
+			{
+				Name:  "host",
+				Usage: "Serve every playground under a root directory at its own path, e.g. for a workshop",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "root",
+						Usage: "directory holding the playgrounds (root/alice/demo1 is served at /alice/demo1/)",
+					},
+					&cli.BoolFlag{
+						Name:  "gists",
+						Usage: "serve any gist playground at <gist id>.<gist host>, fetched on first use and sandboxed",
+					},
+					&cli.StringFlag{
+						Name:  "gist-host",
+						Usage: "host whose subdomains serve gists, one origin each, e.g. gists.example.com (needs wildcard DNS) or localhost:8080",
+					},
+					&cli.DurationFlag{
+						Name:  "gist-ttl",
+						Value: 30 * time.Minute,
+						Usage: "unload a gist playground after this long without requests",
+					},
+					&cli.IntFlag{
+						Name:  "gist-max",
+						Value: 20,
+						Usage: "gist playgrounds loaded at once (the least recently used is unloaded)",
+					},
+					&cli.FloatFlag{
+						Name:  "gist-fetch-rate",
+						Value: 10,
+						Usage: "gist loads per client IP per minute",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return runHost(ctx, c)
+				},
+			},
+			{
+				Name:      "tui",
+				Usage:     "Serve a playground with a live terminal dashboard (same as serve --tui)",
+				ArgsUsage: "[directory or gist URL]",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if err := c.Set("tui", "true"); err != nil {
+						return err
+					}
+					return runServe(ctx, c, c.Args().First())
+				},
+			},
+		},
+	}
+
+	if err := app.Run(context.Background(), os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// defaultTemplate is the skeleton dsplay init writes unless told otherwise.
+const defaultTemplate = "counter"
+
+// skeletonTemplate is one of the embedded skeletons under skeleton/.
+type skeletonTemplate struct {
+	Name        string
+	Description string // from its playground.yaml
+}
+
+// skeletonTemplates lists the embedded skeletons by name.
+func skeletonTemplates() ([]skeletonTemplate, error) {
+	entries, err := fs.ReadDir(skeletonFS, "skeleton")
+	if err != nil {
+		return nil, err
+	}
+	var templates []skeletonTemplate
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		sub, err := fs.Sub(skeletonFS, "skeleton/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, err := server.LoadManifest(sub)
+		if err != nil {
+			return nil, fmt.Errorf("skeleton %s: %w", e.Name(), err)
+		}
+		templates = append(templates, skeletonTemplate{e.Name(), m.Description})
+	}
+	return templates, nil
+}
+
+func hasTemplate(templates []skeletonTemplate, name string) bool {
+	return slices.ContainsFunc(templates, func(t skeletonTemplate) bool { return t.Name == name })
+}
+
+// promptInit asks which template to start from and where to put it,
+// returning "" for the directory to mean the current one.
+func promptInit(templates []skeletonTemplate) (name, dir string, err error) {
+	in := bufio.NewReader(os.Stdin)
+	fmt.Println("Which template would you like to start from?")
+	for i, t := range templates {
+		fmt.Printf("  %d) %-16s %s\n", i+1, t.Name, t.Description)
+	}
+	for name == "" {
+		fmt.Printf("Template [%s]: ", defaultTemplate)
+		answer, readErr := in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		n, convErr := strconv.Atoi(answer)
+		switch {
+		case answer == "" && readErr != nil:
+			return "", "", errors.New("no template chosen")
+		case answer == "":
+			name = defaultTemplate
+		case convErr == nil && n >= 1 && n <= len(templates):
+			name = templates[n-1].Name
+		case hasTemplate(templates, answer):
+			name = answer
+		case readErr != nil:
+			return "", "", fmt.Errorf("unknown template %q", answer)
+		default:
+			fmt.Printf("Enter a number from 1 to %d or a template name.\n", len(templates))
+		}
+	}
+	fmt.Print("Directory [current directory]: ")
+	answer, _ := in.ReadString('\n')
+	return name, strings.TrimSpace(answer), nil
+}
+
+func runInit(ctx context.Context, c *cli.Command) error {
+	var targetDir string
+	var err error
+	force := c.Bool("force")
+
+	templates, err := skeletonTemplates()
+	if err != nil {
+		return fmt.Errorf("reading embedded skeletons: %w", err)
+	}
+	name, dir := c.String("template"), c.Args().First()
+	if !c.IsSet("template") && !force && dir == "" && isTerminal(os.Stdin) {
+		if name, dir, err = promptInit(templates); err != nil {
+			return err
+		}
+	}
+	if !hasTemplate(templates, name) {
+		names := make([]string, len(templates))
+		for i, t := range templates {
+			names[i] = t.Name
+		}
+		return fmt.Errorf("unknown template %q (choose from %s)", name, strings.Join(names, ", "))
+	}
+
+	// Determine target directory
+	if dir != "" {
+		targetDir = dir
+		// Convert to absolute path
+		targetDir, err = filepath.Abs(targetDir)
+		if err != nil {
+			return fmt.Errorf("resolving directory path: %w", err)
+		}
+
+		// Check if directory exists and is not empty (unless force is set)
+		if info, err := os.Stat(targetDir); err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s exists but is not a directory", targetDir)
+			}
+			if !force {
+				// Check if directory is not empty
+				entries, err := os.ReadDir(targetDir)
+				if err != nil {
+					return fmt.Errorf("reading directory: %w", err)
+				}
+				if len(entries) > 0 {
+					return fmt.Errorf("directory %s already exists and is not empty (use --force to override)", targetDir)
+				}
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("checking directory: %w", err)
+		}
+
+		// Create directory if it doesn't exist
+		if err := os.MkdirAll(targetDir, 0o755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+	} else {
+		// Use current working directory
+		targetDir, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting working directory: %w", err)
+		}
+
+		// Check if index.html already exists in current directory (unless force is set)
+		if !force {
+			if _, err := os.Stat(filepath.Join(targetDir, "index.html")); err == nil {
+				return fmt.Errorf("index.html already exists (use --force to override)")
+			}
+		}
+	}
+
+	sub, err := fs.Sub(skeletonFS, "skeleton/"+name)
+	if err != nil {
+		return fmt.Errorf("reading embedded skeleton: %w", err)
+	}
+
+	err = fs.WalkDir(sub, ".", func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		dest := filepath.Join(targetDir, path)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0o755)
+		}
+		content, err := fs.ReadFile(sub, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, content, 0o644)
+	})
+	if err != nil {
+		return fmt.Errorf("writing skeleton files: %w", err)
+	}
+
+	fmt.Printf("Created %s playground at %s\n", name, targetDir)
+	if dir != "" {
+		// If a specific directory was provided, show how to serve it
+		fmt.Printf("Run 'dsplay serve %s' to serve it.\n", targetDir)
+	} else {
+		fmt.Println("Run 'dsplay' to serve it.")
+	}
+	return nil
+}
+
+// githubToken returns --github-token (or GITHUB_TOKEN), else the token
+// saved by dsplay login, else "".
+func githubToken(c *cli.Command) string {
+	if token := c.String("github-token"); token != "" {
+		return token
+	}
+	token, err := gist.LoadToken()
+	if err != nil && !errors.Is(err, gist.ErrNoToken) {
+		log.Printf("Reading saved GitHub token: %v", err)
+	}
+	return token
+}
+
+// withTimeout bounds a share or gist operation, retries and all, by
+// --timeout.
+func withTimeout(ctx context.Context, c *cli.Command) (context.Context, context.CancelFunc) {
+	if d := c.Duration("timeout"); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// gistClient returns a gist client for reading gists: a GitHub App
+// installation's when --github-app-id is set, otherwise token's.
+func gistClient(c *cli.Command, token string) (*gist.Client, error) {
+	appID := c.String("github-app-id")
+	if appID == "" {
+		return gist.NewClient(token), nil
+	}
+	if c.String("github-app-key") == "" || c.Int64("github-app-installation") == 0 {
+		return nil, fmt.Errorf("--github-app-id needs --github-app-installation and --github-app-key")
+	}
+	key, err := os.ReadFile(c.String("github-app-key"))
+	if err != nil {
+		return nil, fmt.Errorf("reading github app key: %w", err)
+	}
+	return gist.NewAppClient(gist.AppAuth{
+		AppID:          appID,
+		InstallationID: c.Int64("github-app-installation"),
+		PrivateKey:     key,
+	})
+}
+
+func runLogin(ctx context.Context, c *cli.Command) error {
+	clientID := c.String("client-id")
+	if clientID == "" {
+		return fmt.Errorf("login needs a GitHub OAuth app with device flow enabled (--client-id or DSPLAY_GITHUB_CLIENT_ID)")
+	}
+	token, err := gist.Login(ctx, clientID, func(userCode, verificationURL string) {
+		fmt.Printf("Open %s and enter the code: %s\n", verificationURL, userCode)
+		fmt.Println("Waiting for approval...")
+	})
+	if err != nil {
+		return err
+	}
+	where, err := gist.SaveToken(token)
+	if err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+	fmt.Printf("Logged in to GitHub. Token saved in %s.\n", where)
+	return nil
+}
+
+// loggedInClient returns a gist client for what command does on the user's
+// behalf, failing when there's no GitHub token.
+func loggedInClient(c *cli.Command, command string) (*gist.Client, error) {
+	token := githubToken(c)
+	if token == "" {
+		return nil, fmt.Errorf("%s requires a GitHub login: run 'dsplay login', or pass --github-token (or set GITHUB_TOKEN)\nCreate a token at https://github.com/settings/personal-access-tokens", command)
+	}
+	return gist.NewClient(token), nil
+}
+
+func runShare(ctx context.Context, c *cli.Command) error {
+	provider := c.String("provider")
+	if provider == "" {
+		provider = share.GitHub
+		if c.String("remote") != "" {
+			provider = share.Git
+		}
+	}
+	if !share.Valid(provider) {
+		return fmt.Errorf("unknown provider %q (want %s)", provider, strings.Join(share.Providers, ", "))
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = wd
+	}
+
+	// The manifest names the gist unless --description does
+	description := c.String("description")
+	if description == "" {
+		manifest, err := server.LoadManifest(os.DirFS(dir))
+		if err != nil {
+			return fmt.Errorf("reading playground.yaml: %w", err)
+		}
+		description = manifest.Summary()
+	}
+	if c.Bool("dry-run") {
+		return listShareFiles(dir, provider, description, c.StringSlice("exclude"))
+	}
+
+	p, err := shareProvider(c, provider, true)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	var total int64
+	ref, err := p.Save(ctx, dir, share.SaveOptions{
+		Public:      !c.Bool("secret"),
+		Description: description,
+		Exclude:     c.StringSlice("exclude"),
+		Progress: func(f gist.File, done, count int) {
+			total += f.Size
+			fmt.Printf("  [%d/%d] %s (%s)\n", done, count, f.Path, formatSize(f.Size))
+			if done == count {
+				fmt.Printf("Uploading %d files (%s) to %s...\n", count, formatSize(total), provider)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("sharing to %s: %w", provider, err)
+	}
+
+	// Remember the gist, so dsplay diff can compare with it
+	if provider == share.GitHub {
+		if err := gist.SaveOrigin(dir, ref); err != nil {
+			log.Printf("Recording the gist in %s: %v", gist.OriginFile, err)
+		}
+	}
+
+	serve := ref
+	if share.Detect(ref) != provider {
+		serve = "--provider " + provider + " " + ref
+	}
+	fmt.Printf("Shared:     %s\n", ref)
+	// Gists and snippets open in a browser, so phones get a link to them
+	web := share.Detect(ref) != share.Git && (strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://"))
+	link := ref
+	if web {
+		if short := shortLink(ctx, c, ref); short != "" {
+			fmt.Printf("Short link: %s\n", short)
+			link = short
+		}
+	}
+	fmt.Printf("Serve with: dsplay serve %s\n", serve)
+	if web {
+		printQR(c, os.Stdout, link)
+	}
+	return nil
+}
+
+// shortLink registers a short link to link when --short-link is set. It
+// returns "" when it isn't, or when registering fails.
+func shortLink(ctx context.Context, c *cli.Command, link string) string {
+	if !c.Bool("short-link") {
+		return ""
+	}
+	short, err := share.Shorten(ctx, c.String("shortener"), link)
+	if err != nil {
+		log.Printf("Registering a short link: %v", err)
+		return ""
+	}
+	return short
+}
+
+// printQR prints a QR code of link to out, for phones to scan, when --qr
+// is on and out is a terminal.
+func printQR(c *cli.Command, out *os.File, link string) {
+	if !c.Bool("qr") || !isTerminal(out) {
+		return
+	}
+	fmt.Fprintln(out)
+	qrterminal.GenerateWithConfig(link, qrterminal.Config{
+		Level:      qrterminal.L,
+		Writer:     out,
+		HalfBlocks: true,
+		QuietZone:  2,
+	})
+	fmt.Fprintln(out)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// announceLAN logs the address phones on the local network can open the
+// playground at, with a QR code, when it is served beyond localhost.
+func announceLAN(ctx context.Context, c *cli.Command, cfg server.Config) {
+	link := lanURL(cfg)
+	if link == "" {
+		return
+	}
+	log.Printf("On your network: %s", link)
+	if short := shortLink(ctx, c, link); short != "" {
+		log.Printf("Short link: %s", short)
+		link = short
+	}
+	printQR(c, os.Stderr, link)
+}
+
+// lanURL returns where others can reach a server listening as cfg says:
+// --public-url if set, or else the machine's local network address when
+// it listens on all interfaces. It is "" for localhost and unix sockets.
+func lanURL(cfg server.Config) string {
+	if cfg.UnixSocket != "" {
+		return ""
+	}
+	if cfg.PublicURL != "" {
+		return cfg.PublicURL
+	}
+	host := cfg.Addr
+	ip := net.ParseIP(host)
+	switch {
+	case host == "" || (ip != nil && ip.IsUnspecified()):
+		if host = lanIP(); host == "" {
+			return ""
+		}
+	case host == "localhost" || (ip != nil && ip.IsLoopback()):
+		return ""
+	}
+	base := "/"
+	if p := strings.Trim(cfg.BasePath, "/"); p != "" {
+		base += p + "/"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port)) + base
+}
+
+// lanIP returns this machine's address on the local network, preferring
+// private IPv4 addresses, or "" when it has none.
+func lanIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	var public string
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.To4() == nil || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.IsPrivate() {
+			return ipNet.IP.String()
+		}
+		if public == "" {
+			public = ipNet.IP.String()
+		}
+	}
+	return public
+}
+
+func runDiff(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() > 1 {
+		return fmt.Errorf("diff takes at most one gist ID or URL")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = wd
+	}
+	ref := c.Args().First()
+	if ref == "" {
+		var err error
+		if ref, err = gist.LoadOrigin(dir); err != nil {
+			return fmt.Errorf("reading %s: %w", gist.OriginFile, err)
+		}
+		if ref == "" {
+			return fmt.Errorf("%s has no gist recorded in %s: pass the gist ID or URL (share and serve --clone record it)", dir, gist.OriginFile)
+		}
+	}
+	gistID, revision := gist.ParseGistRef(ref)
+	gc, err := gistClient(c, githubToken(c))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	diffs, err := gc.Diff(ctx, gistID, revision, dir)
+	if err != nil {
+		return err
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("No differences from gist %s\n", gistID)
+		return nil
+	}
+	if c.Bool("stat") {
+		for _, d := range diffs {
+			fmt.Printf("%-8s %s\n", d.Status, d.Path)
+		}
+		return nil
+	}
+	fmt.Printf("Comparing %s (---) with gist %s (+++)\n", dir, gistID)
+	for _, d := range diffs {
+		fmt.Printf("\n%s", d.Unified)
+	}
+	return nil
+}
+
+// listShareFiles prints what dsplay share would publish from dir.
+func listShareFiles(dir, provider, description string, exclude []string) error {
+	files, err := gist.ListFiles(dir, exclude)
+	if err != nil {
+		return err
+	}
+	if description == "" {
+		description = "(no description)"
+	}
+	fmt.Printf("Would share to %s: %s\n\n", provider, description)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	// Gists and snippets are flat; git keeps the directory layout
+	if provider == share.Git {
+		fmt.Fprintln(w, "FILE\tSIZE")
+	} else {
+		fmt.Fprintln(w, "FILE\tSIZE\tSAVED AS")
+	}
+	var total int64
+	for _, f := range files {
+		total += f.Size
+		if provider == share.Git {
+			fmt.Fprintf(w, "%s\t%s\n", f.Path, formatSize(f.Size))
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", f.Path, formatSize(f.Size), f.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d files, %s. Nothing was published.\n", len(files), formatSize(total))
+	return nil
+}
+
+// formatSize formats a byte count for people, e.g. 1.2 kB.
+func formatSize(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d B", n)
+	case n < 1000*1000:
+		return fmt.Sprintf("%.1f kB", float64(n)/1000)
+	}
+	return fmt.Sprintf("%.1f MB", float64(n)/(1000*1000))
+}
+
+// shareProvider returns the named share provider, set up for publishing
+// when save is set and otherwise for loading.
+func shareProvider(c *cli.Command, name string, save bool) (share.Provider, error) {
+	switch name {
+	case share.GitHub:
+		var gc *gist.Client
+		var err error
+		if save {
+			gc, err = loggedInClient(c, "share")
+		} else {
+			gc, err = gistClient(c, githubToken(c))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return share.GitHubGists{Client: gc}, nil
+	case share.GitLab:
+		if save && c.String("gitlab-token") == "" {
+			return nil, fmt.Errorf("sharing to GitLab requires a token with the api scope (--gitlab-token or GITLAB_TOKEN)")
+		}
+		return share.GitLabSnippets{BaseURL: c.String("gitlab-url"), Token: c.String("gitlab-token")}, nil
+	case share.Git:
+		if save && c.String("remote") == "" {
+			return nil, fmt.Errorf("sharing to git requires --remote <repository URL>")
+		}
+		return share.GitRemote{URL: c.String("remote"), Branch: c.String("branch")}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (want %s)", name, strings.Join(share.Providers, ", "))
+}
+
+func runGistList(ctx context.Context, c *cli.Command) error {
+	gc, err := loggedInClient(c, "gist list")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	gists, err := gc.List(ctx, c.Bool("all"))
+	if err != nil {
+		return err
+	}
+	if len(gists) == 0 {
+		fmt.Println("No playground gists yet. Publish one with 'dsplay share'.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUPDATED\tVISIBILITY\tFILES\tDESCRIPTION")
+	for _, g := range gists {
+		visibility := "secret"
+		if g.Public {
+			visibility = "public"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", g.ID, g.Updated.Local().Format("2006-01-02 15:04"), visibility, g.Files, g.Description)
+	}
+	return w.Flush()
+}
+
+func runGistDelete(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("gist delete takes exactly one gist ID or URL")
+	}
+	id := gist.ParseGistID(c.Args().First())
+	gc, err := loggedInClient(c, "gist delete")
+	if err != nil {
+		return err
+	}
+	if !c.Bool("yes") {
+		fmt.Printf("Delete gist %s? This can't be undone. [y/N] ", id)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("not deleted")
+		}
+	}
+	ctx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	if err := gc.Delete(ctx, id); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted gist %s\n", id)
+	return nil
+}
+
+func runGistFork(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("gist fork takes exactly one gist ID or URL")
+	}
+	gc, err := loggedInClient(c, "gist fork")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	_, htmlURL, err := gc.Fork(ctx, gist.ParseGistID(c.Args().First()))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Forked to:  %s\n", htmlURL)
+	fmt.Printf("Serve with: dsplay serve %s\n", htmlURL)
+	return nil
+}
+
+func runRender(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("render takes exactly one route, e.g. dsplay render /todos")
+	}
+
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = wd
+	}
+
+	var signals map[string]any
+	if s := c.String("signals"); s != "" {
+		if err := json.Unmarshal([]byte(s), &signals); err != nil {
+			return fmt.Errorf("parsing --signals: %w", err)
+		}
+	}
+
+	section := -1
+	if c.IsSet("section") {
+		section = c.Int("section")
+	}
+
+	fileCfg, err := loadConfigFile(c, dir)
+	if err != nil {
+		return err
+	}
+	globals, err := templateGlobals(fileCfg, c.StringSlice("set"))
+	if err != nil {
+		return err
+	}
+
+	out, err := server.Render(server.RenderOptions{
+		PlaygroundsDir:  dir,
+		Route:           c.Args().First(),
+		Method:          c.String("method"),
+		Signals:         signals,
+		Globals:         globals,
+		SSE:             c.Bool("sse"),
+		Section:         section,
+		StrictTemplates: c.Bool("strict-templates"),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	return nil
+}
+
+// bundleOptions leaves git metadata, and dsplay.yaml unless
+// --include-config is set, out of a bundle or archive.
+func bundleOptions(c *cli.Command) bundle.Options {
+	includeConfig := c.Bool("include-config")
+	return bundle.Options{
+		Skip: func(rel string) bool {
+			if rel == ".git" {
+				return true
+			}
+			return !includeConfig && slices.Contains(gist.LocalOnlyFiles, rel)
+		},
+	}
+}
+
+func runPack(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() > 1 {
+		return fmt.Errorf("pack takes at most the output file, e.g. dsplay pack demo.dsplay.zip")
+	}
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = wd
+	}
+	out := c.Args().First()
+	if out == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		out = filepath.Base(abs) + bundle.ArchiveExt
+	}
+	if _, err := os.Stat(out); err == nil && !c.Bool("force") {
+		return fmt.Errorf("%s already exists (use --force to override)", out)
 	}
 
-	if err := app.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+	// Archives in the playground, including the one being written, stay out
+	opts := bundleOptions(c)
+	skip := opts.Skip
+	opts.Skip = func(rel string) bool { return strings.HasSuffix(rel, bundle.ArchiveExt) || skip(rel) }
+
+	n, err := bundle.Pack(out, dir, opts)
+	if err != nil {
+		return fmt.Errorf("packing: %w", err)
 	}
+	fmt.Printf("Packed %d files into %s\n", n, out)
+	fmt.Printf("Serve with: dsplay serve %s (or an https URL to it)\n", out)
+	return nil
 }
 
-func runInit(ctx context.Context, c *cli.Command) error {
-	var targetDir string
-	var err error
-	force := c.Bool("force")
+func runBuild(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("build takes the output file, e.g. dsplay build ./demo")
+	}
+	out := c.Args().First()
+	if _, err := os.Stat(out); err == nil && !c.Bool("force") {
+		return fmt.Errorf("%s already exists (use --force to override)", out)
+	}
 
-	// Determine target directory
-	if c.Args().Len() > 0 {
-		targetDir = c.Args().Get(0)
-		// Convert to absolute path
-		targetDir, err = filepath.Abs(targetDir)
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
 		if err != nil {
-			return fmt.Errorf("resolving directory path: %w", err)
+			return err
 		}
+		dir = wd
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating the dsplay executable: %w", err)
+	}
 
-		// Check if directory exists and is not empty (unless force is set)
-		if info, err := os.Stat(targetDir); err == nil {
-			if !info.IsDir() {
-				return fmt.Errorf("%s exists but is not a directory", targetDir)
-			}
-			if !force {
-				// Check if directory is not empty
-				entries, err := os.ReadDir(targetDir)
-				if err != nil {
-					return fmt.Errorf("reading directory: %w", err)
-				}
-				if len(entries) > 0 {
-					return fmt.Errorf("directory %s already exists and is not empty (use --force to override)", targetDir)
-				}
-			}
-		} else if !os.IsNotExist(err) {
-			return fmt.Errorf("checking directory: %w", err)
-		}
+	n, err := bundle.Write(out, exe, dir, bundleOptions(c))
+	if err != nil {
+		return fmt.Errorf("building: %w", err)
+	}
 
-		// Create directory if it doesn't exist
-		if err := os.MkdirAll(targetDir, 0o755); err != nil {
-			return fmt.Errorf("creating directory: %w", err)
-		}
-	} else {
-		// Use current working directory
-		targetDir, err = os.Getwd()
-		if err != nil {
-			return fmt.Errorf("getting working directory: %w", err)
-		}
+	fmt.Printf("Built %s with %d playground files\n", out, n)
+	fmt.Printf("Run it (on %s/%s) with no arguments to serve the playground; flags like --port work as usual.\n", runtime.GOOS, runtime.GOARCH)
+	return nil
+}
 
-		// Check if home/index.html already exists in current directory (unless force is set)
-		if !force {
-			if _, err := os.Stat(filepath.Join(targetDir, "home", "index.html")); err == nil {
-				return fmt.Errorf("home/index.html already exists (use --force to override)")
-			}
+func runDockerize(ctx context.Context, c *cli.Command) error {
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
 		}
+		dir = wd
 	}
 
-	sub, err := fs.Sub(skeletonFS, "skeleton")
+	names, err := docker.Write(dir, docker.Options{
+		Version:       c.String("version"),
+		IncludeConfig: c.Bool("include-config"),
+		Force:         c.Bool("force"),
+	})
 	if err != nil {
-		return fmt.Errorf("reading embedded skeleton: %w", err)
+		return err
+	}
+	for _, name := range names {
+		fmt.Printf("wrote  %s\n", filepath.Join(dir, name))
 	}
+	fmt.Println("Build and run the image with:")
+	fmt.Println("  docker build -t my-playground " + dir)
+	fmt.Println("  docker run -p 8080:8080 -e DSPLAY_SECRET=change-me my-playground")
+	return nil
+}
 
-	err = fs.WalkDir(sub, ".", func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		dest := filepath.Join(targetDir, path)
-		if d.IsDir() {
-			return os.MkdirAll(dest, 0o755)
-		}
-		content, err := fs.ReadFile(sub, path)
+func runExport(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("export takes the output directory, e.g. dsplay export ./site")
+	}
+
+	dir := c.String("dir")
+	if dir == "" {
+		wd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
-		return os.WriteFile(dest, content, 0o644)
+		dir = wd
+	}
+
+	fileCfg, err := loadConfigFile(c, dir)
+	if err != nil {
+		return err
+	}
+	globals, err := templateGlobals(fileCfg, c.StringSlice("set"))
+	if err != nil {
+		return err
+	}
+
+	out := c.Args().First()
+	rep, err := server.Export(server.ExportOptions{
+		PlaygroundsDir:  dir,
+		OutDir:          out,
+		Force:           c.Bool("force"),
+		BasePath:        c.String("base-path"),
+		Globals:         globals,
+		Static:          fileCfg.Static,
+		Events:          c.Int("events"),
+		StrictTemplates: c.Bool("strict-templates"),
 	})
 	if err != nil {
-		return fmt.Errorf("writing skeleton files: %w", err)
+		return fmt.Errorf("exporting: %w", err)
 	}
 
-	fmt.Printf("Created skeleton playground at %s\n", targetDir)
-	if c.Args().Len() > 0 {
-		// If a specific directory was provided, show how to serve it
-		fmt.Printf("Run 'dsplay serve %s' to serve it.\n", targetDir)
-	} else {
-		fmt.Println("Run 'dsplay' to serve it.")
+	for _, p := range rep.Pages {
+		fmt.Printf("page   %s\n", p)
+	}
+	for _, s := range rep.Streams {
+		fmt.Printf("stream %s\n", s)
+	}
+	fmt.Printf("Exported %d pages, %d streams and %d assets into %s\n", len(rep.Pages), len(rep.Streams), len(rep.Assets), out)
+	if len(rep.Skipped) > 0 {
+		fmt.Printf("\nSkipped:\n")
+		for _, s := range rep.Skipped {
+			fmt.Printf("  %s\n", s)
+		}
 	}
 	return nil
 }
 
-func runShare(ctx context.Context, c *cli.Command) error {
-	token := c.String("github-token")
-	if token == "" {
-		return fmt.Errorf("share requires a GitHub token (--github-token or GITHUB_TOKEN)\nCreate one at https://github.com/settings/personal-access-tokens")
+func runImportSite(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("import site takes the site directory, e.g. dsplay import site ./public")
+	}
+	src := c.Args().First()
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return fmt.Errorf("site directory does not exist: %s", src)
+	}
+
+	dst := c.String("dir")
+	if dst == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dst = wd
 	}
 
+	rep, err := importer.ImportSite(src, dst, importer.Options{Force: c.Bool("force")})
+	if err != nil {
+		return fmt.Errorf("importing site: %w", err)
+	}
+
+	for _, m := range rep.Pages {
+		fmt.Printf("page   %s → %s\n", m.From, m.To)
+	}
+	fmt.Printf("Imported %d pages and %d assets into %s\n", len(rep.Pages), len(rep.Assets), dst)
+	if len(rep.Notes) > 0 {
+		fmt.Printf("\nNeeds attention:\n")
+		for _, n := range rep.Notes {
+			fmt.Printf("  %s\n", n)
+		}
+	}
+	return nil
+}
+
+func runTest(ctx context.Context, c *cli.Command) error {
 	dir := c.String("dir")
 	if dir == "" {
 		wd, err := os.Getwd()
@@ -208,20 +1723,140 @@ func runShare(ctx context.Context, c *cli.Command) error {
 		dir = wd
 	}
 
-	gc := gist.NewClient(token)
-	_, htmlURL, err := gc.SavePlayground(context.Background(), dir, gist.SaveOptions{
-		Public:      !c.Bool("secret"),
-		Description: c.String("description"),
+	results, err := server.RunTests(dir, server.TestOptions{Update: c.Bool("update")})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Updated:
+			fmt.Printf("UPDATE %s: %s → %s\n", r.File, r.Name, r.Golden)
+		case r.Passed:
+			fmt.Printf("PASS   %s: %s\n", r.File, r.Name)
+		default:
+			failed++
+			fmt.Printf("FAIL   %s: %s\n       %s\n", r.File, r.Name, strings.ReplaceAll(r.Err.Error(), "\n", "\n       "))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+	fmt.Printf("ok, %d tests\n", len(results))
+	return nil
+}
+
+func runReplay(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("replay takes a recording file, e.g. dsplay replay session.jsonl")
+	}
+	exchanges, err := server.ReadRecording(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	if format := c.String("export"); format != "" {
+		out := os.Stdout
+		if path := c.String("output"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		switch format {
+		case "har":
+			return server.ExportHAR(out, exchanges, c.String("target"))
+		case "http":
+			return server.ExportHTTP(out, exchanges, c.String("target"))
+		}
+		return fmt.Errorf("unknown export format %q (want har or http)", format)
+	}
+
+	results, err := server.Replay(ctx, exchanges, server.ReplayOptions{
+		Target: c.String("target"),
+		Speed:  c.Float("speed"),
 	})
 	if err != nil {
-		return fmt.Errorf("saving gist: %w", err)
+		return err
 	}
 
-	fmt.Printf("Gist created: %s\n", htmlURL)
-	fmt.Printf("Serve with:   dsplay serve %s\n", htmlURL)
+	differed := 0
+	for _, r := range results {
+		x := r.Exchange
+		switch {
+		case r.Err != nil:
+			differed++
+			fmt.Printf("ERROR  %s %s: %v\n", x.Method, x.Path, r.Err)
+		case len(r.Diffs) > 0:
+			differed++
+			fmt.Printf("DIFF   %s %s: %s\n", x.Method, x.Path, strings.Join(r.Diffs, "; "))
+		default:
+			fmt.Printf("SAME   %s %s → %d\n", x.Method, x.Path, r.Status)
+		}
+	}
+	if differed > 0 {
+		return fmt.Errorf("%d of %d requests differed from the recording", differed, len(results))
+	}
+	fmt.Printf("ok, %d requests\n", len(results))
+	return nil
+}
+
+func runBench(ctx context.Context, c *cli.Command) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("bench takes the URL to load, e.g. dsplay bench http://localhost:8080/clock/ --sse")
+	}
+	opts := bench.Options{
+		URL:         c.Args().First(),
+		Connections: c.Int("connections"),
+		Duration:    c.Duration("duration"),
+		SSE:         c.Bool("sse"),
+	}
+
+	// Ctrl+C stops early and still reports
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Running %d clients against %s for %s…\n", opts.Connections, opts.URL, opts.Duration)
+	res, err := bench.Run(ctx, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	res.Report(os.Stdout)
 	return nil
 }
 
+// loadConfigFile reads --config, or dsplay.yaml in the playground directory.
+func loadConfigFile(c *cli.Command, playgroundsDir string) (*server.ConfigFile, error) {
+	configPath := c.String("config")
+	if configPath == "" {
+		configPath = filepath.Join(playgroundsDir, server.ConfigFileName)
+	}
+	fileCfg, err := server.LoadConfigFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", configPath, err)
+	}
+	return fileCfg, nil
+}
+
+// templateGlobals merges dsplay.yaml globals with --set key=value flags,
+// which take precedence.
+func templateGlobals(fileCfg *server.ConfigFile, sets []string) (map[string]any, error) {
+	globals := make(map[string]any, len(fileCfg.Globals)+len(sets))
+	maps.Copy(globals, fileCfg.Globals)
+	for _, kv := range sets {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("--set %q must be key=value", kv)
+		}
+		globals[key] = value
+	}
+	return globals, nil
+}
+
 func runServe(ctx context.Context, c *cli.Command, source string) error {
 	playgroundsDir, tempDir, err := resolveSource(ctx, c, source)
 	if err != nil {
@@ -235,19 +1870,230 @@ func runServe(ctx context.Context, c *cli.Command, source string) error {
 	if _, err := os.Stat(playgroundsDir); os.IsNotExist(err) {
 		return fmt.Errorf("playgrounds directory does not exist: %s", playgroundsDir)
 	}
-
-	cfg := server.Config{
-		Port:           c.Int("port"),
-		PlaygroundsDir: playgroundsDir,
-		SessionSecret:  c.String("secret"),
-		Debug:          c.Bool("debug"),
+	manifest, err := server.LoadManifest(os.DirFS(playgroundsDir))
+	if err != nil {
+		return fmt.Errorf("reading playground.yaml: %w", err)
+	}
+	if err := manifest.CheckCompatible(dsplayVersion()); err != nil {
+		return err
 	}
 
+	cfg, err := serveConfig(c, playgroundsDir)
+	if err != nil {
+		return err
+	}
+	if c.Bool("tui") {
+		return runDashboard(ctx, cfg)
+	}
+	announceLAN(ctx, c, cfg)
 	return server.Run(cfg)
 }
 
+func runHost(ctx context.Context, c *cli.Command) error {
+	root := c.String("root")
+	if root == "" && !c.Bool("gists") {
+		return fmt.Errorf("host needs --root, --gists or both")
+	}
+	if root != "" {
+		var err error
+		if root, err = filepath.Abs(root); err != nil {
+			return err
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("root directory does not exist: %s", root)
+		}
+	}
+	if c.String("record") != "" || c.Bool("tui") {
+		return fmt.Errorf("host mode doesn't support --record or --tui")
+	}
+
+	listen, err := serveConfig(c, root)
+	if err != nil {
+		return err
+	}
+	hc := server.HostConfig{Root: root, Listen: listen}
+	if c.Bool("gists") {
+		gc, err := gistClient(c, c.String("github-token"))
+		if err != nil {
+			return err
+		}
+		hc.Gists = func(ctx context.Context, id string) (string, error) {
+			return gc.LoadToTempDir(ctx, id, "")
+		}
+		hc.GistHost = c.String("gist-host")
+		hc.GistTTL = c.Duration("gist-ttl")
+		hc.GistMax = c.Int("gist-max")
+		hc.GistFetchRate = c.Float("gist-fetch-rate")
+	}
+	announceLAN(ctx, c, listen)
+	return server.RunHost(hc)
+}
+
+// serveConfig builds the server configuration for the playground in
+// playgroundsDir from the global flags and its dsplay.yaml.
+func serveConfig(c *cli.Command, playgroundsDir string) (server.Config, error) {
+	fileCfg, err := loadConfigFile(c, playgroundsDir)
+	if err != nil {
+		return server.Config{}, err
+	}
+	globals, err := templateGlobals(fileCfg, c.StringSlice("set"))
+	if err != nil {
+		return server.Config{}, err
+	}
+	protection := fileCfg.Protect
+	protection.Basic = append(protection.Basic, c.StringSlice("basic-auth")...)
+	protection.Tokens = append(protection.Tokens, c.StringSlice("auth-token")...)
+	for _, pair := range protection.Basic {
+		if !strings.Contains(pair, ":") {
+			return server.Config{}, fmt.Errorf("basic auth %q must be user:pass", pair)
+		}
+	}
+
+	fetchTimeout := fileCfg.Fetch.Timeout
+	if c.IsSet("fetch-timeout") {
+		fetchTimeout = c.Duration("fetch-timeout")
+	}
+
+	return server.Config{
+		Port:            c.Int("port"),
+		Addr:            c.String("addr"),
+		UnixSocket:      c.String("unix-socket"),
+		BasePath:        c.String("base-path"),
+		TrustedProxies:  c.StringSlice("trusted-proxies"),
+		Static:          fileCfg.Static,
+		Hooks:           fileCfg.Hooks,
+		ReadTimeout:     c.Duration("read-timeout"),
+		WriteTimeout:    c.Duration("write-timeout"),
+		IdleTimeout:     c.Duration("idle-timeout"),
+		MaxBodyBytes:    c.Int64("max-body"),
+		MaxUploadBytes:  c.Int64("max-upload"),
+		UploadDir:       c.String("upload-dir"),
+		CompressLevel:   c.Int("compress-level"),
+		Protect:         protection,
+		CSRF:            c.Bool("csrf"),
+		PlaygroundsDir:  playgroundsDir,
+		SessionSecret:   c.String("secret"),
+		PreviousSecrets: c.StringSlice("previous-secret"),
+		SessionCookie:   c.String("session-cookie"),
+		SessionMaxAge:   c.Duration("session-max-age"),
+		SessionSecure:   c.Bool("session-secure"),
+		SessionSameSite: c.String("session-samesite"),
+		SessionDomain:   c.String("session-domain"),
+		SessionBackend:  c.String("session-store"),
+		SessionDir:      c.String("session-dir"),
+		NATSURL:         c.String("nats-url"),
+		Debug:           c.Bool("debug"),
+		DebugEndpoints:  c.Bool("debug-endpoints"),
+		Admin:           c.Bool("admin"),
+		AdminTokens:     c.StringSlice("admin-token"),
+		Source:          c.Bool("source"),
+		MaxSSE:          c.Int("max-sse"),
+		MaxSSESession:   c.Int("max-sse-per-session"),
+		MaxSSEPerIP:     c.Int("max-sse-per-ip"),
+		RatePerIP:       c.Float("rate-limit"),
+		RatePerSession:  c.Float("rate-limit-session"),
+		RateBurst:       c.Int("rate-burst"),
+		JobWorkers:      c.Int("job-workers"),
+		TailAllow:       c.StringSlice("allow-tail"),
+		Globals:         globals,
+		EnvAllow:        append(fileCfg.Env, c.StringSlice("allow-env")...),
+		FetchAllow:      append(fileCfg.Fetch.Allow, c.StringSlice("allow-fetch")...),
+		FetchTimeout:    fetchTimeout,
+		WSOrigins:       c.StringSlice("ws-origin"),
+		PrefsFile:       c.String("prefs-file"),
+		PrefsTTL:        c.Duration("prefs-ttl"),
+		StrictTemplates: c.Bool("strict-templates"),
+		Dev:             c.Bool("dev"),
+		LiveReload:      c.Bool("live-reload"),
+		Record:          c.String("record"),
+
+		PublicURL:          c.String("public-url"),
+		GitHubClientID:     c.String("auth-github-client-id"),
+		GitHubClientSecret: c.String("auth-github-client-secret"),
+		OIDCIssuer:         c.String("auth-oidc-issuer"),
+		OIDCClientID:       c.String("auth-oidc-client-id"),
+		OIDCClientSecret:   c.String("auth-oidc-client-secret"),
+	}, nil
+}
+
+// runDashboard serves cfg while drawing the live dashboard over the
+// terminal, with log output shown in the dashboard instead. q or Ctrl+C stops
+// both.
+func runDashboard(ctx context.Context, cfg server.Config) error {
+	monitor := server.NewMonitor()
+	cfg.Monitor = monitor
+	log.SetOutput(monitor)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() { served <- server.Run(cfg) }()
+
+	title := fmt.Sprintf("http://localhost:%d%s/", cfg.Port, strings.TrimSuffix(cfg.BasePath, "/"))
+	if cfg.UnixSocket != "" {
+		title = "unix:" + cfg.UnixSocket
+	}
+	title += "  (q to quit)"
+	state := func() tui.State { return dashboardState(monitor.Snapshot(), title) }
+
+	dashboardCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	drawn := make(chan error, 1)
+	go func() { drawn <- tui.Run(dashboardCtx, 500*time.Millisecond, state) }()
+
+	select {
+	case err := <-served:
+		cancel()
+		<-drawn
+		return err
+	case err := <-drawn:
+		return err
+	}
+}
+
+// dashboardState converts a monitor snapshot for the terminal dashboard.
+func dashboardState(s server.MonitorSnapshot, title string) tui.State {
+	st := tui.State{
+		Title: title,
+		Counters: []tui.Counter{
+			{Label: "hits", Value: s.GlobalHits},
+			{Label: "streams", Value: int64(s.Connections.Active)},
+			{Label: "sessions", Value: int64(s.Connections.Sessions)},
+			{Label: "rejected", Value: s.Connections.Rejected},
+			{Label: "jobs queued", Value: int64(s.Jobs.Queued)},
+			{Label: "running", Value: int64(s.Jobs.Running)},
+		},
+		Logs: s.Logs,
+	}
+	for _, u := range s.URLs[:min(len(s.URLs), 3)] {
+		st.Counters = append(st.Counters, tui.Counter{Label: u.URL, Value: u.Hits})
+	}
+	for _, c := range s.Streams {
+		st.Streams = append(st.Streams, tui.Stream{Route: c.Route, Transport: c.Transport, Session: c.SessionID, Started: c.Started, Messages: c.Messages})
+	}
+	for _, r := range s.Requests {
+		st.Requests = append(st.Requests, tui.Request{Time: r.Time, Method: r.Method, Path: r.Path, Status: r.Status, Duration: r.Duration})
+	}
+	for _, m := range s.NATS {
+		st.NATS = append(st.NATS, tui.Message{Time: m.Time, Subject: m.Subject, Bytes: m.Bytes})
+	}
+	return st
+}
+
 func resolveSource(ctx context.Context, c *cli.Command, source string) (playgroundsDir, tempDir string, err error) {
 	if source == "" {
+		// A dsplay build executable serves the playground bundled into it
+		if exe, err := os.Executable(); err == nil {
+			dir, err := bundle.ExtractTemp(exe)
+			if err == nil {
+				return dir, dir, nil
+			}
+			if !errors.Is(err, bundle.ErrNoPlayground) {
+				return "", "", fmt.Errorf("loading bundled playground: %w", err)
+			}
+		}
 		wd, err := os.Getwd()
 		if err != nil {
 			return "", "", err
@@ -255,8 +2101,38 @@ func resolveSource(ctx context.Context, c *cli.Command, source string) (playgrou
 		return wd, "", nil
 	}
 
-	if isGistSource(source) {
+	if bundle.IsArchive(source) {
+		log.Printf("Unpacking %s...", source)
+		tmpDir, dir, err := bundle.ExtractArchiveTemp(ctx, source)
+		if err != nil {
+			return "", "", err
+		}
+		return dir, tmpDir, nil
+	}
+
+	provider := c.String("provider")
+	if provider == "" {
+		if _, err := os.Stat(source); err != nil {
+			provider = share.Detect(source)
+		}
+	}
+	switch provider {
+	case "":
+	case share.GitHub:
 		return resolveGistSource(ctx, c, source)
+	default:
+		p, err := shareProvider(c, provider, false)
+		if err != nil {
+			return "", "", err
+		}
+		log.Printf("Loading %s playground %s into memory...", provider, source)
+		opCtx, cancel := withTimeout(ctx, c)
+		defer cancel()
+		tmpDir, err := share.LoadToTempDir(opCtx, p, source)
+		if err != nil {
+			return "", "", fmt.Errorf("loading %s: %w", source, err)
+		}
+		return tmpDir, tmpDir, nil
 	}
 
 	abs, err := filepath.Abs(source)
@@ -266,14 +2142,18 @@ func resolveSource(ctx context.Context, c *cli.Command, source string) (playgrou
 	return abs, "", nil
 }
 
-func isGistSource(source string) bool {
-	return strings.Contains(source, "gist.github.com")
-}
-
 func resolveGistSource(ctx context.Context, c *cli.Command, source string) (playgroundsDir, tempDir string, err error) {
-	token := c.String("github-token")
-	gistID := gist.ParseGistID(source)
-	gc := gist.NewClient(token)
+	gistID, revision := gist.ParseGistRef(source)
+	if c.IsSet("revision") {
+		revision = c.String("revision")
+	}
+	if revision != "" && !gist.ValidRevision(revision) {
+		return "", "", fmt.Errorf("--revision %q is not a gist revision SHA", revision)
+	}
+	gc, err := gistClient(c, githubToken(c))
+	if err != nil {
+		return "", "", err
+	}
 
 	if c.Bool("clone") {
 		dest := c.String("clone-dir")
@@ -284,16 +2164,59 @@ func resolveGistSource(ctx context.Context, c *cli.Command, source string) (play
 			}
 		}
 		log.Printf("Cloning gist %s to %s...", gistID, dest)
-		if err := gc.ClonePlayground(ctx, gistID, dest); err != nil {
+		opCtx, cancel := withTimeout(ctx, c)
+		defer cancel()
+		report, err := gc.ClonePlayground(opCtx, gistID, revision, dest, gist.CloneOptions{
+			Merge: c.Bool("merge"),
+			Force: c.Bool("force"),
+		})
+		var conflict *gist.ConflictError
+		if errors.As(err, &conflict) {
+			hint := "--merge to keep identical files"
+			if len(conflict.Differing) > 0 {
+				hint = "--force to overwrite them"
+			}
+			return "", "", fmt.Errorf("cloning gist into %s: %w (nothing was written; use %s)", dest, err, hint)
+		}
+		if err != nil {
 			return "", "", fmt.Errorf("cloning gist: %w", err)
 		}
+		if err := gist.SaveOrigin(dest, gistID); err != nil {
+			log.Printf("Recording the gist in %s: %v", gist.OriginFile, err)
+		}
+		log.Printf("Cloned: %d created, %d updated, %d unchanged", len(report.Created), len(report.Updated), len(report.Skipped))
+		for _, f := range report.Created {
+			log.Printf("  created   %s", f)
+		}
+		for _, f := range report.Updated {
+			log.Printf("  updated   %s", f)
+		}
+		for _, f := range report.Skipped {
+			log.Printf("  unchanged %s", f)
+		}
 		return dest, "", nil
 	}
 
-	log.Printf("Loading gist %s into memory...", gistID)
-	tmpDir, err := gc.LoadToTempDir(ctx, gistID)
+	if revision != "" {
+		log.Printf("Loading gist %s at revision %s into memory...", gistID, revision)
+	} else {
+		log.Printf("Loading gist %s into memory...", gistID)
+	}
+	opCtx, cancel := withTimeout(ctx, c)
+	defer cancel()
+	tmpDir, err := gc.LoadToTempDir(opCtx, gistID, revision)
 	if err != nil {
 		return "", "", fmt.Errorf("loading gist: %w", err)
 	}
+	// Pinned revisions never change, so only the latest is worth watching
+	if every := c.Duration("gist-poll"); every > 0 && revision == "" {
+		go gc.Watch(ctx, gistID, tmpDir, every, func(changed []string, err error) {
+			if err != nil {
+				log.Printf("Checking gist %s for edits: %v", gistID, err)
+				return
+			}
+			log.Printf("Gist %s edited, reloaded: %s", gistID, strings.Join(changed, ", "))
+		})
+	}
 	return tmpDir, tmpDir, nil
 }
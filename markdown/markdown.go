@@ -0,0 +1,89 @@
+// Package markdown renders CommonMark and GitHub Flavored Markdown (tables,
+// strikethrough, autolinks, task lists) to HTML with goldmark. Headings get
+// ids for anchor links.
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Options controls rendering.
+type Options struct {
+	// HTML passes raw HTML blocks and inline tags through unchanged. Leave it
+	// off for untrusted input, which then has every tag escaped.
+	HTML bool
+}
+
+var (
+	escaped = newMarkdown(renderer.WithNodeRenderers(util.Prioritized(escapeHTML{}, 100)))
+	raw     = newMarkdown(html.WithUnsafe())
+)
+
+func newMarkdown(opts ...renderer.Option) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(opts...),
+	)
+}
+
+// Render converts markdown source to HTML.
+func Render(src string, opts Options) string {
+	md := escaped
+	if opts.HTML {
+		md = raw
+	}
+	var b bytes.Buffer
+	// Rendering into a buffer can't fail, and goldmark has no parse errors.
+	_ = md.Convert([]byte(src), &b)
+	return b.String()
+}
+
+// escapeHTML shows raw HTML as text, where goldmark's safe mode would drop
+// it for an "omitted" comment.
+type escapeHTML struct{}
+
+func (escapeHTML) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, renderRawHTML)
+}
+
+func renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.HTMLBlock)
+	var text []byte
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		text = append(text, line.Value(source)...)
+	}
+	if n.HasClosure() {
+		closure := n.ClosureLine
+		text = append(text, closure.Value(source)...)
+	}
+	_, _ = w.WriteString("<p>")
+	_, _ = w.Write(util.EscapeHTML([]byte(strings.TrimRight(string(text), "\n"))))
+	_, _ = w.WriteString("</p>\n")
+	return ast.WalkContinue, nil
+}
+
+func renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		n := node.(*ast.RawHTML)
+		for i := 0; i < n.Segments.Len(); i++ {
+			segment := n.Segments.At(i)
+			_, _ = w.Write(util.EscapeHTML(segment.Value(source)))
+		}
+	}
+	return ast.WalkSkipChildren, nil
+}
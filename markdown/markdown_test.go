@@ -0,0 +1,57 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"paragraph", "Hello\nworld", "<p>Hello\nworld</p>\n"},
+		{"heading", "## Getting Started!", `<h2 id="getting-started">Getting Started!</h2>` + "\n"},
+		{"closing hashes", "# Title ##", `<h1 id="title">Title</h1>` + "\n"},
+		{"setext", "Title\n=====", `<h1 id="title">Title</h1>` + "\n"},
+		{"emphasis", "*a* **b** ***c*** _d_ __e__ ~~f~~",
+			"<p><em>a</em> <strong>b</strong> <em><strong>c</strong></em> <em>d</em> <strong>e</strong> <del>f</del></p>\n"},
+		{"nested emphasis", "**bold *and* more**", "<p><strong>bold <em>and</em> more</strong></p>\n"},
+		{"intraword underscore", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"unclosed", "2 * 3 = 6", "<p>2 * 3 = 6</p>\n"},
+		{"code span", "Use `a < b` here", "<p>Use <code>a &lt; b</code> here</p>\n"},
+		{"escape", `\*not em\*`, "<p>*not em*</p>\n"},
+		{"entity kept", "&lt;b&gt; &amp; &copy; & co", "<p>&lt;b&gt; &amp; © &amp; co</p>\n"},
+		{"hard break", "one  \ntwo", "<p>one<br>\ntwo</p>\n"},
+		{"link", `[Datastar](https://data-star.dev "Docs")`,
+			`<p><a href="https://data-star.dev" title="Docs">Datastar</a></p>` + "\n"},
+		{"link with emphasis", "[*x*](/y)", `<p><a href="/y"><em>x</em></a></p>` + "\n"},
+		{"image", "![A cat](cat.png)", `<p><img src="cat.png" alt="A cat"></p>` + "\n"},
+		{"autolink", "<https://example.com>", `<p><a href="https://example.com">https://example.com</a></p>` + "\n"},
+		{"script url", "[x](javascript:alert(1))", `<p><a href="">x</a></p>` + "\n"},
+		{"rule", "a\n\n***\n\nb", "<p>a</p>\n<hr>\n<p>b</p>\n"},
+		{"fence", "```go\nfmt.Println(\"<hi>\")\n```",
+			`<pre><code class="language-go">fmt.Println(&quot;&lt;hi&gt;&quot;)` + "\n</code></pre>\n"},
+		{"quote", "> quoted\n> text", "<blockquote>\n<p>quoted\ntext</p>\n</blockquote>\n"},
+		{"tight list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"ordered list", "3. c\n4. d", "<ol start=\"3\">\n<li>c</li>\n<li>d</li>\n</ol>\n"},
+		{"nested list", "- a\n  - b\n- c", "<ul>\n<li>a\n<ul>\n<li>b</li>\n</ul>\n</li>\n<li>c</li>\n</ul>\n"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li>\n<p>a</p>\n</li>\n<li>\n<p>b</p>\n</li>\n</ul>\n"},
+		{"list then paragraph", "- a\n\nafter", "<ul>\n<li>a</li>\n</ul>\n<p>after</p>\n"},
+		{"table", "| A | B |\n|:--|--:|\n| 1 | 2 |",
+			"<table>\n<thead>\n<tr>\n<th style=\"text-align:left\">A</th>\n<th style=\"text-align:right\">B</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td style=\"text-align:left\">1</td>\n<td style=\"text-align:right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"html escaped by default", "<b>hi</b>\n\n<div>x</div>", "<p>&lt;b&gt;hi&lt;/b&gt;</p>\n<p>&lt;div&gt;x&lt;/div&gt;</p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src, Options{}); got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	src := "<div id=\"demo\" data-signals=\"{n: 1}\">\n  <button>+</button>\n</div>\n\nSome <em>inline</em> HTML."
+	want := "<div id=\"demo\" data-signals=\"{n: 1}\">\n  <button>+</button>\n</div>\n<p>Some <em>inline</em> HTML.</p>\n"
+	if got := Render(src, Options{HTML: true}); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+// Package mustache renders logic-less Mustache templates
+// (https://mustache.github.io/mustache.5.html) with cbroglie/mustache:
+// variables, escaped and raw, sections, inverted sections, comments, and
+// set-delimiter tags. Partials are refused, since they would read files.
+//
+// Names resolve against map keys and exported struct fields, so Go data can
+// be rendered directly; dotted names like {{Signals.name}} walk into values.
+package mustache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cbroglie/mustache"
+)
+
+// Error reports a problem in a template, with the 1-based line it is on.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("mustache: line %d: %s", e.Line, e.Msg) }
+
+// Render parses tmpl and renders it with data as the root context.
+func Render(tmpl string, data any) (string, error) {
+	t, err := mustache.ParseStringPartials(tmpl, noPartials{})
+	if err != nil {
+		var pe mustache.ParseError
+		if errors.As(err, &pe) {
+			return "", &Error{pe.Line, strings.TrimPrefix(pe.Error(), fmt.Sprintf("line %d: ", pe.Line))}
+		}
+		return "", err
+	}
+	return t.Render(data)
+}
+
+// noPartials stands in for the library's default provider, which looks
+// partials up as files in the working directory.
+type noPartials struct{}
+
+func (noPartials) Get(name string) (string, error) {
+	return "", fmt.Errorf("mustache: {{> %s}}: partials are not supported", name)
+}
@@ -0,0 +1,85 @@
+package mustache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type page struct {
+	Username string
+	Signals  map[string]any
+	Items    []map[string]any
+	hidden   string
+}
+
+func TestRender(t *testing.T) {
+	data := page{
+		Username: "clever-fox",
+		Signals:  map[string]any{"name": "<Ada>", "count": 3.0, "on": true, "off": false},
+		Items:    []map[string]any{{"title": "one"}, {"title": "two"}},
+		hidden:   "secret",
+	}
+	tests := []struct {
+		name, tmpl, want string
+	}{
+		{"text", "plain", "plain"},
+		{"variable", "Hi {{Username}}!", "Hi clever-fox!"},
+		{"dotted", "{{ Signals.count }}", "3"},
+		{"escaped", "{{Signals.name}}", "&lt;Ada&gt;"},
+		{"triple", "{{{Signals.name}}}", "<Ada>"},
+		{"ampersand", "{{& Signals.name}}", "<Ada>"},
+		{"missing", "[{{nope}}][{{Signals.nope}}]", "[][]"},
+		{"unexported", "[{{hidden}}]", "[]"},
+		{"section true", "{{#Signals.on}}yes{{/Signals.on}}", "yes"},
+		{"section false", "{{#Signals.off}}yes{{/Signals.off}}", ""},
+		{"inverted", "{{^Signals.off}}no{{/Signals.off}}", "no"},
+		{"list", "{{#Items}}<{{title}}>{{/Items}}", "<one><two>"},
+		{"outer context", "{{#Items}}{{title}}:{{Username}} {{/Items}}", "one:clever-fox two:clever-fox "},
+		{"inverted empty", "{{^Missing}}none{{/Missing}}", "none"},
+		{"comment", "a{{! ignored }}b", "ab"},
+		{"standalone", "<ul>\n  {{#Items}}\n  <li>{{title}}</li>\n  {{/Items}}\n</ul>", "<ul>\n  <li>one</li>\n  <li>two</li>\n</ul>"},
+		{"delimiters", "{{=<% %>=}}<% Username %> {{literal}}", "clever-fox {{literal}}"},
+		{"dot", "{{#Signals.name}}{{.}}{{/Signals.name}}", "&lt;Ada&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.tmpl, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		line int
+	}{
+		{"a\n{{#open}}\nb", 3}, // reported where the input ends
+		{"{{/close}}", 1},
+		{"{{#a}}\n{{/b}}", 2},
+		{"x\ny {{name", 2},
+	}
+	for _, tt := range tests {
+		_, err := Render(tt.tmpl, nil)
+		var me *Error
+		if !errors.As(err, &me) {
+			t.Errorf("Render(%q) error = %v, want *Error", tt.tmpl, err)
+			continue
+		}
+		if me.Line != tt.line {
+			t.Errorf("Render(%q) line = %d, want %d (%v)", tt.tmpl, me.Line, tt.line, err)
+		}
+	}
+}
+
+func TestRenderRefusesPartials(t *testing.T) {
+	if _, err := Render("{{> /etc/passwd}}", nil); err == nil || !strings.Contains(err.Error(), "partials are not supported") {
+		t.Errorf("Render with a partial error = %v, want partials are not supported", err)
+	}
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Transport is how a file's responses are delivered.
+type Transport string
+
+const (
+	HTML      Transport = ""    // plain HTTP response
+	SSE       Transport = "sse" // server-sent events stream
+	WebSocket Transport = "ws"  // WebSocket stream
+)
+
+// Class describes what a file handles, derived from its name.
+type Class struct {
+	Method    string // upper-case HTTP method, "" for any
+	Transport Transport
+	Seq       int // sequence index from a _NNN suffix, -1 if none
+}
+
+var knownMethods = map[string]bool{
+	"get": true, "post": true, "put": true, "patch": true, "delete": true,
+}
+
+// Classify determines what a template handles from its file name (with or
+// without directory and .html or .md extension).
+//
+// Well-known filenames within a directory:
+//
+//	index.html        → HTML, any method
+//	sse.html          → SSE, any method
+//	ws.html           → WebSocket
+//	get.html          → HTML, GET
+//	post.html         → HTML, POST
+//	post_sse.html     → SSE, POST
+//	sse_001.html      → SSE, any method, sequence 1
+//	post_sse_001.html → SSE, POST, sequence 1
+//	post_001.html     → HTML, POST, sequence 1
+//	index_001.html    → HTML, any method, sequence 1
+//	index.md          → HTML, any method, written in Markdown
+func Classify(name string) Class {
+	remaining := strings.TrimSuffix(strings.TrimSuffix(path.Base(name), ".html"), ".md")
+	var c Class
+
+	// 1. Extract _NNN sequence suffix
+	remaining, c.Seq = extractSeqIndex(remaining)
+
+	// 2. Check for _sse/_ws suffix (or exactly "sse"/"ws")
+	for _, t := range []Transport{SSE, WebSocket} {
+		lower := strings.ToLower(remaining)
+		if lower == string(t) {
+			c.Transport = t
+			return c
+		}
+		if strings.HasSuffix(lower, "_"+string(t)) {
+			c.Transport = t
+			remaining = remaining[:len(remaining)-len(t)-1]
+			break
+		}
+	}
+
+	// 3. Check if remaining is a known method; "index" or anything else
+	//    handles any method
+	if knownMethods[strings.ToLower(remaining)] {
+		c.Method = strings.ToUpper(remaining)
+	}
+	return c
+}
+
+// extractSeqIndex extracts the _NNN sequence index from a filename stem.
+// Returns the base name (without _NNN) and the index (-1 if none).
+func extractSeqIndex(stem string) (string, int) {
+	lastUnderscore := strings.LastIndex(stem, "_")
+	if lastUnderscore < 0 {
+		return stem, -1
+	}
+
+	suffix := stem[lastUnderscore+1:]
+	if n, err := strconv.Atoi(suffix); err == nil && len(suffix) > 0 {
+		return stem[:lastUnderscore], n
+	}
+	return stem, -1
+}
+
+// URLPath returns the route a template at rel (a slash-separated path
+// relative to the playground root) serves: its directory.
+func URLPath(rel string) string {
+	dir := path.Dir(rel)
+	if dir == "." {
+		return "/"
+	}
+	return "/" + dir + "/"
+}
+
+// CatchAll names a directory whose templates serve every path below its
+// parent that has no route of its own: docs/[...]/index.html answers
+// /docs/, /docs/a/ and /docs/a/b/.
+const CatchAll = "[...]"
+
+// CatchAllMatch is a catch-all route that could serve a path, and the part
+// of the path left over for it.
+type CatchAllMatch struct {
+	Route    string // e.g. "/docs/[...]/"
+	Wildcard string // e.g. "a/b", "" for the parent itself
+}
+
+// CatchAllRoutes lists the catch-all routes that could serve urlPath (a
+// route path such as "/docs/a/b/"), most specific first.
+func CatchAllRoutes(urlPath string) []CatchAllMatch {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	if parts[0] == "" {
+		parts = nil
+	}
+	matches := make([]CatchAllMatch, 0, len(parts)+1)
+	for i := len(parts); i >= 0; i-- {
+		prefix := "/"
+		if i > 0 {
+			prefix += strings.Join(parts[:i], "/") + "/"
+		}
+		matches = append(matches, CatchAllMatch{
+			Route:    prefix + CatchAll + "/",
+			Wildcard: strings.Join(parts[i:], "/"),
+		})
+	}
+	return matches
+}
+
+// Reserved reports whether a directory name is reserved (e.g. _errors,
+// _tests) and never contains routes.
+func Reserved(dirName string) bool {
+	return strings.HasPrefix(dirName, "_")
+}
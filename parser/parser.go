@@ -0,0 +1,161 @@
+// Package parser implements the playground file format: YAML frontmatter
+// between "---" lines followed by one or more response sections separated by
+// "===" lines, plus the filename conventions that map files to routes.
+//
+// It is what the server uses, so editor plugins, linters, and converters
+// built on it see files exactly as dsplay does. Frontmatter is returned as raw
+// YAML; decode it with the YAML library of your choice.
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	FrontmatterSeparator = "---"
+	SectionSeparator     = "==="
+)
+
+// File is a parsed playground template.
+type File struct {
+	Path        string    // path the file was read from, if any
+	Frontmatter string    // raw YAML between the --- lines, "" if none
+	Sections    []Section // always at least one
+}
+
+// Section is one response body of a file.
+type Section struct {
+	Body    string   // template source with surrounding whitespace trimmed; may be empty
+	Options []Option // from "=== key: value" marker lines, in order
+	Line    int      // 1-based line in the file where Body starts
+}
+
+// Option is a "key: value" (or "key=value") setting from a section marker.
+type Option struct {
+	Key   string
+	Value string
+}
+
+// Get returns the value of the last option with the given key.
+func (s Section) Get(key string) (string, bool) {
+	for i := len(s.Options) - 1; i >= 0; i-- {
+		if s.Options[i].Key == key {
+			return s.Options[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// ParseFile reads and parses a template file from disk.
+func ParseFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := Parse(data)
+	f.Path = path
+	return f, nil
+}
+
+// ParseFS reads and parses a template file from fsys.
+func ParseFS(fsys fs.FS, name string) (*File, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	f := Parse(data)
+	f.Path = name
+	return f, nil
+}
+
+// Parse splits a template into frontmatter and sections. Any input is valid:
+// without a closing "---" the whole file is body, and empty sections are kept
+// because they represent empty responses.
+//
+// Several options for one section go on consecutive marker lines. A marker on
+// the very first body line applies to the first section.
+func Parse(data []byte) *File {
+	content := string(data)
+	f := &File{}
+
+	bodyLine := 1
+	if strings.HasPrefix(strings.TrimSpace(content), FrontmatterSeparator) {
+		trimmed := strings.TrimSpace(content)
+		rest := trimmed[len(FrontmatterSeparator):]
+		endIdx := strings.Index(rest, "\n"+FrontmatterSeparator)
+		if endIdx >= 0 {
+			f.Frontmatter = rest[:endIdx]
+			afterClose := rest[endIdx+len("\n"+FrontmatterSeparator):]
+			body := strings.TrimPrefix(afterClose, "\n")
+			headerLen := len(content) - len(strings.TrimLeftFunc(content, unicode.IsSpace)) + len(trimmed) - len(body)
+			bodyLine += strings.Count(content[:headerLen], "\n")
+			content = body
+		}
+	}
+
+	var current []string
+	var opts []Option
+	start := 0 // index of the first line of current
+	afterMarker := false
+	flush := func() {
+		f.Sections = append(f.Sections, Section{
+			Body:    strings.TrimSpace(strings.Join(current, "\n")),
+			Options: opts,
+			Line:    bodyLine + start + leadingBlankLines(current),
+		})
+	}
+	for i, line := range strings.Split(content, "\n") {
+		isMarker, key, value := ParseMarker(line)
+		if !isMarker {
+			current = append(current, line)
+			afterMarker = false
+			continue
+		}
+		// A marker on the first line, or an option marker directly after
+		// another marker, belongs to the section that is already open.
+		if i > 0 && (key == "" || !afterMarker) {
+			flush()
+			current, opts = nil, nil
+		}
+		if key != "" {
+			opts = append(opts, Option{Key: key, Value: value})
+		}
+		start = i + 1
+		afterMarker = true
+	}
+	flush()
+
+	return f
+}
+
+// ParseMarker reports whether line is a section separator ("===" or
+// "=== key: value") and returns the option it carries, if any.
+func ParseMarker(line string) (isMarker bool, key, value string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == SectionSeparator {
+		return true, "", ""
+	}
+	rest, ok := strings.CutPrefix(line, SectionSeparator+" ")
+	if !ok {
+		return false, "", ""
+	}
+	rest = strings.TrimSpace(rest)
+	i := strings.IndexAny(rest, ":=")
+	if i <= 0 {
+		return false, "", ""
+	}
+	return true, strings.TrimSpace(rest[:i]), strings.TrimSpace(rest[i+1:])
+}
+
+// leadingBlankLines counts the blank lines TrimSpace removes from the start
+// of a section.
+func leadingBlankLines(lines []string) int {
+	n := 0
+	for n < len(lines)-1 && strings.TrimSpace(lines[n]) == "" {
+		n++
+	}
+	return n
+}
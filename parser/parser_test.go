@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		frontmatter string
+		sections    []Section
+	}{
+		{
+			name:     "body only",
+			input:    "<p>hi</p>\n",
+			sections: []Section{{Body: "<p>hi</p>", Line: 1}},
+		},
+		{
+			name:     "empty file",
+			input:    "",
+			sections: []Section{{Body: "", Line: 1}},
+		},
+		{
+			name:        "frontmatter",
+			input:       "---\nstatus: 201\n---\n<p>created</p>\n",
+			frontmatter: "\nstatus: 201",
+			sections:    []Section{{Body: "<p>created</p>", Line: 4}},
+		},
+		{
+			name:        "leading whitespace before frontmatter",
+			input:       "\n\n---\nloop: true\n---\n\n<p>x</p>",
+			frontmatter: "\nloop: true",
+			sections:    []Section{{Body: "<p>x</p>", Line: 7}},
+		},
+		{
+			name:     "unclosed frontmatter is body",
+			input:    "---\nstatus: 201\n<p>x</p>",
+			sections: []Section{{Body: "---\nstatus: 201\n<p>x</p>", Line: 1}},
+		},
+		{
+			name:  "sections",
+			input: "<p>one</p>\n===\n<p>two</p>\n===\n\n<p>three</p>",
+			sections: []Section{
+				{Body: "<p>one</p>", Line: 1},
+				{Body: "<p>two</p>", Line: 3},
+				{Body: "<p>three</p>", Line: 6},
+			},
+		},
+		{
+			name:  "empty sections are kept",
+			input: "<p>one</p>\n===\n===\n<p>three</p>\n===",
+			sections: []Section{
+				{Body: "<p>one</p>", Line: 1},
+				{Body: "", Line: 3},
+				{Body: "<p>three</p>", Line: 4},
+				{Body: "", Line: 6},
+			},
+		},
+		{
+			name:  "marker options",
+			input: "<p>adult</p>\n=== when: signals.age < 18\n=== delay=500\n<p>minor</p>",
+			sections: []Section{
+				{Body: "<p>adult</p>", Line: 1},
+				{Body: "<p>minor</p>", Options: []Option{{"when", "signals.age < 18"}, {"delay", "500"}}, Line: 4},
+			},
+		},
+		{
+			name:  "marker on first line applies to first section",
+			input: "=== when: method == \"POST\"\n<p>post</p>\n===\n<p>other</p>",
+			sections: []Section{
+				{Body: "<p>post</p>", Options: []Option{{"when", `method == "POST"`}}, Line: 2},
+				{Body: "<p>other</p>", Line: 4},
+			},
+		},
+		{
+			name:  "bare marker after option starts a new section",
+			input: "a\n=== when: x\n===\nb",
+			sections: []Section{
+				{Body: "a", Line: 1},
+				{Body: "", Options: []Option{{"when", "x"}}, Line: 3},
+				{Body: "b", Line: 4},
+			},
+		},
+		{
+			name:  "CRLF and trailing spaces on markers",
+			input: "a\r\n===  \r\nb\r\n",
+			sections: []Section{
+				{Body: "a", Line: 1},
+				{Body: "b", Line: 3},
+			},
+		},
+		{
+			name:     "not markers",
+			input:    "====\n===x\n=== : y\n=== text",
+			sections: []Section{{Body: "====\n===x\n=== : y\n=== text", Line: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Parse([]byte(tt.input))
+			if f.Frontmatter != tt.frontmatter {
+				t.Errorf("frontmatter = %q, want %q", f.Frontmatter, tt.frontmatter)
+			}
+			if !reflect.DeepEqual(f.Sections, tt.sections) {
+				t.Errorf("sections =\n  %+v\nwant\n  %+v", f.Sections, tt.sections)
+			}
+		})
+	}
+}
+
+func TestParseMarker(t *testing.T) {
+	tests := []struct {
+		line       string
+		isMarker   bool
+		key, value string
+	}{
+		{"===", true, "", ""},
+		{"===   ", true, "", ""},
+		{"===\r", true, "", ""},
+		{"=== when: a == b", true, "when", "a == b"},
+		{"=== weight=2", true, "weight", "2"},
+		{"===  delay :  100 ", true, "delay", "100"},
+		{"=== when: a:b", true, "when", "a:b"},
+		{"====", false, "", ""},
+		{"===when: x", false, "", ""},
+		{"=== just text", false, "", ""},
+		{"=== :x", false, "", ""},
+		{" ===", false, "", ""},
+		{"", false, "", ""},
+	}
+	for _, tt := range tests {
+		isMarker, key, value := ParseMarker(tt.line)
+		if isMarker != tt.isMarker || key != tt.key || value != tt.value {
+			t.Errorf("ParseMarker(%q) = %v, %q, %q; want %v, %q, %q", tt.line, isMarker, key, value, tt.isMarker, tt.key, tt.value)
+		}
+	}
+}
+
+func TestSectionGet(t *testing.T) {
+	s := Section{Options: []Option{{"when", "a"}, {"delay", "1"}, {"when", "b"}}}
+	if v, ok := s.Get("when"); !ok || v != "b" {
+		t.Errorf("Get(when) = %q, %v; want last value", v, ok)
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Get(missing) reported ok")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		want Class
+	}{
+		{"index.html", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"index", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"sse.html", Class{Method: "", Transport: SSE, Seq: -1}},
+		{"SSE.html", Class{Method: "", Transport: SSE, Seq: -1}},
+		{"ws.html", Class{Method: "", Transport: WebSocket, Seq: -1}},
+		{"get.html", Class{Method: "GET", Transport: HTML, Seq: -1}},
+		{"post.html", Class{Method: "POST", Transport: HTML, Seq: -1}},
+		{"put.html", Class{Method: "PUT", Transport: HTML, Seq: -1}},
+		{"patch.html", Class{Method: "PATCH", Transport: HTML, Seq: -1}},
+		{"delete.html", Class{Method: "DELETE", Transport: HTML, Seq: -1}},
+		{"Post.html", Class{Method: "POST", Transport: HTML, Seq: -1}},
+		{"post_sse.html", Class{Method: "POST", Transport: SSE, Seq: -1}},
+		{"get_ws.html", Class{Method: "GET", Transport: WebSocket, Seq: -1}},
+		{"sse_001.html", Class{Method: "", Transport: SSE, Seq: 1}},
+		{"post_sse_002.html", Class{Method: "POST", Transport: SSE, Seq: 2}},
+		{"post_001.html", Class{Method: "POST", Transport: HTML, Seq: 1}},
+		{"index_010.html", Class{Method: "", Transport: HTML, Seq: 10}},
+		{"ws_003.html", Class{Method: "", Transport: WebSocket, Seq: 3}},
+		{"home/greeting/sse.html", Class{Method: "", Transport: SSE, Seq: -1}},
+		{"about.html", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"options.html", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"news_sse.html", Class{Method: "", Transport: SSE, Seq: -1}},
+		{"post_abc.html", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"_001.html", Class{Method: "", Transport: HTML, Seq: 1}},
+		{"index.md", Class{Method: "", Transport: HTML, Seq: -1}},
+		{"sse_002.md", Class{Method: "", Transport: SSE, Seq: 2}},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.name); got != tt.want {
+			t.Errorf("Classify(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestURLPath(t *testing.T) {
+	tests := []struct{ rel, want string }{
+		{"index.html", "/"},
+		{"home/index.html", "/home/"},
+		{"home/counter/live/sse.html", "/home/counter/live/"},
+	}
+	for _, tt := range tests {
+		if got := URLPath(tt.rel); got != tt.want {
+			t.Errorf("URLPath(%q) = %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestCatchAllRoutes(t *testing.T) {
+	tests := []struct {
+		urlPath string
+		want    []CatchAllMatch
+	}{
+		{"/", []CatchAllMatch{{"/[...]/", ""}}},
+		{"/docs/a/b/", []CatchAllMatch{
+			{"/docs/a/b/[...]/", ""},
+			{"/docs/a/[...]/", "b"},
+			{"/docs/[...]/", "a/b"},
+			{"/[...]/", "docs/a/b"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := CatchAllRoutes(tt.urlPath); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("CatchAllRoutes(%q) = %v, want %v", tt.urlPath, got, tt.want)
+		}
+	}
+}
+
+func TestReserved(t *testing.T) {
+	for name, want := range map[string]bool{"_errors": true, "_tests": true, "home": false, "static": false} {
+		if got := Reserved(name); got != want {
+			t.Errorf("Reserved(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package qr encodes text as QR Code symbols with skip2/go-qrcode, and
+// renders them as SVG for links and short payloads.
+package qr
+
+import (
+	"errors"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Level is the error correction level of a symbol.
+type Level int
+
+const (
+	Low      Level = iota // recovers ~7% damage
+	Medium                // recovers ~15% damage
+	Quartile              // recovers ~25% damage
+	High                  // recovers ~30% damage
+)
+
+// recoveryLevels maps levels to go-qrcode's names for them.
+var recoveryLevels = [...]qrcode.RecoveryLevel{
+	Low:      qrcode.Low,
+	Medium:   qrcode.Medium,
+	Quartile: qrcode.High,
+	High:     qrcode.Highest,
+}
+
+// ErrTooLong is returned when the text does not fit in a version 40 symbol.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded QR Code symbol.
+type Code struct {
+	Version int
+	Size    int // modules per side (17 + 4*Version)
+	Level   Level
+
+	modules [][]bool
+}
+
+// Dark reports whether the module at (x, y) is dark. Coordinates outside the
+// symbol (e.g. in the quiet zone) are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Encode encodes text using the smallest version that fits.
+func Encode(text string, level Level) (*Code, error) {
+	if level < Low || level > High {
+		level = Medium
+	}
+	q, err := qrcode.New(text, recoveryLevels[level])
+	if err != nil {
+		// go-qrcode only fails on text that doesn't fit
+		return nil, ErrTooLong
+	}
+	// The renderers draw their own quiet zone
+	q.DisableBorder = true
+	modules := q.Bitmap()
+	return &Code{Version: q.VersionNumber, Size: len(modules), Level: level, modules: modules}, nil
+}
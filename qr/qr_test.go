@@ -0,0 +1,108 @@
+package qr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncodeVersion(t *testing.T) {
+	tests := []struct {
+		text    string
+		level   Level
+		version int
+	}{
+		{"hello", Low, 1},
+		{"https://example.com/some/long/path", Medium, 3},
+		{strings.Repeat("a", 17), Low, 1},
+		{strings.Repeat("a", 18), Low, 2},
+		{strings.Repeat("a", 2953), Low, 40},
+	}
+	for _, tt := range tests {
+		c, err := Encode(tt.text, tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(tt.text), err)
+		}
+		if c.Version != tt.version {
+			t.Errorf("Encode(%d bytes, %d) version = %d, want %d", len(tt.text), tt.level, c.Version, tt.version)
+		}
+		if c.Size != 17+4*tt.version {
+			t.Errorf("size = %d, want %d", c.Size, 17+4*tt.version)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	_, err := Encode(strings.Repeat("a", 2954), Low)
+	if !errors.Is(err, ErrTooLong) {
+		t.Fatalf("err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestFinderPatterns(t *testing.T) {
+	c, err := Encode("finder", Quartile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Row through the centre of the top-left finder: 1 dark, 1 light, 3 dark, 1 light, 1 dark, then separator.
+	want := []bool{true, false, true, true, true, false, true, false}
+	for x, w := range want {
+		if c.Dark(x, 3) != w {
+			t.Errorf("Dark(%d, 3) = %v, want %v", x, c.Dark(x, 3), w)
+		}
+	}
+	if !c.Dark(8, c.Size-8) {
+		t.Error("dark module not set")
+	}
+	if c.Dark(-1, 0) || c.Dark(c.Size, 0) {
+		t.Error("quiet zone should be light")
+	}
+}
+
+// TestFormatBits checks that the format information around the top-left
+// finder decodes to the chosen level.
+func TestFormatBits(t *testing.T) {
+	for _, level := range []Level{Low, Medium, Quartile, High} {
+		c, err := Encode("format", level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var bits int
+		for i := 0; i <= 5; i++ {
+			if c.Dark(8, i) {
+				bits |= 1 << i
+			}
+		}
+		if c.Dark(8, 7) {
+			bits |= 1 << 6
+		}
+		if c.Dark(8, 8) {
+			bits |= 1 << 7
+		}
+		if c.Dark(7, 8) {
+			bits |= 1 << 8
+		}
+		for i := 9; i < 15; i++ {
+			if c.Dark(14-i, 8) {
+				bits |= 1 << i
+			}
+		}
+		data := (bits ^ 0x5412) >> 10
+		if want := [...]int{Low: 1, Medium: 0, Quartile: 3, High: 2}[level]; data>>3 != want {
+			t.Errorf("level %d: format level bits = %d, want %d", level, data>>3, want)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	c, err := Encode("svg", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := c.SVG(4, "120px")
+	for _, want := range []string{`viewBox="0 0 29 29"`, `width="120px"`, `<path d="M4,4h1v1h-1z`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SVG renders the symbol as a standalone SVG element with a quiet zone of
+// border modules on each side. size sets the width and height attributes
+// (e.g. "200px"); empty leaves the image to scale with its container.
+func (c *Code) SVG(border int, size string) string {
+	if border < 0 {
+		border = 0
+	}
+	dim := c.Size + border*2
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+border, y+border)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<svg xmlns="http://www.w3.org/2000/svg"`)
+	if size != "" {
+		fmt.Fprintf(&sb, ` width="%s" height="%s"`, size, size)
+	}
+	fmt.Fprintf(&sb, ` viewBox="0 0 %d %d" shape-rendering="crispEdges" role="img">`, dim, dim)
+	sb.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+	fmt.Fprintf(&sb, `<path d="%s" fill="#000"/>`, path.String())
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
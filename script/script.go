@@ -0,0 +1,260 @@
+// Package script runs route handler scripts (handler.star), written in
+// Starlark (go.starlark.net). A script defines handle(), which the server
+// calls once per request with the request's values as predeclared names:
+//
+//	def handle():
+//	    items = signals.get("items", [])
+//	    total = 0
+//	    for item in items:
+//	        if item["qty"] == 0:
+//	            fail("empty line item")
+//	        total += item["price"] * item["qty"]
+//	    if total > 100:
+//	        return {"section": "discount", "data": {"total": total}}
+//	    if method == "POST":
+//	        return {"redirect": "/thanks"}
+//	    return {"data": {"total": total}}
+//
+// Go maps become dicts, slices lists and structs read-only structs
+// (user.Login). Scripts cannot load other files, loop with while, recurse or
+// reach the network, and are stopped after maxSteps steps.
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxSteps bounds the Starlark steps one run may execute.
+const maxSteps = 100_000
+
+// entry is the function a script must define.
+const entry = "handle"
+
+// Script is a compiled script, safe for concurrent runs.
+type Script struct {
+	name string
+	prog *starlark.Program
+}
+
+// Parse compiles a script. predeclared lists the names Run's env will hold,
+// so a misspelt one is reported here rather than on some later request.
+func Parse(name, src string, predeclared []string) (*Script, error) {
+	f, err := (&syntax.FileOptions{}).Parse(name, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !defines(f, entry) {
+		return nil, fmt.Errorf("%s: no %s() function", name, entry)
+	}
+	names := make(map[string]bool, len(predeclared))
+	for _, n := range predeclared {
+		names[n] = true
+	}
+	prog, err := starlark.FileProgram(f, func(n string) bool { return names[n] })
+	if err != nil {
+		return nil, err
+	}
+	return &Script{name: name, prog: prog}, nil
+}
+
+func defines(f *syntax.File, fn string) bool {
+	for _, s := range f.Stmts {
+		if def, ok := s.(*syntax.DefStmt); ok && def.Name.Name == fn {
+			return true
+		}
+	}
+	return false
+}
+
+// Run calls the script's handle() with env as predeclared names and returns
+// its result converted back to Go: nil, bool, int64, float64, string,
+// []any or map[string]any.
+func (s *Script) Run(env map[string]any) (any, error) {
+	predeclared := make(starlark.StringDict, len(env))
+	for key, v := range env {
+		sv, err := toStarlark(reflect.ValueOf(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", s.name, key, err)
+		}
+		predeclared[key] = sv
+	}
+	thread := &starlark.Thread{
+		Name:  s.name,
+		Print: func(*starlark.Thread, string) {},
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not supported")
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+
+	globals, err := s.prog.Init(thread, predeclared)
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	fn, ok := globals[entry].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not a function", s.name, entry)
+	}
+	v, err := starlark.Call(thread, fn, nil, nil)
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	return fromStarlark(v)
+}
+
+// wrap puts the script's innermost line in front of an evaluation error,
+// e.g. "handler.star:5: fail: empty line item".
+func (s *Script) wrap(err error) error {
+	var evalErr *starlark.EvalError
+	if !errors.As(err, &evalErr) {
+		return err
+	}
+	for i := len(evalErr.CallStack) - 1; i >= 0; i-- {
+		if pos := evalErr.CallStack[i].Pos; pos.Filename() == s.name {
+			return fmt.Errorf("%s:%d: %s", s.name, pos.Line, evalErr.Msg)
+		}
+	}
+	return fmt.Errorf("%s: %s", s.name, evalErr.Msg)
+}
+
+func toStarlark(v reflect.Value) (starlark.Value, error) {
+	if !v.IsValid() {
+		return starlark.None, nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return starlark.None, nil
+		}
+		return toStarlark(v.Elem())
+	case reflect.Bool:
+		return starlark.Bool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return starlark.MakeInt64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return starlark.MakeUint64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return starlark.Float(v.Float()), nil
+	case reflect.String:
+		return starlark.String(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		elems := make([]starlark.Value, v.Len())
+		for i := range elems {
+			elem, err := toStarlark(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		return starlark.NewList(elems), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		dict := starlark.NewDict(len(keys))
+		for _, key := range keys {
+			elem, err := toStarlark(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key.String()), elem); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	case reflect.Struct:
+		fields := make(starlark.StringDict)
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				elem, err := toStarlark(v.Field(i))
+				if err != nil {
+					return nil, err
+				}
+				fields[f.Name] = elem
+			}
+		}
+		return starlarkstruct.FromStringDict(starlarkstruct.Default, fields), nil
+	}
+	// Anything else (a map with non-string keys, say) goes through its
+	// JSON form, as it would in a template's toJSON.
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("can't pass %s to a script", v.Type())
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, err
+	}
+	return toStarlark(reflect.ValueOf(decoded))
+}
+
+func fromStarlark(v starlark.Value) (any, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		n, ok := v.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s is too large", v)
+		}
+		return n, nil
+	case starlark.Float:
+		f := float64(v)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("can't return %s", v)
+		}
+		return f, nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Indexable: // list, tuple
+		out := make([]any, v.Len())
+		for i := range out {
+			elem, err := fromStarlark(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = elem
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, v.Len())
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be strings, not %s", item[0].Type())
+			}
+			elem, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			out[string(key)] = elem
+		}
+		return out, nil
+	case *starlarkstruct.Struct:
+		fields := make(starlark.StringDict)
+		v.ToStringDict(fields)
+		out := make(map[string]any, len(fields))
+		for name, field := range fields {
+			elem, err := fromStarlark(field)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = elem
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("can't return a %s", v.Type())
+}
@@ -0,0 +1,110 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	src := `
+# Order totals
+def handle():
+    total = 0
+    for item in signals.get("items", []):   # skip empty lines
+        if item["qty"] == 0:
+            continue
+        total += item["price"] * item["qty"]
+        if total > 100:
+            break
+
+    if signals.get("coupon") == "FREE":
+        return {"redirect": "/free"}
+    elif total > 50:
+        return {
+            "section": "discount",
+            "data": {"total": total},
+        }
+    return {"data": {"total": total, "keys": None}}
+`
+	s, err := Parse("handler.star", src, []string{"signals"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := func(qtys ...float64) []any {
+		var out []any
+		for _, q := range qtys {
+			out = append(out, map[string]any{"price": 10.0, "qty": q})
+		}
+		return out
+	}
+	tests := []struct {
+		signals map[string]any
+		want    any
+	}{
+		{map[string]any{"items": items(1, 0, 2)}, map[string]any{"data": map[string]any{"total": 30.0, "keys": nil}}},
+		{map[string]any{"items": items(3, 3)}, map[string]any{"section": "discount", "data": map[string]any{"total": 60.0}}},
+		{map[string]any{"items": items(8, 8, 8)}, map[string]any{"section": "discount", "data": map[string]any{"total": 160.0}}},
+		{map[string]any{"coupon": "FREE"}, map[string]any{"redirect": "/free"}},
+		{nil, map[string]any{"data": map[string]any{"total": int64(0), "keys": nil}}},
+	}
+	for _, tt := range tests {
+		got, err := s.Run(map[string]any{"signals": tt.signals})
+		if err != nil {
+			t.Errorf("Run(%v): %v", tt.signals, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Run(%v) = %#v, want %#v", tt.signals, got, tt.want)
+		}
+	}
+}
+
+func TestEnvAndFail(t *testing.T) {
+	type user struct{ Login string }
+	s, err := Parse("handler.star", "def handle():\n    seen = ''\n    for k in m:\n        seen += k\n    if seen != 'abc' or user.Login != 'ada':\n        fail('got', seen)\n", []string{"m", "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Run(map[string]any{"m": map[string]any{"c": 1, "a": 2, "b": 3}, "user": &user{"ada"}}); err != nil || v != nil {
+		t.Errorf("Run = %v, %v; want nil, nil", v, err)
+	}
+	_, err = s.Run(map[string]any{"m": map[string]int{"x": 1}, "user": &user{"ada"}})
+	if err == nil || err.Error() != "handler.star:6: fail: got x" {
+		t.Errorf("Run error = %v, want handler.star:6: fail: got x", err)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	for src, want := range map[string]string{
+		"def handle():\n    return handle()":                        "called recursively",
+		"def handle():\n    for i in range(1000000):\n        pass": "too many steps",
+		"load('x.star', 'y')\ndef handle():\n    pass":              "load is not supported",
+	} {
+		s, err := Parse("handler.star", src, nil)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", src, err)
+			continue
+		}
+		if _, err := s.Run(nil); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Run(%q) error = %v, want %q", src, err, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		"x = 1":                             "no handle() function",
+		"def handle():\n    return singals": "h.star:2:12: undefined: singals",
+		"def handle():\n    while True:\n        pass": "does not support while loops",
+		"if x:\n    pass\ndef handle():\n    pass":     "if statement not within a function",
+		"def handle(:\n    pass":                       "got ':'",
+		"def handle():\nreturn 1":                      "got return, want indent",
+	} {
+		_, err := Parse("h.star", src, []string{"signals"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", src, err, want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+//go:embed templates
+var templatesFS embed.FS
+
+var adminTemplate = template.Must(template.ParseFS(templatesFS, "templates/admin.html"))
+
+const (
+	adminPathPrefix  = "/_admin"
+	adminTokenCookie = "dsplay_admin_token"
+)
+
+// AdminHandler serves the admin dashboard under /_admin.
+type AdminHandler struct {
+	outbox   *Outbox
+	limiter  *ConnLimiter
+	conns    *ConnRegistry
+	jobs     *JobQueue
+	counters *Counters
+	sessions *SessionManager
+	clock    *Clock
+	tokens   []string // required to reach any admin route; none = open
+}
+
+func NewAdminHandler(outbox *Outbox, limiter *ConnLimiter, conns *ConnRegistry, jobs *JobQueue, counters *Counters, sessions *SessionManager, clock *Clock, tokens []string) *AdminHandler {
+	return &AdminHandler{outbox: outbox, limiter: limiter, conns: conns, jobs: jobs, counters: counters, sessions: sessions, clock: clock, tokens: tokens}
+}
+
+// adminPage is the data rendered by templates/admin.html.
+type adminPage struct {
+	Connections ConnStats
+	Streams     []ConnInfo
+	Jobs        QueueStats
+	Outbox      []OutboxMessage
+	GlobalHits  int64
+	URLs        []URLHitCount
+	Clock       ClockState
+	SessionID   string // the admin's own session, redacted like Streams
+	CSRFToken   string // for the dashboard's forms when --csrf is on
+}
+
+// Routes mounts the admin endpoints on r:
+//
+//	GET  /_admin/              → dashboard
+//	GET  /_admin/metrics       → Prometheus text-format gauges and counters
+//	GET  /_admin/connections.json → open SSE and WebSocket streams
+//	POST /_admin/connections/close → close the stream with the form's id
+//	GET  /_admin/jobs.json     → simulated job queue
+//	GET  /_admin/outbox.json   → recorded outbox messages
+//	POST /_admin/outbox/clear  → empty the outbox
+//	POST /_admin/reset/counters → zero hit counters (all, or the form's url)
+//	POST /_admin/reset/sessions → reset every session's hits and sequence positions
+//	POST /_admin/reset/session  → reset one session (the form's id, default your own)
+//	POST /_admin/clock/pause    → freeze playground time
+//	POST /_admin/clock/resume   → run playground time at normal speed
+//	POST /_admin/clock/speed    → run playground time at the form's speed multiplier
+//	POST /_admin/clock/jump     → move playground time forward by the form's duration
+//	POST /_admin/clock/reset    → return to real time
+//
+// With tokens set, every route requires one, as a bearer token or from a
+// link with ?token= that the browser then remembers in a cookie.
+func (a *AdminHandler) Routes(r chi.Router) {
+	r.Get(adminPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusMovedPermanently)
+	})
+	r.Group(a.routes)
+}
+
+func (a *AdminHandler) routes(r chi.Router) {
+	r.Use(guard(Protection{Tokens: a.tokens}, adminTokenCookie))
+	r.Get(adminPathPrefix+"/", a.dashboard)
+	r.Get(adminPathPrefix+"/metrics", a.metrics)
+	r.Get(adminPathPrefix+"/connections.json", a.connectionsJSON)
+	r.Post(adminPathPrefix+"/connections/close", a.closeConnection)
+	r.Get(adminPathPrefix+"/jobs.json", a.jobsJSON)
+	r.Get(adminPathPrefix+"/outbox.json", a.outboxJSON)
+	r.Post(adminPathPrefix+"/outbox/clear", a.clearOutbox)
+	r.Post(adminPathPrefix+"/reset/counters", a.resetCounters)
+	r.Post(adminPathPrefix+"/reset/sessions", a.resetSessions)
+	r.Post(adminPathPrefix+"/reset/session", a.resetSession)
+	r.Post(adminPathPrefix+"/clock/pause", a.pauseClock)
+	r.Post(adminPathPrefix+"/clock/resume", a.resumeClock)
+	r.Post(adminPathPrefix+"/clock/speed", a.speedClock)
+	r.Post(adminPathPrefix+"/clock/jump", a.jumpClock)
+	r.Post(adminPathPrefix+"/clock/reset", a.resetClock)
+}
+
+func (a *AdminHandler) dashboard(w http.ResponseWriter, r *http.Request) {
+	sess, sd, err := a.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sess.Save(r, w) // new visitors need the cookie for the forms' CSRF token
+	page := adminPage{
+		Connections: a.limiter.Stats(),
+		Streams:     a.conns.List(),
+		Jobs:        a.jobs.Stats(),
+		Outbox:      a.outbox.Messages(),
+		GlobalHits:  a.counters.GetGlobalHits(),
+		URLs:        a.counters.URLs(),
+		Clock:       a.clock.State(),
+		SessionID:   redactID(sd.SessionID),
+		CSRFToken:   a.sessions.CSRFToken(sd.SessionID),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminTemplate.Execute(w, page); err != nil {
+		log.Printf("Admin template error: %v", err)
+	}
+}
+
+func (a *AdminHandler) metrics(w http.ResponseWriter, r *http.Request) {
+	stats := a.limiter.Stats()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP dsplay_sse_connections Open SSE connections.\n")
+	fmt.Fprintf(w, "# TYPE dsplay_sse_connections gauge\n")
+	fmt.Fprintf(w, "dsplay_sse_connections %d\n", stats.Active)
+	fmt.Fprintf(w, "# HELP dsplay_sse_sessions Sessions with at least one open SSE connection.\n")
+	fmt.Fprintf(w, "# TYPE dsplay_sse_sessions gauge\n")
+	fmt.Fprintf(w, "dsplay_sse_sessions %d\n", stats.Sessions)
+	fmt.Fprintf(w, "# HELP dsplay_sse_connections_limit Global SSE connection limit (0 = unlimited).\n")
+	fmt.Fprintf(w, "# TYPE dsplay_sse_connections_limit gauge\n")
+	fmt.Fprintf(w, "dsplay_sse_connections_limit %d\n", stats.MaxGlobal)
+	fmt.Fprintf(w, "# HELP dsplay_sse_rejected_total SSE connections rejected by the limiter.\n")
+	fmt.Fprintf(w, "# TYPE dsplay_sse_rejected_total counter\n")
+	fmt.Fprintf(w, "dsplay_sse_rejected_total %d\n", stats.Rejected)
+}
+
+func (a *AdminHandler) connectionsJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.conns.List())
+}
+
+func (a *AdminHandler) closeConnection(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id must be a connection id", http.StatusBadRequest)
+		return
+	}
+	if !a.conns.Close(id) {
+		http.Error(w, "no such connection", http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) jobsJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.jobs.List(""))
+}
+
+func (a *AdminHandler) outboxJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, a.outbox.Messages())
+}
+
+func (a *AdminHandler) clearOutbox(w http.ResponseWriter, r *http.Request) {
+	a.outbox.Clear()
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) resetCounters(w http.ResponseWriter, r *http.Request) {
+	if u := r.FormValue("url"); u != "" {
+		a.counters.ResetURL(u)
+	} else {
+		a.counters.Reset()
+	}
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) resetSessions(w http.ResponseWriter, r *http.Request) {
+	a.sessions.ResetAllSessions()
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) resetSession(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("id")
+	if id == "" {
+		_, sd, err := a.sessions.GetOrCreate(w, r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		id = sd.SessionID
+	}
+	a.sessions.ResetSession(id)
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) pauseClock(w http.ResponseWriter, r *http.Request) {
+	a.clock.SetSpeed(0)
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) resumeClock(w http.ResponseWriter, r *http.Request) {
+	a.clock.SetSpeed(1)
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) speedClock(w http.ResponseWriter, r *http.Request) {
+	speed, err := strconv.ParseFloat(r.FormValue("speed"), 64)
+	if err != nil || speed < 0 {
+		http.Error(w, "speed must be a non-negative number", http.StatusBadRequest)
+		return
+	}
+	a.clock.SetSpeed(speed)
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) jumpClock(w http.ResponseWriter, r *http.Request) {
+	d, err := time.ParseDuration(r.FormValue("by"))
+	if err != nil || d < 0 {
+		http.Error(w, "by must be a positive duration such as 30s or 5m", http.StatusBadRequest)
+		return
+	}
+	a.clock.Jump(d)
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func (a *AdminHandler) resetClock(w http.ResponseWriter, r *http.Request) {
+	a.clock.Reset()
+	http.Redirect(w, r, basePath(r)+adminPathPrefix+"/", http.StatusSeeOther)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("JSON encode error: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testAdminToken = "admin-secret"
+
+// newAdminServer serves a playground with the admin dashboard behind
+// testAdminToken.
+func newAdminServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	h, closer, err := New(Config{
+		FS:            fstest.MapFS{"index.html": {Data: []byte("hi")}},
+		SessionSecret: "dsplay-test-secret",
+		Admin:         true,
+		AdminTokens:   []string{testAdminToken},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// adminRequest sends method to the admin path, with token as a bearer token
+// when set, and returns the status.
+func adminRequest(t *testing.T, srv *httptest.Server, method, path, form, token string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form != "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func TestAdminResetRequiresToken(t *testing.T) {
+	srv := newAdminServer(t)
+	for _, path := range []string{"/_admin/reset/counters", "/_admin/reset/sessions", "/_admin/reset/session"} {
+		if got := adminRequest(t, srv, http.MethodPost, path, "", ""); got != http.StatusUnauthorized {
+			t.Errorf("POST %s without a token = %d, want 401", path, got)
+		}
+		if got := adminRequest(t, srv, http.MethodPost, path, "", "wrong"); got != http.StatusUnauthorized {
+			t.Errorf("POST %s with a wrong token = %d, want 401", path, got)
+		}
+		if got := adminRequest(t, srv, http.MethodPost, path, "", testAdminToken); got != http.StatusSeeOther {
+			t.Errorf("POST %s with the token = %d, want 303", path, got)
+		}
+	}
+	if got := adminRequest(t, srv, http.MethodGet, "/_admin/", "", ""); got != http.StatusUnauthorized {
+		t.Errorf("GET /_admin/ without a token = %d, want 401", got)
+	}
+	if got := adminRequest(t, srv, http.MethodGet, "/_admin/?token="+testAdminToken, "", ""); got != http.StatusOK {
+		t.Errorf("GET /_admin/?token= = %d, want 200", got)
+	}
+}
+
+func TestAdminClockRequiresToken(t *testing.T) {
+	srv := newAdminServer(t)
+	tests := []struct {
+		path string
+		form string
+	}{
+		{"/_admin/clock/pause", ""},
+		{"/_admin/clock/resume", ""},
+		{"/_admin/clock/speed", "speed=60"},
+		{"/_admin/clock/jump", "by=1h"},
+		{"/_admin/clock/reset", ""},
+	}
+	for _, tt := range tests {
+		if got := adminRequest(t, srv, http.MethodPost, tt.path, tt.form, ""); got != http.StatusUnauthorized {
+			t.Errorf("POST %s without a token = %d, want 401", tt.path, got)
+		}
+		if got := adminRequest(t, srv, http.MethodPost, tt.path, tt.form, testAdminToken); got != http.StatusSeeOther {
+			t.Errorf("POST %s with the token = %d, want 303", tt.path, got)
+		}
+	}
+}
+
+func TestAdminConnections(t *testing.T) {
+	srv := newAdminServer(t)
+	if got := adminRequest(t, srv, http.MethodGet, "/_admin/connections.json", "", ""); got != http.StatusUnauthorized {
+		t.Errorf("GET connections.json without a token = %d, want 401", got)
+	}
+	if got := adminRequest(t, srv, http.MethodPost, "/_admin/connections/close", "id=1", ""); got != http.StatusUnauthorized {
+		t.Errorf("POST connections/close without a token = %d, want 401", got)
+	}
+	if got := adminRequest(t, srv, http.MethodPost, "/_admin/connections/close", "id=1", testAdminToken); got != http.StatusNotFound {
+		t.Errorf("POST connections/close for a closed stream = %d, want 404", got)
+	}
+
+	conns := NewConnRegistry()
+	_, done := conns.Open(context.Background(), ConnInfo{Transport: "sse", Route: "/", SessionID: "s-secret", TabID: "tab-secret"})
+	defer done()
+	info := conns.List()[0]
+	if info.SessionID == "s-secret" || info.SessionID == "" || info.TabID == "tab-secret" || info.TabID == "" {
+		t.Errorf("List() = session %q tab %q, want fingerprints", info.SessionID, info.TabID)
+	}
+}
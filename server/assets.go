@@ -0,0 +1,59 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/ignore"
+	"github.com/dataSPA/dataSPA-playground/parser"
+)
+
+// routeAsset maps a request path to a file kept next to a route's
+// templates, such as /todos/style.css → todos/style.css, so examples can
+// carry their own CSS, scripts and images. Templates, handler scripts,
+// dotfiles, the server config, the manifest, reserved _ files and
+// directories (such as _schedule.yaml) and whatever .dsplayignore lists are
+// never served this way.
+func routeAsset(fsys fs.FS, urlPath string) (string, bool) {
+	if strings.HasSuffix(urlPath, "/") {
+		return "", false
+	}
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	switch path.Ext(name) {
+	case "", ".html", ".md":
+		return "", false
+	}
+	if name == manifestFile || path.Base(name) == scriptFile || hiddenSource(name) {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if parser.Reserved(part) {
+			return "", false
+		}
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	if ignored, _ := ignore.Load(fsys); ignored.Match(name, false) {
+		return "", false
+	}
+	return name, true
+}
+
+// serveRouteAsset serves a co-located asset if the request names one,
+// reporting whether it did.
+func (h *Handler) serveRouteAsset(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	name, ok := routeAsset(h.fsys, r.URL.Path)
+	if !ok {
+		return false
+	}
+	h.debugLog("%s %s → asset %s", r.Method, r.URL.Path, name)
+	http.ServeFileFS(w, r, h.fsys, name)
+	return true
+}
@@ -0,0 +1,9 @@
+// Datastar v1.0.0
+var yt=/🖕JS_DS🚀/.source,Ue=yt.slice(0,5),Je=yt.slice(4),B="datastar-fetch",te="datastar-prop-change",vt="datastar-ready",Ke="datastar-scope-children",ne="datastar-signal-patch";var x=Object.hasOwn??Object.prototype.hasOwnProperty.call;var K=e=>e!==null&&typeof e=="object"&&(Object.getPrototypeOf(e)===Object.prototype||Object.getPrototypeOf(e)===null),bt=e=>{for(let t in e)if(x(e,t))return!1;return!0},re=(e,t)=>{for(let n in e){let r=e[n];K(r)||Array.isArray(r)?re(r,t):e[n]=t(r)}},Le=e=>{let t={};for(let[n,r]of e){let s=n.split("."),i=s.pop(),o=s.reduce((a,c)=>a[c]??={},t);o[i]=r}return t};var xe=[],ze=[],He=0,Ne=0,Ze=0,Qe,j,Pe=0,N=()=>{He++},P=()=>{--He||(Tt(),z())},_=e=>{Qe=j,j=e},k=()=>{j=Qe,Qe=void 0},me=e=>cn.bind(0,{previousValue:e,t:e,e:1}),Ye=Symbol("computed"),_e=e=>{let t=ln.bind(0,{e:17,getter:e});return t[Ye]=1,t},R=e=>{let t={d:e,e:2};j&&et(t,j),_(t),N();try{t.d()}finally{P(),k()}return Mt.bind(0,t)},Tt=()=>{for(;Ne<Ze;){let e=ze[Ne];ze[Ne++]=void 0,wt(e,e.e&=-65)}Ne=0,Ze=0},Et=e=>"getter"in e?At(e):Rt(e,e.t),At=e=>{_(e),Lt(e);try{let t=e.t;return t!==(e.t=e.getter(t))}finally{k(),xt(e)}},Rt=(e,t)=>(e.e=1,e.previousValue!==(e.previousValue=t)),Xe=e=>{let t=e.e;if(!(t&64)){e.e=t|64;let n=e.r;n?Xe(n.o):ze[Ze++]=e}},wt=(e,t)=>{if(t&16||t&32&&Nt(e.s,e)){_(e),Lt(e),N();try{e.d()}finally{P(),k(),xt(e)}return}t&32&&(e.e=t&-33);let n=e.s;for(;n;){let r=n.c,s=r.e;s&64&&wt(r,r.e=s&-65),n=n.i}},cn=(e,...t)=>{if(t.length){if(e.t!==(e.t=t[0])){e.e=17;let r=e.r;return r&&(un(r),He||Tt()),!0}return!1}let n=e.t;if(e.e&16&&Rt(e,n)){let r=e.r;r&&Fe(r)}return j&&et(e,j),n},ln=e=>{let t=e.e;if(t&16||t&32&&Nt(e.s,e)){if(At(e)){let n=e.r;n&&Fe(n)}}else t&32&&(e.e=t&-33);return j&&et(e,j),e.t},Mt=e=>{let t=e.s;for(;t;)t=Ce(t,e);let n=e.r;n&&Ce(n),e.e=0},et=(e,t)=>{let n=t.a;if(n&&n.c===e)return;let r=n?n.i:t.s;if(r&&r.c===e){r.p=Pe,t.a=r;return}let s=e.m;if(s&&s.p===Pe&&s.o===t)return;let i=t.a=e.m={p:Pe,c:e,o:t,l:n,i:r,u:s};r&&(r.l=i),n?n.i=i:t.s=i,s?s.n=i:e.r=i},Ce=(e,t=e.o)=>{let n=e.c,r=e.l,s=e.i,i=e.n,o=e.u;if(s?s.l=r:t.a=r,r?r.i=s:t.s=s,i?i.u=o:n.m=o,o)o.n=i;else if(!(n.r=i))if("getter"in n){let a=n.s;if(a){n.e=17;do a=Ce(a,n);while(a)}}else"previousValue"in n||Mt(n);return s},un=e=>{let t=e.n,n;e:for(;;){let r=e.o,s=r.e;if(s&60?s&12?s&4?!(s&48)&&fn(e,r)?(r.e=s|40,s&=1):s=0:r.e=s&-9|32:s=0:r.e=s|32,s&2&&Xe(r),s&1){let i=r.r;if(i){let o=(e=i).n;o&&(n={t,f:n},t=o);continue}}if(e=t){t=e.n;continue}for(;n;)if(e=n.t,n=n.f,e){t=e.n;continue e}break}},Lt=e=>{Pe++,e.a=void 0,e.e=e.e&-57|4},xt=e=>{let t=e.a,n=t?t.i:e.s;for(;n;)n=Ce(n,e);e.e&=-5},Nt=(e,t)=>{let n,r=0,s=!1;e:for(;;){let i=e.c,o=i.e;if(t.e&16)s=!0;else if((o&17)===17){if(Et(i)){let a=i.r;a.n&&Fe(a),s=!0}}else if((o&33)===33){(e.n||e.u)&&(n={t:e,f:n}),e=i.s,t=i,++r;continue}if(!s){let a=e.i;if(a){e=a;continue}}for(;r--;){let a=t.r,c=a.n;if(c?(e=n.t,n=n.f):e=a,s){if(Et(t)){c&&Fe(a),t=e.o;continue}s=!1}else t.e&=-33;if(t=e.o,e.i){e=e.i;continue e}}return s}},Fe=e=>{do{let t=e.o,n=t.e;(n&48)===32&&(t.e=n|16,n&2&&Xe(t))}while(e=e.n)},fn=(e,t)=>{let n=t.a;for(;n;){if(n===e)return!0;n=n.l}return!1},W=e=>{let t=se,n=e.split(".");for(let r of n){if(t==null||!x(t,r))return;t=t[r]}return t},Oe=(e,t="")=>{let n=Array.isArray(e);if(n||K(e)){let r=n?[]:{};for(let i in e)r[i]=me(Oe(e[i],`${t+i}.`));let s=me(0);return new Proxy(r,{get(i,o){if(!(o==="toJSON"&&!x(r,o)))return n&&o in Array.prototype?(s(),r[o]):typeof o=="symbol"?r[o]:((!x(r,o)||r[o]()==null)&&(r[o]=me(""),z(t+o,""),s(s()+1)),r[o]())},set(i,o,a){let c=t+o;if(n&&o==="length"){let l=r[o]-a;if(r[o]=a,l>0){let u={};for(let f=a;f<r[o];f++)u[f]=null;z(t.slice(0,-1),u),s(s()+1)}}else if(x(r,o))if(a==null)delete r[o];else if(x(a,Ye))r[o]=a,z(c,"");else{let l=r[o](),u=`${c}.`;if(K(l)&&K(a)){for(let f in l)x(a,f)||(delete l[f],z(u+f,null));for(let f in a){let h=a[f];l[f]!==h&&(l[f]=h)}}else r[o](Oe(a,u))&&z(c,a)}else a!=null&&(x(a,Ye)?(r[o]=a,z(c,"")):(r[o]=me(Oe(a,`${c}.`)),z(c,a)),s(s()+1));return!0},deleteProperty(i,o){return delete r[o],s(s()+1),!0},ownKeys(){return s(),Reflect.ownKeys(r)},has(i,o){return s(),o in r}})}return e},z=(e,t)=>{if(e!==void 0&&t!==void 0&&xe.push([e,t]),!He&&xe.length){let n=Le(xe);xe.length=0,document.dispatchEvent(new CustomEvent(ne,{detail:n}))}},D=(e,{ifMissing:t}={})=>{N();for(let n in e)e[n]==null?t||delete se[n]:Pt(e[n],n,se,"",t);P()},S=(e,t)=>D(Le(e),t),Pt=(e,t,n,r,s)=>{if(K(e)){x(n,t)&&(K(n[t])||Array.isArray(n[t]))||(n[t]={});for(let i in e)e[i]==null?s||delete n[t][i]:Pt(e[i],i,n[t],`${r+t}.`,s)}else s&&x(n,t)||(n[t]=e)},St=e=>typeof e=="string"?RegExp(e.replace(/^\/|\/$/g,"")):e,$=({include:e=/.*/,exclude:t=/(?!)/}={},n=se)=>{let r=St(e),s=St(t),i=[],o=[[n,""]];for(;o.length;){let[a,c]=o.pop();for(let l in a){let u=c+l;K(a[l])?o.push([a[l],`${u}.`]):r.test(u)&&!s.test(u)&&i.push([u,W(u)])}}return Le(i)},se=Oe({});var Z=e=>e instanceof HTMLElement||e instanceof SVGElement||e instanceof MathMLElement;var ge=e=>e.replace(/([A-Z]+)([A-Z][a-z])/g,"$1-$2").replace(/([a-z0-9])([A-Z])/g,"$1-$2").replace(/([a-z])([0-9]+)/gi,"$1-$2").replace(/([0-9]+)([a-z])/gi,"$1-$2").replace(/[\s_]+/g,"-").toLowerCase();var Ot=e=>ge(e).replace(/-/g,"_");var dn=/^(?:(?:async\s+)?function\b|(?:async\s*)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*=>)/,ae=(e,t={})=>{let{reviveFunctionStrings:n=!1}=t;try{return n?JSON.parse(e,(r,s)=>{if(typeof s!="string")return s;let i=s.trim();if(!dn.test(i))return s;try{let o=Function(`return (${i})`)();return typeof o=="function"?o:s}catch{return s}}):JSON.parse(e)}catch{return Function(`return (${e})`)()}},Ct={camel:e=>e.replace(/-[a-z]/g,t=>t[1].toUpperCase()),snake:e=>e.replace(/-/g,"_"),pascal:e=>e[0].toUpperCase()+Ct.camel(e.slice(1))},O=(e,t,n="camel")=>{for(let r of t.get("case")||[n])e=Ct[r]?.(e)||e;return e},U=e=>`data-${e}`,tt=e=>e;var pn="https://data-star.dev/errors",he=(e,t,n={})=>{Object.assign(n,e);let r=new Error,s=Ot(t),i=new URLSearchParams({metadata:JSON.stringify(n)}).toString(),o=JSON.stringify(n,null,2);return r.message=`${t}
+More info: ${pn}/${s}?${i}
+Context: ${o}`,r},ye=new Map,nt=new Map,_t=new Map,kt=new Proxy({},{get:(e,t)=>ye.get(t)?.apply,has:(e,t)=>ye.has(t),ownKeys:()=>Reflect.ownKeys(ye),set:()=>!1,deleteProperty:()=>!1}),be=new Map,ke=[],rt=new Set,ve=new Set,Ft=!1,g=e=>{ke.push(e),ke.length===1&&setTimeout(()=>{for(let n of ke)rt.add(n.name),nt.set(n.name,n);ke.length=0;let t=ve.size?[...ve]:[document.documentElement];for(let n of t)En(n,!ve.has(n));rt.clear()})},V=e=>{ye.set(e.name,e)};document.addEventListener(B,e=>{let t=_t.get(e.detail.type);t&&t.apply({error:he.bind(0,{plugin:{type:"watcher",name:t.name},element:{id:e.target.id,tag:e.target.tagName}})},e.detail.argsRaw)});var Ee=e=>{_t.set(e.name,e)},Ht=e=>{for(let t of e){let n=be.get(t);if(n&&be.delete(t))for(let r of n.values())for(let s of r.values())s()}},Dt=U("ignore"),mn=`[${Dt}]`,Vt=e=>e.hasAttribute(`${Dt}__self`)||!!e.closest(mn),De=(e,t)=>{for(let n of e)if(!Vt(n)){let r=new Set;for(let s in n.dataset){let i=s.replace(/[A-Z]/g,"-$&").toLowerCase();r.add(i),st(n,i,n.dataset[s],t)}for(let s of Array.from(n.attributes)){if(!s.name.startsWith("data-"))continue;let i=s.name.slice(5);r.has(i)||st(n,i,s.value,t)}}},gn=e=>{for(let{target:t,type:n,attributeName:r,addedNodes:s,removedNodes:i}of e)if(n==="childList"){for(let o of i)Z(o)&&(Ht([o]),Ht(o.querySelectorAll("*")));for(let o of s)Z(o)&&(De([o]),De(o.querySelectorAll("*")))}else if(n==="attributes"&&r.startsWith("data-")&&Z(t)&&!Vt(t)){let o=r.slice(5),a=tt(o);if(!a)continue;let c=t.getAttribute(r);if(c===null){let l=be.get(t);if(l){let u=l.get(a);if(u){for(let f of u.values())f();l.delete(a)}}}else st(t,o,c)}},hn=new MutationObserver(gn),yn=e=>{let[t,...n]=e.split("__"),[r,s]=t.split(/:(.+)/),i=new Map;for(let o of n){let[a,...c]=o.split(".");i.set(a,new Set(c))}return{pluginName:r,key:s,mods:i}},vn=()=>ve.has(document.documentElement),bn=()=>{Ft||!vn()||(Ft=!0,document.dispatchEvent(new Event(vt)))},En=(e=document.documentElement,t=!0)=>{Z(e)&&De([e],!0),De(e.querySelectorAll("*"),!0),t&&(hn.observe(e,{subtree:!0,childList:!0,attributes:!0}),ve.add(e),bn())};var st=(e,t,n,r)=>{let s=tt(t);if(!s)return;let{pluginName:i,key:o,mods:a}=yn(s),c=nt.get(i);if((!r||rt.has(i))&&!!c){let u={el:e,rawKey:s,mods:a,error:he.bind(0,{plugin:{type:"attribute",name:c.name},element:{id:e.id,tag:e.tagName},expression:{rawKey:s,key:o,value:n}}),key:o,value:n,loadedPluginNames:{actions:new Set(ye.keys()),attributes:new Set(nt.keys())},rx:void 0},f=c.requirement&&(typeof c.requirement=="string"?c.requirement:c.requirement.key)||"allowed",h=c.requirement&&(typeof c.requirement=="string"?c.requirement:c.requirement.value)||"allowed",d=o!=null&&o!=="",p=n!=null&&n!=="";if(d){if(f==="denied")throw u.error("KeyNotAllowed")}else if(f==="must")throw u.error("KeyRequired");if(p){if(h==="denied")throw u.error("ValueNotAllowed")}else if(h==="must")throw u.error("ValueRequired");if(f==="exclusive"||h==="exclusive"){if(d&&p)throw u.error("KeyAndValueProvided");if(!d&&!p)throw u.error("KeyOrValueRequired")}let m=new Map;if(p){let v;u.rx=(...F)=>(v||(v=Sn(n,{returnsValue:c.returnsValue,argNames:c.argNames,cleanups:m})),v(e,...F))}let y=c.apply(u);y&&m.set("attribute",y);let T=be.get(e);if(T){let v=T.get(s);if(v)for(let F of v.values())F()}else T=new Map,be.set(e,T);T.set(s,m)}},Sn=(e,{returnsValue:t=!1,argNames:n=[],cleanups:r=new Map}={})=>{let s="";if(t){let c=/(\/(\\\/|[^/])*\/|"(\\"|[^"])*"|'(\\'|[^'])*'|`(\\`|[^`])*`|\(\s*((function)\s*\(\s*\)|(\(\s*\))\s*=>)\s*(?:\{[\s\S]*?\}|[^;){]*)\s*\)\s*\(\s*\)|[^;])+/gm,l=e.trim().match(c);if(l){let u=l.length-1,f=l[u].trim();f.startsWith("return")||(l[u]=`return (${f});`),s=l.join(`;
+`)}}else s=e.trim();let i=new Map,o=RegExp(`(?:${Ue})(.*?)(?:${Je})`,"gm"),a=0;for(let c of s.matchAll(o)){let l=c[1],u=`__escaped${a++}`;i.set(u,l),s=s.replace(Ue+l+Je,u)}s=s.replace(/("(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'|`(?:\\.|[^`\\$]|\$(?!\{))*`)|\$\{([^{}]*)\}|\$([a-zA-Z_\d]\w*(?:[.-]\w+)*)/g,(c,l,u,f)=>l?c:u!==void 0?`\${${u.replace(/\$([a-zA-Z_\d]\w*(?:[.-]\w+)*)/g,(h,d)=>d.split(".").reduce((p,m)=>`${p}['${m}']`,"$"))}}`:f.split(".").reduce((h,d)=>`${h}['${d}']`,"$")),s=s.replaceAll(/@([A-Za-z_$][\w$]*)\(/g,'__action("$1",evt,');for(let[c,l]of i)s=s.replace(c,l);try{let c=Function("el","$","__action","evt",...n,s);return(l,...u)=>{let f=(h,d,...p)=>{let m=he.bind(0,{plugin:{type:"action",name:h},element:{id:l.id,tag:l.tagName},expression:{fnContent:s,value:e}}),y=kt[h];if(y)return y({el:l,evt:d,error:m,cleanups:r},...p);throw m("UndefinedAction")};try{return c(l,se,f,void 0,...u)}catch(h){throw console.error(h),he({element:{id:l.id,tag:l.tagName},expression:{fnContent:s,value:e},error:h.message},"ExecuteExpression")}}}catch(c){throw console.error(c),he({expression:{fnContent:s,value:e},error:c.message},"GenerateExpression")}};V({name:"peek",apply(e,t){_();try{return t()}finally{k()}}});V({name:"setAll",apply(e,t,n){_();let r=$(n);re(r,()=>t),D(r),k()}});V({name:"toggleAll",apply(e,t){_();let n=$(t);re(n,r=>!r),D(n),k()}});var It=new WeakMap,it=e=>!["GET","DELETE"].includes(e),Se=(e,t,n=!0)=>V({name:e,apply:async({el:r,evt:s,error:i,cleanups:o},a,{selector:c,headers:l,contentType:u="json",filterSignals:{include:f=/.*/,exclude:h=/(^|\.)_/}={},openWhenHidden:d=n,payload:p,requestCancellation:m="auto",retry:y="auto",retryInterval:T=1e3,retryScaler:v=2,retryMaxWait:F=3e4,retryMaxCount:Re=10}={})=>{let X=m instanceof AbortController?m:new AbortController;(m==="auto"||m==="cleanup")&&(It.get(r)?.abort(),It.set(r,X)),m==="cleanup"&&(o.get(`@${e}`)?.(),o.set(`@${e}`,async()=>{X.abort(),await Promise.resolve()}));let ee=()=>{};try{if(!a?.length)throw i("FetchNoUrlProvided",{action:V});let fe={Accept:"text/event-stream, text/html, application/json","Datastar-Request":!0};u==="json"&&it(t)&&(fe["Content-Type"]="application/json");let q=Object.assign({},fe,l),C={input:"",method:t,headers:q,openWhenHidden:d,retry:y,retryInterval:T,retryScaler:v,retryMaxWait:F,retryMaxCount:Re,signal:X.signal,onopen:async b=>{b.status>=400&&ie(Tn,r,{status:b.status.toString()})},onmessage:b=>{if(!b.event.startsWith("datastar"))return;let J=b.event,w={};for(let E of b.data.split(`
+`)){let A=E.indexOf(" "),H=E.slice(0,A),M=E.slice(A+1);(w[H]||=[]).push(M)}let L=Object.fromEntries(Object.entries(w).map(([E,A])=>[E,A.join(`
+`)]));ie(J,r,L)},onerror:b=>{if($t(b))throw b("FetchExpectedTextEventStream",{url:a});b&&(console.error(b.message),ie(An,r,{message:b.message}))}},qe=()=>{let b=new URL(a,document.baseURI),J=new URLSearchParams(b.search);if(u==="json"){_();let w=p!==void 0?p:$({include:f,exclude:h});k();let L=JSON.stringify(w);it(t)?C.body=L:J.set("datastar",L)}else if(u==="form"){let w=c?document.querySelector(c):r.closest("form");if(!w)throw i("FetchFormNotFound",{action:V,selector:c});if(!w.noValidate&&!w.checkValidity()){w.reportValidity();return}let L=new FormData(w),E=r;if(r===w&&s instanceof SubmitEvent)E=s.submitter;else{let M=de=>de.preventDefault();w.addEventListener("submit",M),ee=()=>{w.removeEventListener("submit",M)}}if(E instanceof HTMLButtonElement||E instanceof HTMLInputElement&&E.type==="submit"){let M=E.getAttribute("name");M&&L.append(M,E.value)}let A=w.getAttribute("enctype")==="multipart/form-data";A||(q["Content-Type"]="application/x-www-form-urlencoded");let H=new URLSearchParams(L);if(it(t))A?C.body=L:C.body=H;else for(let[M,de]of H)J.append(M,de)}else throw i("FetchInvalidContentType",{action:V,contentType:u});return b.search=J.toString(),C.input=b.toString(),C};ie(ot,r,{});try{await Nn(r,qe)}catch(b){if(!$t(b))throw i("FetchFailed",{method:t,url:a,error:b.message})}}finally{ie(at,r,{}),ee(),o.delete(`@${e}`)}}});Se("get","GET",!1);Se("patch","PATCH");Se("post","POST");Se("put","PUT");Se("delete","DELETE");var ot="started",at="finished",Tn="error",An="retrying",Rn="retries-failed",ie=(e,t,n)=>document.dispatchEvent(new CustomEvent(B,{detail:{type:e,el:t,argsRaw:n}})),$t=e=>`${e}`.includes("text/event-stream"),wn=async(e,t)=>{let n=e.getReader(),r=await n.read();for(;!r.done;)t(r.value),r=await n.read()},Mn=e=>{let t,n,r,s=!1;return i=>{t?t=xn(t,i):(t=i,n=0,r=-1);let o=t.length,a=0;for(;n<o;){s&&(t[n]===10&&(a=++n),s=!1);let c=-1;for(;n<o&&c===-1;++n)switch(t[n]){case 58:r===-1&&(r=n-a);break;case 13:s=!0;case 10:c=n;break}if(c===-1)break;e(t.subarray(a,c),r),a=n,r=-1}a===o?t=void 0:a&&(t=t.subarray(a),n-=a)}},Ln=(e,t,n)=>{let r=qt(),s=new TextDecoder;return(i,o)=>{if(!i.length)n?.(r),r=qt();else if(o>0){let a=s.decode(i.subarray(0,o)),c=o+(i[o+1]===32?2:1),l=s.decode(i.subarray(c));switch(a){case"data":r.data=r.data?`${r.data}
+${l}`:l;break;case"event":r.event=l;break;case"id":e(r.id=l);break;case"retry":{let u=+l;Number.isNaN(u)||t(r.retry=u);break}}}}},xn=(e,t)=>{let n=new Uint8Array(e.length+t.length);return n.set(e),n.set(t,e.length),n},qt=()=>({data:"",event:"",id:"",retry:void 0}),Nn=(e,t)=>new Promise((n,r)=>{let s=t();if(!s)return;let{input:i,signal:o,headers:a,onopen:c,onmessage:l,onclose:u,onerror:f,openWhenHidden:h,fetch:d,retry:p="auto",retryInterval:m=1e3,retryScaler:y=2,retryMaxWait:T=3e4,retryMaxCount:v=10,responseOverrides:F,...Re}=s,X={...a},ee,fe=()=>{if(ee.abort(),!document.hidden){let E=t();if(!E)return;i=E.input,Re.body=E.body,L()}};h||document.addEventListener("visibilitychange",fe);let q,C=()=>{document.removeEventListener("visibilitychange",fe),clearTimeout(q),ee.abort()};o?.addEventListener("abort",()=>{C(),n()});let qe=d||window.fetch,b=c||(()=>{}),J=0,w=m,L=async()=>{ee=new AbortController;let E=ee.signal;try{let A=await qe(i,{...Re,headers:X,signal:E});await b(A);let H=async(G,pe,Be,we,...an)=>{let ht={[Be]:await pe.text()};for(let je of an){let We=pe.headers.get(`datastar-${ge(je)}`);if(we){let Me=we[je];Me&&(We=typeof Me=="string"?Me:JSON.stringify(Me))}We&&(ht[je]=We)}ie(G,e,ht),C(),n()},M=A.status,de=M===204,gt=M>=300&&M<400,on=M>=400&&M<600;if(M!==200){if(u?.(),p!=="never"&&!de&&!gt&&(p==="always"||p==="error"&&on)){clearTimeout(q),q=setTimeout(L,m);return}C(),n();return}J=0,m=w;let Ge=A.headers.get("Content-Type");if(Ge?.includes("text/html"))return await H("datastar-patch-elements",A,"elements",F,"selector","mode","namespace","useViewTransition");if(Ge?.includes("application/json"))return await H("datastar-patch-signals",A,"signals",F,"onlyIfMissing");if(Ge?.includes("text/javascript")){let G=document.createElement("script"),pe=A.headers.get("datastar-script-attributes");if(pe)for(let[Be,we]of Object.entries(JSON.parse(pe)))G.setAttribute(Be,we);G.textContent=await A.text(),document.head.appendChild(G),C();return}if(await wn(A.body,Mn(Ln(G=>{G?X["last-event-id"]=G:delete X["last-event-id"]},G=>{w=m=G},l))),u?.(),p==="always"&&!gt){clearTimeout(q),q=setTimeout(L,m);return}C(),n()}catch(A){if(!E.aborted)try{let H=f?.(A)||m;clearTimeout(q),q=setTimeout(L,H),m=Math.min(m*y,T),++J>=v?(ie(Rn,e,{}),C(),r("Max retries reached.")):console.error(`Datastar failed to reach ${i.toString()} retrying in ${H}ms.`)}catch(H){C(),r(H)}}};L()});g({name:"attr",requirement:{value:"must"},returnsValue:!0,apply({el:e,key:t,rx:n}){let r=(a,c)=>{c===""||c===!0?e.setAttribute(a,""):c===!1||c==null?e.removeAttribute(a):typeof c=="string"?e.setAttribute(a,c):typeof c=="function"?e.setAttribute(a,c.toString()):e.setAttribute(a,JSON.stringify(c,(l,u)=>typeof u=="function"?u.toString():u))},s=t?()=>{i.disconnect();let a=n();r(t,a),i.observe(e,{attributeFilter:[t]})}:()=>{i.disconnect();let a=n(),c=Object.keys(a);for(let l of c)r(l,a[l]);i.observe(e,{attributeFilter:c})},i=new MutationObserver(s),o=R(s);return()=>{i.disconnect(),o()}}});var Ve=(e,...t)=>({get:n=>n[e],set:(n,r)=>{n[e]=r},events:t}),Gt=(e,...t)=>({get:n=>n.getAttribute(e),set:(n,r)=>{n.setAttribute(e,`${r}`)},events:t}),ct=(e=!1,...t)=>({get:(n,r)=>r==="string"||e&&r==="undefined"?n.value:+n.value,set:(n,r)=>{n.value=`${r}`},events:t}),Pn=/^data:(?<mime>[^;]+);base64,(?<contents>.*)$/,lt=Symbol("empty"),Ie=U("bind"),Bt=(e,t,n,r,s,i)=>{if(i===void 0&&e instanceof HTMLInputElement&&e.type==="radio"){let u=t||n,f=[...document.querySelectorAll(`[${Ie}\\:${CSS.escape(u)}],[${Ie}="${CSS.escape(u)}"]`)].find(h=>h instanceof HTMLInputElement&&h.checked);f&&S([[r,f.value]],{ifMissing:!0})}if(!Array.isArray(i)||e instanceof HTMLSelectElement&&e.multiple)return S([[r,s.get(e,typeof i)]],{ifMissing:!0}),r;let o=t||n,a=document.querySelectorAll(`[${Ie}\\:${CSS.escape(o)}],[${Ie}="${CSS.escape(o)}"]`),c=[],l=0;for(let u of a){if(c.push([`${r}.${l}`,s.get(u,typeof(x(i,l)?i[l]:void 0))]),e===u)break;l++}return S(c,{ifMissing:!0}),`${r}.${l}`};g({name:"bind",requirement:"exclusive",apply({el:e,key:t,mods:n,value:r,error:s}){let i=t!=null?O(t,n):r,o=n.get("prop"),a=n.get("event"),c=null;if(o){let d=[...o][0];if(!d?.length)throw s("BindPropNameMissing");if(!a?.size)throw s("BindEventRequired");c=Ve(d,...a)}else if(a)throw s("BindPropRequiredWhenEventProvided");if(c){let d=W(i),p=Bt(e,t,r,i,c,d),m=()=>{let T=W(p);if(T!=null){let v=c?.get(e,typeof T);v!==lt&&S([[p,v]])}};for(let T of c?.events??[])e.addEventListener(T,m);e.addEventListener(te,m);let y=R(()=>{c?.set(e,W(p))});return()=>{y();for(let T of c?.events??[])e.removeEventListener(T,m);e.removeEventListener(te,m)}}if(e instanceof HTMLInputElement)switch(e.type){case"range":case"number":c=ct(!1,"input");break;case"checkbox":c={get:(d,p)=>d.value!=="on"?p==="boolean"?d.checked:d.checked?d.value:"":p==="string"?d.checked?d.value:"":d.checked,set:(d,p)=>{d.checked=typeof p=="string"?p===d.value:p},events:["change"]};break;case"radio":e.getAttribute("name")?.length||e.setAttribute("name",i),c={get:(d,p)=>d.checked?p==="number"?+d.value:d.value:lt,set:(d,p)=>{d.checked=p===(typeof p=="number"?+d.value:d.value)},events:["change"]};break;case"file":{let d=()=>{let p=[...e.files||[]],m=[];Promise.all(p.map(y=>new Promise(T=>{let v=new FileReader;v.onload=()=>{if(typeof v.result!="string")throw s("InvalidFileResultType",{resultType:typeof v.result});let F=v.result.match(Pn);if(!F?.groups)throw s("InvalidDataUri",{result:v.result});m.push({name:y.name,contents:F.groups.contents,mime:F.groups.mime})},v.onloadend=()=>T(),v.readAsDataURL(y)}))).then(()=>{S([[i,m]])})};return e.addEventListener("change",d),()=>{e.removeEventListener("change",d)}}default:c=ct(!0,"input")}else if(e instanceof HTMLSelectElement&&e.multiple){let d=new Map;c={get:p=>[...p.selectedOptions].map(m=>{let y=d.get(m.value);return y==="string"||y==null?m.value:+m.value}),set:(p,m)=>{for(let y of p.options)m.includes(y.value)?(d.set(y.value,"string"),y.selected=!0):m.includes(+y.value)?(d.set(y.value,"number"),y.selected=!0):y.selected=!1},events:["change"]}}else e instanceof HTMLSelectElement?c=ct(!1,"change"):e instanceof HTMLTextAreaElement?c=Ve("value","input"):e instanceof HTMLElement&&e.tagName.includes("-")?c="value"in e?Ve("value","input","change"):Gt("value","input","change"):e instanceof HTMLElement&&"value"in e?c=Ve("value","change"):c=Gt("value","change");if(!c)throw s("InvalidBindAdapter");let l=W(i),u=Bt(e,t,r,i,c,l),f=()=>{let d=W(u);if(d!=null){let p=c.get(e,typeof d);p!==lt&&S([[u,p]])}};for(let d of c.events)e.addEventListener(d,f);e.addEventListener(te,f);let h=R(()=>{c.set(e,W(u))});return()=>{h();for(let d of c.events)e.removeEventListener(d,f);e.removeEventListener(te,f)}}});g({name:"class",requirement:{value:"must"},returnsValue:!0,apply({key:e,el:t,mods:n,rx:r}){e&&=O(e,n,"kebab");let s,i=()=>{o.disconnect(),s=e?{[e]:r()}:r();for(let c in s){let l=c.split(/\s+/).filter(u=>u.length>0);if(s[c])for(let u of l)t.classList.contains(u)||t.classList.add(u);else for(let u of l)t.classList.contains(u)&&t.classList.remove(u)}o.observe(t,{attributeFilter:["class"]})},o=new MutationObserver(i),a=R(i);return()=>{o.disconnect(),a();for(let c in s){let l=c.split(/\s+/).filter(u=>u.length>0);for(let u of l)t.classList.remove(u)}}}});g({name:"computed",requirement:{value:"must"},returnsValue:!0,apply({key:e,mods:t,rx:n,error:r}){if(e)S([[O(e,t),_e(n)]]);else{let s=Object.assign({},n());re(s,i=>{if(typeof i=="function")return _e(i);throw r("ComputedExpectedFunction")}),D(s)}}});g({name:"effect",requirement:{key:"denied",value:"must"},apply:({rx:e})=>R(e)});g({name:"indicator",requirement:"exclusive",apply({el:e,key:t,mods:n,value:r}){let s=t!=null?O(t,n):r,i=0;S([[s,!1]]);let o=a=>{let{type:c,el:l}=a.detail;if(l===e)switch(c){case ot:i++,S([[s,!0]]);break;case at:i=Math.max(0,i-1),S([[s,i>0]]);break}};return document.addEventListener(B,o),()=>{i=0,S([[s,!1]]),document.removeEventListener(B,o)}}});var Q=e=>{if(!e||e.size<=0)return 0;for(let t of e){if(t.endsWith("ms"))return+t.replace("ms","");if(t.endsWith("s"))return+t.replace("s","")*1e3;try{return Number.parseFloat(t)}catch{}}return 0},oe=(e,t,n=!1)=>e?e.has(t.toLowerCase()):n,jt=(e,t="")=>{if(e&&e.size>0)for(let n of e)return n;return t};var ut=(e,t)=>(...n)=>{setTimeout(()=>{e(...n)},t)},Wt=(e,t,n=!0,r=!1,s=!1)=>{let i=null,o=0;return(...a)=>{n&&!o?(e(...a),i=null):i=a,(!o||s)&&(o&&clearTimeout(o),o=setTimeout(()=>{r&&i!==null&&e(...i),i=null,o=0},t))}},ce=(e,t)=>{let n=t.get("delay");if(n){let i=Q(n);e=ut(e,i)}let r=t.get("debounce");if(r){let i=Q(r),o=oe(r,"leading",!1),a=!oe(r,"notrailing",!1);e=Wt(e,i,o,a,!0)}let s=t.get("throttle");if(s){let i=Q(s),o=!oe(s,"noleading",!1),a=oe(s,"trailing",!1);e=Wt(e,i,o,a)}return e};var ft=!!document.startViewTransition,Y=(e,t)=>{if(t.has("viewtransition")&&ft){let n=e;e=(...r)=>document.startViewTransition(()=>n(...r))}return e};g({name:"init",requirement:{key:"denied",value:"must"},apply({rx:e,mods:t}){let n=()=>{N(),e(),P()};n=Y(n,t);let r=0,s=t.get("delay");s&&(r=Q(s),r>0&&(n=ut(n,r))),n()}});g({name:"json-signals",requirement:{key:"denied"},apply({el:e,value:t,mods:n}){let r=n.has("terse")?0:2,s={};t&&(s=ae(t));let i=()=>{o.disconnect(),e.textContent=JSON.stringify($(s),null,r),o.observe(e,{childList:!0,characterData:!0,subtree:!0})},o=new MutationObserver(i),a=R(i);return()=>{o.disconnect(),a()}}});g({name:"on",requirement:"must",argNames:["evt"],apply({el:e,key:t,mods:n,rx:r}){let s=e;n.has("window")?s=window:n.has("document")&&(s=document);let i=l=>{N(),r(l),P()};i=Y(i,n),i=ce(i,n);let o=O(t,n,"kebab"),a={capture:n.has("capture"),passive:n.has("passive"),once:n.has("once")};if(n.has("outside")){s=document;let l=i;i=u=>{e.contains(u?.target)||l(u)}}(o===B||o===ne)&&(s=document);let c=l=>{l&&(n.has("prevent")&&l.preventDefault(),n.has("stop")&&l.stopPropagation(),e instanceof HTMLFormElement&&o==="submit"&&l.preventDefault()),i(l)};return s.addEventListener(o,c,a),()=>{s.removeEventListener(o,c,a)}}});var Ut=(e,t,n)=>Math.max(t,Math.min(n,e));var dt=new WeakSet;g({name:"on-intersect",requirement:{key:"denied",value:"must"},apply({el:e,mods:t,rx:n}){let r=()=>{N(),n(),P()};r=Y(r,t),r=ce(r,t);let s={threshold:0};if(t.has("full"))s.threshold=1;else if(t.has("half"))s.threshold=.5;else{let a=t.get("threshold");a&&(s.threshold=Ut(Number(jt(a)),0,100)/100)}let i=t.has("exit"),o=new IntersectionObserver(a=>{for(let c of a)c.isIntersecting!==i&&(r(),o&&dt.has(e)&&o.disconnect())},s);return o.observe(e),t.has("once")&&dt.add(e),()=>{t.has("once")||dt.delete(e),o&&(o.disconnect(),o=null)}}});g({name:"on-interval",requirement:{key:"denied",value:"must"},apply({mods:e,rx:t}){let n=()=>{N(),t(),P()};n=Y(n,e);let r=1e3,s=e.get("duration");s&&(r=Q(s),oe(s,"leading",!1)&&n());let i=setInterval(n,r);return()=>{clearInterval(i)}}});g({name:"on-signal-patch",requirement:{value:"must"},argNames:["patch"],returnsValue:!0,apply({el:e,key:t,mods:n,rx:r,error:s}){if(t&&t!=="filter")throw s("KeyNotAllowed");let i=U(`${this.name}-filter`),o=e.getAttribute(i),a={};o&&(a=ae(o));let c=!1,l=ce(u=>{if(c)return;let f=$(a,u.detail);if(!bt(f)){c=!0,N();try{r(f)}finally{P(),c=!1}}},n);return document.addEventListener(ne,l),()=>{document.removeEventListener(ne,l)}}});g({name:"ref",requirement:"exclusive",apply({el:e,key:t,mods:n,value:r}){let s=t!=null?O(t,n):r;S([[s,e]])}});var Jt="none",Kt="display";g({name:"show",requirement:{key:"denied",value:"must"},returnsValue:!0,apply({el:e,rx:t}){let n=()=>{r.disconnect(),t()?e.style.display===Jt&&e.style.removeProperty(Kt):e.style.setProperty(Kt,Jt),r.observe(e,{attributeFilter:["style"]})},r=new MutationObserver(n),s=R(n);return()=>{r.disconnect(),s()}}});g({name:"signals",returnsValue:!0,apply({key:e,mods:t,rx:n}){let r=t.has("ifmissing");if(e){e=O(e,t);let s=n?.();S([[e,s]],{ifMissing:r})}else{let s=Object.assign({},n?.());D(s,{ifMissing:r})}}});g({name:"style",requirement:{value:"must"},returnsValue:!0,apply({key:e,el:t,rx:n}){let{style:r}=t,s=new Map,i=(l,u)=>{let f=s.get(l);!u&&u!==0?f!==void 0&&(f?r.setProperty(l,f):r.removeProperty(l)):(f===void 0&&s.set(l,r.getPropertyValue(l)),r.setProperty(l,String(u)))},o=()=>{if(a.disconnect(),e)i(e,n());else{let l=n();for(let[u,f]of s)u in l||(f?r.setProperty(u,f):r.removeProperty(u));for(let u in l)i(ge(u),l[u])}a.observe(t,{attributeFilter:["style"]})},a=new MutationObserver(o),c=R(o);return()=>{a.disconnect(),c();for(let[l,u]of s)u?r.setProperty(l,u):r.removeProperty(l)}}});g({name:"text",requirement:{key:"denied",value:"must"},returnsValue:!0,apply({el:e,rx:t}){let n=()=>{r.disconnect(),e.textContent=`${t()}`,r.observe(e,{childList:!0,characterData:!0,subtree:!0})},r=new MutationObserver(n),s=R(n);return()=>{r.disconnect(),s()}}});var zt=(e,t)=>e.includes(t),On=["remove","outer","inner","replace","prepend","append","before","after"],Cn=["html","svg","mathml"];Ee({name:"datastar-patch-elements",apply(e,t){let n=typeof t.selector=="string"?t.selector:"",r=typeof t.mode=="string"?t.mode:"outer",s=typeof t.namespace=="string"?t.namespace:"html",i=typeof t.useViewTransition=="string"?t.useViewTransition:"",o=t.elements;if(!zt(On,r))throw e.error("PatchElementsInvalidMode",{mode:r});if(!n&&r!=="outer"&&r!=="replace")throw e.error("PatchElementsExpectedSelector");if(!zt(Cn,s))throw e.error("PatchElementsInvalidNamespace",{namespace:s});let a={selector:n,mode:r,namespace:s,useViewTransition:i.trim()==="true",elements:o};ft&&a.useViewTransition?document.startViewTransition(()=>Zt(e,a)):Zt(e,a)}});var Zt=({error:e},{selector:t,mode:n,namespace:r,elements:s})=>{let i=document.createDocumentFragment(),o=typeof s!="string"&&!!s;if(typeof s=="string"){let a=s.replace(/<svg(\s[^>]*>|>)([\s\S]*?)<\/svg>/gim,""),c=/<\/html>/.test(a),l=/<\/head>/.test(a),u=/<\/body>/.test(a),f=r==="svg"?"svg":r==="mathml"?"math":"",h=f?`<${f}>${s}</${f}>`:s,d=new DOMParser().parseFromString(c||l||u?s:`<body><template>${h}</template></body>`,"text/html");if(c)i.appendChild(d.documentElement);else if(l&&u)i.appendChild(d.head),i.appendChild(d.body);else if(l)i.appendChild(d.head);else if(u)i.appendChild(d.body);else if(f){let p=d.querySelector("template").content.querySelector(f);for(let m of p.childNodes)i.appendChild(m)}else i=d.querySelector("template").content}else s&&(s instanceof DocumentFragment?i=s:s instanceof Element&&i.appendChild(s));if(!t&&(n==="outer"||n==="replace")){let a=Array.from(i.children);for(let c of a){let l;if(c instanceof HTMLHtmlElement)l=document.documentElement;else if(c instanceof HTMLBodyElement)l=document.body;else if(c instanceof HTMLHeadElement)l=document.head;else if(l=document.getElementById(c.id),!l){console.warn(e("PatchElementsNoTargetsFound"),{element:{id:c.id}});continue}Yt(n,c,[l],o)}}else{let a=document.querySelectorAll(t);if(!a.length){console.warn(e("PatchElementsNoTargetsFound"),{selector:t});return}let c=o&&n!=="remove"?[a[0]]:a;Yt(n,i,c,o)}},mt=new WeakSet;for(let e of document.querySelectorAll("script"))mt.add(e);var nn=e=>{let t=e instanceof HTMLScriptElement?[e]:e.querySelectorAll("script");for(let n of t)if(!mt.has(n)){let r=document.createElement("script");for(let{name:s,value:i}of n.attributes)r.setAttribute(s,i);r.text=n.text,n.replaceWith(r),mt.add(r)}},Qt=(e,t,n,r)=>{let s=!1;for(let i of e){if(r&&s)break;let o=r?t:t.cloneNode(!0);nn(o),i[n](o),s=!0}},Yt=(e,t,n,r)=>{switch(e){case"remove":for(let s of n)s.remove();break;case"outer":case"inner":{let s=!1;for(let i of n){if(r&&s)break;let o=r?t:t.cloneNode(!0);Hn(i,o,e),nn(i);let a=i.closest("[data-scope-children]");a&&a.dispatchEvent(new CustomEvent(Ke,{bubbles:!1})),s=!0}}break;case"replace":Qt(n,t,"replaceWith",r);break;case"prepend":case"append":case"before":case"after":Qt(n,t,e,r)}},I=new Map,ue=new Set,le=new Map,Te=new Set,$e=document.createElement("div");$e.hidden=!0;var Ae=U("ignore-morph"),Fn=`[${Ae}]`,Hn=(e,t,n="outer")=>{if(Z(e)&&Z(t)&&e.hasAttribute(Ae)&&t.hasAttribute(Ae)||e.parentElement?.closest(Fn))return;let r=document.createElement("div");r.append(t),document.body.insertAdjacentElement("afterend",$e);let s=e.querySelectorAll("[id]");for(let{id:a,tagName:c}of s)le.has(a)?Te.add(a):le.set(a,c);e instanceof Element&&e.id&&(le.has(e.id)?Te.add(e.id):le.set(e.id,e.tagName)),ue.clear();let i=r.querySelectorAll("[id]");for(let{id:a,tagName:c}of i)ue.has(a)?Te.add(a):le.get(a)===c&&ue.add(a);for(let a of Te)ue.delete(a);le.clear(),Te.clear(),I.clear();let o=n==="outer"?e.parentElement:e;tn(o,s),tn(r,i),rn(o,r,n==="outer"?e:null,e.nextSibling),$e.remove()},rn=(e,t,n=null,r=null)=>{e instanceof HTMLTemplateElement&&t instanceof HTMLTemplateElement&&(e=e.content,t=t.content),n??=e.firstChild;for(let s of t.childNodes){if(n&&n!==r){let i=_n(s,n,r);if(i){if(i!==n){let o=n;for(;o&&o!==i;){let a=o;o=o.nextSibling,en(a)}}pt(i,s),n=i.nextSibling;continue}}if(s instanceof Element&&ue.has(s.id)){let i=document.getElementById(s.id),o=i;for(;o=o.parentNode;){let a=I.get(o);a&&(a.delete(s.id),a.size||I.delete(o))}sn(e,i,n),pt(i,s),n=i.nextSibling;continue}if(I.has(s)){let i=s.namespaceURI,o=s.tagName,a=i&&i!=="http://www.w3.org/1999/xhtml"?document.createElementNS(i,o):document.createElement(o);e.insertBefore(a,n),pt(a,s),n=a.nextSibling}else{let i=document.importNode(s,!0);e.insertBefore(i,n),n=i.nextSibling}}for(;n&&n!==r;){let s=n;n=n.nextSibling,en(s)}},_n=(e,t,n)=>{let r=null,s=e.nextSibling,i=0,o=0,a=I.get(e)?.size||0,c=t;for(;c&&c!==n;){if(Xt(c,e)){let l=!1,u=I.get(c),f=I.get(e);if(f&&u){for(let h of u)if(f.has(h)){l=!0;break}}if(l)return c;if(!r&&!I.has(c)){if(!a)return c;r=c}}if(o+=I.get(c)?.size||0,o>a)break;r===null&&s&&Xt(c,s)&&(i++,s=s.nextSibling,i>=2&&(r=void 0)),c=c.nextSibling}return r||null},Xt=(e,t)=>e.nodeType===t.nodeType&&e.tagName===t.tagName&&(!e.id||e.id===t.id),en=e=>{I.has(e)?sn($e,e,null):e.parentNode?.removeChild(e)},sn=(e,t,n)=>{if("moveBefore"in e){e.moveBefore(t,n);return}e.insertBefore(t,n)},kn=U("preserve-attr"),pt=(e,t)=>{let n=t.nodeType;if(n===1){let r=e,s=t,i=r.hasAttribute("data-scope-children");if(r.hasAttribute(Ae)&&s.hasAttribute(Ae))return e;let o=(t.getAttribute(kn)??"").split(" "),a=(l,u,f)=>{let h=u.hasAttribute(f);return l.hasAttribute(f)!==h&&!o.includes(f)?(l[f]=h,!0):!1},c=!1;if(r instanceof HTMLInputElement&&s instanceof HTMLInputElement&&s.type!=="file"){let l=s.getAttribute("value");r.getAttribute("value")!==l&&!o.includes("value")&&(r.value=l??"",c=!0),c=a(r,s,"checked")||c,a(r,s,"disabled")}else if(r instanceof HTMLTextAreaElement&&s instanceof HTMLTextAreaElement){let l=s.value;r.defaultValue!==l&&(r.value=l,c=!0)}else r instanceof HTMLOptionElement&&s instanceof HTMLOptionElement&&(c=a(r,s,"selected")||c);for(let{name:l,value:u}of s.attributes)r.getAttribute(l)!==u&&!o.includes(l)&&r.setAttribute(l,u);for(let{name:l}of Array.from(r.attributes))!s.hasAttribute(l)&&!o.includes(l)&&r.removeAttribute(l);c&&(r instanceof HTMLOptionElement?r.closest("select"):r)?.dispatchEvent(new Event(te,{bubbles:!0})),i&&!r.hasAttribute("data-scope-children")&&r.setAttribute("data-scope-children",""),r instanceof HTMLTemplateElement&&s instanceof HTMLTemplateElement?r.innerHTML=s.innerHTML:r.isEqualNode(s)||rn(r,s),i&&r.dispatchEvent(new CustomEvent(Ke,{bubbles:!1}))}return(n===8||n===3)&&e.nodeValue!==t.nodeValue&&(e.nodeValue=t.nodeValue),e},tn=(e,t)=>{for(let n of t)if(ue.has(n.id)){let r=n;for(;r&&r!==e;){let s=I.get(r);s||(s=new Set,I.set(r,s)),s.add(n.id),r=r.parentElement}}};Ee({name:"datastar-patch-signals",apply({error:e},{signals:t,onlyIfMissing:n}){if(typeof t!="string")throw e("PatchSignalsExpectedSignals");let r=typeof n=="string"&&n.trim()==="true";D(ae(t),{ifMissing:r})}});export{V as action,kt as actions,g as attribute,N as beginBatch,_e as computed,R as effect,P as endBatch,$ as filtered,W as getPath,D as mergePatch,S as mergePaths,se as root,me as signal,_ as startPeeking,k as stopPeeking,Ee as watcher};
+//# sourceMappingURL=datastar.js.map
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/go-github/v68/github"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// User is an authenticated visitor identity, exposed to templates as .User.
+// It is nil for anonymous visitors.
+type User struct {
+	Provider  string
+	Login     string
+	Name      string
+	Email     string
+	AvatarURL string
+}
+
+// AuthProvider is an OAuth2-style login provider that maps a successful
+// authorization code exchange to a User.
+type AuthProvider interface {
+	// Name is the short identifier used in login and callback URLs.
+	Name() string
+	// AuthCodeURL returns the URL the visitor is redirected to for login.
+	AuthCodeURL(state, redirectURL string) string
+	// Exchange trades an authorization code for the visitor's identity.
+	Exchange(ctx context.Context, code, redirectURL string) (*User, error)
+}
+
+const (
+	authPathPrefix = "/_auth"
+)
+
+// gitHubProvider logs visitors in with a GitHub OAuth app.
+type gitHubProvider struct {
+	clientID     string
+	clientSecret string
+}
+
+// NewGitHubProvider returns an AuthProvider backed by a GitHub OAuth app.
+func NewGitHubProvider(clientID, clientSecret string) AuthProvider {
+	return &gitHubProvider{clientID: clientID, clientSecret: clientSecret}
+}
+
+func (p *gitHubProvider) Name() string { return "github" }
+
+func (p *gitHubProvider) config(redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		Endpoint:     endpoints.GitHub,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+	}
+}
+
+func (p *gitHubProvider) AuthCodeURL(state, redirectURL string) string {
+	return p.config(redirectURL).AuthCodeURL(state)
+}
+
+func (p *gitHubProvider) Exchange(ctx context.Context, code, redirectURL string) (*User, error) {
+	cfg := p.config(redirectURL)
+	tok, err := cfg.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	gh := github.NewClient(cfg.Client(ctx, tok))
+	u, _, err := gh.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("fetching github user: %w", err)
+	}
+
+	return &User{
+		Provider:  p.Name(),
+		Login:     u.GetLogin(),
+		Name:      u.GetName(),
+		Email:     u.GetEmail(),
+		AvatarURL: u.GetAvatarURL(),
+	}, nil
+}
+
+// oidcProvider logs visitors in with any OpenID Connect issuer. Endpoints are
+// discovered from the issuer's /.well-known/openid-configuration document and
+// the identity is read from the userinfo endpoint.
+type oidcProvider struct {
+	clientID         string
+	clientSecret     string
+	endpoint         oauth2.Endpoint
+	userinfoEndpoint string
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// NewOIDCProvider discovers the issuer's endpoints and returns an AuthProvider.
+func NewOIDCProvider(ctx context.Context, issuer, clientID, clientSecret string) (AuthProvider, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching oidc discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching oidc discovery document: %s", resp.Status)
+	}
+
+	var d oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("decoding oidc discovery document: %w", err)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("oidc discovery document for %s is missing endpoints", issuer)
+	}
+
+	return &oidcProvider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+		userinfoEndpoint: d.UserinfoEndpoint,
+	}, nil
+}
+
+func (p *oidcProvider) Name() string { return "oidc" }
+
+func (p *oidcProvider) config(redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		Endpoint:     p.endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "profile", "email"},
+	}
+}
+
+func (p *oidcProvider) AuthCodeURL(state, redirectURL string) string {
+	return p.config(redirectURL).AuthCodeURL(state)
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, redirectURL string) (*User, error) {
+	cfg := p.config(redirectURL)
+	tok, err := cfg.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	resp, err := cfg.Client(ctx, tok).Get(p.userinfoEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("fetching userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching userinfo: %s", resp.Status)
+	}
+
+	var claims struct {
+		Subject           string `json:"sub"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+		Email             string `json:"email"`
+		Picture           string `json:"picture"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("decoding userinfo: %w", err)
+	}
+
+	login := claims.PreferredUsername
+	if login == "" {
+		login = claims.Email
+	}
+	if login == "" {
+		login = claims.Subject
+	}
+
+	return &User{
+		Provider:  p.Name(),
+		Login:     login,
+		Name:      claims.Name,
+		Email:     claims.Email,
+		AvatarURL: claims.Picture,
+	}, nil
+}
+
+// AuthHandler serves the login, callback, and logout routes under /_auth.
+type AuthHandler struct {
+	providers map[string]AuthProvider
+	sessions  *SessionManager
+	publicURL string
+	debug     bool
+}
+
+func NewAuthHandler(providers []AuthProvider, sessions *SessionManager, publicURL string, debug bool) *AuthHandler {
+	byName := make(map[string]AuthProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &AuthHandler{
+		providers: byName,
+		sessions:  sessions,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		debug:     debug,
+	}
+}
+
+// Routes mounts the auth endpoints on r:
+//
+//	GET /_auth/login/{provider}?next=/path/  → redirect to the provider
+//	GET /_auth/callback/{provider}           → complete login, redirect to next
+//	GET /_auth/logout?next=/path/            → forget the user
+func (a *AuthHandler) Routes(r chi.Router) {
+	r.Get(authPathPrefix+"/login/{provider}", a.login)
+	r.Get(authPathPrefix+"/callback/{provider}", a.callback)
+	r.Get(authPathPrefix+"/logout", a.logout)
+}
+
+func (a *AuthHandler) redirectURL(provider string) string {
+	return a.publicURL + authPathPrefix + "/callback/" + provider
+}
+
+func (a *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if a.sessions.TokenSession(r) {
+		http.Error(w, "Log in from a browser session, not a session token", http.StatusBadRequest)
+		return
+	}
+
+	sess, _, err := a.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	state, err := randomState()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Generating state: %v", err), http.StatusInternalServerError)
+		return
+	}
+	a.sessions.SetAuthState(w, r, sess, state, safeNext(r.URL.Query().Get("next")))
+
+	http.Redirect(w, r, p.AuthCodeURL(state, a.redirectURL(p.Name())), http.StatusFound)
+}
+
+func (a *AuthHandler) callback(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	sess, sd, err := a.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if a.sessions.TokenSession(r) {
+		http.Error(w, "Log in from a browser session, not a session token", http.StatusBadRequest)
+		return
+	}
+
+	state, next := a.sessions.TakeAuthState(sess)
+	if state == "" || r.URL.Query().Get("state") != state {
+		http.Error(w, "Invalid login state", http.StatusBadRequest)
+		return
+	}
+
+	user, err := p.Exchange(r.Context(), r.URL.Query().Get("code"), a.redirectURL(p.Name()))
+	if err != nil {
+		log.Printf("Login via %s failed: %v", p.Name(), err)
+		http.Error(w, "Login failed", http.StatusBadGateway)
+		return
+	}
+	if a.debug {
+		log.Printf("[debug] session=%s logged in as %s via %s", sd.SessionID, user.Login, user.Provider)
+	}
+
+	// A session ID planted before login must not carry the login
+	a.sessions.Renew(sess, sd)
+	a.sessions.SetUser(w, r, sess, sd, user)
+	http.Redirect(w, r, basePath(r)+next, http.StatusFound)
+}
+
+func (a *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	sess, sd, err := a.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	a.sessions.SetUser(w, r, sess, sd, nil)
+	http.Redirect(w, r, basePath(r)+safeNext(r.URL.Query().Get("next")), http.StatusFound)
+}
+
+// safeNext only allows local redirect targets, defaulting to "/". Browsers
+// read a backslash as a slash, so "/\evil.com" leaves the site just like
+// "//evil.com" and is refused too, escaped or not.
+func safeNext(next string) string {
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(next, "/") ||
+		strings.HasPrefix(next, "//") || strings.ContainsRune(next, '\\') || strings.ContainsRune(u.Path, '\\') {
+		return "/"
+	}
+	return next
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,25 @@
+package server
+
+import "testing"
+
+func TestSafeNext(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"/todos/?tab=2", "/todos/?tab=2"},
+		{"//evil.com", "/"},
+		{`/\evil.com`, "/"},
+		{"/%5Cevil.com", "/"},
+		{"https://evil.com/", "/"},
+		{"evil.com", "/"},
+		{"/\t/evil.com", "/"},
+	}
+	for _, tt := range tests {
+		if got := safeNext(tt.next); got != tt.want {
+			t.Errorf("safeNext(%q) = %q, want %q", tt.next, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type basePathKey struct{}
+
+// cleanBasePath normalises a mount prefix to "/a/b" form, or "" for the root.
+func cleanBasePath(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// withBasePath serves h under prefix: requests outside it get a 404, the
+// prefix is stripped before h sees the request, and handlers can recover it
+// with basePath to build redirects and links.
+func withBasePath(prefix string, h http.Handler) http.Handler {
+	prefix = cleanBasePath(prefix)
+	if prefix == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix {
+			http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), basePathKey{}, prefix)
+		http.StripPrefix(prefix, h).ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// basePath returns the prefix the playground is mounted under ("" at the root).
+func basePath(r *http.Request) string {
+	p, _ := r.Context().Value(basePathKey{}).(string)
+	return p
+}
+
+// cookiePath is the Path for cookies set on r, so a playground mounted
+// under a prefix never sees the cookies of another on the same host.
+func cookiePath(r *http.Request) string {
+	if p := basePath(r); p != "" {
+		return p
+	}
+	return "/"
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Chaos configures fault injection for a file, so playgrounds can demonstrate
+// retry and error handling under flaky network conditions:
+//
+//	chaos:
+//	  min_delay: 200     # ms, random delay before each response/event
+//	  max_delay: 1500
+//	  error_rate: 0.2    # probability of failing the request outright
+//	  error_status: 503  # status used for injected errors (default: 500)
+//	  drop_rate: 0.05    # probability of dropping the connection
+type Chaos struct {
+	MinDelay    int     `yaml:"min_delay"`
+	MaxDelay    int     `yaml:"max_delay"`
+	ErrorRate   float64 `yaml:"error_rate"`
+	ErrorStatus int     `yaml:"error_status"`
+	DropRate    float64 `yaml:"drop_rate"`
+}
+
+// errChaosDrop is returned while streaming when chaos drops the connection.
+var errChaosDrop = errors.New("connection dropped by chaos")
+
+// delay sleeps for a random duration in [MinDelay, MaxDelay], returning
+// early if ctx is cancelled.
+func (c Chaos) delay(ctx context.Context) {
+	if c.MaxDelay <= 0 {
+		return
+	}
+	d := c.MinDelay
+	if c.MaxDelay > c.MinDelay {
+		d += rand.IntN(c.MaxDelay - c.MinDelay + 1)
+	}
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Duration(d) * time.Millisecond):
+	}
+}
+
+func (c Chaos) shouldFail() bool {
+	return c.ErrorRate > 0 && rand.Float64() < c.ErrorRate
+}
+
+func (c Chaos) shouldDrop() bool {
+	return c.DropRate > 0 && rand.Float64() < c.DropRate
+}
+
+func (c Chaos) status() int {
+	if c.ErrorStatus == 0 {
+		return http.StatusInternalServerError
+	}
+	return c.ErrorStatus
+}
+
+// applyChaos injects the configured delay, error, or dropped connection
+// before a response is written. It returns false if the request has already
+// been answered (or aborted) and the caller should stop.
+func (h *Handler) applyChaos(w http.ResponseWriter, r *http.Request, c Chaos, td TemplateData) bool {
+	c.delay(r.Context())
+
+	if c.shouldDrop() {
+		h.debugLog("  chaos: dropping connection")
+		// net/http closes the connection without a response
+		panic(http.ErrAbortHandler)
+	}
+
+	if c.shouldFail() {
+		h.debugLog("  chaos: injecting error status=%d", c.status())
+		h.renderError(w, c.status(), "Injected failure (chaos)", td)
+		return false
+	}
+
+	return true
+}
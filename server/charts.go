@@ -0,0 +1,166 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/qr"
+)
+
+const (
+	defaultChartWidth  = 120
+	defaultChartHeight = 30
+	defaultQRSize      = 160
+)
+
+// qrCode renders text as an inline SVG QR code. An optional size sets the
+// width and height in pixels.
+func qrCode(text string, size ...int) (template.HTML, error) {
+	c, err := qr.Encode(text, qr.Medium)
+	if err != nil {
+		return "", err
+	}
+	px := defaultQRSize
+	if len(size) > 0 && size[0] > 0 {
+		px = size[0]
+	}
+	return template.HTML(c.SVG(4, fmt.Sprintf("%dpx", px))), nil
+}
+
+// sparkline renders values as an inline SVG polyline. Optional width and
+// height are in pixels.
+func sparkline(values any, dims ...int) (template.HTML, error) {
+	vals, err := chartValues(values)
+	if err != nil {
+		return "", err
+	}
+	w, h := chartDims(dims)
+	lo, hi := chartRange(vals)
+
+	points := make([]string, len(vals))
+	for i, v := range vals {
+		x := 0.0
+		if len(vals) > 1 {
+			x = float64(i) * float64(w) / float64(len(vals)-1)
+		}
+		points[i] = fmt.Sprintf("%s,%s", formatCoord(x), formatCoord(scaleY(v, lo, hi, h)))
+	}
+
+	var sb strings.Builder
+	writeSVGOpen(&sb, w, h, "sparkline")
+	if len(points) > 0 {
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="currentColor" stroke-width="1.5" stroke-linejoin="round" stroke-linecap="round"/>`, strings.Join(points, " "))
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String()), nil
+}
+
+// barChart renders values as an inline SVG bar chart. Optional width and
+// height are in pixels.
+func barChart(values any, dims ...int) (template.HTML, error) {
+	vals, err := chartValues(values)
+	if err != nil {
+		return "", err
+	}
+	w, h := chartDims(dims)
+	lo, hi := chartRange(vals)
+	lo = min(lo, 0) // bars grow from zero
+
+	var sb strings.Builder
+	writeSVGOpen(&sb, w, h, "bar chart")
+	if len(vals) > 0 {
+		slot := float64(w) / float64(len(vals))
+		gap := slot * 0.15
+		zero := scaleY(0, lo, hi, h)
+		for i, v := range vals {
+			y := scaleY(v, lo, hi, h)
+			top, height := min(y, zero), max(y, zero)-min(y, zero)
+			fmt.Fprintf(&sb, `<rect x="%s" y="%s" width="%s" height="%s" fill="currentColor"><title>%s</title></rect>`,
+				formatCoord(float64(i)*slot+gap/2), formatCoord(top), formatCoord(slot-gap), formatCoord(height),
+				strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String()), nil
+}
+
+func writeSVGOpen(sb *strings.Builder, w, h int, label string) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" aria-label="%s">`, w, h, w, h, label)
+}
+
+func chartDims(dims []int) (int, int) {
+	w, h := defaultChartWidth, defaultChartHeight
+	if len(dims) > 0 && dims[0] > 0 {
+		w = dims[0]
+	}
+	if len(dims) > 1 && dims[1] > 0 {
+		h = dims[1]
+	}
+	return w, h
+}
+
+// chartRange returns the min and max of vals, widened when they are equal so
+// a flat series draws a line through the middle.
+func chartRange(vals []float64) (float64, float64) {
+	if len(vals) == 0 {
+		return 0, 1
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals[1:] {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi
+}
+
+// scaleY maps v into [0, h] with higher values towards the top, leaving a
+// one-pixel margin so strokes are not clipped.
+func scaleY(v, lo, hi float64, h int) float64 {
+	span := float64(h) - 2
+	return 1 + span - (v-lo)/(hi-lo)*span
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
+
+// chartValues converts a slice of numbers (or numeric strings, as signals
+// often arrive) to float64s.
+func chartValues(values any) ([]float64, error) {
+	if values == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("chart values must be a list, got %T", values)
+	}
+	out := make([]float64, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		for item.Kind() == reflect.Interface && !item.IsNil() {
+			item = item.Elem()
+		}
+		switch item.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			out = append(out, float64(item.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			out = append(out, float64(item.Uint()))
+		case reflect.Float32, reflect.Float64:
+			out = append(out, item.Float())
+		case reflect.String:
+			f, err := strconv.ParseFloat(strings.TrimSpace(item.String()), 64)
+			if err != nil {
+				return nil, fmt.Errorf("chart value %d: %q is not a number", i, item.String())
+			}
+			out = append(out, f)
+		default:
+			return nil, fmt.Errorf("chart value %d: unsupported type %s", i, item.Kind())
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the playground's time source for loop intervals, sequential
+// delays and {{.Now}}. It follows real time until paused, sped up, or
+// jumped from the admin dashboard. A nil *Clock is plain real time.
+type Clock struct {
+	mu      sync.Mutex
+	base    time.Time     // virtual time at anchor
+	anchor  time.Time     // real time when base was taken
+	speed   float64       // virtual seconds per real second (0 = paused)
+	changed chan struct{} // closed and replaced whenever the clock is adjusted
+}
+
+// ClockState describes the clock for the admin dashboard.
+type ClockState struct {
+	Now    time.Time
+	Speed  float64
+	Paused bool
+	Real   bool // following real time at normal speed
+}
+
+func NewClock() *Clock {
+	now := time.Now()
+	return &Clock{base: now, anchor: now, speed: 1, changed: make(chan struct{})}
+}
+
+// Now returns the current virtual time.
+func (c *Clock) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nowLocked()
+}
+
+func (c *Clock) nowLocked() time.Time {
+	elapsed := time.Since(c.anchor)
+	return c.base.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+// State returns the clock's current settings.
+func (c *Clock) State() ClockState {
+	if c == nil {
+		return ClockState{Now: time.Now(), Speed: 1, Real: true}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.nowLocked()
+	return ClockState{
+		Now:    now,
+		Speed:  c.speed,
+		Paused: c.speed == 0,
+		Real:   c.speed == 1 && now.Sub(time.Now()).Abs() < time.Second,
+	}
+}
+
+// adjust re-anchors the clock, applies f, and wakes everything waiting on it.
+func (c *Clock) adjust(f func(now time.Time) time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.nowLocked()
+	c.base = f(now)
+	c.anchor = time.Now()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// SetSpeed runs virtual time at speed times real time (0 pauses it).
+func (c *Clock) SetSpeed(speed float64) {
+	if speed < 0 {
+		speed = 0
+	}
+	c.adjust(func(now time.Time) time.Time {
+		c.speed = speed
+		return now
+	})
+}
+
+// Jump moves virtual time forward by d, firing any waits it skips past.
+func (c *Clock) Jump(d time.Duration) {
+	c.adjust(func(now time.Time) time.Time { return now.Add(d) })
+}
+
+// Reset returns the clock to real time at normal speed.
+func (c *Clock) Reset() {
+	c.adjust(func(time.Time) time.Time {
+		c.speed = 1
+		return time.Now()
+	})
+}
+
+// SleepUntil blocks until virtual time reaches t, ctx is done (returning its
+// error), or wake fires (returning nil early; wake may be nil).
+func (c *Clock) SleepUntil(ctx context.Context, t time.Time, wake <-chan struct{}) error {
+	if c == nil {
+		timer := time.NewTimer(time.Until(t))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+			return nil
+		case <-timer.C:
+			return nil
+		}
+	}
+	for {
+		c.mu.Lock()
+		remaining := t.Sub(c.nowLocked())
+		speed := c.speed
+		changed := c.changed
+		c.mu.Unlock()
+
+		if remaining <= 0 {
+			return nil
+		}
+		var timeout <-chan time.Time
+		if speed > 0 {
+			timer := time.NewTimer(time.Duration(float64(remaining) / speed))
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+			return nil
+		case <-changed:
+		case <-timeout:
+		}
+	}
+}
+
+// Sleep blocks for d of virtual time, or until ctx is done.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) error {
+	return c.SleepUntil(ctx, c.Now().Add(d), nil)
+}
+
+// clockTicker is time.Ticker on a Clock.
+type clockTicker struct {
+	C      <-chan time.Time
+	reset  chan time.Duration
+	cancel context.CancelFunc
+}
+
+// NewTicker ticks every d of virtual time until Stop is called.
+func (c *Clock) NewTicker(d time.Duration) *clockTicker {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan time.Time, 1)
+	t := &clockTicker{C: ch, reset: make(chan time.Duration, 1), cancel: cancel}
+	go func() {
+		next := c.Now().Add(d)
+		wake := make(chan struct{})
+		for {
+			// Resets interrupt the wait through wake
+			done := make(chan struct{})
+			go func() {
+				select {
+				case nd := <-t.reset:
+					d = nd
+					next = c.Now().Add(d)
+					close(wake)
+				case <-done:
+				}
+			}()
+			err := c.SleepUntil(ctx, next, wake)
+			close(done)
+			if err != nil {
+				return
+			}
+			select {
+			case <-wake:
+				wake = make(chan struct{})
+				continue
+			default:
+			}
+			select {
+			case ch <- c.Now():
+			default: // like time.Ticker, drop ticks for slow receivers
+			}
+			next = next.Add(d)
+			if now := c.Now(); next.Before(now) {
+				next = now // after a jump, don't replay every skipped tick
+			}
+		}
+	}()
+	return t
+}
+
+// Reset changes the tick interval, restarting the current period.
+func (t *clockTicker) Reset(d time.Duration) {
+	select {
+	case t.reset <- d:
+	default:
+	}
+}
+
+// Stop ends the ticker.
+func (t *clockTicker) Stop() {
+	t.cancel()
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/CAFxX/httpcompression"
+)
+
+// compressibleTypes are the content types worth compressing: rendered pages,
+// fragments, and the text-based static assets playgrounds ship.
+var compressibleTypes = []string{
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/javascript",
+	"application/javascript",
+	"application/json",
+	"image/svg+xml",
+}
+
+// compress encodes HTML and static responses with brotli or gzip, whichever
+// the client prefers, at the given level (1-9, 0 = off). The choice is made
+// per response: text/event-stream responses are written straight through,
+// since a buffering encoder would hold back events, while Datastar requests
+// answered with HTML fragments are compressed like any page. WebSocket
+// upgrades bypass the compressor entirely, as they need the raw connection.
+func compress(level int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if level <= 0 {
+			return next
+		}
+		adapter, err := httpcompression.DefaultAdapter(
+			httpcompression.ContentTypes(compressibleTypes, false),
+			httpcompression.GzipCompressionLevel(level),
+			httpcompression.BrotliCompressionLevel(level),
+		)
+		if err != nil {
+			log.Printf("Compression disabled: %v", err)
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			adapter(http.HandlerFunc(func(cw http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(&streamAwareWriter{ResponseWriter: cw, raw: w}, r)
+			})).ServeHTTP(w, r)
+		})
+	}
+}
+
+// streamAwareWriter sends a response through the compressor unless, by the
+// time its headers go out, it turns out to be an event stream, which is
+// then written to the raw connection instead.
+type streamAwareWriter struct {
+	http.ResponseWriter // the compressor until decided
+	raw                 http.ResponseWriter
+	decided             bool
+}
+
+func (w *streamAwareWriter) target() http.ResponseWriter {
+	if !w.decided {
+		w.decided = true
+		if strings.HasPrefix(w.raw.Header().Get("Content-Type"), "text/event-stream") {
+			w.ResponseWriter = w.raw
+		}
+	}
+	return w.ResponseWriter
+}
+
+func (w *streamAwareWriter) WriteHeader(code int) { w.target().WriteHeader(code) }
+
+func (w *streamAwareWriter) Write(b []byte) (int, error) { return w.target().Write(b) }
+
+func (w *streamAwareWriter) Flush() {
+	if f, ok := w.target().(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// lift the write deadline on a stream.
+func (w *streamAwareWriter) Unwrap() http.ResponseWriter { return w.target() }
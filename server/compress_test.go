@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	page := strings.Repeat("<li>item</li>", 200)
+	h := compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sse" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		w.Write([]byte(page))
+	}))
+	tests := []struct {
+		path         string
+		header       string
+		wantEncoding string
+	}{
+		{"/", "", "gzip"},
+		{"/fragment", "datastar-request", "gzip"},
+		{"/sse", "datastar-request", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		if tt.header != "" {
+			r.Header.Set(tt.header, "true")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("GET %s: Content-Encoding = %q, want %q", tt.path, got, tt.wantEncoding)
+		}
+		if tt.wantEncoding == "" && w.Body.String() != page {
+			t.Errorf("GET %s: body changed by the compressor", tt.path)
+		}
+	}
+}
+
+// TestCompressStreamFlush checks that an event stream's headers and first
+// event go out as soon as they are flushed.
+func TestCompressStreamFlush(t *testing.T) {
+	sent := make(chan struct{})
+	srv := httptest.NewServer(compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: hi\n\n"))
+		http.NewResponseController(w).Flush()
+		<-sent
+	})))
+	defer srv.Close()
+	defer close(sent)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	events, err := ReadSSEEvents(res.Body, 1)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("ReadSSEEvents = %v, %v; want one event", events, err)
+	}
+}
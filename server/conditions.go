@@ -0,0 +1,84 @@
+package server
+
+import (
+	"log"
+	"net/url"
+
+	"github.com/dataSPA/dataSPA-playground/expr"
+)
+
+// exprEnv exposes template data to frontmatter expressions under
+// lower-camel-case names (signals.email, method, sessionURLHits, ...).
+func exprEnv(td TemplateData) map[string]any {
+	return map[string]any{
+		"signals":         td.Signals,
+		"url":             td.URL,
+		"method":          td.Method,
+		"username":        td.Username,
+		"user":            td.User,
+		"sessionID":       td.SessionID,
+		"globalHits":      td.GlobalHits,
+		"urlHits":         td.URLHits,
+		"sessionURLHits":  td.SessionURLHits,
+		"sseMessageCount": td.SSEMessageCount,
+		"loopCounter":     td.LoopCounter,
+		"vars":            td.Vars,
+		"form":            formValues(td.Form),
+		"state":           td.State,
+	}
+}
+
+// formValues flattens a posted form to each field's first value.
+func formValues(form url.Values) map[string]any {
+	values := make(map[string]any, len(form))
+	for key := range form {
+		values[key] = form.Get(key)
+	}
+	return values
+}
+
+// hasConditions reports whether any section declares a when: condition or
+// time options.
+func hasConditions(sections []sectionEntry) bool {
+	for _, s := range sections {
+		if s.options.When != "" || s.options.hasSchedule() {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionMatches evaluates a section's time options and when: condition.
+// Sections without either always match; conditions that fail to evaluate
+// never do.
+func (h *Handler) sectionMatches(s sectionEntry, td TemplateData) bool {
+	if s.options.hasSchedule() && !s.options.inSchedule(h.clock.Now(), s.frontmatter.location()) {
+		h.debugLog("  section at line %d is out of schedule", s.line)
+		return false
+	}
+	if s.options.When == "" {
+		return true
+	}
+	td, err := withVars(s.frontmatter.Vars, td)
+	if err != nil {
+		log.Printf("Section condition error: %v", err)
+		return false
+	}
+	ok, err := expr.EvalBool(s.options.When, exprEnv(td))
+	if err != nil {
+		log.Printf("Section condition error: %v", err)
+		return false
+	}
+	h.debugLog("  when %s → %v", s.options.When, ok)
+	return ok
+}
+
+// firstMatch returns the index of the first section whose condition holds.
+func (h *Handler) firstMatch(sections []sectionEntry, td TemplateData) (int, bool) {
+	for i, s := range sections {
+		if h.sectionMatches(s, td) {
+			return i, true
+		}
+	}
+	return 0, false
+}
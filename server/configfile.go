@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the server settings file looked for in the playground
+// root. Unlike playground.yaml it describes how this server runs the
+// playground (and may hold credentials), so it is never shared.
+const ConfigFileName = "dsplay.yaml"
+
+// ConfigFile is the parsed dsplay.yaml. Every field is optional.
+type ConfigFile struct {
+	Protect Protection     `yaml:"protect"`
+	Fetch   FetchConfig    `yaml:"fetch"`
+	Env     []string       `yaml:"env"`     // environment variables templates may read with env
+	Globals map[string]any `yaml:"globals"` // values every template sees as .Globals
+	Static  []StaticMount  `yaml:"static"`  // directories served at URL prefixes
+	Hooks   Hooks          `yaml:"hooks"`   // webhook endpoints at /_hooks/<name>
+}
+
+// Protection restricts routes to visitors with a password or token.
+type Protection struct {
+	Basic  []string `yaml:"basic"`  // "user:pass" pairs accepted via HTTP basic auth
+	Tokens []string `yaml:"tokens"` // bearer tokens (Authorization header or ?token=)
+	Paths  []string `yaml:"paths"`  // route prefixes to protect (default: every route)
+}
+
+// FetchConfig enables fetchJSON and fetchText for some hosts.
+type FetchConfig struct {
+	Allow   []string      `yaml:"allow"`   // host patterns, e.g. "api.github.com" or "*.open-meteo.com"
+	Timeout time.Duration `yaml:"timeout"` // per request (default 5s)
+}
+
+// LoadConfigFile reads a dsplay.yaml. A missing file yields an empty config.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	cf := &ConfigFile{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cf); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
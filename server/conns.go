@@ -0,0 +1,120 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnRegistry tracks every open stream (SSE or WebSocket) so the admin
+// dashboard can list them and close one.
+type ConnRegistry struct {
+	mu     sync.Mutex
+	nextID int64
+	conns  map[int64]*liveConn
+}
+
+// ConnInfo describes an open stream. List reports the session and tab as
+// fingerprints: the IDs themselves would let a reader act as that visitor.
+type ConnInfo struct {
+	ID        int64     `json:"id"`
+	Transport string    `json:"transport"` // "sse" or "ws"
+	Route     string    `json:"route"`
+	SessionID string    `json:"session_id"`
+	TabID     string    `json:"tab_id,omitempty"`
+	Started   time.Time `json:"started"`
+	Messages  int64     `json:"messages"`
+}
+
+type liveConn struct {
+	info     ConnInfo
+	messages atomic.Int64
+	cancel   context.CancelFunc
+}
+
+type liveConnKey struct{}
+
+func NewConnRegistry() *ConnRegistry {
+	return &ConnRegistry{conns: make(map[int64]*liveConn)}
+}
+
+// Open registers a stream and returns a context that is cancelled when the
+// stream is closed from the dashboard, plus a func to call when the stream
+// ends. A nil registry tracks nothing.
+func (c *ConnRegistry) Open(ctx context.Context, info ConnInfo) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if c == nil {
+		return ctx, cancel
+	}
+	conn := &liveConn{info: info, cancel: cancel}
+
+	c.mu.Lock()
+	c.nextID++
+	conn.info.ID = c.nextID
+	conn.info.Started = time.Now()
+	c.conns[conn.info.ID] = conn
+	c.mu.Unlock()
+
+	return context.WithValue(ctx, liveConnKey{}, conn), func() {
+		c.mu.Lock()
+		delete(c.conns, conn.info.ID)
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// List returns the open streams, oldest first.
+func (c *ConnRegistry) List() []ConnInfo {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list := make([]ConnInfo, 0, len(c.conns))
+	for _, conn := range c.conns {
+		info := conn.info
+		info.SessionID = redactID(info.SessionID)
+		info.TabID = redactID(info.TabID)
+		info.Messages = conn.messages.Load()
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b ConnInfo) int { return cmp.Compare(a.ID, b.ID) })
+	return list
+}
+
+// redactID replaces a session or tab ID with a short fingerprint, stable so
+// streams of the same visitor can still be told apart.
+func redactID(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Close ends the stream with the given id. It reports false if no such
+// stream is open.
+func (c *ConnRegistry) Close(id int64) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	conn, ok := c.conns[id]
+	c.mu.Unlock()
+	if ok {
+		conn.cancel()
+	}
+	return ok
+}
+
+// countMessage records a message sent on the stream ctx belongs to.
+func countMessage(ctx context.Context) {
+	if conn, ok := ctx.Value(liveConnKey{}).(*liveConn); ok {
+		conn.messages.Add(1)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+const (
+	csrfField  = "csrf_token"
+	csrfHeader = "X-CSRF-Token"
+)
+
+// CSRFToken returns the anti-forgery token for a session: an HMAC of the
+// session ID, so nothing extra is stored and it survives restarts with the
+// same secret.
+func (sm *SessionManager) CSRFToken(sessionID string) string {
+	mac := hmac.New(sha256.New, sm.csrfKey)
+	mac.Write([]byte(sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sessionID returns the request's session ID without creating a session.
+func (sm *SessionManager) sessionID(r *http.Request) string {
+	sess, err := sm.storeFor(r).Get(r, sm.name)
+	if err != nil {
+		return ""
+	}
+	id, _ := sess.Values[keySessionID].(string)
+	return id
+}
+
+// csrfProtect rejects unsafe requests that don't carry the session's CSRF
+// token in a csrf_token form field or X-CSRF-Token header. Datastar requests
+// are exempt: their custom header can't be sent cross-site without CORS.
+// So are webhooks, which come from other sites by design.
+func csrfProtect(enabled bool, sm *SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.Header.Get("datastar-request") != "" || strings.HasPrefix(r.URL.Path, hooksPathPrefix+"/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			got := r.Header.Get(csrfHeader)
+			if got == "" {
+				got = r.PostFormValue(csrfField)
+			}
+			id := sm.sessionID(r)
+			if id == "" || !hmac.Equal([]byte(got), []byte(sm.CSRFToken(id))) {
+				http.Error(w, "Invalid or missing CSRF token", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// csrfInput is the hidden form field carrying token.
+func csrfInput(token string) template.HTML {
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`, csrfField, template.HTMLEscapeString(token)))
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCSRF(t *testing.T) {
+	srv, c := newTestServerWith(t, fstest.MapFS{
+		"form/index.html":    {Data: []byte("{{csrfToken}}")},
+		"form/post.html":     {Data: []byte("saved")},
+		"form/post_sse.html": {Data: []byte(`<p id="form">saved</p>`)},
+	}, TestHandlerOptions{CSRF: true})
+	status, token := get(t, c, srv.URL+"/form/")
+	if status != http.StatusOK || token == "" {
+		t.Fatalf("GET /form/ = %d %q, want 200 and a token", status, token)
+	}
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		header map[string]string
+		status int
+	}{
+		{"no token", nil, nil, http.StatusForbidden},
+		{"wrong token", url.Values{csrfField: {"forged"}}, nil, http.StatusForbidden},
+		{"form field", url.Values{csrfField: {token}}, nil, http.StatusOK},
+		{"header", nil, map[string]string{csrfHeader: token}, http.StatusOK},
+		{"datastar request", nil, map[string]string{"Datastar-Request": "true"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/form/", strings.NewReader(tt.form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		cancel()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s: POST /form/ = %d, want %d", tt.name, res.StatusCode, tt.status)
+		}
+	}
+
+	// The token belongs to the session: another visitor can't replay it
+	res, err := http.PostForm(srv.URL+"/form/", url.Values{csrfField: {token}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("POST /form/ with another session's token = %d, want 403", res.StatusCode)
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dataDir holds fixture files exposed to templates as .Data, e.g.
+// _data/products.csv becomes {{range .Data.products}}.
+const dataDir = "_data"
+
+// LoadData parses every YAML, JSON and CSV file directly under _data/,
+// keyed by file name without its extension. CSV files become a list of rows
+// keyed by the header line. A playground without _data/ gets an empty map.
+func LoadData(fsys fs.FS) (map[string]any, error) {
+	data := map[string]any{}
+	entries, err := fs.ReadDir(fsys, dataDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := path.Ext(e.Name())
+		name := strings.TrimSuffix(e.Name(), ext)
+		switch strings.ToLower(ext) {
+		case ".yaml", ".yml", ".json", ".csv":
+		default:
+			continue
+		}
+		if _, dup := data[name]; dup {
+			return nil, fmt.Errorf("%s: more than one file named %q", dataDir, name)
+		}
+		raw, err := fs.ReadFile(fsys, path.Join(dataDir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		v, err := parseData(ext, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path.Join(dataDir, e.Name()), err)
+		}
+		data[name] = v
+	}
+	return data, nil
+}
+
+func parseData(ext string, raw []byte) (any, error) {
+	var v any
+	switch strings.ToLower(ext) {
+	case ".json":
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case ".csv":
+		return parseCSV(raw)
+	default:
+		err := yaml.Unmarshal(raw, &v)
+		return v, err
+	}
+}
+
+// parseCSV turns a CSV file with a header line into a list of rows, each a
+// map from column name to value.
+func parseCSV(raw []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, col := range header {
+			if i < len(rec) {
+				row[col] = rec[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DatastarVersion is the Datastar client release served at datastarPath.
+const DatastarVersion = "v1.0.0"
+
+const datastarPath = "/_datastar/datastar.js"
+
+// datastarVersionPattern matches release tags, e.g. v1.0.0-RC.7 or v1.0.0-beta.11.
+var datastarVersionPattern = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?$`)
+
+// normalizeDatastarVersion returns version as a release tag ("1.0.0" → "v1.0.0").
+func normalizeDatastarVersion(version string) string {
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
+
+// datastarCDN is where a release's bundle is published.
+func datastarCDN(version string) string {
+	return "https://cdn.jsdelivr.net/gh/starfederation/datastar@" + version + "/bundles/datastar.js"
+}
+
+// datastarBundle is the pinned client, committed in assets/ with its
+// release in assets/VERSION. After bumping DatastarVersion, refresh both
+// with go generate ./server:
+//
+//go:generate curl -fsSL -o assets/datastar.js https://cdn.jsdelivr.net/gh/starfederation/datastar@v1.0.0/bundles/datastar.js
+//go:generate sh -c "echo v1.0.0 > assets/VERSION"
+//go:embed assets/datastar.js
+var datastarBundle []byte
+
+// serveDatastar serves the embedded Datastar bundle.
+func serveDatastar(w http.ResponseWriter, r *http.Request) {
+	writeBundle(w, r, DatastarVersion, datastarBundle)
+}
+
+// serveDatastarVersion serves GET /_datastar/{version}/datastar.js: the
+// embedded bundle when it matches, otherwise one downloaded on first use and
+// cached on disk. When the download fails the client is sent to the CDN.
+func (b *bundleCache) serveDatastarVersion(w http.ResponseWriter, r *http.Request) {
+	version := chi.URLParam(r, "version")
+	if !datastarVersionPattern.MatchString(version) {
+		http.NotFound(w, r)
+		return
+	}
+	if version == DatastarVersion {
+		serveDatastar(w, r)
+		return
+	}
+	js, err := b.get(version)
+	if err != nil {
+		log.Printf("Fetching Datastar %s: %v", version, err)
+		http.Redirect(w, r, datastarCDN(version), http.StatusFound)
+		return
+	}
+	writeBundle(w, r, version, js)
+}
+
+func writeBundle(w http.ResponseWriter, r *http.Request, version string, js []byte) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", fmt.Sprintf("%q", version))
+	http.ServeContent(w, r, "datastar.js", time.Time{}, bytes.NewReader(js))
+}
+
+// bundleCache holds Datastar bundles other than the embedded one, in memory
+// and under dir (skipped when dir is "").
+type bundleCache struct {
+	dir string
+
+	mu      sync.Mutex
+	bundles map[string][]byte
+}
+
+// newBundleCache caches downloaded bundles in the user cache directory.
+func newBundleCache() *bundleCache {
+	b := &bundleCache{bundles: make(map[string][]byte)}
+	if dir, err := os.UserCacheDir(); err == nil {
+		b.dir = filepath.Join(dir, "dsplay", "datastar")
+	}
+	return b
+}
+
+func (b *bundleCache) get(version string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if js, ok := b.bundles[version]; ok {
+		return js, nil
+	}
+	var file string
+	if b.dir != "" {
+		file = filepath.Join(b.dir, version, "datastar.js")
+		if js, err := os.ReadFile(file); err == nil {
+			b.bundles[version] = js
+			return js, nil
+		}
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(datastarCDN(version))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", datastarCDN(version), resp.Status)
+	}
+	js, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	b.bundles[version] = js
+
+	if file != "" {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err == nil {
+			if err := os.WriteFile(file, js, 0o644); err != nil {
+				log.Printf("Caching Datastar %s: %v", version, err)
+			}
+		}
+	}
+	return js, nil
+}
+
+// datastarScriptPath is where a page loads the given Datastar release from.
+func datastarScriptPath(basePath, version string) string {
+	version = normalizeDatastarVersion(version)
+	if version == "" || version == DatastarVersion {
+		return basePath + datastarPath
+	}
+	return basePath + "/_datastar/" + version + "/datastar.js"
+}
+
+// datastarScript is the script tag loading the locally served bundle for
+// version ("" = the embedded one).
+func datastarScript(basePath, version string) template.HTML {
+	src := datastarScriptPath(basePath, version)
+	return template.HTML(fmt.Sprintf(`<script type="module" src="%s"></script>`, template.HTMLEscapeString(src)))
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestBundleEmbedded checks that the committed bundle is the release
+// DatastarVersion pins.
+func TestBundleEmbedded(t *testing.T) {
+	if len(datastarBundle) == 0 {
+		t.Fatal("assets/datastar.js is empty (refresh it with go generate ./server)")
+	}
+	version, err := os.ReadFile("assets/VERSION")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(version)); got != DatastarVersion {
+		t.Errorf("assets/VERSION = %s, want %s (refresh with go generate ./server)", got, DatastarVersion)
+	}
+	if first, _, _ := strings.Cut(string(datastarBundle), "\n"); first != "// Datastar "+DatastarVersion {
+		t.Errorf("assets/datastar.js starts %q, want the %s bundle", first, DatastarVersion)
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// eventDialect is the SSE event vocabulary a Datastar client release speaks.
+type eventDialect int
+
+const (
+	dialectPatch eventDialect = iota // v1.0.0-RC.1 and later: datastar-patch-elements
+	dialectMerge                     // v1.0.0-beta.x and earlier: datastar-merge-fragments
+)
+
+// dialectFor returns the event dialect understood by a Datastar version.
+func dialectFor(version string) eventDialect {
+	v := strings.TrimPrefix(version, "v")
+	if strings.HasPrefix(v, "0.") || strings.HasPrefix(v, "1.0.0-beta") {
+		return dialectMerge
+	}
+	return dialectPatch
+}
+
+// patchSpec says where and how rendered elements land in the page.
+type patchSpec struct {
+	Selector        string
+	Mode            string // outer (default), inner, replace, prepend, append, before, after, remove
+	Namespace       string // html, svg, mathml
+	ViewTransitions bool
+}
+
+// patchElements sends elements to the client in dialect d.
+func patchElements(sse *datastar.ServerSentEventGenerator, d eventDialect, elements string, p patchSpec) error {
+	if d == dialectMerge {
+		return mergeFragments(sse, elements, p)
+	}
+
+	var opts []datastar.PatchElementOption
+	if p.ViewTransitions {
+		opts = append(opts, datastar.WithViewTransitions())
+	}
+
+	if p.Selector != "" {
+		opts = append(opts, datastar.WithSelector(p.Selector))
+	}
+
+	switch p.Mode {
+	case "outer":
+		opts = append(opts, datastar.WithModeOuter())
+	case "inner":
+		opts = append(opts, datastar.WithModeInner())
+	case "replace":
+		opts = append(opts, datastar.WithModeReplace())
+	case "prepend":
+		opts = append(opts, datastar.WithModePrepend())
+	case "append":
+		opts = append(opts, datastar.WithModeAppend())
+	case "before":
+		opts = append(opts, datastar.WithModeBefore())
+	case "after":
+		opts = append(opts, datastar.WithModeAfter())
+	case "remove":
+		opts = append(opts, datastar.WithModeRemove())
+	}
+
+	switch p.Namespace {
+	case "":
+	case "mathml":
+		opts = append(opts, datastar.WithNamespace(datastar.NamespaceMathML))
+	case "html":
+		opts = append(opts, datastar.WithNamespace(datastar.NamespaceHTML))
+	case "svg":
+		opts = append(opts, datastar.WithNamespace(datastar.NamespaceSVG))
+	default:
+		return fmt.Errorf("unsupported namespace: %s", p.Namespace)
+	}
+
+	return sse.PatchElements(elements, opts...)
+}
+
+// legacyMergeModes maps patch modes onto the beta-era merge modes.
+var legacyMergeModes = map[string]string{
+	"outer":   "morph",
+	"inner":   "inner",
+	"replace": "outer",
+	"prepend": "prepend",
+	"append":  "append",
+	"before":  "before",
+	"after":   "after",
+}
+
+// mergeFragments writes the beta-era equivalent of a patch. Namespaces
+// did not exist yet and are ignored.
+func mergeFragments(sse *datastar.ServerSentEventGenerator, elements string, p patchSpec) error {
+	if p.Mode == "remove" {
+		return sse.Send(datastar.EventType("datastar-remove-fragments"), []string{"selector " + p.Selector})
+	}
+
+	var lines []string
+	if p.Selector != "" {
+		lines = append(lines, "selector "+p.Selector)
+	}
+	if mode, ok := legacyMergeModes[p.Mode]; ok && mode != "morph" {
+		lines = append(lines, "mergeMode "+mode)
+	}
+	if p.ViewTransitions {
+		lines = append(lines, "useViewTransition true")
+	}
+	for _, line := range strings.Split(elements, "\n") {
+		lines = append(lines, "fragments "+line)
+	}
+	return sse.Send(datastar.EventType("datastar-merge-fragments"), lines)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/starfederation/datastar-go/datastar"
+	"gopkg.in/yaml.v3"
+)
+
+// Download makes a file's response a file download (Content-Disposition:
+// attachment) instead of a page fragment:
+//
+//	download:
+//	  filename: orders-{{.Now.Format "2006-01-02"}}.csv
+//	  content_type: text/csv
+//
+// The section is the file's contents, rendered with text/template so CSV,
+// JSON and the like aren't HTML-escaped. A fixed file can be given as a
+// base64 body instead. "download: report.txt" is short for just a filename.
+type Download struct {
+	Filename    string `yaml:"filename"`     // name the browser saves as; may use template actions
+	ContentType string `yaml:"content_type"` // default: guessed from the filename's extension
+	Body        string `yaml:"body"`         // base64 contents, sent instead of the section
+
+	body []byte
+}
+
+func (d *Download) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*d = Download{Filename: node.Value}
+	} else {
+		type plain Download
+		if err := node.Decode((*plain)(d)); err != nil {
+			return err
+		}
+	}
+	if d.Filename == "" {
+		return fmt.Errorf("download: filename is required")
+	}
+	if d.Body != "" {
+		body, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(d.Body), ""))
+		if err != nil {
+			return fmt.Errorf("download: body is not valid base64: %w", err)
+		}
+		d.body = body
+	}
+	return nil
+}
+
+func (d Download) enabled() bool {
+	return d.Filename != ""
+}
+
+// renderText executes content as a text/template, for output that isn't
+// HTML.
+func (h *Handler) renderText(content string, td TemplateData) (string, error) {
+	tmpl := texttemplate.New("download").Funcs(texttemplate.FuncMap(h.templateFuncs(td)))
+	if h.strictTemplates {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	tmpl, err := tmpl.Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("parsing template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, td); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// serveDownload answers with the section as a file download. A Datastar
+// request is a fetch, which can't save files, so it gets a script that
+// hands the file to the browser from the page instead.
+func (h *Handler) serveDownload(w http.ResponseWriter, r *http.Request, section sectionEntry, td TemplateData, status int, isDatastarRequest bool) {
+	d := section.frontmatter.Download
+	filename, err := h.renderText(d.Filename, td)
+	if err != nil {
+		h.writeTemplateError(w, newTemplateError(fmt.Errorf("download: filename: %w", err), section))
+		return
+	}
+	filename = path.Base(strings.ReplaceAll(strings.TrimSpace(filename), `\`, "/"))
+	if filename == "." || filename == "/" {
+		filename = "download"
+	}
+
+	body := d.body
+	if body == nil {
+		rendered, err := h.render(section, td)
+		if err != nil {
+			h.writeTemplateError(w, err)
+			return
+		}
+		body = []byte(rendered)
+	}
+
+	contentType := d.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.debugLog("  download: %s (%s, %d bytes)", filename, contentType, len(body))
+
+	if isDatastarRequest {
+		name, _ := json.Marshal(filename)
+		kind, _ := json.Marshal(contentType)
+		data, _ := json.Marshal(base64.StdEncoding.EncodeToString(body))
+		script := fmt.Sprintf(`(() => {
+  const a = document.createElement("a");
+  a.href = URL.createObjectURL(new Blob([Uint8Array.from(atob(%s), c => c.charCodeAt(0))], {type: %s}));
+  a.download = %s;
+  a.click();
+  setTimeout(() => URL.revokeObjectURL(a.href), 1000);
+})()`, data, kind, name)
+		datastar.NewSSE(w, r).ExecuteScript(script)
+		return
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
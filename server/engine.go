@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/dataSPA/dataSPA-playground/mustache"
+)
+
+// Template engines a file can choose with the engine: frontmatter key.
+const (
+	engineGo       = "go"       // html/template with sprig and the playground functions (default)
+	engineRaw      = "raw"      // no templating: the section is sent as written
+	engineMustache = "mustache" // logic-less Mustache over the same template data
+)
+
+func validEngine(engine string) error {
+	switch engine {
+	case "", engineGo, engineRaw, engineMustache:
+		return nil
+	}
+	return fmt.Errorf("unknown engine %q (want go, raw, or mustache)", engine)
+}
+
+// execute renders a section's content with the engine its file asks for.
+func (h *Handler) execute(section sectionEntry, td TemplateData) (string, error) {
+	switch section.frontmatter.Engine {
+	case engineRaw:
+		return section.content, nil
+	case engineMustache:
+		return mustache.Render(section.content, td)
+	default:
+		if section.frontmatter.Download.enabled() {
+			return h.renderText(section.content, td)
+		}
+		return renderTemplate(h.templates, section.content, td, h.templateFuncs(td), h.strictTemplates)
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+)
+
+var errorTemplate = template.Must(template.ParseFS(templatesFS, "templates/error.html"))
+
+// errorsDir holds playground-provided error pages, e.g. _errors/503.html.
+const errorsDir = "_errors"
+
+// ErrorData is passed to error page templates. It embeds the request's
+// TemplateData so custom error pages can use the usual variables.
+type ErrorData struct {
+	TemplateData
+	Status     int
+	StatusText string
+	Message    string
+}
+
+// renderError responds with status, rendering the playground's
+// _errors/<status>.html if present, or the built-in error page otherwise.
+func (h *Handler) renderError(w http.ResponseWriter, status int, message string, td TemplateData) {
+	ed := ErrorData{
+		TemplateData: td,
+		Status:       status,
+		StatusText:   http.StatusText(status),
+		Message:      message,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	name := path.Join(errorsDir, fmt.Sprintf("%d.html", status))
+	if _, statErr := fs.Stat(h.fsys, name); statErr == nil {
+		tmpl, err := h.parseErrorPage(name, h.templateFuncs(td))
+		if err == nil {
+			w.WriteHeader(status)
+			if err := tmpl.Execute(w, ed); err != nil {
+				log.Printf("Error page template error: %v", err)
+			}
+			return
+		}
+		log.Printf("Error page %s unusable, falling back to default: %v", name, err)
+	}
+
+	w.WriteHeader(status)
+	if err := errorTemplate.Execute(w, ed); err != nil {
+		log.Printf("Error page template error: %v", err)
+	}
+}
+
+func (h *Handler) parseErrorPage(name string, funcs template.FuncMap) (*template.Template, error) {
+	pf, err := ParseFS(h.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return template.New("error").Funcs(funcs).Parse(pf.Sections[0])
+}
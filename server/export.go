@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dataSPA/dataSPA-playground/parser"
+)
+
+const (
+	defaultExportEvents = 20
+	exportStreamTimeout = 5 * time.Second // per stream, on a clock running at defaultTestSpeed
+	exportTranscript    = "events.txt"
+)
+
+// ExportOptions describes a static snapshot of a playground.
+type ExportOptions struct {
+	PlaygroundsDir  string
+	OutDir          string
+	Force           bool           // write into OutDir even if it isn't empty
+	BasePath        string         // prefix the snapshot will be hosted under, e.g. "/repo" for GitHub Pages
+	Globals         map[string]any // values every template sees as .Globals
+	Static          []StaticMount  // extra static directories, as in dsplay.yaml
+	Events          int            // SSE events recorded per stream (default 20)
+	StrictTemplates bool
+}
+
+// ExportReport lists what an export wrote, relative to OutDir, and the
+// routes it left out.
+type ExportReport struct {
+	Pages   []string
+	Streams []string
+	Assets  []string
+	Skipped []string // "GET /path/: reason"
+}
+
+// datastarRef finds a page's locally served Datastar bundle, with its
+// release when it isn't the embedded one.
+var datastarRef = regexp.MustCompile(`/_datastar/(?:(v[0-9A-Za-z.-]+)/)?datastar\.js`)
+
+// Export renders a playground into a directory of static files that any
+// web server (GitHub Pages, say) can host. Every GET route's HTML is
+// requested by one visitor, as many times as the route has sequential
+// sections: the first response is saved as index.html, later ones as
+// index-2.html, index-3.html and so on. GET SSE routes are saved as
+// transcripts of their first events, and static directories and the
+// Datastar bundles the pages load are copied alongside.
+func Export(opts ExportOptions) (*ExportReport, error) {
+	fsys := os.DirFS(opts.PlaygroundsDir)
+	routes, err := ScanFS(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("scanning playgrounds: %w", err)
+	}
+	if entries, err := os.ReadDir(opts.OutDir); err == nil && len(entries) > 0 && !opts.Force {
+		return nil, fmt.Errorf("directory %s already exists and is not empty (use --force to override)", opts.OutDir)
+	}
+
+	ns, nc, err := StartEmbeddedNATS()
+	if err != nil {
+		return nil, fmt.Errorf("starting nats: %w", err)
+	}
+	defer ns.Shutdown()
+	defer nc.Close()
+
+	// One fast-clocked visitor, so sequential delays and SSE intervals
+	// elapse almost at once
+	sessions := NewSessionManager(SessionOptions{Secrets: []string{"dsplay-export-secret"}})
+	prefs, _ := NewPrefStore("", 0)
+	clock := NewClock()
+	clock.SetSpeed(defaultTestSpeed)
+	h := NewHandler(HandlerConfig{
+		FS:              fsys,
+		Counters:        NewCounters(),
+		Sessions:        sessions,
+		NC:              nc,
+		Outbox:          NewOutbox(),
+		Limiter:         NewConnLimiter(0, 0, 0),
+		Conns:           NewConnRegistry(),
+		Jobs:            NewJobQueue(nc, 1),
+		Prefs:           prefs,
+		Clock:           clock,
+		Globals:         opts.Globals,
+		StrictTemplates: opts.StrictTemplates,
+	})
+	basePath := cleanBasePath(opts.BasePath)
+	srv := httptest.NewServer(withBasePath(basePath, http.HandlerFunc(h.ServePlayground)))
+	defer srv.Close()
+	jar, _ := cookiejar.New(nil)
+
+	x := &exporter{
+		srv: srv,
+		client: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		base:     basePath,
+		out:      opts.OutDir,
+		events:   opts.Events,
+		datastar: make(map[string]bool),
+		report:   &ExportReport{},
+	}
+	if x.events <= 0 {
+		x.events = defaultExportEvents
+	}
+
+	for _, urlPath := range slices.Sorted(maps.Keys(routes)) {
+		rf := routes[urlPath]
+		html, sse := rf.LookupHTML(http.MethodGet), rf.LookupSSE(http.MethodGet)
+		switch {
+		case strings.Contains(urlPath, parser.CatchAll):
+			x.skip(urlPath, "catch-all routes have no fixed URLs")
+			continue
+		case len(html) == 0 && len(sse) == 0:
+			x.skip(urlPath, "no GET templates")
+			continue
+		}
+		if len(html) > 0 {
+			if err := x.page(urlPath, exportSteps(html)); err != nil {
+				return x.report, err
+			}
+		}
+		if len(sse) > 0 {
+			if err := x.stream(urlPath); err != nil {
+				return x.report, err
+			}
+		}
+	}
+
+	if err := x.assets(fsys, withDefaultStatic(opts.Static)); err != nil {
+		return x.report, err
+	}
+	// GitHub Pages hides directories starting with _ (like _datastar/)
+	// unless Jekyll is switched off
+	return x.report, x.write("/.nojekyll", nil)
+}
+
+// exportSteps is how many requests walk a route's sections: one per
+// section when they are served in sequence, otherwise one.
+func exportSteps(files []*ParsedFile) int {
+	sections := collectSections(files)
+	if len(sections) == 0 || sections[0].isRandom() || hasConditions(sections) {
+		return 1
+	}
+	for _, f := range files {
+		if f.Frontmatter.SelectBy.From != "" {
+			return 1
+		}
+	}
+	return len(sections)
+}
+
+type exporter struct {
+	srv      *httptest.Server
+	client   *http.Client
+	base     string
+	out      string
+	events   int
+	datastar map[string]bool // bundle paths the pages load
+	report   *ExportReport
+}
+
+func (x *exporter) skip(urlPath, reason string) {
+	x.report.Skipped = append(x.report.Skipped, fmt.Sprintf("GET %s: %s", urlPath, reason))
+}
+
+// page saves steps successive HTML responses from urlPath.
+func (x *exporter) page(urlPath string, steps int) error {
+	for i := 1; i <= steps; i++ {
+		resp, err := x.client.Get(x.srv.URL + x.base + urlPath)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		switch {
+		case resp.StatusCode != http.StatusOK:
+			x.skip(urlPath, resp.Status)
+			return nil
+		case !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"):
+			x.skip(urlPath, "not an HTML page ("+resp.Header.Get("Content-Type")+")")
+			return nil
+		}
+
+		name := path.Join(urlPath, "index.html")
+		if i > 1 {
+			name = path.Join(urlPath, fmt.Sprintf("index-%d.html", i))
+		}
+		if err := x.write(name, body); err != nil {
+			return err
+		}
+		x.report.Pages = append(x.report.Pages, strings.TrimPrefix(name, "/"))
+		for _, m := range datastarRef.FindAllString(string(body), -1) {
+			x.datastar[m] = true
+		}
+	}
+	return nil
+}
+
+// stream records the first events of urlPath's SSE stream, in
+// text/event-stream form.
+func (x *exporter) stream(urlPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), exportStreamTimeout)
+	defer cancel()
+	target := x.srv.URL + x.base + urlPath + "?" + url.Values{"datastar": {"{}"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("datastar-request", "true")
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		x.skip(urlPath, "SSE request answered with "+resp.Status)
+		return nil
+	}
+
+	// Streams that loop never end, and finite ones end early: keep
+	// whatever arrived before either
+	events, err := ReadSSEEvents(resp.Body, x.events)
+	if len(events) == 0 {
+		x.skip(urlPath, fmt.Sprintf("no SSE events (%v)", err))
+		return nil
+	}
+	var sb strings.Builder
+	for _, e := range events {
+		sb.WriteString(e.String())
+		sb.WriteString("\n")
+	}
+	name := path.Join(urlPath, exportTranscript)
+	if err := x.write(name, []byte(sb.String())); err != nil {
+		return err
+	}
+	x.report.Streams = append(x.report.Streams, strings.TrimPrefix(name, "/"))
+	return nil
+}
+
+// assets copies the static mounts and the Datastar bundles the pages load.
+func (x *exporter) assets(fsys fs.FS, mounts []StaticMount) error {
+	for _, m := range mounts {
+		dir, err := mountFS(fsys, m.Dir)
+		if err != nil {
+			return fmt.Errorf("static mount %s: %w", m.Path, err)
+		}
+		if _, err := fs.Stat(dir, "."); err != nil {
+			continue
+		}
+		prefix := cleanBasePath(m.Path)
+		err = fs.WalkDir(dir, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := fs.ReadFile(dir, name)
+			if err != nil {
+				return err
+			}
+			return x.addAsset(path.Join(prefix, name), data)
+		})
+		if err != nil {
+			return fmt.Errorf("copying static mount %s: %w", prefix, err)
+		}
+	}
+
+	bundles := newBundleCache()
+	for _, ref := range slices.Sorted(maps.Keys(x.datastar)) {
+		version := DatastarVersion
+		if m := datastarRef.FindStringSubmatch(ref); m[1] != "" {
+			version = m[1]
+		}
+		js := datastarBundle
+		if version != DatastarVersion {
+			var err error
+			if js, err = bundles.get(version); err != nil {
+				return fmt.Errorf("fetching Datastar %s: %w", version, err)
+			}
+		}
+		if err := x.addAsset(ref, js); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *exporter) addAsset(name string, data []byte) error {
+	if err := x.write(name, data); err != nil {
+		return err
+	}
+	x.report.Assets = append(x.report.Assets, strings.TrimPrefix(name, "/"))
+	return nil
+}
+
+// write saves data at the URL path name under the output directory.
+func (x *exporter) write(name string, data []byte) error {
+	file := filepath.Join(x.out, filepath.FromSlash(strings.TrimPrefix(name, "/")))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
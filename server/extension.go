@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+)
+
+// Extension adds to a playground embedded in a Go program (see
+// Config.Extensions), so an application can teach templates about its own
+// domain without forking dsplay. Every field is optional.
+type Extension struct {
+	// Name identifies the extension in error messages.
+	Name string
+
+	// Funcs are extra template functions for Go templates. They replace
+	// built-in functions of the same name, and later extensions replace
+	// earlier ones.
+	Funcs template.FuncMap
+
+	// Data returns values added to .Data on each render, next to the
+	// fixtures from _data/ (replacing any of the same name). ctx is the
+	// request's context, or a background context for scheduled events.
+	Data func(ctx context.Context) (map[string]any, error)
+
+	// Middleware wraps every request to the playground, inside dsplay's
+	// own middleware (logging, protection, rate limits) so it sees
+	// requests that made it through them.
+	Middleware func(http.Handler) http.Handler
+}
+
+// loadData returns the playground's fixtures plus the extensions' data.
+func (h *Handler) loadData(ctx context.Context) (map[string]any, error) {
+	data, err := LoadData(h.fsys)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range h.extensions {
+		if ext.Data == nil {
+			continue
+		}
+		values, err := ext.Data(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("extension %s: %w", ext.Name, err)
+		}
+		maps.Copy(data, values)
+	}
+	return data, nil
+}
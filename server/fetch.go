@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fetchCacheTTL is how long a fetched response is reused, so an SSE loop
+	// ticking every second doesn't hammer (or get rate limited by) an API.
+	fetchCacheTTL = 10 * time.Second
+
+	// maxFetchBytes caps the size of a fetched response body.
+	maxFetchBytes = 1 << 20
+
+	defaultFetchTimeout = 5 * time.Second
+)
+
+// Fetcher performs the HTTP GETs behind fetchJSON and fetchText. Only hosts
+// matching the allowlist can be reached.
+type Fetcher struct {
+	allow   []string
+	client  *http.Client
+	mu      sync.Mutex
+	cache   map[string]fetched
+	timeout time.Duration
+}
+
+type fetched struct {
+	body []byte
+	at   time.Time
+}
+
+// NewFetcher allows requests to hosts matching any of the allow patterns
+// (path.Match syntax, e.g. "api.github.com" or "*.open-meteo.com"). An
+// empty list allows nothing; timeout <= 0 uses 5s.
+func NewFetcher(allow []string, timeout time.Duration) *Fetcher {
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+	f := &Fetcher{
+		allow:   allow,
+		cache:   make(map[string]fetched),
+		timeout: timeout,
+	}
+	f.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !f.allowed(req.URL) {
+				return fmt.Errorf("redirect to %q is not allowlisted", req.URL.Hostname())
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// allowed reports whether u is an http(s) URL on an allowlisted host.
+func (f *Fetcher) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range f.allow {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the body of rawURL, from cache if it was fetched recently.
+func (f *Fetcher) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	return f.get(ctx, rawURL, false)
+}
+
+// get fetches rawURL, skipping the cache when fresh is set. The response is
+// cached either way.
+func (f *Fetcher) get(ctx context.Context, rawURL string, fresh bool) ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("fetch %s: fetching is not enabled here", rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	if !f.allowed(u) {
+		return nil, fmt.Errorf("fetch %s: host %q is not allowlisted (see --allow-fetch)", rawURL, u.Hostname())
+	}
+
+	f.mu.Lock()
+	if c, ok := f.cache[rawURL]; ok && !fresh && time.Since(c.at) < fetchCacheTTL {
+		f.mu.Unlock()
+		return c.body, nil
+	}
+	f.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", "dsplay")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("fetch %s: %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	if len(body) > maxFetchBytes {
+		return nil, fmt.Errorf("fetch %s: response larger than %d bytes", rawURL, maxFetchBytes)
+	}
+
+	f.mu.Lock()
+	for k, c := range f.cache {
+		if time.Since(c.at) >= fetchCacheTTL {
+			delete(f.cache, k)
+		}
+	}
+	f.cache[rawURL] = fetched{body: body, at: time.Now()}
+	f.mu.Unlock()
+	return body, nil
+}
+
+// JSON fetches rawURL and decodes it as JSON.
+func (f *Fetcher) JSON(ctx context.Context, rawURL string) (any, error) {
+	body, err := f.Get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("fetch %s: decoding JSON: %w", rawURL, err)
+	}
+	return v, nil
+}
+
+// Text fetches rawURL as a string.
+func (f *Fetcher) Text(ctx context.Context, rawURL string) (string, error) {
+	body, err := f.Get(ctx, rawURL)
+	return string(body), err
+}
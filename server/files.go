@@ -1,34 +1,285 @@
 package server
 
 import (
+	"fmt"
+	"io/fs"
+	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dataSPA/dataSPA-playground/ignore"
+	"github.com/dataSPA/dataSPA-playground/parser"
+	"github.com/dataSPA/dataSPA-playground/script"
 	"gopkg.in/yaml.v3"
 )
 
 // Frontmatter holds the parsed header of a template file.
 type Frontmatter struct {
-	Loop            bool   `yaml:"loop"`
-	Interval        int    `yaml:"interval"`         // milliseconds between loop iterations
-	Status          int    `yaml:"status"`           // HTTP status code (0 means use default: 200)
-	Count           int    `yaml:"count"`            // number of loops before advancing to next SSE file (0 = infinite)
-	Delay           int    `yaml:"delay"`            // milliseconds between sequential SSE sections (default: 5000)
-	ViewTransitions bool   `yaml:"view-transitions"` // use datastar useViewTransitions option
-	Namespace       string `yaml:"namespace"`        // DOM namespace
-	Mode            string `yaml:"mode"`             // Morph mode
-	Selector        string `yaml:"selector"`         // Selector for target element
+	Loop            bool      `yaml:"loop"`
+	Interval        int       `yaml:"interval"`         // milliseconds between loop iterations
+	Status          int       `yaml:"status"`           // HTTP status code (0 means use default: 200)
+	Count           int       `yaml:"count"`            // number of loops before advancing to next SSE file (0 = infinite)
+	Delay           int       `yaml:"delay"`            // milliseconds between sequential SSE sections (default: 5000)
+	ViewTransitions bool      `yaml:"view-transitions"` // use datastar useViewTransitions option
+	Namespace       string    `yaml:"namespace"`        // DOM namespace
+	Mode            string    `yaml:"mode"`             // Morph mode
+	Selector        string    `yaml:"selector"`         // Selector for target element
+	Chaos           Chaos     `yaml:"chaos"`            // fault injection (delays, errors, dropped connections)
+	Tail            Tail      `yaml:"tail"`             // stream lines of a file or command (SSE only)
+	Select          string    `yaml:"select"`           // section selection: "" (sequential) or "random"
+	SelectBy        SelectBy  `yaml:"select_by"`        // serve the section a query parameter or signal names (query:step, signal:step)
+	Cacheable       bool      `yaml:"cacheable"`        // send an ETag and answer matching If-None-Match GETs with 304
+	Stream          Stream    `yaml:"stream"`           // send HTML responses in paced chunks for progressive rendering
+	Redirect        string    `yaml:"redirect"`         // navigate the browser here after the section (SSE) or instead of it (HTML)
+	Timezone        string    `yaml:"timezone"`         // zone for sections' during:, before: and after: options (default: the server's)
+	Weights         []float64 `yaml:"weights"`          // per-section weights for select: random (default 1)
+	DatastarVersion string    `yaml:"datastar_version"` // Datastar release this file targets, overriding playground.yaml
+	Once            bool      `yaml:"once"`             // serve each section only once per session
+	Jitter          Jitter    `yaml:"jitter"`           // random ± variation of each loop interval (ms or "N%")
+	Backoff         Backoff   `yaml:"backoff"`          // grow the loop interval after each tick
+	Engine          string    `yaml:"engine"`           // template engine: go (default), raw, or mustache
+	Dedupe          bool      `yaml:"dedupe"`           // skip a patch identical to the previous one on the stream
+	Method          Methods   `yaml:"method"`           // methods the file answers, overriding its name (e.g. [POST, PUT])
+	Aliases         Aliases   `yaml:"aliases"`          // more URLs the file answers on, e.g. [/old/path/]
+	Proxy           Upstream  `yaml:"proxy"`            // forward requests to a real backend instead of rendering
+	Subscribe       []string  `yaml:"subscribe"`        // webhooks (/_hooks/<name>) whose events re-render the stream
+	Vars            Vars      `yaml:"vars"`             // computed values (name: expression), exposed as .Vars
+	Signals         Signals   `yaml:"signals"`          // signals Datastar requests must send, checked before rendering
+	Invalid         string    `yaml:"invalid"`          // section (by its name: option) answering signals that fail the schema
+	Download        Download  `yaml:"download"`         // send the section as a file download (filename, content type, base64 body)
+	ResetSequence   ResetSeq  `yaml:"reset_sequence"`   // restart these routes' sequences (true = this route) for the session's next request
+	Advance         Advance   `yaml:"advance"`          // move another route's sequence on (or to a step) for the session's next request
+}
+
+// Aliases are route paths, normalised to "/a/b/" form.
+type Aliases []string
+
+func (a *Aliases) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return fmt.Errorf("aliases: want a list of paths")
+	}
+	for i, alias := range list {
+		p, err := routePath(alias)
+		if err != nil {
+			return fmt.Errorf("aliases: %w", err)
+		}
+		list[i] = p
+	}
+	*a = list
+	return nil
+}
+
+// routePath normalises a route path written in frontmatter to "/a/b/" form.
+func routePath(p string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%q must start with /", p)
+	}
+	if p = strings.Trim(path.Clean(p), "/"); p != "" {
+		return "/" + p + "/", nil
+	}
+	return "/", nil
+}
+
+// ResetSeq is the reset_sequence: frontmatter, written as true (the file's
+// own route), one route path, or a list of them.
+type ResetSeq struct {
+	Self  bool
+	Paths []string // normalised to "/a/b/" form
+}
+
+func (rs *ResetSeq) UnmarshalYAML(node *yaml.Node) error {
+	var self bool
+	if node.Kind == yaml.ScalarNode && node.Decode(&self) == nil {
+		*rs = ResetSeq{Self: self}
+		return nil
+	}
+	var list []string
+	if node.Kind == yaml.ScalarNode {
+		list = []string{node.Value}
+	} else if err := node.Decode(&list); err != nil {
+		return fmt.Errorf("reset_sequence: want true, a path or a list of paths")
+	}
+	for i, p := range list {
+		var err error
+		if list[i], err = routePath(p); err != nil {
+			return fmt.Errorf("reset_sequence: %w", err)
+		}
+	}
+	*rs = ResetSeq{Paths: list}
+	return nil
+}
+
+// paths lists the routes to reset, given the file's own.
+func (rs ResetSeq) paths(self string) []string {
+	if rs.Self {
+		return []string{self}
+	}
+	return rs.Paths
+}
+
+// Advance is the advance: frontmatter, which moves another route's sequence
+// when the file is served, e.g. so a POST decides what the next GET of a
+// wizard shows:
+//
+//	advance: /wizard/                 # one step on
+//	advance: {route: /wizard/, by: 2}
+//	advance: {route: /wizard/, to: 3} # straight to the third step
+type Advance struct {
+	Route string `yaml:"route"`
+	To    int    `yaml:"to"` // 1-based step to jump to
+	By    int    `yaml:"by"` // steps to move on, or back if negative (default 1)
+}
+
+func (a *Advance) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*a = Advance{Route: node.Value}
+	} else {
+		type plain Advance
+		if err := node.Decode((*plain)(a)); err != nil {
+			return err
+		}
+	}
+	route, err := routePath(a.Route)
+	if err != nil {
+		return fmt.Errorf("advance: route: %w", err)
+	}
+	a.Route = route
+	switch {
+	case a.To < 0:
+		return fmt.Errorf("advance: to is a step number, starting at 1")
+	case a.To > 0 && a.By != 0:
+		return fmt.Errorf("advance: set either to or by, not both")
+	case a.To == 0 && a.By == 0:
+		a.By = 1
+	}
+	return nil
+}
+
+// Methods is the frontmatter method list, written as one method
+// ("method: POST") or several ("method: [POST, PUT]").
+type Methods []string
+
+func (m *Methods) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		list = []string{node.Value}
+	case yaml.SequenceNode:
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("method: want a method or a list of methods")
+	}
+	for i, method := range list {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		switch method {
+		case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return fmt.Errorf("method: %q is not one of GET, POST, PUT, PATCH or DELETE", list[i])
+		}
+		list[i] = method
+	}
+	*m = list
+	return nil
 }
 
 // ParsedFile represents a single template file parsed into frontmatter + response sections.
 type ParsedFile struct {
-	Frontmatter Frontmatter
-	Sections    []string // template bodies (split by ===), may include empty strings
-	Path        string   // original file path on disk
-	SeqIndex    int      // sequence index from _NNN suffix (-1 if none)
+	Frontmatter    Frontmatter
+	Sections       []string         // template bodies (split by ===), may include empty strings
+	SectionOptions []SectionOptions // per-section options from "=== key: value" markers, parallel to Sections
+	SectionLines   []int            // 1-based line in the file where each section's body starts, parallel to Sections
+	Path           string           // file path relative to the playground root
+	SeqIndex       int              // sequence index from _NNN suffix (-1 if none)
+	Markdown       bool             // .md file: sections are converted to HTML after templating
+}
+
+// SectionOptions holds per-section settings given on the separator line that
+// starts a section, e.g. "=== when: signals.age < 18". Several options for
+// one section go on consecutive separator lines. A marker on the very first
+// body line applies to the first section.
+type SectionOptions struct {
+	When string // expression selecting this section (see package expr)
+	Name string // label a handler.star script can pick the section by
+	Once bool   // serve this section only the first time a session reaches it
+
+	// Redirect sends the browser to this URL after the section (in place
+	// of it for non-streaming responses), overriding the file's redirect:.
+	Redirect string
+
+	// During, Before and After limit the section to times on the playground
+	// clock, in the file's timezone.
+	During *timeWindow
+	Before *wallTime
+	After  *wallTime
+
+	// Delay is the pause before this section in sequential SSE mode, in place
+	// of the file's delay. HasDelay distinguishes "delay: 0" from no option.
+	Delay    time.Duration
+	HasDelay bool
+}
+
+// set applies a single "key: value" option.
+func (o *SectionOptions) set(key, value string) error {
+	switch key {
+	case "when":
+		o.When = value
+	case "name":
+		o.Name = value
+	case "redirect":
+		o.Redirect = value
+	case "once":
+		once, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("once: %q is not a boolean", value)
+		}
+		o.Once = once
+	case "delay":
+		d, err := parseDelay(value)
+		if err != nil {
+			return err
+		}
+		o.Delay, o.HasDelay = d, true
+	case "during":
+		w, err := parseWindow(value)
+		if err != nil {
+			return err
+		}
+		o.During = w
+	case "before", "after":
+		t, err := parseWallTime(key, value)
+		if err != nil {
+			return err
+		}
+		if key == "before" {
+			o.Before = t
+		} else {
+			o.After = t
+		}
+	default:
+		return fmt.Errorf("unknown section option %q", key)
+	}
+	return nil
+}
+
+// parseDelay reads a delay option: milliseconds like the frontmatter's
+// delay ("500"), or a Go duration ("2s", "1m30s").
+func parseDelay(value string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("delay: %q is not a number of milliseconds or a duration", value)
+	}
+	return d, nil
 }
 
 // RouteFiles holds all the files for a given route, keyed by HTTP method.
@@ -36,6 +287,8 @@ type ParsedFile struct {
 type RouteFiles struct {
 	HTMLFiles map[string][]*ParsedFile // method → files for regular HTML responses
 	SSEFiles  map[string][]*ParsedFile // method → files for SSE responses
+	WSFiles   map[string][]*ParsedFile // method → files for WebSocket streams
+	Script    *script.Script           // the route's handler.star, if any
 }
 
 func (rf *RouteFiles) LookupHTML(method string) []*ParsedFile {
@@ -52,10 +305,49 @@ func (rf *RouteFiles) LookupSSE(method string) []*ParsedFile {
 	return rf.SSEFiles[""]
 }
 
-const (
-	frontmatterSeparator = "---"
-	sectionSeparator     = "==="
-)
+func (rf *RouteFiles) LookupWS(method string) []*ParsedFile {
+	if files, ok := rf.WSFiles[strings.ToUpper(method)]; ok && len(files) > 0 {
+		return files
+	}
+	return rf.WSFiles[""]
+}
+
+// routeMethods is the order methods are listed in Allow headers.
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+// Methods lists the HTTP methods the route answers, for an Allow header.
+// HEAD comes with GET and OPTIONS is always answered.
+func (rf *RouteFiles) Methods() []string {
+	has := map[string]bool{http.MethodOptions: true}
+	for _, byMethod := range []map[string][]*ParsedFile{rf.HTMLFiles, rf.SSEFiles, rf.WSFiles} {
+		for method := range byMethod {
+			if method == "" {
+				return slices.Clone(routeMethods)
+			}
+			has[method] = true
+		}
+	}
+	has[http.MethodHead] = has[http.MethodGet]
+	var out []string
+	for _, m := range routeMethods {
+		if has[m] {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Transports lists the streaming transports the route offers, preferred first.
+func (rf *RouteFiles) Transports() []string {
+	var out []string
+	if len(rf.WSFiles) > 0 {
+		out = append(out, transportWS)
+	}
+	if len(rf.SSEFiles) > 0 {
+		out = append(out, transportSSE)
+	}
+	return out
+}
 
 // ParseFile reads and parses a template file from disk.
 func ParseFile(path string) (*ParsedFile, error) {
@@ -63,152 +355,184 @@ func ParseFile(path string) (*ParsedFile, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseContent(path, data)
+}
 
-	content := string(data)
+// ParseFS reads and parses a template file from fsys.
+func ParseFS(fsys fs.FS, name string) (*ParsedFile, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return parseContent(name, data)
+}
+
+func parseContent(path string, data []byte) (*ParsedFile, error) {
+	f := parser.Parse(data)
 	pf := &ParsedFile{
 		Path:     path,
 		SeqIndex: -1,
 	}
 
-	// Parse frontmatter
-	if strings.HasPrefix(strings.TrimSpace(content), frontmatterSeparator) {
-		trimmed := strings.TrimSpace(content)
-		rest := trimmed[len(frontmatterSeparator):]
-		endIdx := strings.Index(rest, "\n"+frontmatterSeparator)
-		if endIdx >= 0 {
-			fmContent := rest[:endIdx]
-			if err := yaml.Unmarshal([]byte(fmContent), &pf.Frontmatter); err != nil {
-				return nil, err
-			}
-			afterClose := rest[endIdx+len("\n"+frontmatterSeparator):]
-			content = strings.TrimPrefix(afterClose, "\n")
+	if f.Frontmatter != "" {
+		if err := yaml.Unmarshal([]byte(f.Frontmatter), &pf.Frontmatter); err != nil {
+			return nil, err
 		}
 	}
 
-	// Split body into sections — keep empty sections (they represent empty responses)
-	sections := strings.Split(content, "\n"+sectionSeparator+"\n")
-	for _, s := range sections {
-		pf.Sections = append(pf.Sections, strings.TrimSpace(s))
+	if err := validEngine(pf.Frontmatter.Engine); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := time.LoadLocation(pf.Frontmatter.Timezone); err != nil {
+		return nil, fmt.Errorf("%s: timezone: %w", path, err)
 	}
 
-	if len(pf.Sections) == 0 {
-		pf.Sections = []string{""}
+	for _, sec := range f.Sections {
+		var opts SectionOptions
+		for _, o := range sec.Options {
+			if err := opts.set(o.Key, o.Value); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		pf.Sections = append(pf.Sections, sec.Body)
+		pf.SectionOptions = append(pf.SectionOptions, opts)
+		pf.SectionLines = append(pf.SectionLines, sec.Line)
 	}
 
 	return pf, nil
 }
 
-// extractSeqIndex extracts the _NNN sequence index from a filename stem.
-// Returns the base name (without _NNN) and the index (-1 if none).
-func extractSeqIndex(stem string) (string, int) {
-	lastUnderscore := strings.LastIndex(stem, "_")
-	if lastUnderscore < 0 {
-		return stem, -1
-	}
+// Transports a file can respond over.
+const (
+	transportSSE = string(parser.SSE)
+	transportWS  = string(parser.WebSocket)
+)
 
-	suffix := stem[lastUnderscore+1:]
-	if n, err := strconv.Atoi(suffix); err == nil && len(suffix) > 0 {
-		return stem[:lastUnderscore], n
+// ScanPlaygrounds scans the playgrounds directory and returns a map of URL path → RouteFiles.
+// The directory path becomes the URL. Files within each directory are handlers:
+//
+//	index.html    → default HTML handler
+//	sse.html      → SSE handler
+//	post.html     → POST-specific HTML handler
+//	post_sse.html → POST-specific SSE handler
+//	index.md      → HTML handler written in Markdown
+//	handler.star  → script run before the route's templates
+func ScanPlaygrounds(root string) (map[string]*RouteFiles, error) {
+	return ScanFS(os.DirFS(root))
+}
+
+// MatchRoute finds the route serving urlPath: its own, or else the most
+// specific catch-all ([...]) route above it, with the rest of the path as
+// the wildcard.
+func MatchRoute(routes map[string]*RouteFiles, urlPath string) (rf *RouteFiles, wildcard string, ok bool) {
+	if rf, ok := routes[urlPath]; ok {
+		return rf, "", true
 	}
-	return stem, -1
+	for _, m := range parser.CatchAllRoutes(urlPath) {
+		if rf, ok := routes[m.Route]; ok {
+			return rf, m.Wildcard, true
+		}
+	}
+	return nil, "", false
 }
 
-var knownMethods = map[string]bool{
-	"get": true, "post": true, "put": true, "patch": true, "delete": true,
+// routeAlias is an extra URL a file answers on, from its aliases: frontmatter.
+type routeAlias struct {
+	urlPath string
+	class   parser.Class
+	pf      *ParsedFile
 }
 
-// classifyFile determines the file type from its stem (filename without .html).
-//
-// Well-known filenames within a directory:
-//
-//	index.html        → HTML, any method
-//	sse.html          → SSE, any method
-//	get.html          → HTML, GET
-//	post.html         → HTML, POST
-//	post_sse.html     → SSE, POST
-//	sse_001.html      → SSE, any method, sequence 1
-//	post_sse_001.html → SSE, POST, sequence 1
-//	post_001.html     → HTML, POST, sequence 1
-//	index_001.html    → HTML, any method, sequence 1
-func classifyFile(stem string) (method string, isSSE bool, seqIdx int) {
-	remaining := stem
+// routeFiles returns the route at urlPath, adding it if it is new.
+func routeFiles(routes map[string]*RouteFiles, urlPath string) *RouteFiles {
+	rf, ok := routes[urlPath]
+	if !ok {
+		rf = &RouteFiles{
+			HTMLFiles: make(map[string][]*ParsedFile),
+			SSEFiles:  make(map[string][]*ParsedFile),
+			WSFiles:   make(map[string][]*ParsedFile),
+		}
+		routes[urlPath] = rf
+	}
+	return rf
+}
 
-	// 1. Extract _NNN sequence suffix
-	remaining, seqIdx = extractSeqIndex(remaining)
+// addRoute registers pf on urlPath for the methods it answers.
+func addRoute(routes map[string]*RouteFiles, urlPath string, class parser.Class, pf *ParsedFile) {
+	rf := routeFiles(routes, urlPath)
 
-	// 2. Check for _sse suffix (or exactly "sse")
-	if strings.ToLower(remaining) == "sse" {
-		return "", true, seqIdx
+	// A method: in the frontmatter takes precedence over the file name
+	methods := []string{class.Method}
+	if len(pf.Frontmatter.Method) > 0 {
+		methods = pf.Frontmatter.Method
 	}
-	if strings.HasSuffix(strings.ToLower(remaining), "_sse") {
-		isSSE = true
-		remaining = remaining[:len(remaining)-4]
-	}
-
-	// 3. Check if remaining is a known method
-	if knownMethods[strings.ToLower(remaining)] {
-		return strings.ToUpper(remaining), isSSE, seqIdx
+	for _, method := range methods {
+		switch class.Transport {
+		case parser.SSE:
+			rf.SSEFiles[method] = append(rf.SSEFiles[method], pf)
+		case parser.WebSocket:
+			rf.WSFiles[method] = append(rf.WSFiles[method], pf)
+		default:
+			rf.HTMLFiles[method] = append(rf.HTMLFiles[method], pf)
+		}
 	}
-
-	// 4. "index" or anything else → HTML, any method
-	return "", isSSE, seqIdx
 }
 
-// ScanPlaygrounds scans the playgrounds directory and returns a map of URL path → RouteFiles.
-// The directory path becomes the URL. Files within each directory are handlers:
-//
-//	index.html    → default HTML handler
-//	sse.html      → SSE handler
-//	post.html     → POST-specific HTML handler
-//	post_sse.html → POST-specific SSE handler
-func ScanPlaygrounds(root string) (map[string]*RouteFiles, error) {
+// ScanFS is ScanPlaygrounds for a playground held in fsys, e.g. an embed.FS
+// or fstest.MapFS. File paths in the result are relative to fsys. Files
+// the playground's .dsplayignore lists are left out.
+func ScanFS(fsys fs.FS) (map[string]*RouteFiles, error) {
 	routes := make(map[string]*RouteFiles)
+	var aliases []routeAlias
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	ignored, err := ignore.Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	err = fs.WalkDir(fsys, ".", func(rel string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
+		if rel != "." && ignored.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
 			return nil
 		}
-		if filepath.Ext(path) != ".html" {
+		if d.IsDir() {
+			// Directories starting with "_" are reserved (e.g. _errors/) and never routes
+			if rel != "." && parser.Reserved(d.Name()) {
+				return fs.SkipDir
+			}
 			return nil
 		}
-
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			return err
+		if path.Base(rel) == scriptFile {
+			s, err := parseScript(fsys, rel)
+			if err != nil {
+				return err
+			}
+			routeFiles(routes, parser.URLPath(rel)).Script = s
+			return nil
 		}
-
-		// The directory path is the URL
-		dir := filepath.Dir(rel)
-		var urlPath string
-		if dir == "." {
-			urlPath = "/"
-		} else {
-			urlPath = "/" + filepath.ToSlash(dir) + "/"
+		ext := path.Ext(rel)
+		if ext != ".html" && ext != ".md" {
+			return nil
 		}
 
-		stem := strings.TrimSuffix(filepath.Base(rel), ".html")
-		method, isSSE, seqIdx := classifyFile(stem)
+		// The directory path is the URL
+		urlPath := parser.URLPath(rel)
+		class := parser.Classify(rel)
 
-		pf, parseErr := ParseFile(path)
+		pf, parseErr := ParseFS(fsys, rel)
 		if parseErr != nil {
 			return parseErr
 		}
-		pf.SeqIndex = seqIdx
-
-		if _, ok := routes[urlPath]; !ok {
-			routes[urlPath] = &RouteFiles{
-				HTMLFiles: make(map[string][]*ParsedFile),
-				SSEFiles:  make(map[string][]*ParsedFile),
-			}
-		}
+		pf.SeqIndex = class.Seq
+		pf.Markdown = ext == ".md"
 
-		if isSSE {
-			routes[urlPath].SSEFiles[method] = append(routes[urlPath].SSEFiles[method], pf)
-		} else {
-			routes[urlPath].HTMLFiles[method] = append(routes[urlPath].HTMLFiles[method], pf)
+		addRoute(routes, urlPath, class, pf)
+		for _, alias := range pf.Frontmatter.Aliases {
+			aliases = append(aliases, routeAlias{urlPath: alias, class: class, pf: pf})
 		}
 
 		return nil
@@ -217,6 +541,18 @@ func ScanPlaygrounds(root string) (map[string]*RouteFiles, error) {
 		return nil, err
 	}
 
+	// Aliases only answer where no file does, so a page can never be
+	// shadowed by another file's alias
+	own := make(map[string]bool, len(routes))
+	for urlPath := range routes {
+		own[urlPath] = true
+	}
+	for _, a := range aliases {
+		if !own[a.urlPath] {
+			addRoute(routes, a.urlPath, a.class, a.pf)
+		}
+	}
+
 	// Sort sequential files by SeqIndex
 	for _, rf := range routes {
 		for _, files := range rf.HTMLFiles {
@@ -229,6 +565,11 @@ func ScanPlaygrounds(root string) (map[string]*RouteFiles, error) {
 				return files[i].SeqIndex < files[j].SeqIndex
 			})
 		}
+		for _, files := range rf.WSFiles {
+			sort.Slice(files, func(i, j int) bool {
+				return files[i].SeqIndex < files[j].SeqIndex
+			})
+		}
 	}
 
 	return routes, nil
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/fake"
+	"github.com/dataSPA/dataSPA-playground/markdown"
+	sprig "github.com/go-task/slim-sprig/v3"
+)
+
+// templateFuncs returns the function map for rendering a template with td:
+// slim-sprig plus the playground's built-in functions.
+func (h *Handler) templateFuncs(td TemplateData) template.FuncMap {
+	funcs := sprig.FuncMap()
+
+	// Notification stubs: recorded in the outbox, never delivered.
+	funcs["sendEmail"] = func(to, subject, body string) string {
+		h.outbox.Record(OutboxMessage{
+			Kind:      "email",
+			To:        to,
+			Subject:   subject,
+			Body:      body,
+			Username:  td.Username,
+			SessionID: td.SessionID,
+			URL:       td.URL,
+		})
+		return ""
+	}
+	funcs["notify"] = func(channel, message string) string {
+		h.outbox.Record(OutboxMessage{
+			Kind:      "notify",
+			To:        channel,
+			Body:      message,
+			Username:  td.Username,
+			SessionID: td.SessionID,
+			URL:       td.URL,
+		})
+		return ""
+	}
+
+	// Simulated background jobs, scoped to the visitor's session.
+	funcs["enqueue"] = func(name string, opts ...map[string]any) (string, error) {
+		var o map[string]any
+		if len(opts) > 0 {
+			o = opts[0]
+		}
+		j, err := h.jobs.Enqueue(td.SessionID, name, o)
+		return j.ID, err
+	}
+	funcs["job"] = func(id string) *Job {
+		j, ok := h.jobs.Get(id)
+		if !ok {
+			return nil
+		}
+		return &j
+	}
+	funcs["jobs"] = func() []Job {
+		return h.jobs.List(td.SessionID)
+	}
+	funcs["queueStats"] = h.jobs.Stats
+
+	// Per-session preferences, updated via POST /_prefs.
+	funcs["pref"] = func(key string, def ...string) string {
+		if v := h.prefs.Get(td.SessionID, key); v != "" {
+			return v
+		}
+		if len(def) > 0 {
+			return def[0]
+		}
+		return ""
+	}
+	funcs["prefs"] = func() map[string]string {
+		return h.prefs.All(td.SessionID)
+	}
+
+	// Scratch state remembered server-side across requests, for the whole
+	// session or (the tab variants) the tab sending a tab_id signal.
+	tabID, _ := td.Signals["tab_id"].(string)
+	getState := func(tab, key string, def []any) any {
+		if v := h.state.Get(td.SessionID, tab, key); v != nil {
+			return v
+		}
+		if len(def) > 0 {
+			return def[0]
+		}
+		return nil
+	}
+	funcs["state"] = func(key string, def ...any) any {
+		return getState("", key, def)
+	}
+	funcs["setState"] = func(key string, value any) (string, error) {
+		return "", h.state.Set(td.SessionID, "", map[string]any{key: value})
+	}
+	funcs["unsetState"] = func(key string) (string, error) {
+		return "", h.state.Set(td.SessionID, "", map[string]any{key: nil})
+	}
+	funcs["tabState"] = func(key string, def ...any) any {
+		if tabID == "" {
+			def = append(def, nil) // no tab to read from, so just the default
+			return def[0]
+		}
+		return getState(tabID, key, def)
+	}
+	funcs["setTabState"] = func(key string, value any) (string, error) {
+		if tabID == "" {
+			return "", fmt.Errorf("setTabState: the request has no tab_id signal")
+		}
+		return "", h.state.Set(td.SessionID, tabID, map[string]any{key: value})
+	}
+
+	// Restart a demo: zero hit counters (all, or one URL), clear this
+	// session's hits and sequence positions from its next request on, or
+	// restart just one route's sequence.
+	funcs["resetCounters"] = func(url ...string) string {
+		if len(url) > 0 {
+			h.counters.ResetURL(url[0])
+		} else {
+			h.counters.Reset()
+		}
+		return ""
+	}
+	funcs["resetSession"] = func() string {
+		if h.sessions != nil {
+			h.sessions.ResetSession(td.SessionID)
+		}
+		h.state.Clear(td.SessionID)
+		return ""
+	}
+	funcs["resetSeq"] = func(url string) (string, error) {
+		p, err := routePath(url)
+		if err != nil {
+			return "", fmt.Errorf("resetSeq: %w", err)
+		}
+		if h.sessions != nil {
+			h.sessions.ResetSeq(td.SessionID, p)
+		}
+		return "", nil
+	}
+
+	// Anti-forgery token for classic form posts (checked with --csrf).
+	funcs["csrfToken"] = func() string {
+		if h.sessions == nil {
+			return ""
+		}
+		return h.sessions.CSRFToken(td.SessionID)
+	}
+	funcs["csrfField"] = func() template.HTML {
+		if h.sessions == nil {
+			return csrfInput("")
+		}
+		return csrfInput(h.sessions.CSRFToken(td.SessionID))
+	}
+
+	// Locally served Datastar client.
+	funcs["datastarScript"] = func() template.HTML {
+		return datastarScript(td.BasePath, td.DatastarVersion)
+	}
+
+	// Fake data, random on every render unless fakeSeed pins it (for
+	// example to .SessionID, so each visitor sees stable content).
+	faker := fake.New()
+	funcs["fakeSeed"] = func(seed ...any) string {
+		faker.Seed(fmt.Sprint(seed...))
+		return ""
+	}
+	funcs["fakeName"] = faker.Name
+	funcs["fakeFirstName"] = faker.FirstName
+	funcs["fakeLastName"] = faker.LastName
+	funcs["fakeUsername"] = faker.Username
+	funcs["fakeEmail"] = faker.Email
+	funcs["fakeCompany"] = faker.Company
+	funcs["fakeCity"] = faker.City
+	funcs["fakePhone"] = faker.Phone
+	funcs["fakeWord"] = faker.Word
+	funcs["fakeSentence"] = func(words ...int) string {
+		return faker.Sentence(firstInt(words))
+	}
+	funcs["fakeParagraph"] = func(sentences ...int) string {
+		return faker.Paragraph(firstInt(sentences))
+	}
+	funcs["fakeInt"] = faker.Int
+	funcs["fakePrice"] = func(bounds ...float64) string {
+		lo, hi := 1.0, 100.0
+		if len(bounds) == 2 {
+			lo, hi = bounds[0], bounds[1]
+		}
+		return faker.Price(lo, hi)
+	}
+
+	// Environment variables, limited to DSPLAY_VAR_* and --allow-env names.
+	delete(funcs, "expandenv")
+	funcs["env"] = func(name string) (string, error) {
+		if !envAllowed(h.envAllow, name) {
+			return "", fmt.Errorf("env %s: only %s* and --allow-env variables can be read", name, envVarPrefix)
+		}
+		return os.Getenv(name), nil
+	}
+
+	// Live external data from allowlisted hosts (--allow-fetch).
+	funcs["fetchJSON"] = func(url string) (any, error) {
+		return h.fetcher.JSON(context.Background(), url)
+	}
+	funcs["fetchText"] = func(url string) (string, error) {
+		return h.fetcher.Text(context.Background(), url)
+	}
+	funcs["sourceData"] = h.sources.Get
+
+	// Link to the file behind the current section in the source viewer.
+	funcs["sourceLink"] = func(text ...string) template.HTML {
+		if !h.source || td.Source == "" {
+			return ""
+		}
+		label := "View source"
+		if len(text) > 0 {
+			label = text[0]
+		}
+		return template.HTML(`<a href="` + template.HTMLEscapeString(sourceURL(td.BasePath, td.Source, td.SourceLine)) + `">` +
+			template.HTMLEscapeString(label) + `</a>`)
+	}
+
+	// Markdown, e.g. for previewing a comment. Raw HTML in the input is
+	// escaped, so it is safe on signals.
+	funcs["markdown"] = func(src string) template.HTML {
+		return template.HTML(markdown.Render(src, markdown.Options{}))
+	}
+
+	// Inline SVG visuals.
+	funcs["qrCode"] = qrCode
+	funcs["sparkline"] = sparkline
+	funcs["barChart"] = barChart
+
+	// Functions added by embedders, which win over everything above.
+	for _, ext := range h.extensions {
+		maps.Copy(funcs, ext.Funcs)
+	}
+
+	return funcs
+}
+
+// envVarPrefix marks environment variables that templates can always read.
+const envVarPrefix = "DSPLAY_VAR_"
+
+func envAllowed(allow []string, name string) bool {
+	return strings.HasPrefix(name, envVarPrefix) && len(name) > len(envVarPrefix) || slices.Contains(allow, name)
+}
+
+// firstInt returns the first of an optional template argument, or 0.
+func firstInt(args []int) int {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return 0
+}
@@ -2,15 +2,20 @@ package server
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
-	sprig "github.com/go-task/slim-sprig/v3"
 	"github.com/gorilla/sessions"
 	"github.com/nats-io/nats.go"
 	"github.com/starfederation/datastar-go/datastar"
@@ -22,31 +27,122 @@ type TemplateData struct {
 	URLHits         int64
 	SessionURLHits  int64
 	Username        string
+	User            *User // authenticated identity, nil for anonymous visitors
 	SessionID       string
-	URL             string
+	URL             string // request path relative to the playground root
+	BasePath        string // prefix the playground is mounted under ("" at the root), for building links
+	Wildcard        string // rest of the path below a catch-all ([...]) route, e.g. "a/b"
 	Method          string
+	Scheme          string // "http" or "https", as the visitor sees it
+	Host            string // host the visitor requested, e.g. "play.example.com"
+	ClientIP        string // visitor's address
 	Signals         map[string]any
+	Form            url.Values // fields of a posted HTML form (multipart or urlencoded)
+	Uploads         []Upload   // files posted in a multipart form, saved for the session
 	SSEMessageCount int64
 	LoopCounter     int64
 	LoopCounter0    int64
+	Line            string         // current line when streaming a tail: source
+	Hook            *HookEvent     // latest webhook on a stream subscribed to one, nil before the first
+	Transports      []string       // streaming transports the route offers ("ws", "sse")
+	DatastarVersion string         // Datastar release the page targets ("" = the embedded one)
+	Data            map[string]any // fixtures from _data/, keyed by file name
+	Globals         map[string]any // server-wide values from --set and dsplay.yaml
+	Vars            map[string]any // data returned by the route's handler.star
+	State           map[string]any // the session's scratch state when the request arrived
+	Section         string         // section the route's handler.star picked, by its name: option
+	Errors          SignalErrors   // signals that failed the file's schema, in its invalid: section
+	Source          string         // playground file being rendered, e.g. "home/index.html"
+	SourceLine      int            // line in Source where the rendered section starts
+	Now             time.Time      // playground clock time, which the admin dashboard can pause or shift
 }
 
 // Handler handles playground requests.
 type Handler struct {
-	playgroundsDir string
-	counters       *Counters
-	sessions       *SessionManager
-	nc             *nats.Conn
-	debug          bool
+	fsys            fs.FS
+	counters        *Counters
+	sessions        *SessionManager
+	nc              *nats.Conn
+	outbox          *Outbox
+	limiter         *ConnLimiter
+	conns           *ConnRegistry
+	jobs            *JobQueue
+	prefs           *PrefStore
+	clock           *Clock
+	timeline        *Timeline // debug-mode signals inspector, nil when off
+	templates       *templateCache
+	fetcher         *Fetcher
+	uploads         *UploadStore
+	sources         *sourceValues // latest responses polled from _sources.yaml
+	state           *StateStore   // per-session scratch values saved by templates and requests
+	reload          *liveReload   // set when pages reload on file changes
+	subjects        subjects      // NATS subject names, prefixed when the connection is shared
+	globals         map[string]any
+	extensions      []Extension
+	source          bool // /_source is mounted, so sourceLink can point at it
+	tailAllow       []string
+	envAllow        []string // environment variables templates may read besides DSPLAY_VAR_*
+	wsOrigins       []string // other hosts whose pages may open WebSockets
+	strictTemplates bool
+	dev             bool
+	debug           bool
 }
 
-func NewHandler(playgroundsDir string, counters *Counters, sessions *SessionManager, nc *nats.Conn, debug bool) *Handler {
+// HandlerConfig is what a Handler serves and the shared state it works
+// with. Collaborators left nil turn their feature off, so tools rendering
+// offline only set what they need.
+type HandlerConfig struct {
+	FS              fs.FS // playground files
+	Counters        *Counters
+	Sessions        *SessionManager
+	NC              *nats.Conn
+	Outbox          *Outbox
+	Limiter         *ConnLimiter
+	Conns           *ConnRegistry
+	Jobs            *JobQueue
+	Prefs           *PrefStore
+	Clock           *Clock
+	Timeline        *Timeline // debug-mode signals inspector
+	Fetcher         *Fetcher
+	Uploads         *UploadStore
+	Globals         map[string]any
+	Extensions      []Extension
+	TailAllow       []string
+	EnvAllow        []string // environment variables templates may read besides DSPLAY_VAR_*
+	WSOrigins       []string // other hosts whose pages may open WebSockets
+	StrictTemplates bool
+	Dev             bool
+	Debug           bool
+	Source          bool // /_source is mounted
+}
+
+func NewHandler(cfg HandlerConfig) *Handler {
 	return &Handler{
-		playgroundsDir: playgroundsDir,
-		counters:       counters,
-		sessions:       sessions,
-		nc:             nc,
-		debug:          debug,
+		fsys:            cfg.FS,
+		counters:        cfg.Counters,
+		sessions:        cfg.Sessions,
+		nc:              cfg.NC,
+		outbox:          cfg.Outbox,
+		limiter:         cfg.Limiter,
+		conns:           cfg.Conns,
+		jobs:            cfg.Jobs,
+		prefs:           cfg.Prefs,
+		clock:           cfg.Clock,
+		timeline:        cfg.Timeline,
+		templates:       newTemplateCache(),
+		fetcher:         cfg.Fetcher,
+		uploads:         cfg.Uploads,
+		sources:         newSourceValues(),
+		state:           NewStateStore(),
+		globals:         cfg.Globals,
+		extensions:      cfg.Extensions,
+		tailAllow:       cfg.TailAllow,
+		envAllow:        cfg.EnvAllow,
+		wsOrigins:       cfg.WSOrigins,
+		strictTemplates: cfg.StrictTemplates,
+		dev:             cfg.Dev,
+		debug:           cfg.Debug,
+		source:          cfg.Source,
 	}
 }
 
@@ -58,6 +154,10 @@ func (h *Handler) debugLog(format string, args ...any) {
 
 // ServePlayground handles all playground requests.
 func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
+	if h.serveRouteAsset(w, r) {
+		return
+	}
+
 	urlPath := r.URL.Path
 
 	if urlPath == "" {
@@ -68,26 +168,80 @@ func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Scan files fresh each request (hot reload)
-	routes, err := ScanPlaygrounds(h.playgroundsDir)
+	routes, err := ScanFS(h.fsys)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error scanning playgrounds: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	rf, ok := routes[urlPath]
+	manifest, err := LoadManifest(h.fsys)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading %s: %v", manifestFile, err), http.StatusInternalServerError)
+		return
+	}
+
+	fixtures, err := h.loadData(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error loading %s: %v", dataDir, err), http.StatusInternalServerError)
+		return
+	}
+
+	rf, wildcard, ok := MatchRoute(routes, urlPath)
+	if !ok && urlPath == "/" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		h.renderRouteIndex(w, r, routes)
+		return
+	}
 	if !ok {
 		h.debugLog("%s %s → no route found (404)", r.Method, urlPath)
 		http.NotFound(w, r)
 		return
 	}
 
-	isDatastarRequest := r.Header.Get("datastar-request") != ""
-	h.debugLog("%s %s datastar=%v", r.Method, urlPath, isDatastarRequest)
+	if pf := upstreamFile(rf, routeMethod(r)); pf != nil {
+		h.serveUpstream(w, r, pf, wildcard)
+		return
+	}
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", strings.Join(rf.Methods(), ", "))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// HEAD is answered by the GET handler (net/http drops the body) without
+	// counting as a visit or moving sequences on
+	head := r.Method == http.MethodHead
+	isDatastarRequest := r.Header.Get("datastar-request") != "" && !head
+	isUpgrade := isWebSocketUpgrade(r)
+	h.debugLog("%s %s datastar=%v websocket=%v", r.Method, urlPath, isDatastarRequest, isUpgrade)
+
+	transports := rf.Transports()
+	if len(transports) > 0 {
+		w.Header().Set(transportsHeader, strings.Join(transports, ", "))
+	}
 
 	// Read signals from the request (must happen before NewSSE for POST bodies)
 	signals := map[string]any{}
-	if isDatastarRequest {
+	var form url.Values
+	if isUpgrade {
+		signals = wsSignals(r)
+	} else if isFormPost(r) {
+		if err := r.ParseMultipartForm(uploadMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			if bodyTooLarge(err) {
+				http.Error(w, "Upload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Invalid form: %v", err), http.StatusBadRequest)
+			return
+		}
+		form = r.PostForm
+		h.debugLog("  form: %v", form)
+	} else if isDatastarRequest {
 		if err := datastar.ReadSignals(r, &signals); err != nil {
+			if bodyTooLarge(err) {
+				http.Error(w, "Signals too large", http.StatusRequestEntityTooLarge)
+				return
+			}
 			log.Printf("Warning: failed to read signals: %v", err)
 		}
 		h.debugLog("  signals: %v", signals)
@@ -100,22 +254,76 @@ func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	h.debugLog("  session=%s user=%s", sd.SessionID, sd.Username)
+	if updates, ok := signals[stateSignalsName].(map[string]any); ok {
+		if err := h.state.Set(sd.SessionID, "", updates); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	uploads, err := h.uploads.Save(sd.SessionID, r.MultipartForm)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Upload error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if isDatastarRequest {
+		h.timeline.Signals(sd.SessionID, "http", r.Method, urlPath, signals)
+	} else if isUpgrade {
+		h.timeline.Signals(sd.SessionID, "ws", r.Method, urlPath, signals)
+	}
 
 	// Bump counters
-	globalHits, urlHits := h.counters.Hit(urlPath)
-	sessionURLHits := h.sessions.IncrementURLHits(w, r, sess, sd, urlPath)
+	var globalHits, urlHits, sessionURLHits int64
+	if head {
+		globalHits, urlHits, sessionURLHits = h.counters.GetGlobalHits(), h.counters.GetURLHits(urlPath), sd.URLHits[urlPath]
+	} else {
+		globalHits, urlHits = h.counters.Hit(urlPath)
+		sessionURLHits = h.sessions.IncrementURLHits(w, r, sess, sd, urlPath)
+	}
 
 	td := TemplateData{
-		GlobalHits:     globalHits,
-		URLHits:        urlHits,
-		SessionURLHits: sessionURLHits,
-		Username:       sd.Username,
-		SessionID:      sd.SessionID,
-		URL:            urlPath,
-		Method:         r.Method,
-		Signals:        signals,
-		LoopCounter:    1,
-		LoopCounter0:   0,
+		GlobalHits:      globalHits,
+		URLHits:         urlHits,
+		SessionURLHits:  sessionURLHits,
+		Username:        sd.Username,
+		User:            sd.User,
+		SessionID:       sd.SessionID,
+		URL:             urlPath,
+		BasePath:        basePath(r),
+		Wildcard:        wildcard,
+		DatastarVersion: manifest.DatastarVersion,
+		Method:          r.Method,
+		Scheme:          requestScheme(r),
+		Host:            r.Host,
+		ClientIP:        clientIP(r),
+		Signals:         signals,
+		Form:            form,
+		Uploads:         uploads,
+		State:           h.state.All(sd.SessionID, ""),
+		LoopCounter:     1,
+		LoopCounter0:    0,
+		Transports:      transports,
+		Data:            fixtures,
+		Globals:         h.globals,
+		Now:             h.clock.Now(),
+	}
+
+	if rf.Script != nil && !h.runScript(w, r, rf.Script, &td, isDatastarRequest) {
+		return
+	}
+
+	// WebSocket upgrades use ws.html unless the client asked for SSE, which
+	// lets playgrounds demonstrate falling back when WebSockets are blocked
+	if isUpgrade {
+		wsFiles := rf.LookupWS(r.Method)
+		if len(wsFiles) == 0 || preferredTransport(r, signals) == transportSSE {
+			h.debugLog("  → no WebSocket transport (404)")
+			http.Error(w, "WebSocket transport not available for this route", http.StatusNotFound)
+			return
+		}
+		h.debugLog("  → WebSocket handler (%d files)", len(wsFiles))
+		w.Header().Set(transportHeader, transportWS)
+		h.handleWS(w, r, wsFiles, sd, td, urlPath)
+		return
 	}
 
 	// Route to SSE or HTML handler based on datastar-request header
@@ -123,6 +331,7 @@ func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
 		sseFiles := rf.LookupSSE(r.Method)
 		if len(sseFiles) > 0 {
 			h.debugLog("  → SSE handler (%d files)", len(sseFiles))
+			w.Header().Set(transportHeader, transportSSE)
 			for _, f := range sseFiles {
 				h.debugLog("    file=%s sections=%d seq=%d", f.Path, len(f.Sections), f.SeqIndex)
 			}
@@ -132,7 +341,7 @@ func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
 		h.debugLog("  no SSE files for %s, falling through to HTML", r.Method)
 	}
 
-	htmlFiles := rf.LookupHTML(r.Method)
+	htmlFiles := rf.LookupHTML(routeMethod(r))
 	if len(htmlFiles) > 0 {
 		h.debugLog("  → HTML handler (%d files)", len(htmlFiles))
 		for _, f := range htmlFiles {
@@ -142,24 +351,100 @@ func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.debugLog("  → no handler found (404)")
-	http.NotFound(w, r)
+	h.debugLog("  → no handler for %s (405)", r.Method)
+	w.Header().Set("Allow", strings.Join(rf.Methods(), ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// routeMethod is the method whose templates answer r: GET's for HEAD.
+func routeMethod(r *http.Request) string {
+	if r.Method == http.MethodHead {
+		return http.MethodGet
+	}
+	return r.Method
+}
+
+// moveSequences applies a section's reset_sequence: and advance: to the
+// session's sequences, from its next request on.
+func (h *Handler) moveSequences(sessionID string, fm Frontmatter, urlPath string) {
+	if h.sessions == nil {
+		return
+	}
+	for _, p := range fm.ResetSequence.paths(urlPath) {
+		h.debugLog("  reset_sequence: %s", p)
+		h.sessions.ResetSeq(sessionID, p)
+	}
+	if a := fm.Advance; a.Route != "" {
+		h.debugLog("  advance: %s to=%d by=%d", a.Route, a.To, a.By)
+		if a.To > 0 {
+			h.sessions.SetSeq(sessionID, a.Route, a.To-1)
+		} else {
+			h.sessions.StepSeq(sessionID, a.Route, a.By)
+		}
+	}
 }
 
 func (h *Handler) handleHTML(w http.ResponseWriter, r *http.Request, files []*ParsedFile, isDatastarRequest bool, sess *sessions.Session, sd *SessionData, td TemplateData, urlPath string) {
-	allSections := collectSections(files)
+	if isDatastarRequest && !h.checkSignals(w, files, td) {
+		return
+	}
+	allSections := h.dropServed(sd.SessionID, collectSections(files))
+	if len(allSections) == 0 {
+		h.debugLog("  html: every section already served once (204)")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
-	pos := h.sessions.GetSeqPos(sd, urlPath+":html:"+r.Method)
+	seqKey := urlPath + ":html:" + routeMethod(r)
+	pos := h.sessions.GetSeqPos(sd, seqKey)
 	if pos >= len(allSections) {
 		pos = len(allSections) - 1
 	}
+	selected, bySelect, err := selectedSection(r, allSections, td)
+	if err != nil && td.Section == "" {
+		h.debugLog("  html: select_by: %v (404)", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	pinned := td.Section != "" || bySelect
+	conditional := !pinned && hasConditions(allSections)
+	if td.Section == "" && bySelect {
+		pos = selected
+	} else if pinned {
+		match, ok := pickedSection(allSections, td.Section)
+		if !ok {
+			h.debugLog("  html: no section named %q (500)", td.Section)
+			http.Error(w, fmt.Sprintf("Script error: %s picked section %q, which doesn't exist", scriptFile, td.Section), http.StatusInternalServerError)
+			return
+		}
+		pos = match
+	} else if conditional {
+		match, ok := h.firstMatch(allSections, td)
+		if !ok {
+			h.debugLog("  html: no section condition matched (404)")
+			http.NotFound(w, r)
+			return
+		}
+		pos = match
+	} else if allSections[0].isRandom() {
+		pos = pickRandom(allSections)
+	}
 	h.debugLog("  html: total_sections=%d seq_pos=%d", len(allSections), pos)
 
 	section := allSections[pos]
+	h.moveSequences(sd.SessionID, section.frontmatter, urlPath)
+
+	// A once: section drops out of the sequence once served, which moves the
+	// sections after it up a place, so the position must not advance as well
+	head := r.Method == http.MethodHead
+	once := section.isOnce()
+	if once && !head {
+		h.sessions.MarkServed(sd.SessionID, section.onceKey())
+	}
 
 	// Advance sequence for next request (before writing response so cookie is set)
-	if len(allSections) > 1 {
-		h.sessions.AdvanceSeqPos(w, r, sess, sd, urlPath+":html:"+r.Method, len(allSections), section.frontmatter.Loop)
+	if len(allSections) > 1 && !section.isRandom() && !conditional && !pinned && !once && !head {
+		h.sessions.AdvanceSeqPos(w, r, sess, sd, seqKey, len(allSections), section.frontmatter.Loop)
 	}
 
 	// Publish signals to NATS for listening SSE connections
@@ -167,8 +452,21 @@ func (h *Handler) handleHTML(w http.ResponseWriter, r *http.Request, files []*Pa
 		h.publishSignals(td)
 	}
 
+	if !h.applyChaos(w, r, section.frontmatter.Chaos, td) {
+		return
+	}
+
 	status := section.frontmatter.Status
 
+	if section.frontmatter.Download.enabled() {
+		h.serveDownload(w, r, section, td, status, isDatastarRequest)
+		return
+	}
+	if section.redirect() != "" {
+		h.serveRedirect(w, r, section, td, status, isDatastarRequest)
+		return
+	}
+
 	// Empty response
 	if section.content == "" {
 		if status == 0 {
@@ -180,10 +478,10 @@ func (h *Handler) handleHTML(w http.ResponseWriter, r *http.Request, files []*Pa
 
 	h.debugLog("  template data: GlobalHits=%d URLHits=%d SessionURLHits=%d Username=%q SessionID=%q URL=%q Method=%q Signals=%v SSEMessageCount=%d LoopCounter=%d",
 		td.GlobalHits, td.URLHits, td.SessionURLHits, td.Username, td.SessionID, td.URL, td.Method, td.Signals, td.SSEMessageCount, td.LoopCounter)
-	rendered, err := renderTemplate(section.content, td)
+	rendered, err := h.render(section, td)
 	if err != nil {
 		h.debugLog("  html: template error: %v", err)
-		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+		h.writeTemplateError(w, err)
 		return
 	}
 
@@ -191,6 +489,20 @@ func (h *Handler) handleHTML(w http.ResponseWriter, r *http.Request, files []*Pa
 		status = http.StatusOK
 	}
 
+	if isDatastarRequest {
+		h.timeline.Patch(sd.SessionID, "html", urlPath, section.frontmatter, rendered)
+	}
+	if st := section.frontmatter.Stream; st.Enabled && !head {
+		h.writeStream(w, r, st, status, rendered)
+		return
+	}
+	if h.reload != nil && !isDatastarRequest {
+		rendered = injectReload(rendered, basePath(r))
+	}
+	if section.frontmatter.Cacheable && status == http.StatusOK && (r.Method == http.MethodGet || head) {
+		serveCacheable(w, r, rendered)
+		return
+	}
 	h.debugLog("  html: responding status=%d len=%d", status, len(rendered))
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(status)
@@ -198,7 +510,15 @@ func (h *Handler) handleHTML(w http.ResponseWriter, r *http.Request, files []*Pa
 }
 
 func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*ParsedFile, sess *sessions.Session, sd *SessionData, td TemplateData, urlPath string) {
-	allSections := collectSections(files)
+	if !h.checkSignals(w, files, td) {
+		return
+	}
+	allSections := h.dropServed(sd.SessionID, collectSections(files))
+	if len(allSections) == 0 {
+		h.debugLog("  sse: every section already served once (204)")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
 	section := allSections[0]
 
@@ -217,23 +537,92 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 		section = allSections[pos]
 		h.debugLog("  sse: loop start pos=%d", pos)
 	}
+	selected, bySelect, err := selectedSection(r, allSections, td)
+	if err != nil && td.Section == "" {
+		h.debugLog("  sse: select_by: %v (404)", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	pinned := td.Section != "" || bySelect
+	conditional := !pinned && hasConditions(allSections)
+	matched := true
+	if td.Section == "" && bySelect {
+		pos = selected
+		section = allSections[pos]
+		h.debugLog("  sse: select_by start pos=%d", pos)
+	} else if pinned {
+		var ok bool
+		if pos, ok = pickedSection(allSections, td.Section); !ok {
+			h.debugLog("  sse: no section named %q (500)", td.Section)
+			http.Error(w, fmt.Sprintf("Script error: %s picked section %q, which doesn't exist", scriptFile, td.Section), http.StatusInternalServerError)
+			return
+		}
+		section = allSections[pos]
+		h.debugLog("  sse: picked start pos=%d", pos)
+	} else if conditional {
+		var match int
+		if match, matched = h.firstMatch(allSections, td); matched {
+			pos = match
+			section = allSections[pos]
+			h.debugLog("  sse: condition start pos=%d", pos)
+		}
+	} else if section.isRandom() {
+		pos = pickRandom(allSections)
+		section = allSections[pos]
+		h.debugLog("  sse: random start pos=%d", pos)
+	}
+	h.moveSequences(sd.SessionID, section.frontmatter, urlPath)
+
+	tail := section.frontmatter.Tail
+	if tail.enabled() && !h.checkTail(w, tail, td) {
+		return
+	}
+
+	if !h.applyChaos(w, r, section.frontmatter.Chaos, td) {
+		return
+	}
+
+	// Enforce SSE connection limits before committing to a stream
+	if !h.limiter.Acquire(sd.SessionID, clientIP(r)) {
+		h.debugLog("  sse: connection limit reached (503)")
+		w.Header().Set("Retry-After", "5")
+		h.renderError(w, http.StatusServiceUnavailable, "Too many live connections, please try again shortly.", td)
+		return
+	}
+	defer h.limiter.Release(sd.SessionID, clientIP(r))
+
+	// Streams outlive the server's timeouts
+	clearStreamDeadlines(w)
+
+	// Register the stream so the admin dashboard can list and close it
+	tabID, _ := td.Signals["tab_id"].(string)
+	ctx, closeConn := h.conns.Open(r.Context(), ConnInfo{Transport: "sse", Route: urlPath, SessionID: sd.SessionID, TabID: tabID})
+	defer closeConn()
+	r = r.WithContext(ctx)
 
 	// Create SSE writer (flushes headers — no more cookie changes after this)
 	sse := datastar.NewSSE(w, r)
+	last := &lastPatch{}
+
+	if tail.enabled() {
+		h.debugLog("  sse: tailing %s", tail.source())
+		h.streamTail(r.Context(), sse, allSections[pos:], td)
+		return
+	}
 
 	// Set up NATS subscriptions
 	natsCh := make(chan *nats.Msg, 16)
 	var subs []*nats.Subscription
 
-	sessionSubject := fmt.Sprintf("dspen.session.%s", sd.SessionID)
+	sessionSubject := h.subjects.session(sd.SessionID)
 	if sub, err := h.nc.ChanSubscribe(sessionSubject, natsCh); err == nil {
 		subs = append(subs, sub)
 	} else {
 		log.Printf("NATS subscribe error (session): %v", err)
 	}
 
-	if tabID, ok := td.Signals["tab_id"].(string); ok && tabID != "" {
-		tabSubject := fmt.Sprintf("dspen.tab.%s", tabID)
+	if tabID != "" {
+		tabSubject := h.subjects.tab(tabID)
 		if sub, err := h.nc.ChanSubscribe(tabSubject, natsCh); err == nil {
 			subs = append(subs, sub)
 		} else {
@@ -241,23 +630,34 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 		}
 	}
 
+	for _, name := range hookSubscriptions(files) {
+		if sub, err := h.nc.ChanSubscribe(h.subjects.hook(name), natsCh); err == nil {
+			subs = append(subs, sub)
+		} else {
+			log.Printf("NATS subscribe error (hook %s): %v", name, err)
+		}
+	}
+
 	defer func() {
 		for _, sub := range subs {
 			sub.Unsubscribe()
 		}
 	}()
 
-	// Send the initial response (skip if empty)
-	if section.content != "" {
-		if err := h.sendSSESection(sse, allSections, pos, td); err != nil {
-			log.Printf("Error sending initial response: %v", err)
+	// Send the initial response (skip if empty or no condition matched)
+	if (section.content != "" || section.redirect() != "") && matched {
+		if err := h.sendSSESection(sse, last, allSections, pos, td); err != nil {
+			if !errors.Is(err, errRedirected) {
+				log.Printf("Error sending initial response: %v", err)
+			}
 			return
 		}
 	}
 
 	if loop && interval > 0 {
 		// Looping mode: ticker + NATS
-		ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+		pacer := newLoopPacer(section.frontmatter)
+		ticker := h.clock.NewTicker(pacer.first())
 		defer ticker.Stop()
 
 		loopPos := pos
@@ -277,6 +677,9 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 			case <-r.Context().Done():
 				return
 			case <-ticker.C:
+				if !pacer.steady() {
+					ticker.Reset(pacer.next())
+				}
 				if count > 0 {
 					// Check if current file group's loops are exhausted
 					if groupTicks >= count*groupLen {
@@ -292,7 +695,8 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 						groupTicks = 0
 
 						if nextSection.frontmatter.Interval > 0 {
-							ticker.Reset(time.Duration(nextSection.frontmatter.Interval) * time.Millisecond)
+							pacer = newLoopPacer(nextSection.frontmatter)
+							ticker.Reset(pacer.first())
 						}
 
 						// If next file doesn't loop, send all its sections once and close
@@ -305,15 +709,27 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 								td.SSEMessageCount = messageCount
 								td.LoopCounter = loopCounter
 								td.LoopCounter = loopCounter - 1
-								if err := h.sendSSESection(sse, allSections, nextStart+i, td); err != nil {
+								if err := h.sendSSESection(sse, last, allSections, nextStart+i, td); err != nil {
 									return
 								}
 							}
 							return
 						}
 					}
-					loopPos = groupStart + (groupTicks % groupLen)
+					if allSections[groupStart].isRandom() {
+						loopPos = groupStart + pickRandom(allSections[groupStart:groupStart+groupLen])
+					} else {
+						loopPos = groupStart + (groupTicks % groupLen)
+					}
 					groupTicks++
+				} else if conditional {
+					match, ok := h.firstMatch(allSections, td)
+					if !ok {
+						continue
+					}
+					loopPos = match
+				} else if allSections[loopPos].isRandom() {
+					loopPos = pickRandom(allSections)
 				} else {
 					loopPos++
 					loopPos = loopPos % len(allSections)
@@ -326,49 +742,59 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 				td.LoopCounter = loopCounter
 				td.LoopCounter0 = loopCounter - 1
 
-				if err := h.sendSSESection(sse, allSections, loopPos, td); err != nil {
+				if err := h.sendSSESection(sse, last, allSections, loopPos, td); err != nil {
 					return
 				}
 				messageCount++
 			case msg := <-natsCh:
-				h.mergeNATSSignals(msg.Data, &td)
+				h.applyNATSMessage(msg, &td)
 				td.GlobalHits = h.counters.GetGlobalHits()
 				td.URLHits = h.counters.GetURLHits(urlPath)
 				td.SSEMessageCount = messageCount
 				td.LoopCounter = loopCounter
 				td.LoopCounter0 = loopCounter - 1
 
-				if err := h.sendSSESection(sse, allSections, loopPos, td); err != nil {
+				if conditional {
+					match, ok := h.firstMatch(allSections, td)
+					if !ok {
+						continue
+					}
+					loopPos = match
+				}
+
+				if err := h.sendSSESection(sse, last, allSections, loopPos, td); err != nil {
 					return
 				}
 				messageCount++
 			}
 		}
 	} else {
-		// Sequential mode: send all sections from the beginning with a delay between each.
-		delay := section.frontmatter.Delay
-		if delay <= 0 {
-			delay = 5000 // default 5 seconds
-		}
-
+		// Sequential mode: send all sections from the beginning, each after its delay.
 		messageCount := int64(1)
 		td.SSEMessageCount = messageCount
 		td.LoopCounter = 1
 		td.LoopCounter0 = 0
 
-		for i := 1; i < len(allSections); i++ {
-			select {
-			case <-r.Context().Done():
+		// Random, conditional and scripted selection send only the picked
+		// section, then wait for NATS
+		lastPos := len(allSections) - 1
+		start := 1
+		if section.isRandom() || conditional || pinned {
+			lastPos = pos
+			start = len(allSections)
+		}
+
+		for i := start; i < len(allSections); i++ {
+			if h.clock.Sleep(r.Context(), allSections[i].delay()) != nil {
 				return
-			case <-time.After(time.Duration(delay) * time.Millisecond):
-				messageCount++
-				td.GlobalHits = h.counters.GetGlobalHits()
-				td.URLHits = h.counters.GetURLHits(urlPath)
-				td.SSEMessageCount = messageCount
+			}
+			messageCount++
+			td.GlobalHits = h.counters.GetGlobalHits()
+			td.URLHits = h.counters.GetURLHits(urlPath)
+			td.SSEMessageCount = messageCount
 
-				if err := h.sendSSESection(sse, allSections, i, td); err != nil {
-					return
-				}
+			if err := h.sendSSESection(sse, last, allSections, i, td); err != nil {
+				return
 			}
 		}
 
@@ -378,13 +804,21 @@ func (h *Handler) handleSSE(w http.ResponseWriter, r *http.Request, files []*Par
 			case <-r.Context().Done():
 				return
 			case msg := <-natsCh:
-				h.mergeNATSSignals(msg.Data, &td)
+				h.applyNATSMessage(msg, &td)
 				td.GlobalHits = h.counters.GetGlobalHits()
 				td.URLHits = h.counters.GetURLHits(urlPath)
 				messageCount++
 				td.SSEMessageCount = messageCount
 
-				if err := h.sendSSESection(sse, allSections, len(allSections)-1, td); err != nil {
+				if conditional {
+					match, ok := h.firstMatch(allSections, td)
+					if !ok {
+						continue
+					}
+					lastPos = match
+				}
+
+				if err := h.sendSSESection(sse, last, allSections, lastPos, td); err != nil {
 					return
 				}
 			}
@@ -401,20 +835,48 @@ func (h *Handler) publishSignals(td TemplateData) {
 	}
 
 	// Publish to session subject
-	subject := fmt.Sprintf("dspen.session.%s", td.SessionID)
+	subject := h.subjects.session(td.SessionID)
 	if err := h.nc.Publish(subject, data); err != nil {
 		log.Printf("NATS publish error (session): %v", err)
 	}
 
 	// Publish to tab subject if present
 	if tabID, ok := td.Signals["tab_id"].(string); ok && tabID != "" {
-		subject := fmt.Sprintf("dspen.tab.%s", tabID)
+		subject := h.subjects.tab(tabID)
 		if err := h.nc.Publish(subject, data); err != nil {
 			log.Printf("NATS publish error (tab): %v", err)
 		}
 	}
 }
 
+// applyNATSMessage updates the template data from a message on one of a
+// stream's subjects: a webhook becomes .Hook, anything else carries signals.
+func (h *Handler) applyNATSMessage(msg *nats.Msg, td *TemplateData) {
+	if strings.HasPrefix(msg.Subject, h.subjects.hookPrefix()) {
+		event, err := decodeHookEvent(msg.Data)
+		if err != nil {
+			log.Printf("Webhook message unmarshal error: %v", err)
+			return
+		}
+		td.Hook = event
+		return
+	}
+	h.mergeNATSSignals(msg.Data, td)
+}
+
+// hookSubscriptions lists the webhooks the files subscribe to.
+func hookSubscriptions(files []*ParsedFile) []string {
+	var names []string
+	for _, f := range files {
+		for _, name := range f.Frontmatter.Subscribe {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
 // mergeNATSSignals merges JSON signal data from a NATS message into the template data.
 func (h *Handler) mergeNATSSignals(data []byte, td *TemplateData) {
 	if len(data) == 0 {
@@ -434,24 +896,82 @@ func (h *Handler) mergeNATSSignals(data []byte, td *TemplateData) {
 type sectionEntry struct {
 	content     string
 	frontmatter Frontmatter
-	fileIndex   int // index of the source file in the files slice
+	path        string  // source file, for error reporting
+	line        int     // line in the source file where content starts
+	fileIndex   int     // index of the source file in the files slice
+	weight      float64 // relative weight for select: random
+	options     SectionOptions
+	markdown    bool // render the templated content as Markdown
+}
+
+// datastarVersion is the Datastar release the section targets: its file's
+// datastar_version if set, else the playground's.
+func (s sectionEntry) datastarVersion(playground string) string {
+	if s.frontmatter.DatastarVersion != "" {
+		return s.frontmatter.DatastarVersion
+	}
+	return playground
+}
+
+// defaultDelay separates sequential SSE sections when nothing sets a delay.
+const defaultDelay = 5 * time.Second
+
+// delay is how long sequential mode waits before sending the section: its
+// "=== delay:" option, else its file's delay, else defaultDelay.
+func (s sectionEntry) delay() time.Duration {
+	if s.options.HasDelay {
+		return s.options.Delay
+	}
+	if s.frontmatter.Delay > 0 {
+		return time.Duration(s.frontmatter.Delay) * time.Millisecond
+	}
+	return defaultDelay
 }
 
 // collectSections flattens files and their sections into a linear sequence.
 func collectSections(files []*ParsedFile) []sectionEntry {
 	var entries []sectionEntry
 	for i, f := range files {
-		for _, s := range f.Sections {
-			entries = append(entries, sectionEntry{
-				content:     s,
-				frontmatter: f.Frontmatter,
-				fileIndex:   i,
-			})
+		for j := range f.Sections {
+			entry := newSectionEntry(files, i, j)
+			// The invalid: section only answers requests whose signals fail
+			// the file's schema
+			if f.Frontmatter.Invalid != "" && entry.options.Name == f.Frontmatter.Invalid {
+				continue
+			}
+			entries = append(entries, entry)
 		}
 	}
 	return entries
 }
 
+// newSectionEntry describes section j of files[i].
+func newSectionEntry(files []*ParsedFile, i, j int) sectionEntry {
+	f := files[i]
+	weight := 1.0
+	if j < len(f.Frontmatter.Weights) {
+		weight = f.Frontmatter.Weights[j]
+	}
+	var opts SectionOptions
+	if j < len(f.SectionOptions) {
+		opts = f.SectionOptions[j]
+	}
+	line := 1
+	if j < len(f.SectionLines) {
+		line = f.SectionLines[j]
+	}
+	return sectionEntry{
+		content:     f.Sections[j],
+		frontmatter: f.Frontmatter,
+		path:        f.Path,
+		line:        line,
+		fileIndex:   i,
+		weight:      weight,
+		options:     opts,
+		markdown:    f.Markdown,
+	}
+}
+
 // fileGroupStart returns the index of the first section belonging to the same file as sections[pos].
 func fileGroupStart(sections []sectionEntry, pos int) int {
 	fi := sections[pos].fileIndex
@@ -475,7 +995,27 @@ func fileGroupLen(sections []sectionEntry, start int) int {
 	return length
 }
 
-func (h *Handler) sendSSESection(sse *datastar.ServerSentEventGenerator, sections []sectionEntry, pos int, td TemplateData) error {
+// lastPatch remembers the last patch a stream sent, so sections with
+// dedupe: true can skip re-sending identical output every tick.
+type lastPatch struct {
+	sum  [sha256.Size]byte
+	sent bool
+}
+
+// repeat records the patch and reports whether the section can skip it
+// because it matches the previous one. A nil lastPatch never skips.
+func (l *lastPatch) repeat(section sectionEntry, rendered string) bool {
+	if l == nil {
+		return false
+	}
+	fm := section.frontmatter
+	sum := sha256.Sum256([]byte(fm.Selector + "\x00" + fm.Mode + "\x00" + rendered))
+	same := l.sent && sum == l.sum
+	l.sum, l.sent = sum, true
+	return same && fm.Dedupe
+}
+
+func (h *Handler) sendSSESection(sse *datastar.ServerSentEventGenerator, last *lastPatch, sections []sectionEntry, pos int, td TemplateData) error {
 	if pos >= len(sections) {
 		pos = len(sections) - 1
 	}
@@ -487,58 +1027,82 @@ func (h *Handler) sendSSESection(sse *datastar.ServerSentEventGenerator, section
 		return nil
 	}
 
+	// A once: section is skipped on later passes through the stream
+	if !h.claimOnce(td.SessionID, section) {
+		return nil
+	}
+
+	chaos := section.frontmatter.Chaos
+	chaos.delay(sse.Context())
+	if chaos.shouldDrop() {
+		h.debugLog("  chaos: dropping SSE stream")
+		return errChaosDrop
+	}
+
 	h.debugLog("  template data: GlobalHits=%d URLHits=%d SessionURLHits=%d Username=%q SessionID=%q URL=%q Method=%q Signals=%v SSEMessageCount=%d LoopCounter=%d",
 		td.GlobalHits, td.URLHits, td.SessionURLHits, td.Username, td.SessionID, td.URL, td.Method, td.Signals, td.SSEMessageCount, td.LoopCounter)
-	rendered, err := renderTemplate(section.content, td)
+	rendered, err := h.render(section, td)
 	if err != nil {
 		log.Printf("Template render error: %v", err)
+		var te *TemplateError
+		if h.dev && errors.As(err, &te) {
+			// Keep the stream open so later updates can still render
+			return sendOverlay(sse, dialectFor(section.datastarVersion(td.DatastarVersion)), te)
+		}
 		return err
 	}
-
-	var opts []datastar.PatchElementOption
-	if section.frontmatter.ViewTransitions {
-		opts = append(opts, datastar.WithViewTransitions())
+	if last.repeat(section, rendered) {
+		h.debugLog("  dedupe: output unchanged, skipping patch")
+		return nil
 	}
 
-	if section.frontmatter.Selector != "" {
-		opts = append(opts, datastar.WithSelector(section.frontmatter.Selector))
+	fm := section.frontmatter
+	dialect := dialectFor(section.datastarVersion(td.DatastarVersion))
+	if rendered != "" || section.redirect() == "" {
+		if err := patchElements(sse, dialect, rendered, patchSpec{
+			Selector:        fm.Selector,
+			Mode:            fm.Mode,
+			Namespace:       fm.Namespace,
+			ViewTransitions: fm.ViewTransitions,
+		}); err != nil {
+			return err
+		}
+		countMessage(sse.Context())
+		h.timeline.Patch(td.SessionID, "sse", td.URL, fm, rendered)
 	}
 
-	switch section.frontmatter.Mode {
-	case "outer":
-		opts = append(opts, datastar.WithModeOuter())
-	case "inner":
-		opts = append(opts, datastar.WithModeInner())
-	case "replace":
-		opts = append(opts, datastar.WithModeReplace())
-	case "prepend":
-		opts = append(opts, datastar.WithModePrepend())
-	case "append":
-		opts = append(opts, datastar.WithModeAppend())
-	case "before":
-		opts = append(opts, datastar.WithModeBefore())
-	case "after":
-		opts = append(opts, datastar.WithModeAfter())
-	case "remove":
-		opts = append(opts, datastar.WithModeRemove())
+	if section.redirect() != "" {
+		target, err := h.redirectTarget(section, td)
+		if err != nil {
+			return err
+		}
+		h.debugLog("  sse: redirect %s", target)
+		if err := sendRedirect(sse, dialect, target); err != nil {
+			return err
+		}
+		return errRedirected
 	}
+	return nil
+}
 
-	switch section.frontmatter.Namespace {
-	case "mathml":
-		opts = append(opts, datastar.WithNamespace(datastar.NamespaceMathML))
-	case "html":
-		opts = append(opts, datastar.WithNamespace(datastar.NamespaceHTML))
-	case "svg":
-		opts = append(opts, datastar.WithNamespace(datastar.NamespaceSVG))
-	default:
-		return fmt.Errorf("unsupported namespace: %s", section.frontmatter.Namespace)
+// serveCacheable sends a cacheable: response with an ETag of its body, so
+// a browser revalidating with If-None-Match gets 304 Not Modified when the
+// page renders the same.
+func serveCacheable(w http.ResponseWriter, r *http.Request, rendered string) {
+	sum := sha256.Sum256([]byte(rendered))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache") // store, but revalidate every time
 	}
-
-	return sse.PatchElements(rendered, opts...)
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(rendered))
 }
 
-func renderTemplate(content string, td TemplateData) (string, error) {
-	tmpl, err := template.New("page").Funcs(sprig.FuncMap()).Parse(content)
+// renderTemplate executes content with td, reusing a cached parse when
+// cache has one. In strict mode, referencing a missing map key (e.g. an unset
+// signal) is an error instead of "<no value>".
+func renderTemplate(cache *templateCache, content string, td TemplateData, funcs template.FuncMap, strict bool) (string, error) {
+	tmpl, err := cache.parse(content, funcs, strict)
 	if err != nil {
 		return "", fmt.Errorf("parsing template: %w", err)
 	}
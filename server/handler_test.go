@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// newTestServer serves files through NewTestHandler and returns a client
+// that keeps the session cookie between requests.
+func newTestServer(t *testing.T, files fstest.MapFS) (*httptest.Server, *http.Client) {
+	t.Helper()
+	return newTestServerWith(t, files, TestHandlerOptions{})
+}
+
+// newTestServerWith is newTestServer with handler options.
+func newTestServerWith(t *testing.T, files fstest.MapFS, opts TestHandlerOptions) (*httptest.Server, *http.Client) {
+	t.Helper()
+	h, err := NewTestHandler(files, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	jar, _ := cookiejar.New(nil)
+	return srv, &http.Client{Jar: jar}
+}
+
+func get(t *testing.T, c *http.Client, u string) (int, string) {
+	t.Helper()
+	res, err := c.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body)
+}
+
+func TestServeHTML(t *testing.T) {
+	srv, c := newTestServer(t, fstest.MapFS{
+		"index.html":       {Data: []byte("<h1>Home {{.URL}}</h1>")},
+		"todos/index.html": {Data: []byte("---\nstatus: 201\n---\n<ul>{{range list 1 2}}<li>{{.}}</li>{{end}}</ul>")},
+		"form/post.html":   {Data: []byte("posted")},
+	})
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/", http.StatusOK, "<h1>Home /</h1>"},
+		{"/todos/", http.StatusCreated, "<ul><li>1</li><li>2</li></ul>"},
+		{"/todos", http.StatusCreated, "<ul><li>1</li><li>2</li></ul>"},
+		{"/missing/", http.StatusNotFound, "404 page not found\n"},
+		{"/form/", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		status, body := get(t, c, srv.URL+tt.path)
+		if status != tt.wantStatus || tt.wantBody != "" && body != tt.wantBody {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, status, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestSignalRoundTrip(t *testing.T) {
+	srv, c := newTestServer(t, fstest.MapFS{
+		"echo/sse.html":      {Data: []byte(`<p id="echo">got {{.Signals.message}}</p>`)},
+		"echo/post_sse.html": {Data: []byte(`<p id="echo">posted {{.Signals.message}} by {{.Username}}</p>`)},
+	})
+	signals := `{"message":"hello"}`
+	tests := []struct {
+		method string
+		url    string
+		body   string
+		want   string
+	}{
+		{http.MethodGet, srv.URL + "/echo/?datastar=" + url.QueryEscape(signals), "", `elements <p id="echo">got hello</p>`},
+		{http.MethodPost, srv.URL + "/echo/", signals, `elements <p id="echo">posted hello by `},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, _ := http.NewRequestWithContext(ctx, tt.method, tt.url, strings.NewReader(tt.body))
+		req.Header.Set("Datastar-Request", "true")
+		req.Header.Set("Content-Type", "application/json")
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("%s %s Content-Type = %q, want text/event-stream", tt.method, tt.url, ct)
+		}
+		events, err := ReadSSEEvents(res.Body, 1)
+		res.Body.Close()
+		cancel()
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.url, err)
+		}
+		if e := events[0]; e.Event != "datastar-patch-elements" || len(e.Data) != 1 || !strings.HasPrefix(e.Data[0], tt.want) {
+			t.Errorf("%s %s sent\n%s\nwant a patch starting %q", tt.method, tt.url, e, tt.want)
+		}
+	}
+}
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  []string // bodies of successive requests from one session
+	}{
+		{
+			name:  "sections stay on the last",
+			files: fstest.MapFS{"index.html": {Data: []byte("one\n===\ntwo")}},
+			want:  []string{"one", "two", "two"},
+		},
+		{
+			name:  "looping sections",
+			files: fstest.MapFS{"index.html": {Data: []byte("---\nloop: true\n---\none\n===\ntwo")}},
+			want:  []string{"one", "two", "one"},
+		},
+		{
+			name: "numbered files",
+			files: fstest.MapFS{
+				"index_001.html": {Data: []byte("first")},
+				"index_002.html": {Data: []byte("second")},
+			},
+			want: []string{"first", "second", "second"},
+		},
+		{
+			name:  "once section drops out",
+			files: fstest.MapFS{"index.html": {Data: []byte("---\nloop: true\n---\n=== once: true\nwelcome\n===\none\n===\ntwo")}},
+			want:  []string{"welcome", "one", "two", "one", "two"},
+		},
+		{
+			name:  "all sections once",
+			files: fstest.MapFS{"index.html": {Data: []byte("---\nonce: true\n---\nhello\n===\nagain")}},
+			want:  []string{"hello", "again", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, c := newTestServer(t, tt.files)
+			for i, want := range tt.want {
+				if _, body := get(t, c, srv.URL+"/"); body != want {
+					t.Errorf("request %d = %q, want %q", i+1, body, want)
+				}
+			}
+			// A new session starts from the beginning
+			if _, body := get(t, http.DefaultClient, srv.URL+"/"); body != tt.want[0] {
+				t.Errorf("new session got %q, want %q", body, tt.want[0])
+			}
+		})
+	}
+}
+
+func TestProxyDropsCredentials(t *testing.T) {
+	seen := make(chan *http.Request, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r
+		io.WriteString(w, "upstream")
+	}))
+	t.Cleanup(upstream.Close)
+
+	h, closer, err := New(Config{
+		FS:            fstest.MapFS{"api/index.html": {Data: []byte("---\nproxy: " + upstream.URL + "\n---\n")}},
+		SessionSecret: "dsplay-test-secret",
+		FetchAllow:    []string{"127.0.0.1"},
+		Protect:       Protection{Tokens: []string{"visitor-secret"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closer.Close() })
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/?q=go&token=visitor-secret&session=demo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer visitor-secret")
+	req.Header.Set(sessionTokenHeader, "demo")
+	req.AddCookie(&http.Cookie{Name: protectTokenCookie, Value: "visitor-secret"})
+	req.AddCookie(&http.Cookie{Name: adminTokenCookie, Value: "admin-secret"})
+	req.AddCookie(&http.Cookie{Name: defaultSessionName, Value: "session"})
+	req.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "upstream" {
+		t.Fatalf("GET /api/ = %d %q, want 200 %q", res.StatusCode, body, "upstream")
+	}
+
+	got := <-seen
+	for _, name := range []string{"Authorization", sessionTokenHeader} {
+		if v := got.Header.Get(name); v != "" {
+			t.Errorf("upstream got %s %q", name, v)
+		}
+	}
+	if got.URL.RawQuery != "q=go" {
+		t.Errorf("upstream got query %q, want q=go", got.URL.RawQuery)
+	}
+	for _, name := range []string{protectTokenCookie, adminTokenCookie, defaultSessionName} {
+		if c, err := got.Cookie(name); err == nil {
+			t.Errorf("upstream got cookie %s=%q", c.Name, c.Value)
+		}
+	}
+	if c, err := got.Cookie("theme"); err != nil || c.Value != "dark" {
+		t.Errorf("upstream lost the visitor's own cookie: %v", err)
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	srv, _ := newTestServer(t, fstest.MapFS{
+		"live/ws.html": {Data: []byte(`<p id="live">hello</p>`)},
+	})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/live/"
+	for origin, wantOK := range map[string]bool{
+		"":                    true, // not a browser
+		srv.URL:               true,
+		"http://evil.example": false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		c, res, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{HTTPHeader: header})
+		if wantOK {
+			if err != nil {
+				t.Errorf("Origin %q: %v, want a connection", origin, err)
+			} else if _, msg, err := c.Read(ctx); err != nil || !strings.Contains(string(msg), "hello") {
+				t.Errorf("Origin %q: read %q, %v; want the section", origin, msg, err)
+			}
+		} else if err == nil || res == nil || res.StatusCode != http.StatusForbidden {
+			t.Errorf("Origin %q: %v, want 403", origin, err)
+		}
+		if c != nil {
+			c.CloseNow()
+		}
+		cancel()
+	}
+}
+
+func TestPrefsForm(t *testing.T) {
+	srv, c := newTestServer(t, fstest.MapFS{
+		"index.html": {Data: []byte(`{{range $k, $v := prefs}}{{$k}}={{$v}};{{end}}`)},
+	})
+	// A first-time visitor's preferences stick, without the CSRF field
+	res, err := c.PostForm(srv.URL+"/_prefs", url.Values{"theme": {"dark"}, "csrf_token": {"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if status, body := get(t, c, srv.URL+"/"); status != http.StatusOK || body != "theme=dark;" {
+		t.Errorf("GET / after POST /_prefs = %d %q, want theme=dark;", status, body)
+	}
+}
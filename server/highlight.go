@@ -0,0 +1,63 @@
+package server
+
+import (
+	"html/template"
+	"path"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// sourceStyle is the source viewer's chroma style, dark like its page.
+var sourceStyle = styles.Get("github-dark")
+
+// sourceFormatter marks tokens up with classes styled by sourceCSS and
+// numbers every line, anchored as #L<n> so sourceURL can link to it.
+var sourceFormatter = html.New(
+	html.WithClasses(true),
+	html.WithLineNumbers(true),
+	html.WithLinkableLineNumbers(true, "L"),
+	html.TabWidth(4),
+)
+
+// sourceCSS styles sourceFormatter's classes.
+var sourceCSS = func() template.CSS {
+	var b strings.Builder
+	if err := sourceFormatter.WriteCSS(&b, sourceStyle); err != nil {
+		panic(err)
+	}
+	return template.CSS(b.String())
+}()
+
+// sourceLexer picks the lexer for a playground file. Templates are HTML
+// with Go template actions; anything else is matched by file name, then
+// by content.
+func sourceLexer(name, src string) chroma.Lexer {
+	var l chroma.Lexer
+	if path.Ext(name) == ".html" {
+		l = lexers.Get("go-html-template")
+	} else if l = lexers.Match(name); l == nil {
+		l = lexers.Analyse(src)
+	}
+	if l == nil {
+		l = lexers.Fallback
+	}
+	return chroma.Coalesce(l)
+}
+
+// highlight renders a playground file as highlighted HTML for the source
+// viewer.
+func highlight(name, src string) (template.HTML, error) {
+	tokens, err := sourceLexer(name, src).Tokenise(nil, src)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := sourceFormatter.Format(&b, sourceStyle, tokens); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
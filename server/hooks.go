@@ -0,0 +1,131 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	hooksPathPrefix = "/_hooks"
+
+	// maxHookBytes caps the size of a webhook body.
+	maxHookBytes = 1 << 20
+)
+
+// Hook configures a webhook endpoint at /_hooks/<name>, declared under
+// hooks: in dsplay.yaml.
+type Hook struct {
+	// Secret, when set, is checked against a GitHub-style
+	// X-Hub-Signature-256 header (an HMAC-SHA256 of the body).
+	Secret string `yaml:"secret"`
+}
+
+// Hooks are webhook endpoints by name.
+type Hooks map[string]Hook
+
+// HookEvent is a received webhook, as templates see it in .Hook.
+type HookEvent struct {
+	Name    string            `json:"name"`
+	Time    time.Time         `json:"time"`
+	Headers map[string]string `json:"headers"`
+	Body    any               `json:"body"` // decoded JSON, or the raw text for other content types
+}
+
+// HookHandler accepts webhooks and publishes each one on NATS, where SSE
+// routes with subscribe: in their frontmatter pick it up.
+type HookHandler struct {
+	hooks    Hooks
+	nc       *nats.Conn
+	clock    *Clock
+	subjects subjects
+}
+
+func NewHookHandler(hooks Hooks, nc *nats.Conn, clock *Clock) *HookHandler {
+	return &HookHandler{hooks: hooks, nc: nc, clock: clock}
+}
+
+// Routes mounts POST /_hooks/{name} on r.
+func (hh *HookHandler) Routes(r chi.Router) {
+	r.Post(hooksPathPrefix+"/{name}", hh.receive)
+}
+
+func (hh *HookHandler) receive(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	hook, ok := hh.hooks[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHookBytes+1))
+	if err != nil {
+		http.Error(w, "Error reading body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHookBytes {
+		http.Error(w, "Webhook body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if hook.Secret != "" && !validSignature(hook.Secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event := HookEvent{Name: name, Time: hh.clock.Now(), Headers: map[string]string{}, Body: string(body)}
+	for key := range r.Header {
+		event.Headers[key] = r.Header.Get(key)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var decoded any
+		if err := json.Unmarshal(body, &decoded); err == nil {
+			event.Body = decoded
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := hh.nc.Publish(hh.subjects.hook(name), data); err != nil {
+		log.Printf("NATS publish error (hook %s): %v", name, err)
+		http.Error(w, "Error publishing webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validSignature checks a "sha256=<hex>" HMAC of body made with secret.
+func validSignature(secret string, body []byte, header string) bool {
+	got, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// decodeHookEvent reads a webhook published by HookHandler.
+func decodeHookEvent(data []byte) (*HookEvent, error) {
+	var event HookEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
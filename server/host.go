@@ -0,0 +1,367 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"golang.org/x/sync/singleflight"
+)
+
+// hostRescan is how often a request for an unknown prefix rescans the root,
+// so playgrounds added while the host runs are picked up.
+const hostRescan = 2 * time.Second
+
+// HostConfig describes a server hosting many playgrounds, one per directory
+// under Root, each served at its path: /alice/demo1/ for Root/alice/demo1.
+type HostConfig struct {
+	Root string
+
+	// Listen configures the shared listener and every playground under
+	// Root. A playground's own dsplay.yaml may only add globals: the host
+	// operator, not each directory's owner, decides what playgrounds can
+	// reach (environment, network, files, hooks) and who may see them.
+	Listen Config
+
+	// Gists, when set, serves any gist playground at <gist id>.<GistHost>
+	// by loading it into a new temporary directory on first use. Each gist
+	// gets an origin of its own, so its scripts can't reach the host's
+	// playgrounds or other gists; /g/<gist id>/ redirects there. Gist
+	// playgrounds run sandboxed, with Listen's settings minus access to
+	// files, commands, the environment and the network.
+	Gists         func(ctx context.Context, id string) (dir string, err error)
+	GistHost      string        // parent host of the gist origins, e.g. "gists.example.com" or "localhost:8080"
+	GistTTL       time.Duration // unload a gist unused this long (default 30m)
+	GistMax       int           // gists loaded at once; the least recently used goes first (default 20)
+	GistFetchRate float64       // gist loads per client IP per minute (default 10)
+}
+
+// Host serves each playground under the root with an engine of its own, so
+// counters, sessions and NATS subjects never cross between them. Engines
+// start on a playground's first request.
+type Host struct {
+	cfg  HostConfig
+	base string
+
+	mu          sync.Mutex
+	dirs        map[string]string  // prefix → playground directory
+	engines     map[string]*engine // by prefix, once started
+	engineStart singleflight.Group // engines starting, by prefix
+	scanned     time.Time
+
+	gistMu      sync.Mutex
+	gists       map[string]*gistSite // by gist ID
+	gistLoads   singleflight.Group   // gist loads in progress, by ID
+	gistLimiter *RateLimiter
+	gistNS      *natsserver.Server // shared by every gist; nil with Listen.NATSURL
+	gistNC      *nats.Conn
+	stop        context.CancelFunc // stops the gist sweeper
+}
+
+// NewHost scans cfg.Root for playgrounds.
+func NewHost(cfg HostConfig) (*Host, error) {
+	h := &Host{cfg: cfg, base: cleanBasePath(cfg.Listen.BasePath), engines: make(map[string]*engine), stop: func() {}}
+	if err := h.scan(); err != nil {
+		return nil, err
+	}
+	if cfg.Gists != nil {
+		if cfg.GistHost == "" {
+			return nil, errors.New("serving gists needs a gist host for their origins, e.g. gists.example.com")
+		}
+		ns, nc, err := connectNATS(cfg.Listen.NATSURL, false, "")
+		if err != nil {
+			return nil, fmt.Errorf("starting nats: %w", err)
+		}
+		h.gistNS, h.gistNC = ns, nc
+		rate := cfg.GistFetchRate
+		if rate <= 0 {
+			rate = defaultGistFetchRate
+		}
+		h.gists = make(map[string]*gistSite)
+		h.gistLimiter = NewRateLimiter(rate/60, 0, int(rate))
+		var ctx context.Context
+		ctx, h.stop = context.WithCancel(context.Background())
+		go h.sweepGists(ctx)
+	}
+	return h, nil
+}
+
+// isPlayground reports whether dir holds a playground: its settings, its
+// manifest, or a home page.
+func isPlayground(dir string) bool {
+	for _, name := range []string{ConfigFileName, manifestFile, "index.html", "index.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// scan maps each playground directory under the root to its prefix. The
+// walk stops at a playground, since the directories inside it are routes.
+func (h *Host) scan() error {
+	dirs := make(map[string]string)
+	if h.cfg.Root == "" {
+		h.mu.Lock()
+		h.dirs, h.scanned = dirs, time.Now()
+		h.mu.Unlock()
+		return nil
+	}
+	err := filepath.WalkDir(h.cfg.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == h.cfg.Root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_") {
+			return fs.SkipDir
+		}
+		if !isPlayground(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(h.cfg.Root, path)
+		if err != nil {
+			return err
+		}
+		dirs["/"+filepath.ToSlash(rel)] = path
+		return fs.SkipDir
+	})
+	if err != nil {
+		return fmt.Errorf("scanning %s: %w", h.cfg.Root, err)
+	}
+	h.mu.Lock()
+	h.dirs, h.scanned = dirs, time.Now()
+	h.mu.Unlock()
+	return nil
+}
+
+// prefixFor returns the playground prefix urlPath falls under, the longest
+// when playgrounds nest.
+func (h *Host) prefixFor(urlPath string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	best := ""
+	for prefix := range h.dirs {
+		if (urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return best, best != ""
+}
+
+// engineFor returns the running engine for prefix, starting it if needed.
+// Engines start outside mu, so a playground starting up never holds up the
+// others, and concurrent first requests share one start.
+func (h *Host) engineFor(prefix string) (*engine, error) {
+	if e, ok := h.runningEngine(prefix); ok {
+		return e, nil
+	}
+	v, err, _ := h.engineStart.Do(prefix, func() (any, error) {
+		if e, ok := h.runningEngine(prefix); ok {
+			return e, nil // started while this request waited
+		}
+		return h.startEngine(prefix)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*engine), nil
+}
+
+func (h *Host) runningEngine(prefix string) (*engine, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.engines[prefix]
+	return e, ok
+}
+
+func (h *Host) startEngine(prefix string) (*engine, error) {
+	h.mu.Lock()
+	dir := h.dirs[prefix]
+	h.mu.Unlock()
+	cfg, err := h.tenantConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.BasePath = h.base + prefix
+	// Each playground's sessions live in their own cookie
+	if cfg.SessionCookie == "" {
+		cfg.SessionCookie = defaultSessionName
+	}
+	cfg.SessionCookie += "-" + strings.ReplaceAll(strings.Trim(prefix, "/"), "/", "-")
+	e, err := newEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.engines == nil {
+		e.Close()
+		return nil, errHostClosed
+	}
+	log.Printf("Started playground %s from %s", cfg.BasePath+"/", dir)
+	h.engines[prefix] = e
+	return e, nil
+}
+
+var errHostClosed = errors.New("host is shutting down")
+
+// tenantConfig is the configuration for the playground in dir: the
+// operator's, plus the globals from the playground's dsplay.yaml. The
+// operator's globals win.
+func (h *Host) tenantConfig(dir string) (Config, error) {
+	cfg := h.cfg.Listen
+	cfg.PlaygroundsDir = dir
+	fileCfg, err := LoadConfigFile(filepath.Join(dir, ConfigFileName))
+	if err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", ConfigFileName, err)
+	}
+	if len(fileCfg.Globals) > 0 {
+		globals := maps.Clone(fileCfg.Globals)
+		maps.Copy(globals, cfg.Globals)
+		cfg.Globals = globals
+	}
+	return cfg, nil
+}
+
+func (h *Host) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Path
+	if h.base != "" {
+		if !strings.HasPrefix(urlPath+"/", h.base+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		urlPath = strings.TrimPrefix(urlPath, h.base)
+	}
+	if h.cfg.Gists != nil {
+		if id, ok := h.gistOrigin(r.Host); ok {
+			h.serveGist(w, r, id)
+			return
+		}
+		if strings.HasPrefix(urlPath, gistPrefix+"/") {
+			h.redirectGist(w, r, urlPath)
+			return
+		}
+	}
+	if urlPath == "" || urlPath == "/" {
+		h.serveIndex(w, r)
+		return
+	}
+
+	prefix, ok := h.prefixFor(urlPath)
+	if !ok {
+		h.mu.Lock()
+		stale := time.Since(h.scanned) > hostRescan
+		h.mu.Unlock()
+		if stale {
+			if err := h.scan(); err != nil {
+				log.Printf("Host: %v", err)
+			}
+			prefix, ok = h.prefixFor(urlPath)
+		}
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	e, err := h.engineFor(prefix)
+	if err != nil {
+		log.Printf("Host: starting %s: %v", prefix, err)
+		http.Error(w, "Playground failed to start: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	e.ServeHTTP(w, r)
+}
+
+var hostIndexTemplate = template.Must(template.New("host").Parse(`<!doctype html>
+<html>
+<head><meta charset="utf-8"><title>Playgrounds</title></head>
+<body>
+<h1>Playgrounds</h1>
+{{if .}}<ul>
+{{range .}}<li><a href="{{.}}/">{{.}}/</a></li>
+{{end}}</ul>{{else}}<p>No playgrounds yet.</p>{{end}}
+</body>
+</html>
+`))
+
+// serveIndex lists the hosted playgrounds.
+func (h *Host) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if err := h.scan(); err != nil {
+		log.Printf("Host: %v", err)
+	}
+	h.mu.Lock()
+	links := make([]string, 0, len(h.dirs))
+	for prefix := range h.dirs {
+		links = append(links, h.base+prefix)
+	}
+	h.mu.Unlock()
+	slices.Sort(links)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	hostIndexTemplate.Execute(w, links)
+}
+
+// Close stops every running playground and unloads any gists.
+func (h *Host) Close() error {
+	h.stop()
+	h.gistMu.Lock()
+	h.evictGists(func(*gistSite) bool { return true }, false)
+	h.gistMu.Unlock()
+	if h.gistNC != nil {
+		h.gistNC.Close()
+		h.gistNS.Shutdown()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range h.engines {
+		e.Close()
+	}
+	h.engines = nil
+	return nil
+}
+
+// RunHost serves the playgrounds under cfg.Root until the listener fails.
+func RunHost(cfg HostConfig) error {
+	h, err := NewHost(cfg)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	l, err := listen(cfg.Listen)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	if cfg.Root != "" {
+		log.Printf("Hosting %d playgrounds from: %s", len(h.dirs), cfg.Root)
+	}
+	if cfg.Gists != nil {
+		log.Printf("Serving gist playgrounds at <gist id>.%s%s/", cfg.GistHost, h.base)
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: cfg.Listen.ReadTimeout,
+		ReadTimeout:       cfg.Listen.ReadTimeout,
+		WriteTimeout:      cfg.Listen.WriteTimeout,
+		IdleTimeout:       cfg.Listen.IdleTimeout,
+	}
+	return srv.Serve(l)
+}
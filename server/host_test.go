@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestHost serves the playgrounds in files, a map of paths under the
+// root to contents, with listen as the operator's settings.
+func newTestHost(t *testing.T, files map[string]string, listen Config) (*Host, *httptest.Server) {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if listen.SessionSecret == "" {
+		listen.SessionSecret = "dsplay-test-secret"
+	}
+	listen.JobWorkers = 1
+	h, err := NewHost(HostConfig{Root: root, Listen: listen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return h, srv
+}
+
+// TestHostRoutes checks that each playground sees paths without its prefix.
+func TestHostRoutes(t *testing.T) {
+	_, srv := newTestHost(t, map[string]string{
+		"alice/demo/index.html":       "alice {{.URL}}",
+		"alice/demo/todos/index.html": "todos {{.URL}}",
+		"bob/index.html":              "bob {{.URL}}",
+		"bob/nested/index.html":       "bob's route",
+	}, Config{})
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/alice/demo/", http.StatusOK, "alice /"},
+		{"/alice/demo/todos/", http.StatusOK, "todos /todos/"},
+		{"/bob/", http.StatusOK, "bob /"},
+		{"/bob/nested/", http.StatusOK, "bob's route"},
+		{"/alice/", http.StatusNotFound, ""},
+		{"/carol/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		status, body := get(t, http.DefaultClient, srv.URL+tt.path)
+		if status != tt.wantStatus || tt.wantBody != "" && body != tt.wantBody {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, status, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+	if _, body := get(t, http.DefaultClient, srv.URL+"/"); !strings.Contains(body, `href="/alice/demo/"`) || !strings.Contains(body, `href="/bob/"`) {
+		t.Errorf("index = %q, want links to both playgrounds", body)
+	}
+}
+
+// TestHostTenantConfig checks that a playground's dsplay.yaml can't grant
+// itself what only the operator may.
+func TestHostTenantConfig(t *testing.T) {
+	t.Setenv("HOST_TEST_SECRET", "hunter2")
+	_, srv := newTestHost(t, map[string]string{
+		"alice/dsplay.yaml": "env: [HOST_TEST_SECRET]\n" +
+			"protect:\n  tokens: [alice]\n" +
+			"globals:\n  title: Alice's\n  owner: alice\n",
+		"alice/index.html":     "{{.Globals.title}} by {{.Globals.owner}}",
+		"alice/env/index.html": `{{env "HOST_TEST_SECRET"}}`,
+	}, Config{Globals: map[string]any{"title": "Hosted"}})
+
+	if status, body := get(t, http.DefaultClient, srv.URL+"/alice/"); status != http.StatusOK || body != "Hosted by alice" {
+		t.Errorf("GET /alice/ = %d %q, want 200 with the operator's title and the playground's owner", status, body)
+	}
+	if status, body := get(t, http.DefaultClient, srv.URL+"/alice/env/"); status == http.StatusOK || strings.Contains(body, "hunter2") {
+		t.Errorf("GET /alice/env/ = %d %q, want the variable kept from the playground", status, body)
+	}
+}
+
+func TestHostSessionsPerPlayground(t *testing.T) {
+	_, srv := newTestHost(t, map[string]string{
+		"a/index.html": "a",
+		"b/index.html": "b",
+	}, Config{})
+	for _, prefix := range []string{"/a", "/b"} {
+		res, err := http.Get(srv.URL + prefix + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if len(res.Cookies()) == 0 {
+			t.Errorf("GET %s/ sets no session cookie", prefix)
+		}
+		for _, c := range res.Cookies() {
+			if c.Name != defaultSessionName+"-"+prefix[1:] || c.Path != prefix {
+				t.Errorf("GET %s/ sets cookie %s on %s, want %s-%s on %s", prefix, c.Name, c.Path, defaultSessionName, prefix[1:], prefix)
+			}
+		}
+	}
+}
+
+func TestHostConcurrentStart(t *testing.T) {
+	h, srv := newTestHost(t, map[string]string{"demo/index.html": "demo"}, Config{})
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Get(srv.URL + "/demo/")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("GET /demo/ = %d, want 200", res.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.engines) != 1 {
+		t.Errorf("%d engines running, want 1", len(h.engines))
+	}
+}
+
+// newGistHost serves gists from gists.test, each loaded from page.
+func newGistHost(t *testing.T, page string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var loads atomic.Int32
+	h, err := NewHost(HostConfig{
+		Listen:   Config{SessionSecret: "dsplay-test-secret", JobWorkers: 1},
+		GistHost: "gists.test",
+		Gists: func(ctx context.Context, id string) (string, error) {
+			loads.Add(1)
+			dir, err := os.MkdirTemp("", "dsplay-gist-test-")
+			if err != nil {
+				return "", err
+			}
+			return dir, os.WriteFile(filepath.Join(dir, "index.html"), []byte(page), 0o644)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, &loads
+}
+
+func getHost(t *testing.T, srv *httptest.Server, host, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = host
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res
+}
+
+func TestHostGistOrigins(t *testing.T) {
+	srv, loads := newGistHost(t, "gist {{.URL}}")
+
+	res := getHost(t, srv, "abc123.gists.test", "/")
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET abc123.gists.test/ = %d, want 200", res.StatusCode)
+	}
+	if csp := res.Header.Get("Content-Security-Policy"); !strings.HasPrefix(csp, "sandbox ") {
+		t.Errorf("gist Content-Security-Policy = %q, want a sandbox", csp)
+	}
+	for _, c := range res.Cookies() {
+		if c.Path != "/" || c.Domain != "" {
+			t.Errorf("gist sets cookie %s for %q%s, want its own host only", c.Name, c.Domain, c.Path)
+		}
+	}
+
+	res = getHost(t, srv, "play.test", "/g/abc123/todos/?x=1")
+	if loc := res.Header.Get("Location"); res.StatusCode != http.StatusFound || loc != "//abc123.gists.test/todos/?x=1" {
+		t.Errorf("GET /g/abc123/todos/ = %d to %q, want a redirect to the gist's origin", res.StatusCode, loc)
+	}
+	for _, host := range []string{"not-hex.gists.test", "abc123.gists.test.evil", "abc123.other.test"} {
+		if res := getHost(t, srv, host, "/"); res.Header.Get("Content-Security-Policy") != "" {
+			t.Errorf("GET %s/ served a gist, want the host's own index", host)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("%d gist loads, want 1", n)
+	}
+}
+
+func TestHostGistConcurrentLoad(t *testing.T) {
+	srv, loads := newGistHost(t, "gist")
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/", nil)
+			req.Host = "def456.gists.test"
+			res, err := http.DefaultTransport.RoundTrip(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("GET def456.gists.test/ = %d, want 200", res.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("%d gist loads for concurrent first requests, want 1", n)
+	}
+}
+
+func TestHostGistsNeedHost(t *testing.T) {
+	_, err := NewHost(HostConfig{Gists: func(context.Context, string) (string, error) { return "", nil }})
+	if err == nil {
+		t.Error("NewHost with gists and no gist host succeeded")
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	gistPrefix           = "/g"
+	defaultGistTTL       = 30 * time.Minute
+	defaultGistMax       = 20
+	defaultGistFetchRate = 10 // per client IP per minute
+	gistFetchTimeout     = 30 * time.Second
+)
+
+// gistIDPattern matches gist IDs, which are hex.
+var gistIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{5,64}$`)
+
+// gistSite is a gist playground loaded on demand by a Host.
+type gistSite struct {
+	dir      string
+	engine   *engine
+	lastUsed time.Time
+}
+
+// gistSandbox is the Content-Security-Policy sent with gist responses. The
+// page keeps its scripts and its own origin, which is the gist's alone, but
+// can't navigate the top window or open the host's pages unsandboxed.
+const gistSandbox = "sandbox allow-scripts allow-same-origin allow-forms allow-modals allow-popups allow-downloads"
+
+// sandboxed strips a configuration of everything an untrusted playground
+// shouldn't reach: host files and commands, environment variables, the
+// network, webhooks and the admin and debug endpoints.
+func sandboxed(cfg Config) Config {
+	cfg.TailAllow = nil
+	cfg.EnvAllow = nil
+	cfg.FetchAllow = nil
+	cfg.Globals = nil
+	cfg.Static = nil
+	cfg.Hooks = nil
+	cfg.Extensions = nil
+	cfg.Admin = false
+	cfg.Debug = false
+	cfg.DebugEndpoints = false
+	cfg.Record = ""
+	cfg.Monitor = nil
+	cfg.PrefsFile = ""
+	cfg.UploadDir = ""
+	cfg.SessionBackend = SessionBackendCookie
+	cfg.SessionStore = nil
+	cfg.SessionDomain = "" // a cookie for the parent domain would reach every gist
+	cfg.WSOrigins = nil
+	return cfg
+}
+
+// gistOrigin returns the gist whose origin host is, if it is one.
+func (h *Host) gistOrigin(host string) (string, bool) {
+	id, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(h.cfg.GistHost))
+	return id, ok && gistIDPattern.MatchString(id)
+}
+
+// redirectGist sends /g/<gist id>/... on the host's own origin to the same
+// path on the gist's origin.
+func (h *Host) redirectGist(w http.ResponseWriter, r *http.Request, urlPath string) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(urlPath, gistPrefix+"/"), "/")
+	if !gistIDPattern.MatchString(id) {
+		http.NotFound(w, r)
+		return
+	}
+	target := "//" + strings.ToLower(id) + "." + h.cfg.GistHost + h.base + "/" + rest
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// serveGist serves r from gist id, fetching the gist first if it isn't
+// loaded.
+func (h *Host) serveGist(w http.ResponseWriter, r *http.Request, id string) {
+	e, err := h.gistEngine(r, id)
+	if err != nil {
+		if retry, ok := err.(gistRateError); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.wait.Seconds())+1))
+			http.Error(w, "Too many gist loads, try again shortly", http.StatusTooManyRequests)
+			return
+		}
+		log.Printf("Host: gist %s: %v", id, err)
+		http.Error(w, "Gist playground failed to load: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", gistSandbox)
+	e.ServeHTTP(w, r)
+}
+
+type gistRateError struct{ wait time.Duration }
+
+func (e gistRateError) Error() string {
+	return fmt.Sprintf("gist fetch rate exceeded, retry in %s", e.wait)
+}
+
+// gistEngine returns the engine serving gist id, loading it if needed.
+// Loads run outside gistMu, so a slow fetch never holds up gists already
+// loaded, and concurrent requests for the same new gist share one load.
+func (h *Host) gistEngine(r *http.Request, id string) (*engine, error) {
+	if e, ok := h.loadedGist(id); ok {
+		return e, nil
+	}
+	ip := clientIP(r)
+	v, err, _ := h.gistLoads.Do(id, func() (any, error) {
+		if e, ok := h.loadedGist(id); ok {
+			return e, nil // loaded while this request waited
+		}
+		if h.gistLimiter != nil {
+			if ok, wait := h.gistLimiter.Allow(ip, ""); !ok {
+				return nil, gistRateError{wait}
+			}
+		}
+		return h.loadGist(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*engine), nil
+}
+
+// loadedGist returns the engine of a loaded gist, marking it used.
+func (h *Host) loadedGist(id string) (*engine, bool) {
+	h.gistMu.Lock()
+	defer h.gistMu.Unlock()
+	site, ok := h.gists[id]
+	if !ok {
+		return nil, false
+	}
+	site.lastUsed = time.Now()
+	return site.engine, true
+}
+
+// loadGist fetches gist id and starts its engine on the host's shared NATS
+// connection, under subjects of its own.
+func (h *Host) loadGist(id string) (*engine, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gistFetchTimeout)
+	defer cancel()
+	dir, err := h.cfg.Gists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cfg := sandboxed(h.cfg.Listen)
+	cfg.PlaygroundsDir = dir
+	cfg.BasePath = h.base
+	if cfg.SessionCookie == "" {
+		cfg.SessionCookie = defaultSessionName
+	}
+	cfg.SessionCookie += "-g-" + id
+	cfg.nc = h.gistNC
+	cfg.subjects = subjects(string(defaultSubjects) + "g." + id + ".")
+	e, err := newEngine(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	log.Printf("Loaded gist %s at %s.%s%s/", id, id, h.cfg.GistHost, cfg.BasePath)
+
+	h.gistMu.Lock()
+	defer h.gistMu.Unlock()
+	h.gists[id] = &gistSite{dir: dir, engine: e, lastUsed: time.Now()}
+	if len(h.gists) > h.gistMax() {
+		h.evictGists(func(*gistSite) bool { return false }, true)
+	}
+	return e, nil
+}
+
+func (h *Host) gistMax() int {
+	if h.cfg.GistMax > 0 {
+		return h.cfg.GistMax
+	}
+	return defaultGistMax
+}
+
+// evictGists unloads the gists drop selects, plus the least recently used
+// one when oldest is set. Call with gistMu held.
+func (h *Host) evictGists(drop func(*gistSite) bool, oldest bool) {
+	var lru string
+	for id, site := range h.gists {
+		if drop(site) {
+			h.unloadGist(id)
+			continue
+		}
+		if lru == "" || site.lastUsed.Before(h.gists[lru].lastUsed) {
+			lru = id
+		}
+	}
+	if oldest && lru != "" {
+		h.unloadGist(lru)
+	}
+}
+
+func (h *Host) unloadGist(id string) {
+	site := h.gists[id]
+	site.engine.Close()
+	os.RemoveAll(site.dir)
+	delete(h.gists, id)
+	log.Printf("Unloaded gist %s", id)
+}
+
+// sweepGists unloads gists idle for longer than the TTL until ctx ends.
+func (h *Host) sweepGists(ctx context.Context) {
+	ttl := h.cfg.GistTTL
+	if ttl <= 0 {
+		ttl = defaultGistTTL
+	}
+	t := time.NewTicker(min(ttl, time.Minute))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.gistMu.Lock()
+			h.evictGists(func(s *gistSite) bool { return time.Since(s.lastUsed) > ttl }, false)
+			h.gistMu.Unlock()
+		}
+	}
+}
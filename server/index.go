@@ -0,0 +1,91 @@
+package server
+
+import (
+	"html/template"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/parser"
+)
+
+var routesTemplate = template.Must(template.ParseFS(templatesFS, "templates/routes.html"))
+
+// routesPath lists the playground's routes. The same listing is served at
+// / when the playground has no root route.
+const routesPath = "/_routes"
+
+// routeEntry is one row of the route index.
+type routeEntry struct {
+	Path       string
+	Link       string   // Path under the base path, "" when GET has no HTML page to show
+	Methods    []string // "ANY" for files that take every method
+	Transports []string // "sse" and "ws" where the route streams
+	Files      []routeFile
+}
+
+// routeFile is a template behind a route, linked to the source viewer
+// when it is mounted.
+type routeFile struct {
+	Path   string
+	Source string
+}
+
+// routeIndex summarises routes for the index page, sorted by path.
+func routeIndex(routes map[string]*RouteFiles, base string, source bool) []routeEntry {
+	entries := make([]routeEntry, 0, len(routes))
+	for _, urlPath := range slices.Sorted(maps.Keys(routes)) {
+		rf := routes[urlPath]
+		e := routeEntry{Path: urlPath, Transports: rf.Transports()}
+		methods := map[string]bool{}
+		for _, byMethod := range []map[string][]*ParsedFile{rf.HTMLFiles, rf.SSEFiles, rf.WSFiles} {
+			for method, files := range byMethod {
+				if method == "" {
+					method = "ANY"
+				}
+				methods[method] = true
+				for _, f := range files {
+					file := routeFile{Path: f.Path}
+					if source {
+						file.Source = sourceURL(base, f.Path, 0)
+					}
+					e.Files = append(e.Files, file)
+				}
+			}
+		}
+		e.Methods = slices.Sorted(maps.Keys(methods))
+		slices.SortFunc(e.Files, func(a, b routeFile) int { return strings.Compare(a.Path, b.Path) })
+		e.Files = slices.Compact(e.Files) // a file with several methods is listed once
+		if rf.LookupHTML(http.MethodGet) != nil {
+			e.Link = base + strings.TrimSuffix(urlPath, parser.CatchAll+"/")
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// ServeRouteIndex lists every route with its methods and transports.
+func (h *Handler) ServeRouteIndex(w http.ResponseWriter, r *http.Request) {
+	routes, err := ScanFS(h.fsys)
+	if err != nil {
+		http.Error(w, "Error scanning playgrounds: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.renderRouteIndex(w, r, routes)
+}
+
+func (h *Handler) renderRouteIndex(w http.ResponseWriter, r *http.Request, routes map[string]*RouteFiles) {
+	data := struct {
+		Routes []routeEntry
+		NoRoot bool // shown at / because the playground has no root route
+	}{
+		Routes: routeIndex(routes, basePath(r), h.source),
+		NoRoot: strings.TrimSuffix(r.URL.Path, "/") != routesPath,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := routesTemplate.Execute(w, data); err != nil {
+		log.Printf("Route index template error: %v", err)
+	}
+}
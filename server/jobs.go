@@ -0,0 +1,286 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	jobHistoryLimit  = 500
+	defaultJobTime   = 3000 // ms
+	defaultJobSteps  = 10
+	jobQueueCapacity = 1024
+)
+
+// Job status values.
+const (
+	JobQueued   = "queued"
+	JobRunning  = "running"
+	JobRetrying = "retrying"
+	JobDone     = "done"
+	JobFailed   = "failed"
+)
+
+// Job is a simulated background job. Snapshots are handed to templates.
+type Job struct {
+	ID         string
+	Name       string
+	SessionID  string
+	Status     string
+	Progress   int // 0–100
+	Attempts   int
+	MaxRetries int
+	Duration   int     // ms per attempt
+	FailRate   float64 // probability an attempt fails
+	Steps      int     // progress updates per attempt
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Finished reports whether the job has reached a terminal status.
+func (j Job) Finished() bool {
+	return j.Status == JobDone || j.Status == JobFailed
+}
+
+// JobQueue simulates a worker pool processing jobs. Progress is broadcast on
+// the owning session's NATS subject so open SSE streams re-render.
+type JobQueue struct {
+	nc       *nats.Conn
+	subjects subjects
+	queue    chan string
+
+	mu    sync.RWMutex
+	jobs  map[string]*Job
+	order []string
+	seq   int
+}
+
+// NewJobQueue starts workers goroutines processing enqueued jobs.
+func NewJobQueue(nc *nats.Conn, workers int) *JobQueue {
+	if workers <= 0 {
+		workers = 1
+	}
+	q := &JobQueue{
+		nc:    nc,
+		queue: make(chan string, jobQueueCapacity),
+		jobs:  make(map[string]*Job),
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Enqueue adds a job for the session. opts may set "duration" (ms),
+// "fail_rate", "retries", and "steps".
+func (q *JobQueue) Enqueue(sessionID, name string, opts map[string]any) (Job, error) {
+	now := time.Now()
+
+	q.mu.Lock()
+	q.seq++
+	j := &Job{
+		ID:         fmt.Sprintf("job-%d", q.seq),
+		Name:       name,
+		SessionID:  sessionID,
+		Status:     JobQueued,
+		MaxRetries: optInt(opts, "retries", 0),
+		Duration:   optInt(opts, "duration", defaultJobTime),
+		FailRate:   optFloat(opts, "fail_rate", 0),
+		Steps:      optInt(opts, "steps", defaultJobSteps),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if j.Steps <= 0 {
+		j.Steps = 1
+	}
+	q.jobs[j.ID] = j
+	q.order = append(q.order, j.ID)
+	q.trim()
+	snapshot := *j
+	q.mu.Unlock()
+
+	select {
+	case q.queue <- j.ID:
+	default:
+		q.update(j.ID, func(j *Job) {
+			j.Status = JobFailed
+			j.Error = "queue full"
+		})
+		return snapshot, fmt.Errorf("job queue is full")
+	}
+
+	q.broadcast(sessionID)
+	return snapshot, nil
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (q *JobQueue) Get(id string) (Job, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// List returns snapshots of the session's jobs, oldest first. An empty
+// sessionID returns every job.
+func (q *JobQueue) List(sessionID string) []Job {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	var out []Job
+	for _, id := range q.order {
+		j := q.jobs[id]
+		if sessionID == "" || j.SessionID == sessionID {
+			out = append(out, *j)
+		}
+	}
+	return out
+}
+
+// QueueStats counts jobs by status.
+type QueueStats struct {
+	Queued   int
+	Running  int
+	Done     int
+	Failed   int
+	Retrying int
+}
+
+func (q *JobQueue) Stats() QueueStats {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	var s QueueStats
+	for _, j := range q.jobs {
+		switch j.Status {
+		case JobQueued:
+			s.Queued++
+		case JobRunning:
+			s.Running++
+		case JobRetrying:
+			s.Retrying++
+		case JobDone:
+			s.Done++
+		case JobFailed:
+			s.Failed++
+		}
+	}
+	return s
+}
+
+func (q *JobQueue) work() {
+	for id := range q.queue {
+		q.run(id)
+	}
+}
+
+// run processes one attempt of a job, requeueing it on a retryable failure.
+func (q *JobQueue) run(id string) {
+	j, ok := q.Get(id)
+	if !ok {
+		return
+	}
+
+	q.update(id, func(j *Job) {
+		j.Status = JobRunning
+		j.Progress = 0
+		j.Attempts++
+		j.Error = ""
+	})
+	q.broadcast(j.SessionID)
+
+	step := time.Duration(j.Duration/j.Steps) * time.Millisecond
+	for i := 1; i <= j.Steps; i++ {
+		time.Sleep(step)
+		progress := i * 100 / j.Steps
+		q.update(id, func(j *Job) { j.Progress = progress })
+		q.broadcast(j.SessionID)
+	}
+
+	if j.FailRate > 0 && rand.Float64() < j.FailRate {
+		var retry bool
+		q.update(id, func(j *Job) {
+			j.Error = fmt.Sprintf("attempt %d failed", j.Attempts)
+			retry = j.Attempts <= j.MaxRetries
+			if retry {
+				j.Status = JobRetrying
+			} else {
+				j.Status = JobFailed
+			}
+		})
+		q.broadcast(j.SessionID)
+		if retry {
+			q.queue <- id
+		}
+		return
+	}
+
+	q.update(id, func(j *Job) {
+		j.Status = JobDone
+		j.Progress = 100
+	})
+	q.broadcast(j.SessionID)
+}
+
+func (q *JobQueue) update(id string, fn func(j *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j, ok := q.jobs[id]; ok {
+		fn(j)
+		j.UpdatedAt = time.Now()
+	}
+}
+
+// trim forgets the oldest finished jobs once the history limit is exceeded.
+// Must be called with q.mu held.
+func (q *JobQueue) trim() {
+	for len(q.order) > jobHistoryLimit {
+		oldest := q.jobs[q.order[0]]
+		if !oldest.Finished() {
+			return
+		}
+		delete(q.jobs, q.order[0])
+		q.order = q.order[1:]
+	}
+}
+
+// broadcast nudges the session's SSE streams to re-render with fresh job state.
+func (q *JobQueue) broadcast(sessionID string) {
+	if q.nc == nil {
+		return // offline rendering
+	}
+	if err := q.nc.Publish(q.subjects.session(sessionID), []byte("{}")); err != nil {
+		log.Printf("NATS publish error (jobs): %v", err)
+	}
+}
+
+func optInt(opts map[string]any, key string, def int) int {
+	switch v := opts[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+func optFloat(opts map[string]any, key string, def float64) float64 {
+	switch v := opts[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return def
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConnLimiter caps the number of simultaneous SSE connections across the
+// server, per session, and per client IP. A limit of 0 means unlimited.
+type ConnLimiter struct {
+	maxGlobal     int
+	maxPerSession int
+	maxPerIP      int
+
+	mu        sync.Mutex
+	active    int
+	bySession map[string]int
+	byIP      map[string]int
+	rejected  int64
+}
+
+func NewConnLimiter(maxGlobal, maxPerSession, maxPerIP int) *ConnLimiter {
+	return &ConnLimiter{
+		maxGlobal:     maxGlobal,
+		maxPerSession: maxPerSession,
+		maxPerIP:      maxPerIP,
+		bySession:     make(map[string]int),
+		byIP:          make(map[string]int),
+	}
+}
+
+// Acquire reserves a connection slot for the session and client IP. It
+// returns false (and counts a rejection) when any limit is already reached.
+func (l *ConnLimiter) Acquire(sessionID, ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if (l.maxGlobal > 0 && l.active >= l.maxGlobal) ||
+		(l.maxPerSession > 0 && l.bySession[sessionID] >= l.maxPerSession) ||
+		(l.maxPerIP > 0 && l.byIP[ip] >= l.maxPerIP) {
+		l.rejected++
+		return false
+	}
+
+	l.active++
+	l.bySession[sessionID]++
+	l.byIP[ip]++
+	return true
+}
+
+// Release frees a slot previously reserved with Acquire.
+func (l *ConnLimiter) Release(sessionID, ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	decrement(l.bySession, sessionID)
+	decrement(l.byIP, ip)
+}
+
+func decrement(counts map[string]int, key string) {
+	if n := counts[key] - 1; n > 0 {
+		counts[key] = n
+	} else {
+		delete(counts, key)
+	}
+}
+
+// ConnStats is a point-in-time view of SSE connection usage.
+type ConnStats struct {
+	Active        int
+	Sessions      int
+	Rejected      int64
+	MaxGlobal     int
+	MaxPerSession int
+	MaxPerIP      int
+}
+
+func (l *ConnLimiter) Stats() ConnStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return ConnStats{
+		Active:        l.active,
+		Sessions:      len(l.bySession),
+		Rejected:      l.rejected,
+		MaxGlobal:     l.maxGlobal,
+		MaxPerSession: l.maxPerSession,
+		MaxPerIP:      l.maxPerIP,
+	}
+}
+
+// limitBody caps request bodies at maxBytes, or multipart forms (which
+// carry file uploads) at maxUpload; 0 means unlimited. Reads past the limit
+// fail with *http.MaxBytesError, which handlers turn into a 413.
+func limitBody(maxBytes, maxUpload int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 && maxUpload <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := maxBytes
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+				limit = maxUpload
+			}
+			if limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// bodyTooLarge reports whether err came from reading past limitBody's cap.
+func bodyTooLarge(err error) bool {
+	var mbe *http.MaxBytesError
+	return errors.As(err, &mbe)
+}
+
+// clearStreamDeadlines lifts the server's read and write timeouts for a
+// long-lived stream, which would otherwise cut every SSE connection off once
+// they expire. It must run before ReadTimeout elapses.
+func clearStreamDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	for _, set := range []func(time.Time) error{rc.SetReadDeadline, rc.SetWriteDeadline} {
+		if err := set(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			log.Printf("Clearing stream deadline: %v", err)
+		}
+	}
+}
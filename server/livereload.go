@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dataSPA/dataSPA-playground/ignore"
+)
+
+const reloadPath = "/_reload"
+
+// liveReload tells open pages to reload when the playground's files change.
+// Pages served while it is on carry a small script that listens on
+// /_reload; the stream sends one reload event at the next change.
+type liveReload struct {
+	mu      sync.Mutex
+	changed chan struct{} // closed, and replaced, on each change
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{changed: make(chan struct{})}
+}
+
+// run polls fsys for changes until ctx is done.
+func (lr *liveReload) run(ctx context.Context, fsys fs.FS) {
+	last := fingerprint(fsys)
+	t := time.NewTicker(watchPoll)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if sum := fingerprint(fsys); sum != last {
+			last = sum
+			lr.mu.Lock()
+			close(lr.changed)
+			lr.changed = make(chan struct{})
+			lr.mu.Unlock()
+		}
+	}
+}
+
+// fingerprint hashes the name, size and modification time of every file in
+// fsys, except those .dsplayignore lists, so editor swap files don't
+// trigger reloads.
+func fingerprint(fsys fs.FS) uint64 {
+	h := fnv.New64a()
+	ignored, _ := ignore.Load(fsys)
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if name != "." && ignored.Match(name, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return h.Sum64()
+}
+
+// ServeHTTP holds an SSE stream open until the next change, then sends a
+// reload event.
+func (lr *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lr.mu.Lock()
+	changed := lr.changed
+	lr.mu.Unlock()
+
+	clearStreamDeadlines(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	http.NewResponseController(w).Flush()
+	select {
+	case <-r.Context().Done():
+	case <-changed:
+		fmt.Fprint(w, "event: reload\ndata: \n\n")
+		http.NewResponseController(w).Flush()
+	}
+}
+
+// injectReload adds the reload listener before a page's </body>. Fragments
+// without one are left alone.
+func injectReload(page, base string) string {
+	i := strings.LastIndex(page, "</body>")
+	if i < 0 {
+		return page
+	}
+	script := `<script>new EventSource("` + html.EscapeString(base+reloadPath) + `").addEventListener("reload", () => location.reload())</script>`
+	return page[:i] + script + page[i:]
+}
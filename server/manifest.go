@@ -0,0 +1,146 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestFile holds playground-wide settings, at the playground root.
+const manifestFile = "playground.yaml"
+
+// Manifest is the parsed playground.yaml. Every field is optional.
+type Manifest struct {
+	Name            string       `yaml:"name"`
+	Description     string       `yaml:"description"`
+	Author          string       `yaml:"author"`
+	Tags            []string     `yaml:"tags"`
+	Requires        Requirements `yaml:"requires"`         // oldest dsplay and Datastar releases the playground works with
+	DatastarVersion string       `yaml:"datastar_version"` // Datastar release pages load and SSE events target
+}
+
+// Requirements are minimum versions, e.g. v0.5.0 or v1.0.0-RC.6.
+type Requirements struct {
+	Dsplay   string `yaml:"dsplay"`
+	Datastar string `yaml:"datastar"`
+}
+
+// LoadManifest reads playground.yaml from fsys. A playground without one gets
+// an empty manifest.
+func LoadManifest(fsys fs.FS) (*Manifest, error) {
+	m := &Manifest{}
+	data, err := fs.ReadFile(fsys, manifestFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Summary is a one-line description of the playground, for a gist: its
+// name and description, followed by its tags as #hashtags. It is "" when
+// the manifest has none of them.
+func (m *Manifest) Summary() string {
+	parts := make([]string, 0, 2+len(m.Tags))
+	switch {
+	case m.Name != "" && m.Description != "":
+		parts = append(parts, m.Name+": "+m.Description)
+	case m.Name != "":
+		parts = append(parts, m.Name)
+	case m.Description != "":
+		parts = append(parts, m.Description)
+	}
+	if m.Author != "" {
+		parts = append(parts, "by "+m.Author)
+	}
+	for _, tag := range m.Tags {
+		if tag = strings.Join(strings.Fields(tag), "-"); tag != "" {
+			parts = append(parts, "#"+strings.TrimPrefix(tag, "#"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// CheckCompatible reports whether this dsplay, at dsplayVersion, meets the
+// manifest's requirements. An unknown dsplayVersion ("" or "dev", for a
+// development build) passes the dsplay check. The Datastar requirement is
+// checked against the release pages load: datastar_version, or the
+// embedded one.
+func (m *Manifest) CheckCompatible(dsplayVersion string) error {
+	if req := m.Requires.Dsplay; req != "" && dsplayVersion != "" && dsplayVersion != "dev" {
+		if compareVersions(dsplayVersion, req) < 0 {
+			return fmt.Errorf("%s requires dsplay %s or later, this is %s", manifestFile, req, dsplayVersion)
+		}
+	}
+	if req := m.Requires.Datastar; req != "" {
+		loaded, pinned := DatastarVersion, normalizeDatastarVersion(m.DatastarVersion)
+		if pinned != "" {
+			loaded = pinned
+		}
+		if compareVersions(loaded, req) < 0 {
+			if pinned != "" {
+				return fmt.Errorf("%s requires Datastar %s or later but pins datastar_version %s", manifestFile, req, pinned)
+			}
+			return fmt.Errorf("%s requires Datastar %s or later, this dsplay serves %s (set datastar_version to load a newer release)", manifestFile, req, loaded)
+		}
+	}
+	return nil
+}
+
+// compareVersions orders semantic versions, with or without a leading v:
+// -1 when a is older than b, 0 when equal, +1 when newer. Prereleases come
+// before their release, and compare by their dot-separated parts, numbers
+// numerically (v1.0.0-beta.11 < v1.0.0-RC.7 < v1.0.0).
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	aRel, aPre, _ := strings.Cut(a, "-")
+	bRel, bPre, _ := strings.Cut(b, "-")
+	if c := compareParts(aRel, bRel); c != 0 {
+		return c
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return compareParts(strings.ToLower(aPre), strings.ToLower(bPre))
+}
+
+// compareParts compares dot-separated version parts; missing parts count
+// as 0.
+func compareParts(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range max(len(as), len(bs)) {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				return cmp.Compare(xn, yn)
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
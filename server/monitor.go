@@ -0,0 +1,147 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/nats-io/nats.go"
+)
+
+// monitorSize is how many requests, NATS messages and log lines a Monitor
+// keeps.
+const monitorSize = 200
+
+// Monitor collects live server activity for a dashboard such as dsplay tui:
+// completed requests, NATS traffic and log output. Set Config.Monitor to
+// attach one. It is also an io.Writer, so log output can be sent to it
+// instead of the terminal the dashboard is drawn on.
+type Monitor struct {
+	mu       sync.Mutex
+	requests []RequestEvent
+	messages []NATSEvent
+	logs     []string
+	partial  string // log output not yet terminated by a newline
+
+	// set when the engine attaches the monitor
+	conns    *ConnRegistry
+	limiter  *ConnLimiter
+	counters *Counters
+	jobs     *JobQueue
+}
+
+// RequestEvent is a completed HTTP request.
+type RequestEvent struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Status   int
+	Duration time.Duration
+}
+
+// NATSEvent is a message published on the playground's NATS subjects.
+type NATSEvent struct {
+	Time    time.Time
+	Subject string
+	Bytes   int
+}
+
+// MonitorSnapshot is a point-in-time view of a Monitor.
+type MonitorSnapshot struct {
+	Requests    []RequestEvent // oldest first
+	NATS        []NATSEvent    // oldest first
+	Logs        []string       // oldest first
+	Streams     []ConnInfo
+	Connections ConnStats
+	GlobalHits  int64
+	URLs        []URLHitCount
+	Jobs        QueueStats
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{}
+}
+
+// attach points the monitor at the engine's state and starts watching NATS.
+func (m *Monitor) attach(nc *nats.Conn, conns *ConnRegistry, limiter *ConnLimiter, counters *Counters, jobs *JobQueue) {
+	m.mu.Lock()
+	m.conns, m.limiter, m.counters, m.jobs = conns, limiter, counters, jobs
+	m.mu.Unlock()
+
+	if _, err := nc.Subscribe("dspen.>", func(msg *nats.Msg) {
+		m.mu.Lock()
+		m.messages = appendRing(m.messages, NATSEvent{Time: time.Now(), Subject: msg.Subject, Bytes: len(msg.Data)})
+		m.mu.Unlock()
+	}); err != nil {
+		log.Printf("Monitor: subscribing to NATS: %v", err)
+	}
+}
+
+// middleware records each request once it completes.
+func (m *Monitor) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.mu.Lock()
+		m.requests = appendRing(m.requests, RequestEvent{
+			Time:     start,
+			Method:   r.Method,
+			Path:     r.URL.RequestURI(),
+			Status:   status,
+			Duration: time.Since(start),
+		})
+		m.mu.Unlock()
+	})
+}
+
+// Write collects log output line by line.
+func (m *Monitor) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	text := m.partial + string(p)
+	lines := strings.Split(text, "\n")
+	m.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		m.logs = appendRing(m.logs, line)
+	}
+	return len(p), nil
+}
+
+// Snapshot returns the monitor's current view.
+func (m *Monitor) Snapshot() MonitorSnapshot {
+	m.mu.Lock()
+	s := MonitorSnapshot{
+		Requests: append([]RequestEvent(nil), m.requests...),
+		NATS:     append([]NATSEvent(nil), m.messages...),
+		Logs:     append([]string(nil), m.logs...),
+	}
+	conns, limiter, counters, jobs := m.conns, m.limiter, m.counters, m.jobs
+	m.mu.Unlock()
+
+	if counters != nil {
+		s.Streams = conns.List()
+		s.Connections = limiter.Stats()
+		s.GlobalHits = counters.GetGlobalHits()
+		s.URLs = counters.URLs()
+		s.Jobs = jobs.Stats()
+	}
+	return s
+}
+
+// appendRing appends v, dropping the oldest entries beyond monitorSize.
+func appendRing[T any](list []T, v T) []T {
+	list = append(list, v)
+	if len(list) > monitorSize {
+		list = append(list[:0:0], list[len(list)-monitorSize:]...)
+	}
+	return list
+}
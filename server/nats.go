@@ -11,8 +11,31 @@ import (
 
 // StartEmbeddedNATS starts an in-process NATS server and returns a client connection.
 func StartEmbeddedNATS() (*natsserver.Server, *nats.Conn, error) {
+	return startNATS(false, "")
+}
+
+// connectNATS connects to the NATS server at url, or starts an embedded one
+// as startNATS does when url is empty. The returned server is nil for an
+// external connection.
+func connectNATS(url string, jetStream bool, storeDir string) (*natsserver.Server, *nats.Conn, error) {
+	if url == "" {
+		return startNATS(jetStream, storeDir)
+	}
+	nc, err := nats.Connect(url, nats.Name("dsplay"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+	log.Printf("Connected to NATS at %s", nc.ConnectedUrlRedacted())
+	return nil, nc, nil
+}
+
+// startNATS is StartEmbeddedNATS, optionally with JetStream persisting to
+// storeDir ("" = the server's default temp directory).
+func startNATS(jetStream bool, storeDir string) (*natsserver.Server, *nats.Conn, error) {
 	opts := &natsserver.Options{
 		DontListen: true, // in-process only, no TCP listener
+		JetStream:  jetStream,
+		StoreDir:   storeDir,
 	}
 
 	ns, err := natsserver.NewServer(opts)
@@ -35,3 +58,29 @@ func StartEmbeddedNATS() (*natsserver.Server, *nats.Conn, error) {
 	log.Printf("Embedded NATS server started (in-process)")
 	return ns, nc, nil
 }
+
+// subjects names the NATS subjects a playground publishes and subscribes
+// on. Playgrounds sharing one connection, such as the gists under a host,
+// each get their own prefix so their messages never reach each other. The
+// zero value uses the standalone "dspen." prefix.
+type subjects string
+
+const defaultSubjects subjects = "dspen."
+
+func (s subjects) prefix() string {
+	if s == "" {
+		return string(defaultSubjects)
+	}
+	return string(s)
+}
+
+// session is the subject re-rendering every stream of a session.
+func (s subjects) session(id string) string { return s.prefix() + "session." + id }
+
+// tab is the subject re-rendering one browser tab's streams.
+func (s subjects) tab(id string) string { return s.prefix() + "tab." + id }
+
+// hook is the subject webhooks, schedules and sources named name publish on.
+func (s subjects) hook(name string) string { return s.hookPrefix() + name }
+
+func (s subjects) hookPrefix() string { return s.prefix() + "hook." }
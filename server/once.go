@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// isOnce reports whether the section is served at most once per session,
+// via "=== once: true" or "once: true" in its file's frontmatter.
+func (s sectionEntry) isOnce() bool {
+	return s.options.Once || s.frontmatter.Once
+}
+
+// onceKey identifies the section across requests.
+func (s sectionEntry) onceKey() string {
+	return fmt.Sprintf("%s:%d", s.path, s.line)
+}
+
+// onceServed is the once: sections a session has been served.
+type onceServed struct {
+	keys map[string]bool
+	seen time.Time
+}
+
+// MarkServed records that the session has been served a once: section. It
+// returns false if the section had already been served.
+func (sm *SessionManager) MarkServed(sessionID, key string) bool {
+	sm.onceMu.Lock()
+	defer sm.onceMu.Unlock()
+	now := time.Now()
+	sm.sweepServed(now)
+	served := sm.once[sessionID]
+	if served == nil {
+		served = &onceServed{keys: make(map[string]bool)}
+		sm.once[sessionID] = served
+	}
+	served.seen = now
+	if served.keys[key] {
+		return false
+	}
+	served.keys[key] = true
+	return true
+}
+
+// Served reports whether the session has been served a once: section.
+func (sm *SessionManager) Served(sessionID, key string) bool {
+	sm.onceMu.Lock()
+	defer sm.onceMu.Unlock()
+	served := sm.once[sessionID]
+	return served != nil && served.keys[key]
+}
+
+// touchServed keeps the session's served sections while it is in use.
+func (sm *SessionManager) touchServed(sessionID string) {
+	sm.onceMu.Lock()
+	defer sm.onceMu.Unlock()
+	if served := sm.once[sessionID]; served != nil {
+		served.seen = time.Now()
+	}
+}
+
+// sweepServed forgets sessions unused for onceTTL, at most once a minute.
+// sm.onceMu must be held.
+func (sm *SessionManager) sweepServed(now time.Time) {
+	if now.Sub(sm.onceSweep) < time.Minute {
+		return
+	}
+	sm.onceSweep = now
+	for id, served := range sm.once {
+		if now.Sub(served.seen) > sm.onceTTL {
+			delete(sm.once, id)
+		}
+	}
+}
+
+// dropServed removes once: sections the session has already been served.
+func (h *Handler) dropServed(sessionID string, sections []sectionEntry) []sectionEntry {
+	if h.sessions == nil {
+		return sections
+	}
+	out := sections[:0:0]
+	for _, s := range sections {
+		if s.isOnce() && h.sessions.Served(sessionID, s.onceKey()) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// claimOnce reports whether section may be sent, marking once: sections as
+// served. Streams use it to skip a once: section on later passes.
+func (h *Handler) claimOnce(sessionID string, section sectionEntry) bool {
+	if !section.isOnce() || h.sessions == nil {
+		return true
+	}
+	return h.sessions.MarkServed(sessionID, section.onceKey())
+}
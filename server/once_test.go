@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServedExpires(t *testing.T) {
+	sm := NewSessionManager(SessionOptions{Secrets: []string{"secret"}, MaxAge: 60})
+	if !sm.MarkServed("s-old", "a.html:1") || sm.MarkServed("s-old", "a.html:1") {
+		t.Fatal("MarkServed should succeed once")
+	}
+	sm.MarkServed("s-new", "a.html:1")
+
+	sm.onceMu.Lock()
+	sm.once["s-old"].seen = time.Now().Add(-2 * time.Minute)
+	sm.onceSweep = time.Time{}
+	sm.sweepServed(time.Now())
+	sm.onceMu.Unlock()
+
+	if sm.Served("s-old", "a.html:1") {
+		t.Error("expired session's once: sections were kept")
+	}
+	if !sm.Served("s-new", "a.html:1") {
+		t.Error("live session's once: sections were dropped")
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+const outboxCapacity = 200
+
+// OutboxMessage is a notification recorded by the sendEmail or notify
+// template functions. Nothing is ever delivered; messages are only listed
+// on the admin dashboard.
+type OutboxMessage struct {
+	Kind      string // "email" or "notify"
+	To        string // email recipient or notification channel
+	Subject   string
+	Body      string
+	Username  string
+	SessionID string
+	URL       string
+	Time      time.Time
+}
+
+// Outbox keeps the most recent notification side effects in memory.
+type Outbox struct {
+	mu       sync.RWMutex
+	messages []OutboxMessage
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Record appends a message, dropping the oldest once the outbox is full.
+func (o *Outbox) Record(msg OutboxMessage) {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	if len(o.messages) > outboxCapacity {
+		o.messages = o.messages[len(o.messages)-outboxCapacity:]
+	}
+}
+
+// Messages returns a copy of the recorded messages, newest first.
+func (o *Outbox) Messages() []OutboxMessage {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	out := make([]OutboxMessage, len(o.messages))
+	for i, m := range o.messages {
+		out[len(o.messages)-1-i] = m
+	}
+	return out
+}
+
+// Clear removes all recorded messages.
+func (o *Outbox) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = nil
+}
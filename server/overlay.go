@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/markdown"
+	"github.com/dataSPA/dataSPA-playground/mustache"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+var overlayTemplate = template.Must(template.ParseFS(templatesFS, "templates/overlay.html"))
+
+// overlayID is the element id of the dev error overlay.
+const overlayID = "dsplay-error-overlay"
+
+// TemplateError locates a template parse or execution error in the
+// playground file it came from.
+type TemplateError struct {
+	File    string
+	Line    int    // line in File, 0 if unknown
+	Expr    string // offending expression, e.g. ".Signals.nmae"
+	Message string
+	Source  []SourceLine // lines around Line
+	Err     error
+}
+
+// SourceLine is one line of template source shown in the overlay.
+type SourceLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+func (e *TemplateError) Error() string {
+	loc := e.File
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Expr != "" {
+		return fmt.Sprintf("%s: at <%s>: %s", loc, e.Expr, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", loc, e.Message)
+}
+
+func (e *TemplateError) Unwrap() error { return e.Err }
+
+// Matches text/template error positions, e.g.
+// `template: page:3:14: executing "page" at <.Signals.x>: map has no entry for key "x"`.
+var templateErrorRe = regexp.MustCompile(`template: [^:]*:(\d+)(?::\d+)?: (?:executing "[^"]*" at <(.*?)>: )?(.*)$`)
+
+// newTemplateError wraps a template engine error for the section, translating
+// template-relative line numbers to lines in the source file.
+func newTemplateError(err error, section sectionEntry) *TemplateError {
+	te := &TemplateError{File: section.path, Message: err.Error(), Err: err}
+
+	var line int
+	var me *mustache.Error
+	if errors.As(err, &me) {
+		line = me.Line
+		te.Message = me.Msg
+	} else {
+		m := templateErrorRe.FindStringSubmatch(err.Error())
+		if m == nil {
+			return te
+		}
+		line, _ = strconv.Atoi(m[1])
+		te.Expr = m[2]
+		te.Message = m[3]
+	}
+
+	lines := strings.Split(section.content, "\n")
+	if line < 1 || line > len(lines) {
+		return te
+	}
+	te.Line = section.line + line - 1
+	for i := max(line-3, 0); i < min(line+2, len(lines)); i++ {
+		te.Source = append(te.Source, SourceLine{
+			Number:  section.line + i,
+			Text:    lines[i],
+			Current: i == line-1,
+		})
+	}
+	return te
+}
+
+// render renders a section's template, returning a *TemplateError on failure.
+func (h *Handler) render(section sectionEntry, td TemplateData) (string, error) {
+	td.DatastarVersion = section.datastarVersion(td.DatastarVersion)
+	td.Now = h.clock.Now()
+	td.Source, td.SourceLine = section.path, section.line
+	td, err := withVars(section.frontmatter.Vars, td)
+	if err != nil {
+		return "", newTemplateError(err, section)
+	}
+	rendered, err := h.execute(section, td)
+	if err != nil {
+		return "", newTemplateError(err, section)
+	}
+	if section.markdown {
+		rendered = markdown.Render(rendered, markdown.Options{HTML: true})
+	}
+	return rendered, nil
+}
+
+// writeTemplateError responds to a failed HTML render: a full-page overlay in
+// dev mode, a plain 500 otherwise.
+func (h *Handler) writeTemplateError(w http.ResponseWriter, err error) {
+	var te *TemplateError
+	if !h.dev || !errors.As(err, &te) {
+		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	if err := overlayTemplate.ExecuteTemplate(w, "page", te); err != nil {
+		log.Printf("Overlay template error: %v", err)
+	}
+}
+
+// sendOverlay pushes the error overlay into the page over SSE, replacing any
+// overlay already shown.
+func sendOverlay(sse *datastar.ServerSentEventGenerator, d eventDialect, te *TemplateError) error {
+	var buf bytes.Buffer
+	if err := overlayTemplate.ExecuteTemplate(&buf, "overlay", te); err != nil {
+		return err
+	}
+	if err := patchElements(sse, d, "", patchSpec{Selector: "#" + overlayID, Mode: "remove", Namespace: "html"}); err != nil {
+		return err
+	}
+	return patchElements(sse, d, buf.String(), patchSpec{Selector: "body", Mode: "append", Namespace: "html"})
+}
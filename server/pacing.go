@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Jitter randomizes each loop interval by up to ± a number of milliseconds
+// ("jitter: 200") or a percentage of the interval ("jitter: 20%").
+type Jitter struct {
+	Millis  int
+	Percent float64
+}
+
+func (j *Jitter) UnmarshalYAML(node *yaml.Node) error {
+	s := strings.TrimSpace(node.Value)
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || p < 0 {
+			return fmt.Errorf("jitter: %q is not a percentage", s)
+		}
+		*j = Jitter{Percent: p}
+		return nil
+	}
+	ms, err := strconv.Atoi(strings.TrimSuffix(s, "ms"))
+	if err != nil || ms < 0 {
+		return fmt.Errorf("jitter: %q is not a number of milliseconds or a percentage", s)
+	}
+	*j = Jitter{Millis: ms}
+	return nil
+}
+
+// Backoff grows the loop interval after every tick, for demoing polling
+// that slows down over time:
+//
+//	backoff:
+//	  factor: 2      # multiply the interval by this each tick
+//	  max: 30000     # ms, cap on the interval (default: no cap)
+type Backoff struct {
+	Factor float64 `yaml:"factor"`
+	Max    int     `yaml:"max"`
+}
+
+// loopPacer yields the wait before each loop tick from a file's interval,
+// jitter and backoff.
+type loopPacer struct {
+	interval time.Duration // current interval before jitter
+	jitter   Jitter
+	backoff  Backoff
+}
+
+func newLoopPacer(fm Frontmatter) *loopPacer {
+	return &loopPacer{
+		interval: time.Duration(fm.Interval) * time.Millisecond,
+		jitter:   fm.Jitter,
+		backoff:  fm.Backoff,
+	}
+}
+
+// steady reports whether every interval is the same, so a plain ticker will do.
+func (p *loopPacer) steady() bool {
+	return p.jitter == Jitter{} && p.backoff.Factor <= 0
+}
+
+// first is the wait before the first tick.
+func (p *loopPacer) first() time.Duration {
+	return p.jittered(p.interval)
+}
+
+// next applies backoff and returns the wait before the following tick.
+func (p *loopPacer) next() time.Duration {
+	if p.backoff.Factor > 0 {
+		p.interval = time.Duration(float64(p.interval) * p.backoff.Factor)
+		if limit := time.Duration(p.backoff.Max) * time.Millisecond; limit > 0 && p.interval > limit {
+			p.interval = limit
+		}
+	}
+	return p.jittered(p.interval)
+}
+
+func (p *loopPacer) jittered(d time.Duration) time.Duration {
+	spread := time.Duration(p.jitter.Millis)*time.Millisecond + time.Duration(float64(d)*p.jitter.Percent/100)
+	if spread <= 0 {
+		return d
+	}
+	d += time.Duration(rand.Int64N(int64(2*spread)+1)) - spread
+	return max(d, time.Millisecond)
+}
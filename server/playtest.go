@@ -0,0 +1,313 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// testsDir holds playground test specs, e.g. _tests/todos.yaml.
+const testsDir = "_tests"
+
+const defaultTestTimeout = 10000 // ms
+
+// defaultTestSpeed runs the playground clock fast enough that loop intervals
+// and sequential delays elapse almost instantly under test.
+const defaultTestSpeed = 1000
+
+// TestSpec is a _tests/*.yaml file. Its cases run in order against a fresh
+// server with one shared visitor session, so sequences and counters advance
+// exactly as they would for a real visitor.
+type TestSpec struct {
+	Speed float64    `yaml:"speed"` // playground clock multiplier (default 1000; 1 = real time)
+	Tests []TestCase `yaml:"tests"`
+}
+
+// TestCase describes a request and where its expected output is stored.
+type TestCase struct {
+	Name    string         `yaml:"name"`
+	Path    string         `yaml:"path"`
+	Method  string         `yaml:"method"`  // default GET
+	Signals map[string]any `yaml:"signals"` // sent as a Datastar request when set
+	SSE     bool           `yaml:"sse"`     // send as a Datastar request and capture SSE events
+	Events  int            `yaml:"events"`  // SSE events to capture (default 1)
+	Repeat  int            `yaml:"repeat"`  // times to send the request (default 1)
+	Timeout int            `yaml:"timeout"` // ms to wait for each response (default 10000)
+	Golden  string         `yaml:"golden"`  // golden file relative to _tests/ (default <spec>/<name>.golden)
+}
+
+// TestOptions controls a test run.
+type TestOptions struct {
+	Update bool // rewrite golden files with the actual output
+}
+
+// TestResult is the outcome of one test case.
+type TestResult struct {
+	File    string // spec file, relative to the playground
+	Name    string
+	Golden  string // golden file path
+	Passed  bool
+	Updated bool
+	Err     error // mismatch or failure description
+}
+
+// RunTests runs every _tests/*.yaml spec in the playground directory.
+func RunTests(playgroundsDir string, opts TestOptions) ([]TestResult, error) {
+	specs, err := filepath.Glob(filepath.Join(playgroundsDir, testsDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no test specs found in %s", filepath.Join(playgroundsDir, testsDir))
+	}
+
+	ns, nc, err := StartEmbeddedNATS()
+	if err != nil {
+		return nil, fmt.Errorf("starting nats: %w", err)
+	}
+	defer ns.Shutdown()
+	defer nc.Close()
+
+	var results []TestResult
+	for _, specPath := range specs {
+		data, err := os.ReadFile(specPath)
+		if err != nil {
+			return results, err
+		}
+		var spec TestSpec
+		if err := yaml.Unmarshal(data, &spec); err != nil {
+			return results, fmt.Errorf("%s: %w", specPath, err)
+		}
+
+		// Fresh server state per spec so counters start from zero
+		sessions := NewSessionManager(SessionOptions{Secrets: []string{"dsplay-test-secret"}})
+		prefs, _ := NewPrefStore("", 0)
+		clock := NewClock()
+		if spec.Speed <= 0 {
+			spec.Speed = defaultTestSpeed
+		}
+		clock.SetSpeed(spec.Speed)
+		h := NewHandler(HandlerConfig{
+			FS:       os.DirFS(playgroundsDir),
+			Counters: NewCounters(),
+			Sessions: sessions,
+			NC:       nc,
+			Outbox:   NewOutbox(),
+			Limiter:  NewConnLimiter(0, 0, 0),
+			Conns:    NewConnRegistry(),
+			Jobs:     NewJobQueue(nc, 1),
+			Prefs:    prefs,
+			Clock:    clock,
+		})
+		srv := httptest.NewServer(http.HandlerFunc(h.ServePlayground))
+		jar, _ := cookiejar.New(nil)
+		r := &testRunner{
+			srv:      srv,
+			client:   &http.Client{Jar: jar},
+			sessions: sessions,
+			dir:      filepath.Join(playgroundsDir, testsDir),
+			spec:     strings.TrimSuffix(filepath.Base(specPath), ".yaml"),
+		}
+		rel, _ := filepath.Rel(playgroundsDir, specPath)
+		for i, tc := range spec.Tests {
+			if tc.Name == "" {
+				tc.Name = fmt.Sprintf("test-%d", i+1)
+			}
+			res := r.run(tc, opts)
+			res.File = rel
+			results = append(results, res)
+		}
+		srv.Close()
+	}
+	return results, nil
+}
+
+type testRunner struct {
+	srv      *httptest.Server
+	client   *http.Client
+	sessions *SessionManager
+	dir      string
+	spec     string
+}
+
+func (r *testRunner) run(tc TestCase, opts TestOptions) TestResult {
+	golden := tc.Golden
+	if golden == "" {
+		golden = filepath.Join(r.spec, slugify(tc.Name)+".golden")
+	}
+	res := TestResult{Name: tc.Name, Golden: filepath.Join(r.dir, golden)}
+
+	var out strings.Builder
+	repeat := max(tc.Repeat, 1)
+	for i := 1; i <= repeat; i++ {
+		body, err := r.do(tc)
+		if err != nil {
+			res.Err = fmt.Errorf("request %d: %w", i, err)
+			return res
+		}
+		if repeat > 1 {
+			fmt.Fprintf(&out, "--- request %d ---\n", i)
+		}
+		out.WriteString(body)
+	}
+	actual := r.normalize(out.String())
+
+	if opts.Update {
+		if err := os.MkdirAll(filepath.Dir(res.Golden), 0o755); err != nil {
+			res.Err = err
+			return res
+		}
+		if err := os.WriteFile(res.Golden, []byte(actual), 0o644); err != nil {
+			res.Err = err
+			return res
+		}
+		res.Passed, res.Updated = true, true
+		return res
+	}
+
+	want, err := os.ReadFile(res.Golden)
+	if errors.Is(err, os.ErrNotExist) {
+		res.Err = fmt.Errorf("missing golden file %s (run with --update)", res.Golden)
+		return res
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if err := diffGolden(string(want), actual); err != nil {
+		res.Err = err
+		return res
+	}
+	res.Passed = true
+	return res
+}
+
+// do sends one request and returns its status line and body, or the
+// captured SSE events.
+func (r *testRunner) do(tc TestCase) (string, error) {
+	method := strings.ToUpper(tc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	timeout := tc.Timeout
+	if timeout <= 0 {
+		timeout = defaultTestTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	target := r.srv.URL + tc.Path
+	datastarRequest := tc.SSE || tc.Signals != nil
+	var body io.Reader
+	if datastarRequest {
+		signals, err := json.Marshal(tc.Signals)
+		if err != nil {
+			return "", err
+		}
+		if method == http.MethodGet || method == http.MethodDelete {
+			target += "?" + url.Values{"datastar": {string(signals)}}.Encode()
+		} else {
+			body = bytes.NewReader(signals)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", err
+	}
+	if datastarRequest {
+		req.Header.Set("datastar-request", "true")
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("status: %d\n\n%s\n", resp.StatusCode, data), nil
+	}
+
+	// SSE streams stay open, so stop after the expected number of events
+	events, err := ReadSSEEvents(resp.Body, max(tc.Events, 1))
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for i, e := range events {
+		fmt.Fprintf(&out, "--- event %d ---\n%s", i+1, e)
+	}
+	return out.String(), nil
+}
+
+// normalize replaces the random per-session username and ID with stable
+// placeholders so golden files don't change between runs.
+func (r *testRunner) normalize(s string) string {
+	u, err := url.Parse(r.srv.URL)
+	if err != nil {
+		return s
+	}
+	req := httptest.NewRequest(http.MethodGet, r.srv.URL, nil)
+	for _, c := range r.client.Jar.Cookies(u) {
+		req.AddCookie(c)
+	}
+	sess, err := r.sessions.store.Get(req, r.sessions.name)
+	if err != nil {
+		return s
+	}
+	if id, ok := sess.Values[keySessionID].(string); ok && id != "" {
+		s = strings.ReplaceAll(s, id, "<session-id>")
+	}
+	if name, ok := sess.Values[keyUsername].(string); ok && name != "" {
+		s = strings.ReplaceAll(s, name, "<username>")
+	}
+	return s
+}
+
+// diffGolden reports the first line where actual departs from want.
+func diffGolden(want, actual string) error {
+	if want == actual {
+		return nil
+	}
+	wl := strings.Split(want, "\n")
+	al := strings.Split(actual, "\n")
+	for i := 0; i < max(len(wl), len(al)); i++ {
+		var w, a string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(al) {
+			a = al[i]
+		}
+		if w != a {
+			return fmt.Errorf("output differs at line %d:\n  want: %s\n   got: %s", i+1, w, a)
+		}
+	}
+	return fmt.Errorf("output differs")
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
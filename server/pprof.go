@@ -0,0 +1,34 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// mountDebugEndpoints serves the runtime profilers and expvar under /_debug,
+// for profiling a long-running playground whose loops are eating CPU or
+// memory:
+//
+//	GET /_debug/pprof/          → profile index
+//	GET /_debug/pprof/{profile} → a named profile (heap, goroutine, …)
+//	GET /_debug/pprof/profile   → CPU profile (?seconds=30)
+//	GET /_debug/pprof/trace     → execution trace (?seconds=5)
+//	GET /_debug/vars            → expvar JSON (memstats, cmdline)
+func mountDebugEndpoints(r chi.Router) {
+	r.Get(debugPathPrefix+"/pprof", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, basePath(r)+debugPathPrefix+"/pprof/", http.StatusMovedPermanently)
+	})
+	r.Get(debugPathPrefix+"/pprof/", pprof.Index)
+	r.Get(debugPathPrefix+"/pprof/cmdline", pprof.Cmdline)
+	r.Get(debugPathPrefix+"/pprof/profile", pprof.Profile)
+	r.Get(debugPathPrefix+"/pprof/symbol", pprof.Symbol)
+	r.Post(debugPathPrefix+"/pprof/symbol", pprof.Symbol)
+	r.Get(debugPathPrefix+"/pprof/trace", pprof.Trace)
+	r.Get(debugPathPrefix+"/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+	r.Get(debugPathPrefix+"/vars", expvar.Handler().ServeHTTP)
+}
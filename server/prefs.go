@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+const (
+	prefsPath        = "/_prefs"
+	maxPrefsPerUser  = 32
+	maxPrefSessions  = 10_000 // sessions with preferences; the least recently seen go first
+	maxPrefKeyLen    = 64
+	maxPrefValueLen  = 256
+	prefsSignalsName = "prefs" // datastar signal object holding updates
+	defaultPrefsTTL  = 30 * 24 * time.Hour
+	prefsSweepEvery  = time.Hour
+	prefsSaveDelay   = time.Second // updates within it are written together
+)
+
+// PrefStore keeps per-session preferences (theme, locale, layout, ...) on the
+// server. With a file path, preferences are saved as JSON and survive restarts.
+// A session's preferences are forgotten once it goes unseen for the TTL.
+type PrefStore struct {
+	path string
+	ttl  time.Duration
+
+	mu        sync.Mutex
+	prefs     map[string]*prefEntry // session ID → preferences
+	lastSweep time.Time
+	pending   *time.Timer // scheduled write, nil when the file is current
+
+	writeMu sync.Mutex // orders writes, so the newest snapshot lands last
+}
+
+type prefEntry struct {
+	Values map[string]string `json:"values"`
+	Seen   time.Time         `json:"seen"` // last read or update
+}
+
+// NewPrefStore returns a store, loading path if it exists. An empty path
+// keeps preferences in memory only; ttl <= 0 keeps them for 30 days.
+func NewPrefStore(path string, ttl time.Duration) (*PrefStore, error) {
+	if ttl <= 0 {
+		ttl = defaultPrefsTTL
+	}
+	s := &PrefStore{path: path, ttl: ttl, prefs: make(map[string]*prefEntry)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading preferences: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.prefs); err != nil {
+		return nil, fmt.Errorf("parsing preferences %s: %w", path, err)
+	}
+	s.sweep(time.Now())
+	return s, nil
+}
+
+// Get returns a session's preference, or "" if unset.
+func (s *PrefStore) Get(sessionID, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.touch(sessionID)
+	if e == nil {
+		return ""
+	}
+	return e.Values[key]
+}
+
+// All returns a copy of a session's preferences.
+func (s *PrefStore) All(sessionID string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]string)
+	if e := s.touch(sessionID); e != nil {
+		maps.Copy(out, e.Values)
+	}
+	return out
+}
+
+// touch marks a session's preferences as used and returns them, or nil if
+// it has none. Must be called with s.mu held.
+func (s *PrefStore) touch(sessionID string) *prefEntry {
+	e := s.prefs[sessionID]
+	if e != nil {
+		e.Seen = time.Now()
+	}
+	return e
+}
+
+// Set merges updates into a session's preferences. An empty value deletes
+// the key. The file is only rewritten when something changed.
+func (s *PrefStore) Set(sessionID string, updates map[string]string) error {
+	for k, v := range updates {
+		if k == "" || len(k) > maxPrefKeyLen {
+			return fmt.Errorf("invalid preference key %q", k)
+		}
+		if len(v) > maxPrefValueLen {
+			return fmt.Errorf("preference %q is too long", k)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.prefs[sessionID]
+	p := make(map[string]string)
+	if old != nil {
+		maps.Copy(p, old.Values)
+	}
+	for k, v := range updates {
+		if v == "" {
+			delete(p, k)
+		} else {
+			p[k] = v
+		}
+	}
+	if len(p) > maxPrefsPerUser {
+		return fmt.Errorf("too many preferences (max %d)", maxPrefsPerUser)
+	}
+	now := time.Now()
+	changed := false
+	if now.Sub(s.lastSweep) > prefsSweepEvery {
+		changed = s.sweep(now)
+	}
+	switch {
+	case old != nil && maps.Equal(old.Values, p):
+		old.Seen = now
+	case len(p) == 0:
+		delete(s.prefs, sessionID)
+		changed = changed || old != nil
+	default:
+		if old == nil && len(s.prefs) >= maxPrefSessions {
+			s.evictOldest()
+		}
+		s.prefs[sessionID] = &prefEntry{Values: p, Seen: now}
+		changed = true
+	}
+	if changed && s.path != "" && s.pending == nil {
+		s.pending = time.AfterFunc(prefsSaveDelay, s.flush)
+	}
+	return nil
+}
+
+// sweep forgets sessions unseen for the TTL, reporting whether it forgot
+// any. Must be called with s.mu held.
+func (s *PrefStore) sweep(now time.Time) bool {
+	n := len(s.prefs)
+	for id, e := range s.prefs {
+		if now.Sub(e.Seen) > s.ttl {
+			delete(s.prefs, id)
+		}
+	}
+	s.lastSweep = now
+	return len(s.prefs) < n
+}
+
+// evictOldest forgets the least recently seen session, to make room for a
+// new one. Must be called with s.mu held.
+func (s *PrefStore) evictOldest() {
+	var oldest string
+	for id, e := range s.prefs {
+		if oldest == "" || e.Seen.Before(s.prefs[oldest].Seen) {
+			oldest = id
+		}
+	}
+	delete(s.prefs, oldest)
+}
+
+// Close writes any pending updates to disk.
+func (s *PrefStore) Close() error {
+	s.mu.Lock()
+	pending := s.pending != nil && s.pending.Stop()
+	s.mu.Unlock()
+	if !pending {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *PrefStore) flush() {
+	if err := s.save(); err != nil {
+		log.Printf("Saving preferences: %v", err)
+	}
+}
+
+// save writes the store to disk through a temporary file, so a crash
+// never leaves it half written.
+func (s *PrefStore) save() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	s.pending = nil
+	data, err := json.MarshalIndent(s.prefs, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".prefs-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// PrefsHandler serves the preference update endpoint.
+type PrefsHandler struct {
+	prefs    *PrefStore
+	sessions *SessionManager
+	nc       *nats.Conn
+	subjects subjects
+}
+
+func NewPrefsHandler(prefs *PrefStore, sessions *SessionManager, nc *nats.Conn) *PrefsHandler {
+	return &PrefsHandler{prefs: prefs, sessions: sessions, nc: nc}
+}
+
+// Routes mounts the preference endpoint on r:
+//
+//	POST /_prefs → merge preferences from the "prefs" signal object, or
+//	               from form fields for plain HTML forms
+func (p *PrefsHandler) Routes(r chi.Router) {
+	r.Post(prefsPath, p.update)
+}
+
+func (p *PrefsHandler) update(w http.ResponseWriter, r *http.Request) {
+	isDatastarRequest := r.Header.Get("datastar-request") != ""
+
+	updates := map[string]string{}
+	if isDatastarRequest {
+		signals := map[string]any{}
+		if err := datastar.ReadSignals(r, &signals); err != nil {
+			if bodyTooLarge(err) {
+				http.Error(w, "Preferences too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Invalid signals: %v", err), http.StatusBadRequest)
+			return
+		}
+		obj, _ := signals[prefsSignalsName].(map[string]any)
+		for k, v := range obj {
+			if k != csrfField {
+				updates[k] = fmt.Sprint(v)
+			}
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			if bodyTooLarge(err) {
+				http.Error(w, "Preferences too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Invalid form: %v", err), http.StatusBadRequest)
+			return
+		}
+		for k := range r.PostForm {
+			// The form's CSRF token is not a preference
+			if k != csrfField {
+				updates[k] = r.PostForm.Get(k)
+			}
+		}
+	}
+
+	sess, sd, err := p.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if sess.IsNew {
+		sess.Save(r, w) // otherwise the preferences belong to a session the visitor never gets
+	}
+	if err := p.prefs.Set(sd.SessionID, updates); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Re-render every open stream in the session (all of the user's tabs)
+	if err := p.nc.Publish(p.subjects.session(sd.SessionID), []byte("{}")); err != nil {
+		log.Printf("NATS publish error (prefs): %v", err)
+	}
+
+	if isDatastarRequest {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// Plain forms return to the page they were posted from
+	next := basePath(r) + "/"
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Host == r.Host {
+		next = safeNext(ref.RequestURI())
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
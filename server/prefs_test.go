@@ -0,0 +1,52 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPrefStoreWritesOnChange(t *testing.T) {
+	s, err := NewPrefStore(filepath.Join(t.TempDir(), "prefs.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.pending != nil
+	}
+	if err := s.Set("a", map[string]string{"theme": "dark"}); err != nil {
+		t.Fatal(err)
+	}
+	if !pending() {
+		t.Fatal("no write scheduled after a change")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("a", map[string]string{"theme": "dark"}); err != nil {
+		t.Fatal(err)
+	}
+	if pending() {
+		t.Error("write scheduled for an unchanged preference")
+	}
+}
+
+func TestPrefStoreCap(t *testing.T) {
+	s, err := NewPrefStore("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("first", map[string]string{"n": "1"})
+	for range maxPrefSessions {
+		if err := s.Set(newSessionID(), map[string]string{"n": "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.prefs); n != maxPrefSessions {
+		t.Errorf("%d sessions kept, want %d", n, maxPrefSessions)
+	}
+	if s.Get("first", "n") != "" {
+		t.Error("the least recently seen session was kept")
+	}
+}
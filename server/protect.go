@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+const (
+	protectRealm       = "dsplay"
+	protectTokenParam  = "token"
+	protectTokenCookie = "dsplay_token"
+)
+
+// protect requires a valid basic-auth pair or bearer token on routes under
+// p.Paths (every route when empty). A visitor opening a link with ?token=
+// gets the token as a cookie, so shared links keep working as they browse.
+func protect(p Protection) func(http.Handler) http.Handler {
+	return guard(p, protectTokenCookie)
+}
+
+// guard is protect, remembering link tokens in the named cookie.
+func guard(p Protection, cookie string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(p.Basic) == 0 && len(p.Tokens) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !p.covers(r.URL.Path) || p.allows(w, r, cookie) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(p.Basic) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+protectRealm+`", charset="UTF-8"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+protectRealm+`"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+// covers reports whether urlPath falls under a protected prefix.
+func (p Protection) covers(urlPath string) bool {
+	if len(p.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range p.Paths {
+		if strings.HasPrefix(urlPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows checks the request's credentials, remembering a ?token= in cookie.
+func (p Protection) allows(w http.ResponseWriter, r *http.Request, cookie string) bool {
+	if user, pass, ok := r.BasicAuth(); ok && matchAny(p.Basic, user+":"+pass) {
+		return true
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && matchAny(p.Tokens, token) {
+		return true
+	}
+	if c, err := r.Cookie(cookie); err == nil && matchAny(p.Tokens, c.Value) {
+		return true
+	}
+	if token := r.URL.Query().Get(protectTokenParam); token != "" && matchAny(p.Tokens, token) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookie,
+			Value:    token,
+			Path:     cookiePath(r),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return true
+	}
+	return false
+}
+
+// matchAny compares got against each candidate in constant time.
+func matchAny(candidates []string, got string) bool {
+	ok := false
+	for _, c := range candidates {
+		if subtle.ConstantTimeCompare([]byte(c), []byte(got)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProtect(t *testing.T) {
+	srv, c := newTestServerWith(t, fstest.MapFS{
+		"index.html":       {Data: []byte("home")},
+		"admin/index.html": {Data: []byte("secret")},
+	}, TestHandlerOptions{Protect: Protection{
+		Basic:  []string{"ada:lovelace"},
+		Tokens: []string{"s3cret"},
+		Paths:  []string{"/admin/"},
+	}})
+	tests := []struct {
+		name   string
+		path   string
+		auth   func(*http.Request)
+		status int
+	}{
+		{"open path", "/", nil, http.StatusOK},
+		{"no credentials", "/admin/", nil, http.StatusUnauthorized},
+		{"basic auth", "/admin/", func(r *http.Request) { r.SetBasicAuth("ada", "lovelace") }, http.StatusOK},
+		{"wrong password", "/admin/", func(r *http.Request) { r.SetBasicAuth("ada", "babbage") }, http.StatusUnauthorized},
+		{"bearer token", "/admin/", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, http.StatusOK},
+		{"wrong token", "/admin/", func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") }, http.StatusUnauthorized},
+		{"wrong link token", "/admin/?token=guess", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+		if tt.auth != nil {
+			tt.auth(req)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.path, res.StatusCode, tt.status)
+		}
+		if tt.status == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 without WWW-Authenticate", tt.name)
+		}
+	}
+
+	// A link's ?token= is remembered, so the visitor stays in as they browse
+	if status, body := get(t, c, srv.URL+"/admin/?token=s3cret"); status != http.StatusOK || body != "secret" {
+		t.Fatalf("GET /admin/?token= = %d %q, want 200 secret", status, body)
+	}
+	if status, _ := get(t, c, srv.URL+"/admin/"); status != http.StatusOK {
+		t.Errorf("GET /admin/ after ?token= = %d, want 200", status)
+	}
+}
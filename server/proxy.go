@@ -0,0 +1,131 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies are the reverse proxies (nginx, Caddy, a platform load
+// balancer) whose X-Forwarded-* headers are believed.
+type trustedProxies struct {
+	prefixes []netip.Prefix
+	unix     bool // connections on --unix-socket, which only local processes can open
+}
+
+// parseTrustedProxies accepts IP addresses, CIDR ranges and "unix".
+func parseTrustedProxies(list []string) (trustedProxies, error) {
+	var t trustedProxies
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		switch {
+		case s == "":
+		case s == "unix":
+			t.unix = true
+		case strings.Contains(s, "/"):
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return trustedProxies{}, fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			t.prefixes = append(t.prefixes, p.Masked())
+		default:
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return trustedProxies{}, fmt.Errorf("trusted proxy %q: %w", s, err)
+			}
+			t.prefixes = append(t.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		}
+	}
+	return t, nil
+}
+
+func (t trustedProxies) trusts(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range t.prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// middleware rewrites requests arriving from a trusted proxy so the rest of
+// the server sees the original client: RemoteAddr becomes the nearest
+// untrusted address in X-Forwarded-For, Host comes from X-Forwarded-Host
+// and the scheme from X-Forwarded-Proto. Headers from anyone else are
+// ignored, since clients can send whatever they like.
+func (t trustedProxies) middleware(next http.Handler) http.Handler {
+	if len(t.prefixes) == 0 && !t.unix {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !t.trusts(clientIP(r)) && !(t.unix && isUnixPeer(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.Clone(r.Context())
+		if client := t.forwardedFor(r.Header.Values("X-Forwarded-For")); client != "" {
+			r.RemoteAddr = client
+		}
+		if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+			r.Host = host
+		}
+		if proto := strings.ToLower(firstValue(r.Header.Get("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+			r.URL.Scheme = proto
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedFor walks X-Forwarded-For from the nearest hop outwards,
+// skipping trusted proxies, and returns the first address that isn't one.
+// Addresses further out could have been forged by the client.
+func (t trustedProxies) forwardedFor(headers []string) string {
+	var hops []string
+	for _, h := range headers {
+		for _, hop := range strings.Split(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			return ""
+		}
+		if !t.trusts(hops[i]) || i == 0 {
+			return addr.Unmap().String()
+		}
+	}
+	return ""
+}
+
+// isUnixPeer reports whether r arrived on a Unix socket, whose peers have
+// no IP address.
+func isUnixPeer(r *http.Request) bool {
+	_, err := netip.ParseAddr(clientIP(r))
+	return err != nil
+}
+
+func firstValue(header string) string {
+	v, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(v)
+}
+
+// requestScheme is "https" or "http": the scheme the client used, as
+// reported by a trusted proxy or seen on the connection.
+func requestScheme(r *http.Request) string {
+	switch {
+	case r.URL.Scheme != "":
+		return r.URL.Scheme
+	case r.TLS != nil:
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	files := fstest.MapFS{"index.html": {Data: []byte("{{.ClientIP}} {{.Scheme}} {{.Host}}")}}
+	forwarded := map[string]string{
+		"X-Forwarded-For":   "198.51.100.9, 203.0.113.7",
+		"X-Forwarded-Host":  "play.example.com",
+		"X-Forwarded-Proto": "https",
+	}
+	tests := []struct {
+		name    string
+		trusted []string
+		want    func(host string) string
+	}{
+		{"trusted proxy", []string{"127.0.0.0/8"}, func(string) string { return "203.0.113.7 https play.example.com" }},
+		{"trusted chain", []string{"127.0.0.1", "203.0.113.0/24"}, func(string) string { return "198.51.100.9 https play.example.com" }},
+		{"untrusted peer", []string{"10.0.0.0/8"}, func(host string) string { return "127.0.0.1 http " + host }},
+		{"no proxies", nil, func(host string) string { return "127.0.0.1 http " + host }},
+	}
+	for _, tt := range tests {
+		srv, c := newTestServerWith(t, files, TestHandlerOptions{TrustedProxies: tt.trusted})
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/", nil)
+		for k, v := range forwarded {
+			req.Header.Set(k, v)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		u, _ := url.Parse(srv.URL)
+		if want := tt.want(u.Host); string(body) != want {
+			t.Errorf("%s: got %q, want %q", tt.name, body, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// bucketIdle is how long an untouched token bucket is kept; by then it has
+// refilled anyway.
+const bucketIdle = time.Minute
+
+// RateLimiter is a token-bucket limiter on requests per second, per client
+// IP and per session. A rate of 0 leaves that dimension unlimited.
+type RateLimiter struct {
+	perIP      float64
+	perSession float64
+	burst      float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows perIP and perSession requests per second on
+// average, with bursts of up to burst requests (at least the rate itself).
+// It returns nil, which limits nothing, when both rates are 0.
+func NewRateLimiter(perIP, perSession float64, burst int) *RateLimiter {
+	if perIP <= 0 && perSession <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		perIP:      perIP,
+		perSession: perSession,
+		burst:      max(float64(burst), math.Ceil(perIP), math.Ceil(perSession), 1),
+		buckets:    make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token for the request from ip and sessionID (which may be
+// empty for a new visitor). When either bucket is empty it returns false
+// and how long until a token is available.
+func (l *RateLimiter) Allow(ip, sessionID string) (bool, time.Duration) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > bucketIdle {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > bucketIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	var checks []*bucket
+	var rates []float64
+	if l.perIP > 0 {
+		checks, rates = append(checks, l.bucket("ip:"+ip, now)), append(rates, l.perIP)
+	}
+	if l.perSession > 0 && sessionID != "" {
+		checks, rates = append(checks, l.bucket("session:"+sessionID, now)), append(rates, l.perSession)
+	}
+
+	var wait time.Duration
+	for i, b := range checks {
+		b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*rates[i])
+		b.last = now
+		if b.tokens < 1 {
+			wait = max(wait, time.Duration((1-b.tokens)/rates[i]*float64(time.Second)))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, b := range checks {
+		b.tokens--
+	}
+	return true, 0
+}
+
+func (l *RateLimiter) bucket(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// rateLimit answers requests over l's limits with 429 Too Many Requests,
+// rendered with the playground's _errors/429.html when it has one.
+func rateLimit(l *RateLimiter, sm *SessionManager, h *Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := l.Allow(clientIP(r), sm.sessionID(r))
+			if ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			h.renderError(w, http.StatusTooManyRequests, "Too many requests, please slow down.", TemplateData{
+				URL:      r.URL.Path,
+				Method:   r.Method,
+				BasePath: basePath(r),
+				Globals:  h.globals,
+				Now:      h.clock.Now(),
+			})
+		})
+	}
+}
+
+// clientIP is the address the request came from, without its port.
+// Behind a reverse proxy this is the proxy's address, unless the proxy is
+// trusted and its X-Forwarded-For has replaced RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
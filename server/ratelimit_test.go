@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRateLimit(t *testing.T) {
+	files := fstest.MapFS{
+		"index.html":       {Data: []byte("home")},
+		"_errors/429.html": {Data: []byte("{{.Status}}: {{.Message}}")},
+	}
+
+	t.Run("per IP", func(t *testing.T) {
+		srv, c := newTestServerWith(t, files, TestHandlerOptions{RatePerIP: 0.01, RateBurst: 2})
+		for i := range 2 {
+			if status, _ := get(t, c, srv.URL+"/"); status != http.StatusOK {
+				t.Fatalf("request %d = %d, want 200 within the burst", i+1, status)
+			}
+		}
+		res, err := c.Get(srv.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusTooManyRequests || res.Header.Get("Retry-After") == "" {
+			t.Errorf("request 3 = %d Retry-After %q, want 429 with Retry-After", res.StatusCode, res.Header.Get("Retry-After"))
+		}
+		if status, body := get(t, c, srv.URL+"/"); status != http.StatusTooManyRequests || body != "429: Too many requests, please slow down." {
+			t.Errorf("request 4 = %d %q, want 429 from _errors/429.html", status, body)
+		}
+	})
+
+	t.Run("per session", func(t *testing.T) {
+		srv, c := newTestServerWith(t, files, TestHandlerOptions{RatePerSession: 0.01, RateBurst: 1})
+		// The first request has no session yet, so only the next one counts
+		for _, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			if status, _ := get(t, c, srv.URL+"/"); status != want {
+				t.Errorf("GET / = %d, want %d", status, want)
+			}
+		}
+		// A new visitor has a bucket of their own
+		jar, _ := cookiejar.New(nil)
+		if status, _ := get(t, &http.Client{Jar: jar}, srv.URL+"/"); status != http.StatusOK {
+			t.Errorf("GET / from a new session = %d, want 200", status)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRecordedBody caps how much of each request and response body a
+	// recording keeps.
+	maxRecordedBody = 1 << 20
+
+	// maxRecordedEvents caps the events kept from one (possibly endless)
+	// SSE stream.
+	maxRecordedEvents = 10000
+)
+
+// Exchange is one recorded request and the response it got. SSE responses
+// are split into their events, each stamped with when it was sent.
+type Exchange struct {
+	Time           time.Time     `json:"time"`
+	Method         string        `json:"method"`
+	Host           string        `json:"host"`
+	Path           string        `json:"path"` // including any query string
+	Header         http.Header   `json:"header"`
+	Body           string        `json:"body,omitempty"`
+	Status         int           `json:"status"`
+	ResponseHeader http.Header   `json:"response_header"`
+	Response       string        `json:"response,omitempty"` // non-SSE response body
+	Events         []RecordedSSE `json:"events,omitempty"`
+	Duration       time.Duration `json:"duration"`
+	Truncated      bool          `json:"truncated,omitempty"` // a body passed maxRecordedBody
+}
+
+// RecordedSSE is one server-sent event, At after the request started.
+type RecordedSSE struct {
+	At    time.Duration `json:"at"`
+	Event string        `json:"event"`
+}
+
+// Recorder appends every exchange the server handles to a JSON Lines file,
+// for dsplay replay. Cookies are left out: replay keeps its own cookie jar.
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder opens (or appends to) the recording at path.
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording: %w", err)
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Close flushes and closes the recording.
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.f.Close()
+}
+
+// Wrap records each request through next once it completes. WebSocket
+// upgrades pass straight through: their frames aren't recorded.
+func (rec *Recorder) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		x := &Exchange{
+			Time:   time.Now(),
+			Method: r.Method,
+			Host:   r.Host,
+			Path:   r.URL.RequestURI(),
+			Header: r.Header.Clone(),
+		}
+		x.Header.Del("Cookie")
+
+		var body limitedBuffer
+		if r.Body != nil {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(r.Body, &body), r.Body}
+		}
+		rw := &recordingWriter{ResponseWriter: w, x: x, start: x.Time}
+		next.ServeHTTP(rw, r)
+
+		x.Body = body.String()
+		x.Truncated = x.Truncated || body.truncated
+		x.Duration = time.Since(x.Time)
+		rw.finish()
+		rec.write(x)
+	})
+}
+
+func (rec *Recorder) write(x *Exchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if err := rec.enc.Encode(x); err != nil {
+		log.Printf("Recording %s %s: %v", x.Method, x.Path, err)
+	}
+}
+
+// recordingWriter copies a response into its Exchange as it is written.
+type recordingWriter struct {
+	http.ResponseWriter
+	x       *Exchange
+	start   time.Time
+	sse     bool
+	body    limitedBuffer
+	pending string // SSE text not yet terminated by a blank line
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if rw.x.Status == 0 {
+		rw.x.Status = status
+		rw.x.ResponseHeader = rw.Header().Clone()
+		rw.x.ResponseHeader.Del("Set-Cookie")
+		rw.sse = strings.HasPrefix(rw.Header().Get("Content-Type"), "text/event-stream")
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	if rw.x.Status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if !rw.sse {
+		rw.body.Write(p)
+		return rw.ResponseWriter.Write(p)
+	}
+
+	rw.pending += string(p)
+	for {
+		i := strings.Index(rw.pending, "\n\n")
+		if i < 0 {
+			break
+		}
+		rw.addEvent(rw.pending[:i])
+		rw.pending = rw.pending[i+2:]
+	}
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recordingWriter) addEvent(event string) {
+	if len(rw.x.Events) >= maxRecordedEvents {
+		rw.x.Truncated = true
+		return
+	}
+	rw.x.Events = append(rw.x.Events, RecordedSSE{At: time.Since(rw.start), Event: event})
+}
+
+func (rw *recordingWriter) finish() {
+	if rw.x.Status == 0 {
+		rw.x.Status = http.StatusOK
+	}
+	if strings.TrimSpace(rw.pending) != "" {
+		rw.addEvent(rw.pending)
+	}
+	rw.x.Response = rw.body.String()
+	rw.x.Truncated = rw.x.Truncated || rw.body.truncated
+}
+
+func (rw *recordingWriter) Flush() {
+	http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// lift deadlines on streams.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter { return rw.ResponseWriter }
+
+// limitedBuffer keeps the first maxRecordedBody bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxRecordedBody - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// ReadRecording loads the exchanges in a recording file.
+func ReadRecording(path string) ([]Exchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var exchanges []Exchange
+	dec := json.NewDecoder(f)
+	for {
+		var x Exchange
+		if err := dec.Decode(&x); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading %s: entry %d: %w", path, len(exchanges)+1, err)
+		}
+		exchanges = append(exchanges, x)
+	}
+	return exchanges, nil
+}
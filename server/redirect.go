@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// errRedirected ends an SSE stream after it has sent the browser elsewhere.
+var errRedirected = errors.New("stream ended by a redirect")
+
+// redirect returns the section's redirect target: its own redirect: option,
+// or the file's. It may contain template actions.
+func (s sectionEntry) redirect() string {
+	if s.options.Redirect != "" {
+		return s.options.Redirect
+	}
+	return s.frontmatter.Redirect
+}
+
+// redirectTarget renders a redirect: target. Paths starting with / are
+// relative to the playground root, so they get the mount prefix.
+func (h *Handler) redirectTarget(section sectionEntry, td TemplateData) (string, error) {
+	target, err := h.renderText(section.redirect(), td)
+	if err != nil {
+		return "", newTemplateError(fmt.Errorf("redirect: %w", err), section)
+	}
+	return basePathURL(td.BasePath, strings.TrimSpace(target)), nil
+}
+
+// basePathURL prefixes a root-relative URL with the playground's mount
+// prefix, leaving absolute and protocol-relative URLs alone.
+func basePathURL(basePath, target string) string {
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		return basePath + target
+	}
+	return target
+}
+
+// sendRedirect tells the browser to navigate to target, in dialect d.
+func sendRedirect(sse *datastar.ServerSentEventGenerator, d eventDialect, target string) error {
+	if d == dialectMerge {
+		js, _ := json.Marshal(target)
+		return sse.Send(datastar.EventType("datastar-execute-script"), []string{"script setTimeout(() => window.location = " + string(js) + ")"})
+	}
+	return sse.Redirect(target)
+}
+
+// serveRedirect answers a non-streaming request with the section's redirect:
+// an SSE redirect event for Datastar requests, which are fetches, or an HTTP
+// redirect (303 See Other, or the file's 3xx status:) for everything else.
+func (h *Handler) serveRedirect(w http.ResponseWriter, r *http.Request, section sectionEntry, td TemplateData, status int, isDatastarRequest bool) {
+	target, err := h.redirectTarget(section, td)
+	if err != nil {
+		h.writeTemplateError(w, err)
+		return
+	}
+	h.debugLog("  redirect: %s", target)
+	if isDatastarRequest {
+		sendRedirect(datastar.NewSSE(w, r), dialectFor(section.datastarVersion(td.DatastarVersion)), target)
+		return
+	}
+	if status < 300 || status > 399 {
+		status = http.StatusSeeOther
+	}
+	http.Redirect(w, r, target, status)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RenderOptions describes an offline render of one playground section.
+type RenderOptions struct {
+	PlaygroundsDir  string
+	Route           string         // URL path, e.g. "/todos/"
+	Method          string         // HTTP method (default GET)
+	Signals         map[string]any // Datastar signals sent with the request
+	Globals         map[string]any // .Globals, as set with --set or dsplay.yaml
+	SSE             bool           // render the route's SSE files instead of its HTML files
+	Section         int            // index into the route's sections; -1 picks as the server would
+	StrictTemplates bool
+}
+
+// Render renders a section of a route to a string with synthetic template
+// data, without starting the server. Side effects (outbox, jobs, preferences)
+// go to throwaway in-memory stores.
+func Render(opts RenderOptions) (string, error) {
+	route := opts.Route
+	if !strings.HasPrefix(route, "/") {
+		route = "/" + route
+	}
+	if route != "/" && !strings.HasSuffix(route, "/") {
+		route += "/"
+	}
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	signals := opts.Signals
+	if signals == nil {
+		signals = map[string]any{}
+	}
+
+	fsys := os.DirFS(opts.PlaygroundsDir)
+	routes, err := ScanFS(fsys)
+	if err != nil {
+		return "", fmt.Errorf("scanning playgrounds: %w", err)
+	}
+	manifest, err := LoadManifest(fsys)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", manifestFile, err)
+	}
+	fixtures, err := LoadData(fsys)
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", dataDir, err)
+	}
+	rf, wildcard, ok := MatchRoute(routes, route)
+	if !ok {
+		return "", fmt.Errorf("no route %s", route)
+	}
+	files := rf.LookupHTML(method)
+	kind := "HTML"
+	if opts.SSE {
+		files = rf.LookupSSE(method)
+		kind = "SSE"
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no %s files for %s %s", kind, method, route)
+	}
+
+	prefs, _ := NewPrefStore("", 0)
+	h := NewHandler(HandlerConfig{
+		FS:              fsys,
+		Counters:        NewCounters(),
+		Outbox:          NewOutbox(),
+		Limiter:         NewConnLimiter(0, 0, 0),
+		Jobs:            NewJobQueue(nil, 1),
+		Prefs:           prefs,
+		StrictTemplates: opts.StrictTemplates,
+	})
+
+	td := TemplateData{
+		GlobalHits:      1,
+		URLHits:         1,
+		SessionURLHits:  1,
+		Username:        "render",
+		SessionID:       "s-render",
+		URL:             route,
+		Wildcard:        wildcard,
+		Method:          method,
+		Signals:         signals,
+		SSEMessageCount: 1,
+		LoopCounter:     1,
+		LoopCounter0:    0,
+		DatastarVersion: manifest.DatastarVersion,
+		Data:            fixtures,
+		Globals:         opts.Globals,
+	}
+
+	sections := collectSections(files)
+	pos := opts.Section
+	switch {
+	case pos >= len(sections):
+		return "", fmt.Errorf("section %d out of range (%s %s has %d)", pos, method, route, len(sections))
+	case pos >= 0:
+	case hasConditions(sections):
+		if pos, ok = h.firstMatch(sections, td); !ok {
+			return "", fmt.Errorf("no section condition matched for %s %s", method, route)
+		}
+	default:
+		pos = 0
+	}
+
+	return h.render(sections[pos], td)
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// replayTimeout bounds each non-streaming replayed request.
+const replayTimeout = 30 * time.Second
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	Target string  // base URL to send requests to, e.g. http://localhost:8080
+	Speed  float64 // replay speed multiplier (1 = recorded pace, 0 = no waiting)
+}
+
+// ReplayResult compares one replayed exchange with its recording.
+type ReplayResult struct {
+	Exchange Exchange
+	Status   int
+	Events   int      // SSE events received
+	Diffs    []string // how the replay differed from the recording
+	Err      error
+}
+
+// Replay re-sends recorded exchanges to opts.Target, starting each at its
+// recorded offset so streams overlap the requests that fed them, as they
+// did originally. All requests share one cookie jar, so they replay as a
+// single visitor. SSE streams are read for as long as they were recorded.
+func Replay(ctx context.Context, exchanges []Exchange, opts ReplayOptions) ([]ReplayResult, error) {
+	if len(exchanges) == 0 {
+		return nil, nil
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	target := strings.TrimSuffix(opts.Target, "/")
+
+	exchanges = slices.Clone(exchanges)
+	slices.SortStableFunc(exchanges, func(a, b Exchange) int { return a.Time.Compare(b.Time) })
+	scale := func(d time.Duration) time.Duration {
+		if opts.Speed <= 0 {
+			return 0
+		}
+		return time.Duration(float64(d) / opts.Speed)
+	}
+
+	start := time.Now()
+	results := make([]ReplayResult, len(exchanges))
+	var wg sync.WaitGroup
+	for i, x := range exchanges {
+		if wait := scale(x.Time.Sub(exchanges[0].Time)) - time.Since(start); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				wg.Wait()
+				return results[:i], ctx.Err()
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = replayOne(ctx, client, target, x, scale(x.Duration))
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func replayOne(ctx context.Context, client *http.Client, target string, x Exchange, streamFor time.Duration) ReplayResult {
+	res := ReplayResult{Exchange: x}
+	streaming := len(x.Events) > 0
+	timeout := replayTimeout
+	if streaming {
+		timeout = streamFor + time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, x.Method, target+x.Path, strings.NewReader(x.Body))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	for k, vs := range x.Header {
+		switch http.CanonicalHeaderKey(k) {
+		case "Host", "Content-Length", "Cookie":
+			continue
+		}
+		req.Header[k] = vs
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer resp.Body.Close()
+
+	res.Status = resp.StatusCode
+	if res.Status != x.Status {
+		res.Diffs = append(res.Diffs, fmt.Sprintf("status %d, recorded %d", res.Status, x.Status))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		io.Copy(io.Discard, resp.Body)
+		if streaming {
+			res.Diffs = append(res.Diffs, "got a plain response, recorded a stream")
+		}
+		return res
+	}
+
+	// Count events until the stream ends or the recorded duration is up
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), maxRecordedBody)
+	inEvent := false
+	for sc.Scan() {
+		if sc.Text() == "" {
+			if inEvent {
+				res.Events++
+			}
+			inEvent = false
+		} else {
+			inEvent = true
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		res.Err = err
+	}
+	if res.Events != len(x.Events) {
+		res.Diffs = append(res.Diffs, fmt.Sprintf("%d events, recorded %d", res.Events, len(x.Events)))
+	}
+	return res
+}
+
+// ExportHTTP writes exchanges as a .http file (VS Code REST Client and
+// JetBrains HTTP Client format), with requests sent to base.
+func ExportHTTP(w io.Writer, exchanges []Exchange, base string) error {
+	base = strings.TrimSuffix(base, "/")
+	bw := bufio.NewWriter(w)
+	for _, x := range exchanges {
+		fmt.Fprintf(bw, "### %s %s (recorded %d)\n", x.Method, x.Path, x.Status)
+		fmt.Fprintf(bw, "%s %s%s\n", x.Method, base, x.Path)
+		for _, k := range slices.Sorted(maps.Keys(x.Header)) {
+			if k == "Content-Length" {
+				continue
+			}
+			for _, v := range x.Header[k] {
+				fmt.Fprintf(bw, "%s: %s\n", k, v)
+			}
+		}
+		if x.Body != "" {
+			fmt.Fprintf(bw, "\n%s\n", x.Body)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// ExportHAR writes exchanges as an HTTP Archive (HAR 1.2), viewable in
+// browser devtools, with URLs rooted at base.
+func ExportHAR(w io.Writer, exchanges []Exchange, base string) error {
+	base = strings.TrimSuffix(base, "/")
+	type nameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	headers := func(h http.Header) []nameValue {
+		list := []nameValue{}
+		for _, k := range slices.Sorted(maps.Keys(h)) {
+			for _, v := range h[k] {
+				list = append(list, nameValue{k, v})
+			}
+		}
+		return list
+	}
+
+	entries := make([]map[string]any, 0, len(exchanges))
+	for _, x := range exchanges {
+		request := map[string]any{
+			"method":      x.Method,
+			"url":         base + x.Path,
+			"httpVersion": "HTTP/1.1",
+			"headers":     headers(x.Header),
+			"queryString": []nameValue{},
+			"cookies":     []nameValue{},
+			"headersSize": -1,
+			"bodySize":    len(x.Body),
+		}
+		if x.Body != "" {
+			request["postData"] = map[string]any{"mimeType": x.Header.Get("Content-Type"), "text": x.Body}
+		}
+		text := x.Response
+		if len(x.Events) > 0 {
+			var b strings.Builder
+			for _, ev := range x.Events {
+				b.WriteString(ev.Event)
+				b.WriteString("\n\n")
+			}
+			text = b.String()
+		}
+		entries = append(entries, map[string]any{
+			"startedDateTime": x.Time.Format(time.RFC3339Nano),
+			"time":            x.Duration.Milliseconds(),
+			"request":         request,
+			"response": map[string]any{
+				"status":      x.Status,
+				"statusText":  http.StatusText(x.Status),
+				"httpVersion": "HTTP/1.1",
+				"headers":     headers(x.ResponseHeader),
+				"cookies":     []nameValue{},
+				"content":     map[string]any{"size": len(text), "mimeType": x.ResponseHeader.Get("Content-Type"), "text": text},
+				"redirectURL": x.ResponseHeader.Get("Location"),
+				"headersSize": -1,
+				"bodySize":    len(text),
+			},
+			"cache":   map[string]any{},
+			"timings": map[string]any{"send": 0, "wait": x.Duration.Milliseconds(), "receive": 0},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"log": map[string]any{
+			"version": "1.2",
+			"creator": map[string]any{"name": "dsplay", "version": "1"},
+			"entries": entries,
+		},
+	})
+}
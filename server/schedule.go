@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// scheduleFile lists events the playground publishes on a timer, whether or
+// not any stream is open. It is watched for changes while the server runs.
+const scheduleFile = "_schedule.yaml"
+
+// ScheduleEntry is one timed event in _schedule.yaml. Streams receive it
+// like a webhook of the same name (subscribe: [name]), with .Hook.Body set
+// to the rendered template, or to payload with every string in it
+// rendered as a template.
+type ScheduleEntry struct {
+	Name     string        `yaml:"name"`
+	Every    time.Duration `yaml:"every"`    // fixed interval, e.g. 5s
+	Cron     string        `yaml:"cron"`     // or a cron expression, e.g. "0 * * * *"
+	Template string        `yaml:"template"` // text body
+	Payload  any           `yaml:"payload"`  // structured body
+}
+
+// parseSchedule reads and checks the entries in _schedule.yaml.
+func parseSchedule(data []byte) ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		switch {
+		case e.Name == "" || strings.ContainsAny(e.Name, ".*> \t"):
+			return nil, fmt.Errorf("entry %q: name must be a single word", e.Name)
+		case (e.Every > 0) == (e.Cron != ""):
+			return nil, fmt.Errorf("entry %q: set one of every or cron", e.Name)
+		case e.Every > 0 && e.Every < 100*time.Millisecond:
+			return nil, fmt.Errorf("entry %q: every must be at least 100ms", e.Name)
+		}
+		if e.Cron != "" {
+			if _, err := cron.ParseStandard(e.Cron); err != nil {
+				return nil, fmt.Errorf("entry %q: cron %q: %w", e.Name, e.Cron, err)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// RunSchedule publishes the playground's scheduled events until ctx is
+// done, restarting them whenever _schedule.yaml changes.
+func (h *Handler) RunSchedule(ctx context.Context) {
+	watchFile(ctx, h.fsys, scheduleFile, func(ctx context.Context, data []byte) error {
+		entries, err := parseSchedule(data)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			go h.runScheduleEntry(ctx, e)
+		}
+		if len(entries) > 0 {
+			log.Printf("Schedule: %d entries from %s", len(entries), scheduleFile)
+		}
+		return nil
+	})
+}
+
+// runScheduleEntry publishes e each time it comes due on the playground
+// clock.
+func (h *Handler) runScheduleEntry(ctx context.Context, e ScheduleEntry) {
+	var sched cron.Schedule
+	if e.Cron != "" {
+		sched, _ = cron.ParseStandard(e.Cron) // checked by parseSchedule
+	}
+	for {
+		next := h.clock.Now().Add(e.Every)
+		if sched != nil {
+			if next = sched.Next(h.clock.Now()); next.IsZero() {
+				return
+			}
+		}
+		if h.clock.SleepUntil(ctx, next, nil) != nil {
+			return
+		}
+		if err := h.publishScheduled(e); err != nil {
+			log.Printf("Schedule %s: %v", e.Name, err)
+		}
+	}
+}
+
+// publishScheduled renders e and publishes it on its hook subject.
+func (h *Handler) publishScheduled(e ScheduleEntry) error {
+	fixtures, err := h.loadData(context.Background())
+	if err != nil {
+		return err
+	}
+	td := TemplateData{Data: fixtures, Globals: h.globals, Now: h.clock.Now()}
+	render := func(s string) (string, error) {
+		return renderTemplate(h.templates, s, td, h.templateFuncs(td), h.strictTemplates)
+	}
+
+	event := HookEvent{Name: e.Name, Time: td.Now}
+	if e.Template != "" {
+		if event.Body, err = render(e.Template); err != nil {
+			return err
+		}
+	} else if event.Body, err = renderStrings(e.Payload, render); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return h.nc.Publish(h.subjects.hook(e.Name), data)
+}
+
+// renderStrings copies v, a decoded YAML value, with every string in it
+// passed through render.
+func renderStrings(v any, render func(string) (string, error)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return render(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			r, err := renderStrings(item, render)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			r, err := renderStrings(item, render)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = r
+		}
+		return out, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/expr"
+	"github.com/dataSPA/dataSPA-playground/script"
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+// scriptFile is a route's handler script, run before its templates to pick
+// a section, compute data for them, or redirect (see package script).
+const scriptFile = "handler.star"
+
+func parseScript(fsys fs.FS, name string) (*script.Script, error) {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return script.Parse(name, string(src), scriptNames())
+}
+
+// scriptNames lists the names scriptEnv predeclares, so a script that
+// misspells one fails when the playground loads.
+func scriptNames() []string {
+	var names []string
+	for name := range scriptEnv(&http.Request{URL: &url.URL{}}, TemplateData{}) {
+		names = append(names, name)
+	}
+	return names
+}
+
+// scriptEnv is what a handler.star sees: the names section conditions use,
+// plus the query string, request headers (lower-cased), the catch-all
+// wildcard, fixtures and globals.
+func scriptEnv(r *http.Request, td TemplateData) map[string]any {
+	env := exprEnv(td)
+	query := make(map[string]any)
+	for key, values := range r.URL.Query() {
+		query[key] = values[0]
+	}
+	headers := make(map[string]any)
+	for key := range r.Header {
+		headers[strings.ToLower(key)] = r.Header.Get(key)
+	}
+	env["query"] = query
+	env["headers"] = headers
+	env["wildcard"] = td.Wildcard
+	env["data"] = td.Data
+	env["globals"] = td.Globals
+	return env
+}
+
+// scriptResult is the dict a handler.star's handle() returns, e.g.
+// {"section": "discount", "data": {"total": total}} or {"redirect": "/login"}.
+type scriptResult struct {
+	Redirect string
+	Status   int // redirect status, 302 by default
+	Section  string
+	Data     map[string]any
+}
+
+func decodeScriptResult(v any) (scriptResult, error) {
+	var res scriptResult
+	if v == nil {
+		return res, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return res, fmt.Errorf("%s must return a dict, not %T", scriptFile, v)
+	}
+	for key, value := range m {
+		switch key {
+		case "redirect":
+			res.Redirect = expr.ToString(value)
+		case "status":
+			n, ok := value.(int64)
+			if !ok || n < 300 || n > 399 {
+				return res, fmt.Errorf("%s: status must be a 3xx redirect status", scriptFile)
+			}
+			res.Status = int(n)
+		case "section":
+			res.Section = expr.ToString(value)
+		case "data":
+			if res.Data, ok = value.(map[string]any); !ok && value != nil {
+				return res, fmt.Errorf("%s: data must be a dict", scriptFile)
+			}
+		default:
+			return res, fmt.Errorf("%s: unknown result key %q (want redirect, status, section or data)", scriptFile, key)
+		}
+	}
+	if res.Status != 0 && res.Redirect == "" {
+		return res, fmt.Errorf("%s: status is only for redirects", scriptFile)
+	}
+	return res, nil
+}
+
+// runScript runs the route's handler.star and applies its result to td. It
+// reports false when the script answered the request itself, with a
+// redirect or an error.
+func (h *Handler) runScript(w http.ResponseWriter, r *http.Request, s *script.Script, td *TemplateData, isDatastarRequest bool) bool {
+	v, err := s.Run(scriptEnv(r, *td))
+	var res scriptResult
+	if err == nil {
+		res, err = decodeScriptResult(v)
+	}
+	if err != nil {
+		h.debugLog("  script error: %v", err)
+		http.Error(w, fmt.Sprintf("Script error: %v", err), http.StatusInternalServerError)
+		return false
+	}
+
+	if res.Redirect != "" {
+		target := basePathURL(td.BasePath, res.Redirect)
+		h.debugLog("  script → redirect %s", target)
+		// Datastar requests are fetches, so the browser is told to navigate
+		if isDatastarRequest {
+			datastar.NewSSE(w, r).Redirect(target)
+			return false
+		}
+		status := res.Status
+		if status == 0 {
+			status = http.StatusFound
+		}
+		http.Redirect(w, r, target, status)
+		return false
+	}
+
+	td.Vars, td.Section = res.Data, res.Section
+	h.debugLog("  script → section=%q data=%v", res.Section, res.Data)
+	return true
+}
+
+// pickedSection finds the section named by a handler.star.
+func pickedSection(sections []sectionEntry, name string) (int, bool) {
+	for i, s := range sections {
+		if s.options.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/expr"
+	"gopkg.in/yaml.v3"
+)
+
+// Section selection modes for the select: frontmatter key.
+const (
+	selectSequential = "" // default: advance through sections in order
+	selectRandom     = "random"
+)
+
+// isRandom reports whether the section's file picks sections at random.
+func (s sectionEntry) isRandom() bool {
+	return s.frontmatter.Select == selectRandom
+}
+
+// pickRandom returns a random index into sections, honoring each section's
+// weight (sections without an explicit weight count as 1).
+func pickRandom(sections []sectionEntry) int {
+	var total float64
+	for _, s := range sections {
+		total += s.weight
+	}
+	if total <= 0 {
+		return rand.IntN(len(sections))
+	}
+
+	n := rand.Float64() * total
+	for i, s := range sections {
+		n -= s.weight
+		if n < 0 {
+			return i
+		}
+	}
+	return len(sections) - 1
+}
+
+// SelectBy is the select_by: frontmatter, which takes the served section
+// from the request instead of the session's sequence, so each state of a
+// demo has its own link:
+//
+//	select_by: query:step    # ?step=2 serves the second section
+//	select_by: signal:tab    # or the section whose name: matches $tab
+//
+// A request without the value falls back to the sequence.
+type SelectBy struct {
+	From string // "query" or "signal"
+	Name string
+}
+
+func (sb *SelectBy) UnmarshalYAML(node *yaml.Node) error {
+	from, name, ok := strings.Cut(node.Value, ":")
+	if node.Kind != yaml.ScalarNode || !ok || name == "" || from != "query" && from != "signal" {
+		return fmt.Errorf("select_by: want query:<name> or signal:<name>")
+	}
+	*sb = SelectBy{From: from, Name: name}
+	return nil
+}
+
+// selectedSection returns the section the request selects by the file's
+// select_by:, a 1-based number or a section's name. ok is false when the
+// file has no select_by: or the request doesn't carry the value.
+func selectedSection(r *http.Request, sections []sectionEntry, td TemplateData) (pos int, ok bool, err error) {
+	sb := sections[0].frontmatter.SelectBy
+	var v any
+	switch sb.From {
+	case "query":
+		if r.URL.Query().Has(sb.Name) {
+			v = r.URL.Query().Get(sb.Name)
+		}
+	case "signal":
+		v = signalValue(td.Signals, sb.Name)
+	}
+	if v == nil || v == "" {
+		return 0, false, nil
+	}
+
+	if n, isNum := v.(float64); isNum && n == math.Trunc(n) {
+		v = strconv.Itoa(int(n))
+	}
+	key := expr.ToString(v)
+	if n, err := strconv.Atoi(key); err == nil {
+		if n < 1 || n > len(sections) {
+			return 0, false, fmt.Errorf("%s:%s: no section %d (there are %d)", sb.From, sb.Name, n, len(sections))
+		}
+		return n - 1, true, nil
+	}
+	if i, found := pickedSection(sections, key); found {
+		return i, true, nil
+	}
+	return 0, false, fmt.Errorf("%s:%s: no section named %q", sb.From, sb.Name, key)
+}
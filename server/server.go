@@ -1,77 +1,487 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/sessions"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 )
 
 type Config struct {
-	Port           int
-	PlaygroundsDir string
-	SessionSecret  string
-	Debug          bool
+	Port            int
+	Addr            string // interface to bind, e.g. "127.0.0.1" ("" = all interfaces)
+	UnixSocket      string // listen on this unix socket instead of Addr:Port
+	BasePath        string // URL prefix the playground is served under, e.g. "/play"
+	PlaygroundsDir  string
+	ReadTimeout     time.Duration // max time to read a request, body included (0 = none)
+	WriteTimeout    time.Duration // max time to write a response; lifted for SSE and WebSocket streams (0 = none)
+	IdleTimeout     time.Duration // keep-alive idle timeout (0 = ReadTimeout)
+	MaxBodyBytes    int64         // max request body size, e.g. POSTed signals (0 = unlimited)
+	MaxUploadBytes  int64         // max multipart form body size, files included (0 = unlimited)
+	UploadDir       string        // where uploaded files are saved ("" = a temporary directory)
+	CompressLevel   int           // brotli/gzip level for HTML and static assets, 1-9 (0 = off)
+	Protect         Protection    // password/token protection for some or all routes
+	CSRF            bool          // require csrf_token on non-Datastar form posts
+	FS              fs.FS         // playground files; overrides PlaygroundsDir when set (e.g. an embed.FS)
+	SessionSecret   string
+	PreviousSecrets []string       // retired secrets still accepted when verifying cookies
+	SessionCookie   string         // session cookie name (default "ds-play")
+	SessionMaxAge   time.Duration  // session cookie lifetime (default 1h, negative = browser session)
+	SessionSecure   bool           // mark the session cookie Secure
+	SessionSameSite string         // lax (default), strict, or none (implies Secure)
+	SessionDomain   string         // session cookie domain
+	SessionBackend  string         // where session values live: cookie (default), fs, or nats
+	SessionDir      string         // directory for the fs backend, or JetStream storage for nats
+	NATSURL         string         // use this NATS server instead of an embedded one, e.g. to share nats sessions between instances
+	SessionStore    sessions.Store // custom backend (e.g. Redis) overriding SessionBackend, for embedders
+	Debug           bool
+	DebugEndpoints  bool           // mount net/http/pprof and expvar under /_debug/
+	Admin           bool           // mount the admin dashboard at /_admin/
+	AdminTokens     []string       // tokens required to use the admin dashboard and its endpoints (none = open to anyone)
+	Source          bool           // mount the source viewer at /_source/
+	MaxSSE          int            // max simultaneous SSE connections (0 = unlimited)
+	MaxSSESession   int            // max simultaneous SSE connections per session (0 = unlimited)
+	MaxSSEPerIP     int            // max simultaneous SSE connections per client IP (0 = unlimited)
+	RatePerIP       float64        // requests per second per client IP (0 = unlimited)
+	RatePerSession  float64        // requests per second per session (0 = unlimited)
+	RateBurst       int            // requests a client may make at once before the rate applies
+	JobWorkers      int            // simulated job queue workers
+	TailAllow       []string       // file path / command name patterns tail: routes may stream
+	Globals         map[string]any // values every template sees as .Globals
+	EnvAllow        []string       // environment variables env may read besides DSPLAY_VAR_*
+	FetchAllow      []string       // host patterns fetchJSON and fetchText may reach
+	FetchTimeout    time.Duration  // per-request timeout for fetchJSON and fetchText (default 5s)
+	WSOrigins       []string       // origin host patterns besides the request's own whose pages may open WebSockets
+	PrefsFile       string         // JSON file persisting visitor preferences ("" = memory only)
+	PrefsTTL        time.Duration  // forget a session's preferences once unseen this long (default 30 days)
+	StrictTemplates bool           // error on missing map keys instead of rendering "<no value>"
+	Dev             bool           // show template errors as in-page overlays
+	LiveReload      bool           // reload open pages when playground files change
+	Record          string         // append every request and response to this file, for dsplay replay
+	Monitor         *Monitor       // collect live activity for a dashboard; replaces the request log
+	TrustedProxies  []string       // proxy IPs or CIDR ranges whose X-Forwarded-* headers are believed
+	Static          []StaticMount  // extra static directories; static/ is served at /static unless overridden
+	Hooks           Hooks          // webhook endpoints at /_hooks/<name>, published on NATS
+	Extensions      []Extension    // template funcs, data and middleware from embedders
+
+	// Optional visitor login. A provider is enabled when its client ID is set.
+	PublicURL          string // externally visible base URL, used for OAuth callbacks
+	GitHubClientID     string
+	GitHubClientSecret string
+	OIDCIssuer         string
+	OIDCClientID       string
+	OIDCClientSecret   string
+
+	// Set by a Host running many sandboxed playgrounds on one NATS
+	// connection: the engine uses nc instead of its own and prefixes its
+	// subjects so the playgrounds stay apart.
+	nc       *nats.Conn
+	subjects subjects
 }
 
-func Run(cfg Config) error {
-	// Start embedded NATS
-	ns, nc, err := StartEmbeddedNATS()
+// authProviders builds the login providers enabled by cfg.
+func authProviders(cfg Config) ([]AuthProvider, error) {
+	var providers []AuthProvider
+	if cfg.GitHubClientID != "" {
+		providers = append(providers, NewGitHubProvider(cfg.GitHubClientID, cfg.GitHubClientSecret))
+	}
+	if cfg.OIDCClientID != "" {
+		if cfg.OIDCIssuer == "" {
+			return nil, fmt.Errorf("oidc login requires an issuer URL")
+		}
+		p, err := NewOIDCProvider(context.Background(), cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("configuring oidc login: %w", err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// New builds the playground engine described by cfg without binding a port,
+// so it can be mounted inside another application. Strip the mount prefix
+// before the request reaches the handler:
+//
+//	h, closer, err := server.New(server.Config{PlaygroundsDir: "./playground", SessionSecret: secret})
+//	if err != nil {
+//		return err
+//	}
+//	defer closer.Close()
+//	r.Mount("/play", http.StripPrefix("/play", h))
+//
+// The closer shuts down the embedded NATS server, or disconnects from
+// Config.NATSURL.
+func New(cfg Config) (http.Handler, io.Closer, error) {
+	e, err := newEngine(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, e, nil
+}
+
+// engine is a fully wired playground: its NATS connection, shared state,
+// and the router serving it.
+type engine struct {
+	ns       *natsserver.Server
+	nc       *nats.Conn
+	sharedNC bool // nc belongs to the host and outlives the engine
+	recorder *Recorder
+	handler  http.Handler
+	stop     context.CancelFunc // stops the schedule and source polling
+	uploads  *UploadStore
+	prefs    *PrefStore
+}
+
+func newEngine(cfg Config) (*engine, error) {
+	fsys := cfg.FS
+	if fsys == nil {
+		fsys = os.DirFS(cfg.PlaygroundsDir)
+	}
+
+	providers, err := authProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := NewPrefStore(cfg.PrefsFile, cfg.PrefsTTL)
+	if err != nil {
+		return nil, err
+	}
+	sameSite, err := ParseSameSite(cfg.SessionSameSite)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
 	if err != nil {
-		return fmt.Errorf("starting nats: %w", err)
+		return nil, err
+	}
+
+	sessionTTL := cfg.SessionMaxAge
+	if sessionTTL == 0 {
+		sessionTTL = defaultSessionMaxAge * time.Second
+	}
+	sessionOpts := SessionOptions{
+		Secrets:  append([]string{cfg.SessionSecret}, cfg.PreviousSecrets...),
+		Name:     cfg.SessionCookie,
+		MaxAge:   int(sessionTTL / time.Second),
+		Secure:   cfg.SessionSecure,
+		SameSite: sameSite,
+		Domain:   cfg.SessionDomain,
+		Path:     cleanBasePath(cfg.BasePath),
+		Store:    cfg.SessionStore,
+	}
+	switch cfg.SessionBackend {
+	case "", SessionBackendCookie, SessionBackendNATS:
+	case SessionBackendFS:
+		sessionOpts.Dir = cfg.SessionDir
+		if sessionOpts.Dir == "" {
+			sessionOpts.Dir = filepath.Join(os.TempDir(), "dsplay-sessions")
+		}
+		if err := os.MkdirAll(sessionOpts.Dir, 0o700); err != nil {
+			return nil, fmt.Errorf("creating session directory: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown session backend %q (want cookie, fs, or nats)", cfg.SessionBackend)
+	}
+
+	// Start embedded NATS, or connect to the shared one
+	useKV := cfg.SessionBackend == SessionBackendNATS
+	var ns *natsserver.Server
+	nc := cfg.nc
+	if nc == nil {
+		if ns, nc, err = connectNATS(cfg.NATSURL, useKV, cfg.SessionDir); err != nil {
+			return nil, fmt.Errorf("starting nats: %w", err)
+		}
+	}
+	closeNATS := func() {
+		if cfg.nc == nil {
+			nc.Close()
+			ns.Shutdown()
+		}
+	}
+	if useKV {
+		if sessionOpts.KV, err = openSessionBucket(nc, max(sessionTTL, 0)); err != nil {
+			closeNATS()
+			return nil, fmt.Errorf("opening session bucket: %w", err)
+		}
 	}
-	defer ns.Shutdown()
-	defer nc.Close()
 
 	counters := NewCounters()
-	sessions := NewSessionManager(cfg.SessionSecret)
-	handler := NewHandler(cfg.PlaygroundsDir, counters, sessions, nc, cfg.Debug)
+	sessions := NewSessionManager(sessionOpts)
+	outbox := NewOutbox()
+	limiter := NewConnLimiter(cfg.MaxSSE, cfg.MaxSSESession, cfg.MaxSSEPerIP)
+	conns := NewConnRegistry()
+	jobs := NewJobQueue(nc, cfg.JobWorkers)
+	jobs.subjects = cfg.subjects
+	clock := NewClock()
+	var timeline *Timeline
+	if cfg.Debug {
+		timeline = NewTimeline()
+	}
+	fetcher := NewFetcher(cfg.FetchAllow, cfg.FetchTimeout)
+	uploads, err := NewUploadStore(cfg.UploadDir)
+	if err != nil {
+		closeNATS()
+		return nil, err
+	}
+	handler := NewHandler(HandlerConfig{
+		FS:              fsys,
+		Counters:        counters,
+		Sessions:        sessions,
+		NC:              nc,
+		Outbox:          outbox,
+		Limiter:         limiter,
+		Conns:           conns,
+		Jobs:            jobs,
+		Prefs:           prefs,
+		Clock:           clock,
+		Timeline:        timeline,
+		Fetcher:         fetcher,
+		Uploads:         uploads,
+		Globals:         cfg.Globals,
+		Extensions:      cfg.Extensions,
+		TailAllow:       cfg.TailAllow,
+		EnvAllow:        cfg.EnvAllow,
+		WSOrigins:       cfg.WSOrigins,
+		StrictTemplates: cfg.StrictTemplates,
+		Dev:             cfg.Dev,
+		Debug:           cfg.Debug,
+		Source:          cfg.Source,
+	})
+
+	handler.subjects = cfg.subjects
 
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	if cfg.Monitor != nil {
+		cfg.Monitor.attach(nc, conns, limiter, counters, jobs)
+		r.Use(cfg.Monitor.middleware)
+	} else {
+		r.Use(middleware.Logger)
+	}
 	r.Use(middleware.Recoverer)
+	r.Use(protect(cfg.Protect))
+	r.Use(limitBody(cfg.MaxBodyBytes, cfg.MaxUploadBytes))
+	r.Use(compress(cfg.CompressLevel))
+	r.Use(csrfProtect(cfg.CSRF, sessions))
+	r.Use(rateLimit(NewRateLimiter(cfg.RatePerIP, cfg.RatePerSession, cfg.RateBurst), sessions, handler))
+	for _, ext := range cfg.Extensions {
+		if ext.Middleware != nil {
+			r.Use(ext.Middleware)
+		}
+	}
+
+	// Visitor login
+	if len(providers) > 0 {
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = fmt.Sprintf("http://localhost:%d%s", cfg.Port, cleanBasePath(cfg.BasePath))
+		}
+		NewAuthHandler(providers, sessions, publicURL, cfg.Debug).Routes(r)
+		for _, p := range providers {
+			log.Printf("Login enabled: %s/_auth/login/%s", publicURL, p.Name())
+		}
+	}
+
+	prefsHandler := NewPrefsHandler(prefs, sessions, nc)
+	prefsHandler.subjects = cfg.subjects
+	prefsHandler.Routes(r)
+	NewUploadHandler(uploads, sessions).Routes(r)
+
+	if cfg.Admin {
+		if len(cfg.AdminTokens) == 0 {
+			log.Printf("Warning: the admin dashboard at %s/ has no --admin-token, so anyone who can reach the server can reset sessions, counters and the clock", adminPathPrefix)
+		}
+		NewAdminHandler(outbox, limiter, conns, jobs, counters, sessions, clock, cfg.AdminTokens).Routes(r)
+	}
+
+	if cfg.Debug {
+		NewDebugHandler(timeline, sessions).Routes(r)
+	}
+
+	if cfg.DebugEndpoints {
+		mountDebugEndpoints(r)
+	}
+
+	if cfg.Source {
+		NewSourceHandler(fsys).Routes(r)
+	}
+
+	if len(cfg.Hooks) > 0 {
+		hookHandler := NewHookHandler(cfg.Hooks, nc, clock)
+		hookHandler.subjects = cfg.subjects
+		hookHandler.Routes(r)
+	}
+
+	if cfg.LiveReload {
+		handler.reload = newLiveReload()
+		r.Get(reloadPath, handler.reload.ServeHTTP)
+	}
+	r.Get(routesPath, handler.ServeRouteIndex)
+	r.Get(datastarPath, serveDatastar)
+	r.Get("/_datastar/{version}/datastar.js", newBundleCache().serveDatastarVersion)
 
 	// Static file serving
-	fs := http.FileServer(http.Dir(filepath.Join(cfg.PlaygroundsDir, "static")))
-	r.Handle("/static/*", http.StripPrefix("/static", fs))
+	if err := mountStatic(r, fsys, cfg.Static); err != nil {
+		uploads.Close()
+		closeNATS()
+		return nil, err
+	}
 
 	// Catch-all: every request goes through the playground handler
 	r.HandleFunc("/*", handler.ServePlayground)
 
 	if cfg.Debug {
-		routes, err := ScanPlaygrounds(cfg.PlaygroundsDir)
-		if err != nil {
-			log.Printf("[debug] error scanning route table: %v", err)
-		} else {
-			log.Printf("[debug] route table (%d routes):", len(routes))
-			for urlPath, rf := range routes {
-				for method, files := range rf.HTMLFiles {
-					m := method
-					if m == "" {
-						m = "*"
-					}
-					for _, f := range files {
-						log.Printf("[debug]   %s %s → HTML %s (sections=%d, seq=%d)", m, urlPath, f.Path, len(f.Sections), f.SeqIndex)
-					}
-				}
-				for method, files := range rf.SSEFiles {
-					m := method
-					if m == "" {
-						m = "*"
-					}
-					for _, f := range files {
-						log.Printf("[debug]   %s %s → SSE  %s (sections=%d, seq=%d)", m, urlPath, f.Path, len(f.Sections), f.SeqIndex)
-					}
-				}
+		logRouteTable(fsys)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	go handler.RunSchedule(ctx)
+	go handler.RunSources(ctx)
+	if handler.reload != nil {
+		go handler.reload.run(ctx, fsys)
+	}
+
+	e := &engine{ns: ns, nc: nc, sharedNC: cfg.nc != nil, handler: proxies.middleware(withBasePath(cfg.BasePath, r)), stop: stop, uploads: uploads, prefs: prefs}
+	if cfg.Record != "" {
+		if e.recorder, err = NewRecorder(cfg.Record); err != nil {
+			e.Close()
+			return nil, err
+		}
+		e.handler = e.recorder.Wrap(e.handler)
+		log.Printf("Recording requests to %s", cfg.Record)
+	}
+	return e, nil
+}
+
+// ServeHTTP routes the request through the playground. Any chi routing
+// state from a host router is dropped so the engine always routes on the
+// request path it is given.
+func (e *engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value(chi.RouteCtxKey) != nil {
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, nil))
+	}
+	e.handler.ServeHTTP(w, r)
+}
+
+// Close stops background polling, shuts down the embedded NATS server, closes
+// any recording and removes temporary uploads.
+func (e *engine) Close() error {
+	e.stop()
+	e.uploads.Close()
+	if err := e.prefs.Close(); err != nil {
+		log.Printf("Saving preferences: %v", err)
+	}
+	if e.recorder != nil {
+		e.recorder.Close()
+	}
+	if !e.sharedNC {
+		e.nc.Close()
+		e.ns.Shutdown()
+	}
+	return nil
+}
+
+func logRouteTable(fsys fs.FS) {
+	routes, err := ScanFS(fsys)
+	if err != nil {
+		log.Printf("[debug] error scanning route table: %v", err)
+		return
+	}
+	log.Printf("[debug] route table (%d routes):", len(routes))
+	for urlPath, rf := range routes {
+		for method, files := range rf.HTMLFiles {
+			m := method
+			if m == "" {
+				m = "*"
+			}
+			for _, f := range files {
+				log.Printf("[debug]   %s %s → HTML %s (sections=%d, seq=%d)", m, urlPath, f.Path, len(f.Sections), f.SeqIndex)
+			}
+		}
+		for method, files := range rf.SSEFiles {
+			m := method
+			if m == "" {
+				m = "*"
+			}
+			for _, f := range files {
+				log.Printf("[debug]   %s %s → SSE  %s (sections=%d, seq=%d)", m, urlPath, f.Path, len(f.Sections), f.SeqIndex)
+			}
+		}
+		for method, files := range rf.WSFiles {
+			m := method
+			if m == "" {
+				m = "*"
+			}
+			for _, f := range files {
+				log.Printf("[debug]   %s %s → WS   %s (sections=%d, seq=%d)", m, urlPath, f.Path, len(f.Sections), f.SeqIndex)
 			}
 		}
 	}
+}
+
+// Run serves the playground on cfg.Addr:cfg.Port, or cfg.UnixSocket, until
+// the server fails.
+func Run(cfg Config) error {
+	h, closer, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	l, err := listen(cfg)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
 
-	addr := fmt.Sprintf(":%d", cfg.Port)
-	log.Printf("ds-play listening on http://localhost%s", addr)
 	log.Printf("Serving playgrounds from: %s", cfg.PlaygroundsDir)
-	return http.ListenAndServe(addr, r)
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	return srv.Serve(l)
+}
+
+// listen opens the listener described by cfg and logs where to reach it.
+func listen(cfg Config) (net.Listener, error) {
+	base := cleanBasePath(cfg.BasePath) + "/"
+	if cfg.UnixSocket != "" {
+		// A socket left behind by a previous run would make Listen fail
+		if fi, err := os.Stat(cfg.UnixSocket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(cfg.UnixSocket)
+		}
+		l, err := net.Listen("unix", cfg.UnixSocket)
+		if err != nil {
+			return nil, fmt.Errorf("listening on %s: %w", cfg.UnixSocket, err)
+		}
+		log.Printf("ds-play listening on unix:%s (base path %s)", cfg.UnixSocket, base)
+		return l, nil
+	}
+
+	addr := net.JoinHostPort(cfg.Addr, strconv.Itoa(cfg.Port))
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	host := cfg.Addr
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	log.Printf("ds-play listening on http://%s%s", net.JoinHostPort(host, strconv.Itoa(cfg.Port)), base)
+	return l, nil
 }
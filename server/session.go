@@ -1,27 +1,40 @@
 package server
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/gob"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/sessions"
+	"github.com/nats-io/nats.go"
 )
 
 func init() {
 	gob.Register(map[string]int64{})
 	gob.Register(map[string]int{})
+	gob.Register(&User{})
 }
 
 const (
-	sessionName    = "ds-play"
-	sessionMaxAge  = 3600 // 1 hour in seconds
-	keyUsername    = "username"
-	keySessionID  = "session_id"
-	keyURLHits    = "url_hits"
-	keySeqPos     = "seq_pos" // map[string]int — current sequence position per URL
+	defaultSessionName   = "ds-play"
+	defaultSessionMaxAge = 3600 // 1 hour in seconds
+	keyUsername          = "username"
+	keySessionID         = "session_id"
+	keyURLHits           = "url_hits"
+	keySeqPos            = "seq_pos" // map[string]int — current sequence position per URL
+	keyUser              = "user"    // *User — set after an auth provider login
+	keyAuthState         = "auth_state"
+	keyAuthNext          = "auth_next"
+	keyResetEpoch        = "reset_epoch" // int64 — last admin reset-all the session applied
 )
 
 // Counters tracks global and per-URL hit counts.
@@ -60,6 +73,44 @@ func (c *Counters) Hit(urlPath string) (globalHits int64, urlHits int64) {
 	return
 }
 
+// Reset zeroes the global counter and every per-URL counter.
+func (c *Counters) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	atomic.StoreInt64(&c.globalHits, 0)
+	c.urlHits = make(map[string]*int64)
+}
+
+// ResetURL zeroes the counter for one URL path.
+func (c *Counters) ResetURL(urlPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.urlHits, urlPath)
+}
+
+// URLHitCount is one row of Counters.URLs.
+type URLHitCount struct {
+	URL  string
+	Hits int64
+}
+
+// URLs lists the per-URL counters, busiest first.
+func (c *Counters) URLs() []URLHitCount {
+	c.mu.RLock()
+	out := make([]URLHitCount, 0, len(c.urlHits))
+	for u, n := range c.urlHits {
+		out = append(out, URLHitCount{URL: u, Hits: atomic.LoadInt64(n)})
+	}
+	c.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Hits != out[j].Hits {
+			return out[i].Hits > out[j].Hits
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
 func (c *Counters) GetGlobalHits() int64 {
 	return atomic.LoadInt64(&c.globalHits)
 }
@@ -76,34 +127,278 @@ func (c *Counters) GetURLHits(urlPath string) int64 {
 
 // SessionManager handles session creation and data.
 type SessionManager struct {
-	store *sessions.CookieStore
+	store   sessions.Store
+	tokens  *tokenStore // sessions named by X-DSPlay-Session / ?session= instead of a cookie
+	name    string
+	csrfKey []byte
+
+	// Resets can't reach into cookies, so they are applied the next time
+	// each affected session is seen.
+	resetEpoch atomic.Int64 // bumped to reset every session
+	resetMu    sync.Mutex
+	resets     map[string]bool      // session IDs with a pending reset
+	seqMoves   map[string][]seqMove // session ID → sequence changes applied on its next request
+
+	// once: sections already served, by session ID. Kept in memory because
+	// streams serve them after the cookie has been sent, and dropped once
+	// the session has been unused for onceTTL, as its cookie has expired.
+	onceMu    sync.Mutex
+	once      map[string]*onceServed
+	onceTTL   time.Duration
+	onceSweep time.Time
+}
+
+// SessionOptions configures the session cookie. Zero values give a one-hour
+// SameSite=Lax cookie named "ds-play".
+type SessionOptions struct {
+	Secrets  []string      // signing secrets: the first signs new cookies, all verify (for key rotation)
+	Name     string        // cookie name
+	MaxAge   int           // cookie lifetime in seconds (negative = browser session)
+	Secure   bool          // only send over HTTPS
+	SameSite http.SameSite // SameSiteNoneMode is needed to embed demos in cross-site iframes
+	Domain   string        // cookie domain, e.g. ".example.com" to share across subdomains
+	Path     string        // cookie path (default "/"), the base path when mounted under one
+
+	// Server-side storage; the cookie then carries only the session ID.
+	Dir   string         // keep sessions as files in this directory
+	KV    nats.KeyValue  // keep sessions in this NATS key-value bucket (takes precedence over Dir)
+	Store sessions.Store // any other backend, e.g. a Redis store; used as-is, cookie options included
 }
 
-func NewSessionManager(secret string) *SessionManager {
-	store := sessions.NewCookieStore([]byte(secret))
-	store.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   sessionMaxAge,
+func NewSessionManager(opts SessionOptions) *SessionManager {
+	if opts.Name == "" {
+		opts.Name = defaultSessionName
+	}
+	switch {
+	case opts.MaxAge == 0:
+		opts.MaxAge = defaultSessionMaxAge
+	case opts.MaxAge < 0:
+		opts.MaxAge = 0 // gorilla: no Max-Age, cookie lasts for the browser session
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	if opts.SameSite == http.SameSiteNoneMode {
+		opts.Secure = true // browsers drop SameSite=None cookies that aren't Secure
+	}
+
+	var keyPairs [][]byte
+	for _, secret := range opts.Secrets {
+		keyPairs = append(keyPairs, []byte(secret), nil)
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	cookie := &sessions.Options{
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		MaxAge:   opts.MaxAge,
+		Secure:   opts.Secure,
 		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
+		SameSite: opts.SameSite,
+	}
+	var store sessions.Store
+	switch {
+	case opts.Store != nil:
+		store = opts.Store
+	case opts.KV != nil:
+		kv := newKVStore(opts.KV, keyPairs...)
+		kv.options = cookie
+		store = kv
+	case opts.Dir != "":
+		fs := sessions.NewFilesystemStore(opts.Dir, keyPairs...)
+		fs.MaxLength(0) // values live on disk, not in the cookie
+		fs.Options = cookie
+		store = fs
+	default:
+		cs := sessions.NewCookieStore(keyPairs...)
+		cs.Options = cookie
+		store = cs
+	}
+	var first string
+	if len(opts.Secrets) > 0 {
+		first = opts.Secrets[0]
+	}
+	csrfKey := sha256.Sum256([]byte("dsplay-csrf:" + first))
+	ttl := time.Duration(opts.MaxAge) * time.Second
+	if ttl <= 0 {
+		ttl = defaultSessionMaxAge * time.Second
+	}
+	return &SessionManager{
+		store:   store,
+		tokens:  newTokenStore(ttl),
+		name:    opts.Name,
+		csrfKey: csrfKey[:],
+		resets:  make(map[string]bool),
+		once:    make(map[string]*onceServed),
+		onceTTL: ttl,
+
+		seqMoves: make(map[string][]seqMove),
 	}
-	return &SessionManager{store: store}
+}
+
+// ResetSession clears a session's per-URL hits and sequence positions on
+// its next request, and lets it see once: sections again.
+func (sm *SessionManager) ResetSession(sessionID string) {
+	sm.resetMu.Lock()
+	sm.resets[sessionID] = true
+	sm.resetMu.Unlock()
+
+	sm.onceMu.Lock()
+	delete(sm.once, sessionID)
+	sm.onceMu.Unlock()
+}
+
+// seqMove is a pending change to a session's sequence positions for a route.
+type seqMove struct {
+	path string
+	to   int // position to jump to (0-based), or -1 to move by
+	by   int
+}
+
+// ResetSeq restarts the session's sequences for a route (e.g. "/wizard/"),
+// for every method and transport, from its next request on.
+func (sm *SessionManager) ResetSeq(sessionID, urlPath string) {
+	sm.moveSeq(sessionID, seqMove{path: urlPath})
+}
+
+// SetSeq jumps the session's sequences for a route to pos (0-based) from
+// its next request on.
+func (sm *SessionManager) SetSeq(sessionID, urlPath string, pos int) {
+	sm.moveSeq(sessionID, seqMove{path: urlPath, to: max(pos, 0)})
+}
+
+// StepSeq moves the session's sequences for a route n places on (or back,
+// for a negative n) from its next request on.
+func (sm *SessionManager) StepSeq(sessionID, urlPath string, n int) {
+	sm.moveSeq(sessionID, seqMove{path: urlPath, to: -1, by: n})
+}
+
+func (sm *SessionManager) moveSeq(sessionID string, m seqMove) {
+	sm.resetMu.Lock()
+	sm.seqMoves[sessionID] = append(sm.seqMoves[sessionID], m)
+	sm.resetMu.Unlock()
+}
+
+// takeSeqMoves applies the session's pending sequence changes to sd. A
+// route's GET sequences are moved even before the session has visited it,
+// so a wizard can be sent straight to a later step.
+func (sm *SessionManager) takeSeqMoves(sd *SessionData) {
+	sm.resetMu.Lock()
+	moves := sm.seqMoves[sd.SessionID]
+	delete(sm.seqMoves, sd.SessionID)
+	sm.resetMu.Unlock()
+
+	for _, m := range moves {
+		keys := []string{m.path + ":html:" + http.MethodGet, m.path + ":sse:" + http.MethodGet}
+		for key := range sd.SeqPos {
+			if strings.HasPrefix(key, m.path+":") && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			pos := m.to
+			if pos < 0 {
+				pos = max(sd.SeqPos[key]+m.by, 0)
+			}
+			if pos == 0 {
+				delete(sd.SeqPos, key)
+			} else {
+				sd.SeqPos[key] = pos
+			}
+		}
+	}
+}
+
+// ResetAllSessions is ResetSession for every session, including ones not
+// currently connected.
+func (sm *SessionManager) ResetAllSessions() {
+	sm.resetEpoch.Add(1)
+
+	sm.onceMu.Lock()
+	sm.once = make(map[string]*onceServed)
+	sm.onceMu.Unlock()
+}
+
+// takeReset reports whether a reset is due for the session, marking it applied.
+func (sm *SessionManager) takeReset(sess *sessions.Session, sessionID string) bool {
+	epoch := sm.resetEpoch.Load()
+	seen, _ := sess.Values[keyResetEpoch].(int64)
+
+	sm.resetMu.Lock()
+	pending := sm.resets[sessionID]
+	delete(sm.resets, sessionID)
+	sm.resetMu.Unlock()
+
+	if seen >= epoch && !pending {
+		return false
+	}
+	sess.Values[keyResetEpoch] = epoch
+	return true
+}
+
+// cookieName returns the session cookie's name.
+func (sm *SessionManager) cookieName() string {
+	if sm == nil {
+		return ""
+	}
+	return sm.name
+}
+
+// storeFor picks the token store for clients that name their session
+// explicitly, the configured store otherwise.
+func (sm *SessionManager) storeFor(r *http.Request) sessions.Store {
+	if sm.TokenSession(r) {
+		return sm.tokens
+	}
+	return sm.store
+}
+
+// TokenSession reports whether r names its session with a client-chosen
+// token rather than the session cookie. Anyone knowing the token shares
+// the session, so it never holds a logged-in user.
+func (sm *SessionManager) TokenSession(r *http.Request) bool {
+	return sessionToken(r, sm.name) != ""
+}
+
+// ParseSameSite maps "lax", "strict" or "none" to an http.SameSite ("" = lax).
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("invalid SameSite %q (want lax, strict or none)", s)
 }
 
 // SessionData holds the extracted session values for a request.
 type SessionData struct {
-	Username    string
-	SessionID   string
-	URLHits     map[string]int64
-	SeqPos      map[string]int
+	Username  string
+	SessionID string
+	URLHits   map[string]int64
+	SeqPos    map[string]int
+	User      *User // nil for anonymous visitors
+}
+
+// newSessionID returns a random 128-bit session ID. Preferences, scratch
+// state and once: sections are keyed by it, so it must be unguessable and
+// never collide.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "s-" + base64.RawURLEncoding.EncodeToString(b)
 }
 
 // GetOrCreate retrieves or initializes a session, returning the session data.
 func (sm *SessionManager) GetOrCreate(w http.ResponseWriter, r *http.Request) (*sessions.Session, *SessionData, error) {
-	sess, err := sm.store.Get(r, sessionName)
+	store := sm.storeFor(r)
+	sess, err := store.Get(r, sm.name)
 	if err != nil {
 		// Session decode error — create a fresh one
-		sess, err = sm.store.New(r, sessionName)
+		sess, err = store.New(r, sm.name)
 		if err != nil {
 			return nil, nil, fmt.Errorf("creating session: %w", err)
 		}
@@ -119,11 +414,17 @@ func (sm *SessionManager) GetOrCreate(w http.ResponseWriter, r *http.Request) (*
 		sess.Values[keyUsername] = sd.Username
 	}
 
+	// Authenticated user (overrides the random username)
+	if v, ok := sess.Values[keyUser].(*User); ok && v != nil && store != sm.tokens {
+		sd.User = v
+		sd.Username = v.Login
+	}
+
 	// Session ID
 	if v, ok := sess.Values[keySessionID].(string); ok && v != "" {
 		sd.SessionID = v
 	} else {
-		sd.SessionID = fmt.Sprintf("s-%s", RandomUsername())
+		sd.SessionID = newSessionID()
 		sess.Values[keySessionID] = sd.SessionID
 	}
 
@@ -143,6 +444,16 @@ func (sm *SessionManager) GetOrCreate(w http.ResponseWriter, r *http.Request) (*
 		sess.Values[keySeqPos] = sd.SeqPos
 	}
 
+	// Apply a reset requested from the admin dashboard or a template
+	if sm.takeReset(sess, sd.SessionID) {
+		sd.URLHits = make(map[string]int64)
+		sd.SeqPos = make(map[string]int)
+		sess.Values[keyURLHits] = sd.URLHits
+		sess.Values[keySeqPos] = sd.SeqPos
+	}
+	sm.takeSeqMoves(sd)
+	sm.touchServed(sd.SessionID)
+
 	return sess, sd, nil
 }
 
@@ -172,3 +483,47 @@ func (sm *SessionManager) AdvanceSeqPos(w http.ResponseWriter, r *http.Request,
 	sess.Values[keySeqPos] = sd.SeqPos
 	sess.Save(r, w)
 }
+
+// SetUser records (or, with a nil user, forgets) the authenticated identity
+// for the session and saves. Logging out assigns a fresh random username.
+func (sm *SessionManager) SetUser(w http.ResponseWriter, r *http.Request, sess *sessions.Session, sd *SessionData, user *User) {
+	sd.User = user
+	if user != nil {
+		sd.Username = user.Login
+		sess.Values[keyUser] = user
+	} else {
+		sd.Username = RandomUsername()
+		delete(sess.Values, keyUser)
+	}
+	sess.Values[keyUsername] = sd.Username
+	sess.Save(r, w)
+}
+
+// Renew gives the session a fresh ID, and server-side stores a fresh key,
+// so an ID known before login (session fixation) is worthless after it.
+// The caller saves the session (SetUser does).
+func (sm *SessionManager) Renew(sess *sessions.Session, sd *SessionData) {
+	sess.ID = ""
+	sd.SessionID = newSessionID()
+	sess.Values[keySessionID] = sd.SessionID
+}
+
+// SetAuthState stores the OAuth state and post-login redirect target and saves.
+func (sm *SessionManager) SetAuthState(w http.ResponseWriter, r *http.Request, sess *sessions.Session, state, next string) {
+	sess.Values[keyAuthState] = state
+	sess.Values[keyAuthNext] = next
+	sess.Save(r, w)
+}
+
+// TakeAuthState returns and clears the pending OAuth state. The caller is
+// expected to save the session afterwards (SetUser does).
+func (sm *SessionManager) TakeAuthState(sess *sessions.Session) (state, next string) {
+	state, _ = sess.Values[keyAuthState].(string)
+	next, _ = sess.Values[keyAuthNext].(string)
+	delete(sess.Values, keyAuthState)
+	delete(sess.Values, keyAuthNext)
+	if next == "" {
+		next = "/"
+	}
+	return state, next
+}
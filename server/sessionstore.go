@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/nats-io/nats.go"
+)
+
+// Session backends. The cookie backend keeps every value in the cookie; the
+// others keep values on the server and the cookie carries only a signed ID,
+// so sessions can't outgrow the browser's cookie limit.
+const (
+	SessionBackendCookie = "cookie"
+	SessionBackendFS     = "fs"   // one file per session in a directory
+	SessionBackendNATS   = "nats" // a NATS JetStream key-value bucket
+)
+
+// sessionBucket is the key-value bucket the nats backend stores sessions in.
+const sessionBucket = "dsplay_sessions"
+
+// kvStore is a sessions.Store keeping session values in a NATS key-value
+// bucket, modelled on sessions.FilesystemStore.
+type kvStore struct {
+	kv      nats.KeyValue
+	codecs  []securecookie.Codec
+	options *sessions.Options
+}
+
+func newKVStore(kv nats.KeyValue, keyPairs ...[]byte) *kvStore {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, c := range codecs {
+		if sc, ok := c.(*securecookie.SecureCookie); ok {
+			sc.MaxLength(0) // values live in the bucket, not the cookie
+		}
+	}
+	return &kvStore{kv: kv, codecs: codecs, options: &sessions.Options{Path: "/"}}
+}
+
+// Get returns the session for this request, cached for the request's lifetime.
+func (s *kvStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns the session named by the request's cookie, or a fresh one.
+func (s *kvStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.codecs...); err != nil {
+		return session, err
+	}
+	entry, err := s.kv.Get(session.ID)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return session, nil // expired: keep the ID, start empty
+	}
+	if err != nil {
+		return session, err
+	}
+	if err := securecookie.DecodeMulti(name, string(entry.Value()), &session.Values, s.codecs...); err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save writes the session's values to the bucket and its ID to the cookie.
+// A negative MaxAge deletes both.
+func (s *kvStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.kv.Delete(session.ID); err != nil && !errors.Is(err, nats.ErrKeyNotFound) {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+	values, err := securecookie.EncodeMulti(session.Name(), session.Values, s.codecs...)
+	if err != nil {
+		return err
+	}
+	if _, err := s.kv.Put(session.ID, []byte(values)); err != nil {
+		return err
+	}
+	id, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), id, session.Options))
+	return nil
+}
+
+// openSessionBucket returns the nats backend's bucket, creating it with
+// entries expiring after ttl (0 = never).
+func openSessionBucket(nc *nats.Conn, ttl time.Duration) (nats.KeyValue, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	kv, err := js.KeyValue(sessionBucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		return js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:  sessionBucket,
+			TTL:     ttl,
+			Storage: nats.FileStorage,
+		})
+	}
+	return kv, err
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/ignore"
+	"github.com/go-chi/chi/v5"
+)
+
+var sourceTemplate = template.Must(template.ParseFS(templatesFS, "templates/source.html"))
+
+const sourcePathPrefix = "/_source"
+
+// SourceHandler serves a read-only, syntax-highlighted view of the
+// playground's own files under /_source, so visitors can see the template
+// behind what they are looking at.
+type SourceHandler struct {
+	fsys fs.FS
+}
+
+func NewSourceHandler(fsys fs.FS) *SourceHandler {
+	return &SourceHandler{fsys: fsys}
+}
+
+// sourcePage is the data rendered by templates/source.html.
+type sourcePage struct {
+	Dir   string        // directory being shown, "" for the root
+	Link  string        // URL prefix for entries of Dir
+	Up    string        // link to the enclosing directory, "" at the root
+	Dirs  []string      // subdirectories of Dir
+	Files []string      // files in Dir
+	File  string        // file being shown, "" for a directory listing
+	Code  template.HTML // File, highlighted
+	CSS   template.CSS  // styles for Code
+}
+
+// Routes mounts the source viewer on r:
+//
+//	GET /_source/            → playground root listing
+//	GET /_source/{dir}/      → directory listing
+//	GET /_source/{dir}/{file} → highlighted file, lines anchored as #L<n>
+func (s *SourceHandler) Routes(r chi.Router) {
+	r.Get(sourcePathPrefix, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, basePath(r)+sourcePathPrefix+"/", http.StatusMovedPermanently)
+	})
+	r.Get(sourcePathPrefix+"/*", s.serve)
+}
+
+func (s *SourceHandler) serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(chi.URLParam(r, "*"), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) || hiddenSource(name) {
+		http.NotFound(w, r)
+		return
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// What .dsplayignore lists, such as private notes, isn't shown either
+	ignored, _ := ignore.Load(s.fsys)
+	if name != "." && ignored.Match(name, info.IsDir()) {
+		http.NotFound(w, r)
+		return
+	}
+
+	var page sourcePage
+	dir := name
+	if !info.IsDir() {
+		data, err := fs.ReadFile(s.fsys, name)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error reading %s: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+		page.File = name
+		if page.Code, err = highlight(name, string(data)); err != nil {
+			http.Error(w, fmt.Sprintf("Error highlighting %s: %v", name, err), http.StatusInternalServerError)
+			return
+		}
+		page.CSS = sourceCSS
+		dir = path.Dir(name)
+	} else if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, basePath(r)+r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error listing %s: %v", dir, err), http.StatusInternalServerError)
+		return
+	}
+	for _, e := range entries {
+		rel := path.Join(dir, e.Name())
+		if hiddenSource(rel) || ignored.Match(rel, e.IsDir()) {
+			continue
+		}
+		if e.IsDir() {
+			page.Dirs = append(page.Dirs, e.Name())
+		} else {
+			page.Files = append(page.Files, e.Name())
+		}
+	}
+	root := basePath(r) + sourcePathPrefix + "/"
+	page.Link = root
+	if dir != "." {
+		page.Dir = dir
+		page.Link = root + dir + "/"
+		page.Up = root
+		if parent := path.Dir(dir); parent != "." {
+			page.Up += parent + "/"
+		}
+	}
+	if page.File != "" {
+		page.Up = page.Link
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := sourceTemplate.Execute(w, page); err != nil {
+		log.Printf("Source template error: %v", err)
+	}
+}
+
+// hiddenSource reports whether a playground path stays out of the viewer:
+// dotfiles, and the local server config, which may hold credentials.
+func hiddenSource(name string) bool {
+	if name == ConfigFileName {
+		return true
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceURL links to a playground file in the source viewer, at line if > 0.
+func sourceURL(basePath, file string, line int) string {
+	u := basePath + sourcePathPrefix + "/" + file
+	if line > 0 {
+		u += "#L" + strconv.Itoa(line)
+	}
+	return u
+}
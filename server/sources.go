@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sourcesFile lists HTTP endpoints the playground polls in the background,
+// turning real APIs into live events. It is watched for changes while the
+// server runs.
+const sourcesFile = "_sources.yaml"
+
+// minSourceEvery keeps a typo from hammering an API.
+const minSourceEvery = time.Second
+
+// Source is one polled endpoint in _sources.yaml. Each time its response
+// changes, streams subscribed to name (subscribe: [name]) re-render with
+// .Hook.Body set to it, and sourceData returns it in any template.
+type Source struct {
+	Name  string        `yaml:"name"`
+	URL   string        `yaml:"url"`   // must be allowlisted (--allow-fetch)
+	Every time.Duration `yaml:"every"` // polling interval, e.g. 30s
+}
+
+// parseSources reads and checks the entries in _sources.yaml.
+func parseSources(data []byte) ([]Source, error) {
+	var sources []Source
+	if err := yaml.Unmarshal(data, &sources); err != nil {
+		return nil, err
+	}
+	for _, s := range sources {
+		switch {
+		case s.Name == "" || strings.ContainsAny(s.Name, ".*> \t"):
+			return nil, fmt.Errorf("source %q: name must be a single word", s.Name)
+		case s.URL == "":
+			return nil, fmt.Errorf("source %q: url is required", s.Name)
+		case s.Every < minSourceEvery:
+			return nil, fmt.Errorf("source %q: every must be at least %s", s.Name, minSourceEvery)
+		}
+	}
+	return sources, nil
+}
+
+// sourceValues holds the latest response from each polled source. A nil
+// *sourceValues holds nothing.
+type sourceValues struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func newSourceValues() *sourceValues {
+	return &sourceValues{values: make(map[string]any)}
+}
+
+// Get returns the latest response from the named source, or nil before
+// the first successful poll.
+func (sv *sourceValues) Get(name string) any {
+	if sv == nil {
+		return nil
+	}
+	sv.mu.RLock()
+	defer sv.mu.RUnlock()
+	return sv.values[name]
+}
+
+func (sv *sourceValues) set(name string, v any) {
+	sv.mu.Lock()
+	sv.values[name] = v
+	sv.mu.Unlock()
+}
+
+// keep forgets sources no longer in sources.
+func (sv *sourceValues) keep(sources []Source) {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	for name := range sv.values {
+		if !slices.ContainsFunc(sources, func(s Source) bool { return s.Name == name }) {
+			delete(sv.values, name)
+		}
+	}
+}
+
+// RunSources polls the playground's sources until ctx is done, restarting
+// them whenever _sources.yaml changes.
+func (h *Handler) RunSources(ctx context.Context) {
+	watchFile(ctx, h.fsys, sourcesFile, func(ctx context.Context, data []byte) error {
+		sources, err := parseSources(data)
+		if err != nil {
+			return err
+		}
+		h.sources.keep(sources)
+		for _, s := range sources {
+			go h.pollSource(ctx, s)
+		}
+		if len(sources) > 0 {
+			log.Printf("Polling %d sources from %s", len(sources), sourcesFile)
+		}
+		return nil
+	})
+}
+
+// pollSource fetches s every s.Every, in real time so speeding up the
+// playground clock doesn't hammer the API, and publishes each new
+// response.
+func (h *Handler) pollSource(ctx context.Context, s Source) {
+	ticker := time.NewTicker(s.Every)
+	defer ticker.Stop()
+	var last []byte
+	for {
+		body, err := h.fetcher.get(ctx, s.URL, true)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			log.Printf("Source %s: %v", s.Name, err)
+		case !bytes.Equal(body, last):
+			last = body
+			if err := h.publishSource(s.Name, body); err != nil {
+				log.Printf("Source %s: %v", s.Name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishSource caches a source's response, decoded as JSON when it is
+// JSON, and publishes it on the source's hook subject.
+func (h *Handler) publishSource(name string, body []byte) error {
+	var v any = string(body)
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		v = decoded
+	}
+	h.sources.set(name, v)
+
+	data, err := json.Marshal(HookEvent{Name: name, Time: h.clock.Now(), Body: v})
+	if err != nil {
+		return err
+	}
+	return h.nc.Publish(h.subjects.hook(name), data)
+}
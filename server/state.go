@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	stateSignalsName = "state" // datastar signal object whose values are saved to the session's state
+	maxStateKeys     = 64      // values kept per session or tab
+	stateIdle        = 24 * time.Hour
+)
+
+// StateStore is server-side scratch state: values a template or a request
+// saves for its session (or one browser tab of it) that later requests can
+// read back, unlike signals, which live in the page. It is kept in memory
+// and forgotten a day after a session or tab last used it.
+type StateStore struct {
+	mu        sync.Mutex
+	scopes    map[string]*stateScope
+	lastSweep time.Time
+}
+
+type stateScope struct {
+	values map[string]any
+	used   time.Time
+}
+
+func NewStateStore() *StateStore {
+	return &StateStore{scopes: make(map[string]*stateScope)}
+}
+
+// stateKey scopes state to a session, or to one of its tabs.
+func stateKey(sessionID, tabID string) string {
+	if tabID == "" {
+		return sessionID
+	}
+	return sessionID + "\x00" + tabID
+}
+
+// Get returns one value, or nil.
+func (s *StateStore) Get(sessionID, tabID, key string) any {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := s.scopes[stateKey(sessionID, tabID)]
+	if sc == nil {
+		return nil
+	}
+	sc.used = time.Now()
+	return sc.values[key]
+}
+
+// All returns a copy of every value.
+func (s *StateStore) All(sessionID, tabID string) map[string]any {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := s.scopes[stateKey(sessionID, tabID)]
+	if sc == nil {
+		return map[string]any{}
+	}
+	sc.used = time.Now()
+	return maps.Clone(sc.values)
+}
+
+// Set merges values into the state. A nil value deletes its key.
+func (s *StateStore) Set(sessionID, tabID string, values map[string]any) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+	key := stateKey(sessionID, tabID)
+	sc := s.scopes[key]
+	if sc == nil {
+		sc = &stateScope{values: make(map[string]any)}
+		s.scopes[key] = sc
+	}
+	sc.used = time.Now()
+	for k, v := range values {
+		if v == nil {
+			delete(sc.values, k)
+			continue
+		}
+		if _, ok := sc.values[k]; !ok && len(sc.values) >= maxStateKeys {
+			return fmt.Errorf("state: at most %d values per session", maxStateKeys)
+		}
+		sc.values[k] = v
+	}
+	return nil
+}
+
+// Clear forgets the session's state, its tabs' included.
+func (s *StateStore) Clear(sessionID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.scopes {
+		if key == sessionID || strings.HasPrefix(key, sessionID+"\x00") {
+			delete(s.scopes, key)
+		}
+	}
+}
+
+// sweep drops idle scopes, at most once a minute. s.mu must be held.
+func (s *StateStore) sweep() {
+	if time.Since(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = time.Now()
+	cutoff := time.Now().Add(-stateIdle)
+	for key, sc := range s.scopes {
+		if sc.used.Before(cutoff) {
+			delete(s.scopes, key)
+		}
+	}
+}
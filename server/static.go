@@ -0,0 +1,150 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StaticMount serves a directory of files at a URL prefix, as declared
+// under static: in dsplay.yaml.
+type StaticMount struct {
+	Path  string `yaml:"path"`  // URL prefix, e.g. /assets
+	Dir   string `yaml:"dir"`   // directory relative to the playground root, or absolute
+	Cache string `yaml:"cache"` // Cache-Control value, or a duration such as 1h for "public, max-age=3600"
+	ETag  *bool  `yaml:"etag"`  // send ETags so browsers can revalidate cheaply (default true)
+}
+
+// defaultStaticMount is the playground's static/ directory, served unless
+// dsplay.yaml mounts something else at /static.
+var defaultStaticMount = StaticMount{Path: "/static", Dir: "static"}
+
+// mountStatic registers each mount on r. Mounts whose directory doesn't
+// exist are skipped with a log line (static/ is optional), but malformed
+// mounts are errors.
+func mountStatic(r chi.Router, fsys fs.FS, mounts []StaticMount) error {
+	mounts = withDefaultStatic(mounts)
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		prefix := cleanBasePath(m.Path)
+		switch {
+		case prefix == "":
+			return fmt.Errorf("static mount for %q needs a path other than /", m.Dir)
+		case strings.HasPrefix(prefix, "/_"):
+			return fmt.Errorf("static mount %s: paths starting with _ are reserved", prefix)
+		case seen[prefix]:
+			return fmt.Errorf("static mount %s declared twice", prefix)
+		}
+		seen[prefix] = true
+
+		dir, err := mountFS(fsys, m.Dir)
+		if err != nil {
+			return fmt.Errorf("static mount %s: %w", prefix, err)
+		}
+		if _, err := fs.Stat(dir, "."); err != nil {
+			if m != defaultStaticMount {
+				log.Printf("Static mount %s: %v (skipped)", prefix, err)
+			}
+			continue
+		}
+		cache, err := cacheControl(m.Cache)
+		if err != nil {
+			return fmt.Errorf("static mount %s: %w", prefix, err)
+		}
+		r.Handle(prefix+"/*", http.StripPrefix(prefix, staticHandler(dir, cache, m.ETag == nil || *m.ETag)))
+	}
+	return nil
+}
+
+// withDefaultStatic adds the default static/ mount unless mounts already
+// serve something at /static.
+func withDefaultStatic(mounts []StaticMount) []StaticMount {
+	for _, m := range mounts {
+		if cleanBasePath(m.Path) == defaultStaticMount.Path {
+			return mounts
+		}
+	}
+	return append([]StaticMount{defaultStaticMount}, mounts...)
+}
+
+// mountFS resolves a mount's directory: absolute paths as they are,
+// relative ones inside the playground.
+func mountFS(fsys fs.FS, dir string) (fs.FS, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no dir")
+	}
+	if filepath.IsAbs(dir) {
+		return os.DirFS(dir), nil
+	}
+	name := path.Clean(filepath.ToSlash(dir))
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("dir %q is outside the playground (use an absolute path)", dir)
+	}
+	return fs.Sub(fsys, name)
+}
+
+// cacheControl turns a mount's cache setting into a Cache-Control header
+// value. Durations become public max-age values; anything else is used
+// verbatim, and "" sends no header.
+func cacheControl(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return "", fmt.Errorf("negative cache duration %s", s)
+		}
+		return "public, max-age=" + strconv.Itoa(int(d/time.Second)), nil
+	}
+	return s, nil
+}
+
+// staticHandler serves dir with the mount's Cache-Control header and,
+// when etag is set, an ETag that http.FileServer honours for
+// If-None-Match requests.
+func staticHandler(dir fs.FS, cache string, etag bool) http.Handler {
+	files := http.FileServer(http.FS(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cache != "" {
+			w.Header().Set("Cache-Control", cache)
+		}
+		if etag {
+			if tag := fileETag(dir, strings.TrimPrefix(r.URL.Path, "/")); tag != "" {
+				w.Header().Set("ETag", tag)
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// fileETag identifies a file's version by size and modification time, or
+// by content for filesystems without times (such as embed.FS).
+func fileETag(dir fs.FS, name string) string {
+	if name == "" || strings.HasSuffix(name, "/") {
+		return ""
+	}
+	info, err := fs.Stat(dir, path.Clean(name))
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+	}
+	data, err := fs.ReadFile(dir, path.Clean(name))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultStreamChunk    = 512 // bytes
+	defaultStreamInterval = 100 // milliseconds
+	streamFlushMarker     = "<!-- flush -->"
+)
+
+// Stream is the stream: frontmatter, which sends an HTML response a chunk
+// at a time so the browser renders it progressively:
+//
+//	stream: true
+//	stream: {chunk: 64, interval: 250}
+//
+// A section containing <!-- flush --> markers is split at them instead of
+// every chunk bytes.
+type Stream struct {
+	Enabled  bool
+	Chunk    int // bytes per chunk
+	Interval int // milliseconds between chunks
+}
+
+func (s *Stream) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := node.Decode(&enabled); err != nil {
+			return fmt.Errorf("stream: want true or {chunk, interval}")
+		}
+		*s = Stream{Enabled: enabled}
+	} else {
+		var opts struct {
+			Chunk    int `yaml:"chunk"`
+			Interval int `yaml:"interval"`
+		}
+		if err := node.Decode(&opts); err != nil {
+			return err
+		}
+		if opts.Chunk < 0 || opts.Interval < 0 {
+			return fmt.Errorf("stream: chunk and interval can't be negative")
+		}
+		*s = Stream{Enabled: true, Chunk: opts.Chunk, Interval: opts.Interval}
+	}
+	if s.Chunk == 0 {
+		s.Chunk = defaultStreamChunk
+	}
+	if s.Interval == 0 {
+		s.Interval = defaultStreamInterval
+	}
+	return nil
+}
+
+// chunks splits rendered output at flush markers, or every s.Chunk bytes
+// without cutting a character in two.
+func (s Stream) chunks(rendered string) []string {
+	if strings.Contains(rendered, streamFlushMarker) {
+		return strings.SplitAfter(rendered, streamFlushMarker)
+	}
+	var out []string
+	for len(rendered) > s.Chunk {
+		n := s.Chunk
+		for n > 0 && !utf8.RuneStart(rendered[n]) {
+			n--
+		}
+		if n == 0 {
+			n = s.Chunk
+		}
+		out = append(out, rendered[:n])
+		rendered = rendered[n:]
+	}
+	return append(out, rendered)
+}
+
+// writeStream sends rendered in chunks, flushing each and pausing between
+// them on the playground clock. It stops early if the client goes away.
+func (h *Handler) writeStream(w http.ResponseWriter, r *http.Request, s Stream, status int, rendered string) {
+	chunks := s.chunks(rendered)
+	h.debugLog("  html: streaming %d chunks every %dms", len(chunks), s.Interval)
+	clearStreamDeadlines(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Accel-Buffering", "no") // keep nginx from collecting the chunks
+	w.WriteHeader(status)
+
+	rc := http.NewResponseController(w)
+	for i, chunk := range chunks {
+		if i > 0 {
+			if err := h.clock.Sleep(r.Context(), time.Duration(s.Interval)*time.Millisecond); err != nil {
+				return
+			}
+		}
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			return
+		}
+		rc.Flush()
+	}
+}
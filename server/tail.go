@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/starfederation/datastar-go/datastar"
+)
+
+const (
+	tailPollInterval = 500 * time.Millisecond
+	defaultTailLines = 10
+	tailWaitDelay    = 2 * time.Second
+)
+
+// Tail turns an SSE file into a live log viewer. Every new line of the file
+// (or of the command's output) renders the file's first section with .Line
+// set. Sources must be allowlisted with --allow-tail: files by path,
+// commands by their whole argument list.
+//
+//	tail:
+//	  file: /var/log/app.log        # or:
+//	  command: [journalctl, -f]
+//	  lines: 10                     # lines of backlog sent on connect (file only)
+type Tail struct {
+	File    string   `yaml:"file"`
+	Command []string `yaml:"command"`
+	Lines   int      `yaml:"lines"`
+}
+
+func (t Tail) enabled() bool {
+	return t.File != "" || len(t.Command) > 0
+}
+
+// source returns the path or command line checked against the allowlist.
+func (t Tail) source() string {
+	if t.File != "" {
+		if abs, err := filepath.Abs(t.File); err == nil {
+			return abs
+		}
+		return t.File
+	}
+	return strings.Join(t.Command, " ")
+}
+
+// tailAllowed reports whether the tail source matches one of the allowlist
+// patterns. Patterns use filepath.Match syntax; an empty list allows nothing.
+// A command must match a pattern word for word, arguments included, so
+// "journalctl -f -u *" allows following any unit but not --file=.
+func tailAllowed(allow []string, t Tail) bool {
+	for _, pattern := range allow {
+		if t.File != "" {
+			if ok, _ := filepath.Match(pattern, t.source()); ok {
+				return true
+			}
+		} else if matchArgv(strings.Fields(pattern), t.Command) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchArgv reports whether each word of argv matches the pattern word in
+// the same position.
+func matchArgv(pattern, argv []string) bool {
+	if len(pattern) != len(argv) {
+		return false
+	}
+	for i := range pattern {
+		if ok, _ := filepath.Match(pattern[i], argv[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// streamTail sends one SSE patch per line until the client disconnects or
+// the source ends.
+func (h *Handler) streamTail(ctx context.Context, sse *datastar.ServerSentEventGenerator, sections []sectionEntry, td TemplateData) {
+	t := sections[0].frontmatter.Tail
+	lines := make(chan string, 64)
+
+	go func() {
+		defer close(lines)
+		var err error
+		if t.File != "" {
+			err = tailFile(ctx, t.File, t.Lines, lines)
+		} else {
+			err = tailCommand(ctx, t.Command, lines)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			h.debugLog("  tail: %s: %v", t.source(), err)
+		}
+	}()
+
+	messageCount := int64(0)
+	for line := range lines {
+		messageCount++
+		td.Line = line
+		td.SSEMessageCount = messageCount
+		td.LoopCounter = messageCount
+		td.LoopCounter0 = messageCount - 1
+		if err := h.sendSSESection(sse, nil, sections, 0, td); err != nil {
+			return
+		}
+	}
+}
+
+// tailFile sends the last backlog lines of path, then follows it for new
+// lines, starting over if the file is truncated.
+func tailFile(ctx context.Context, path string, backlog int, out chan<- string) error {
+	if backlog <= 0 {
+		backlog = defaultTailLines
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Backlog: remember the last N complete lines
+	var tailLines []string
+	br := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			break
+		}
+		offset += int64(len(line))
+		tailLines = append(tailLines, strings.TrimRight(line, "\r\n"))
+		if len(tailLines) > backlog {
+			tailLines = tailLines[1:]
+		}
+	}
+	for _, l := range tailLines {
+		select {
+		case out <- l:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Follow
+	var partial string
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(tailPollInterval):
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			offset, partial = 0, ""
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		br.Reset(f)
+		for {
+			chunk, err := br.ReadString('\n')
+			offset += int64(len(chunk))
+			if err != nil {
+				partial += chunk
+				break
+			}
+			line := strings.TrimRight(partial+chunk, "\r\n")
+			partial = ""
+			select {
+			case out <- line:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// tailCommand runs argv and sends each line of its combined output. The
+// command is killed when ctx ends; closing the pipe unblocks exec's output
+// copier, and WaitDelay stops Wait from hanging on children that ignore the
+// kill or leave the pipe open.
+func tailCommand(ctx context.Context, argv []string, out chan<- string) error {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.WaitDelay = tailWaitDelay
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		pw.CloseWithError(cmd.Wait())
+	}()
+
+	sc := bufio.NewScanner(pr)
+	for sc.Scan() {
+		select {
+		case out <- sc.Text():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return sc.Err()
+}
+
+// checkTail rejects tail routes whose source is not allowlisted. It returns
+// false if the request has been answered.
+func (h *Handler) checkTail(w http.ResponseWriter, t Tail, td TemplateData) bool {
+	if tailAllowed(h.tailAllow, t) {
+		return true
+	}
+	h.debugLog("  tail: %s is not allowlisted (403)", t.source())
+	h.renderError(w, http.StatusForbidden, "This log source is not enabled on this server (see --allow-tail).", td)
+	return false
+}
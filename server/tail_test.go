@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTailAllowed(t *testing.T) {
+	allow := []string{"/var/log/*.log", "journalctl -f -u *", "tail"}
+	tests := []struct {
+		tail Tail
+		want bool
+	}{
+		{Tail{File: "/var/log/app.log"}, true},
+		{Tail{File: "/etc/shadow"}, false},
+		{Tail{Command: []string{"journalctl", "-f", "-u", "myapp"}}, true},
+		{Tail{Command: []string{"journalctl", "--file=/etc/shadow"}}, false},
+		{Tail{Command: []string{"journalctl", "-f", "-u", "myapp", "--file=/x"}}, false},
+		{Tail{Command: []string{"tail"}}, true},
+		{Tail{Command: []string{"tail", "-f", "/etc/shadow"}}, false},
+	}
+	for _, tt := range tests {
+		if got := tailAllowed(allow, tt.tail); got != tt.want {
+			t.Errorf("tailAllowed(%q) = %v, want %v", tt.tail.source(), got, tt.want)
+		}
+	}
+}
+
+func TestTailCommandStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := make(chan string)
+	done := make(chan error, 1)
+	go func() { done <- tailCommand(ctx, []string{"yes"}, lines) }()
+	<-lines
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tailCommand did not return after cancel")
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"crypto/sha256"
+	"html/template"
+	"sync"
+)
+
+// maxCachedTemplates bounds the template cache. Editing a file leaves its old
+// parse behind under the old hash, so the cache is emptied when it fills.
+const maxCachedTemplates = 1024
+
+// templateCache holds parsed templates keyed by a hash of their content, so a
+// fast SSE loop doesn't re-parse the same section (and all of sprig's
+// functions) on every tick. A changed file hashes differently and is parsed
+// afresh.
+type templateCache struct {
+	mu      sync.Mutex
+	entries map[templateKey]*template.Template
+}
+
+type templateKey struct {
+	sum    [sha256.Size]byte
+	strict bool
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{entries: make(map[templateKey]*template.Template)}
+}
+
+// parse returns a template for content bound to funcs. Cached templates are
+// never executed themselves: each call gets a clone, so per-request functions
+// (which close over the visitor's session) can be swapped in. A nil cache
+// parses every time.
+func (c *templateCache) parse(content string, funcs template.FuncMap, strict bool) (*template.Template, error) {
+	if c == nil {
+		return newTemplate(content, funcs, strict)
+	}
+	key := templateKey{sum: sha256.Sum256([]byte(content)), strict: strict}
+
+	c.mu.Lock()
+	cached, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		var err error
+		cached, err = newTemplate(content, funcs, strict)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		if len(c.entries) >= maxCachedTemplates {
+			clear(c.entries)
+		}
+		c.entries[key] = cached
+		c.mu.Unlock()
+	}
+
+	tmpl, err := cached.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(funcs), nil
+}
+
+func newTemplate(content string, funcs template.FuncMap, strict bool) (*template.Template, error) {
+	tmpl := template.New("page").Funcs(funcs)
+	if strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	return tmpl.Parse(content)
+}
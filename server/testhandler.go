@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// TestHandlerOptions configures NewTestHandler. The zero value gives a
+// playground like `dsplay serve` with the admin dashboard disabled.
+type TestHandlerOptions struct {
+	StrictTemplates bool
+	Admin           bool
+	MaxSSE          int
+	MaxSSESession   int
+	TailAllow       []string
+	Debug           bool
+	Extensions      []Extension // template funcs, data and middleware under test
+	Protect         Protection
+	CSRF            bool
+	RatePerIP       float64
+	RatePerSession  float64
+	RateBurst       int
+	TrustedProxies  []string
+}
+
+// TestHandler is an in-process playground for Go tests. It binds no port
+// (mount it on httptest.NewServer) and its NATS server is in-process.
+type TestHandler struct {
+	e *engine
+}
+
+// NewTestHandler serves the playground in fsys, e.g. an fstest.MapFS or an
+// embed.FS of fixtures. Call Close when done:
+//
+//	h, err := server.NewTestHandler(fstest.MapFS{
+//		"index.html": {Data: []byte("<h1>Hi {{.Username}}</h1>")},
+//	}, server.TestHandlerOptions{})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	t.Cleanup(func() { h.Close() })
+//	srv := httptest.NewServer(h)
+func NewTestHandler(fsys fs.FS, opts TestHandlerOptions) (*TestHandler, error) {
+	e, err := newEngine(Config{
+		FS:              fsys,
+		SessionSecret:   "dsplay-test-secret",
+		Admin:           opts.Admin,
+		MaxSSE:          opts.MaxSSE,
+		MaxSSESession:   opts.MaxSSESession,
+		JobWorkers:      1,
+		TailAllow:       opts.TailAllow,
+		StrictTemplates: opts.StrictTemplates,
+		Debug:           opts.Debug,
+		Extensions:      opts.Extensions,
+		Protect:         opts.Protect,
+		CSRF:            opts.CSRF,
+		RatePerIP:       opts.RatePerIP,
+		RatePerSession:  opts.RatePerSession,
+		RateBurst:       opts.RateBurst,
+		TrustedProxies:  opts.TrustedProxies,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TestHandler{e: e}, nil
+}
+
+func (h *TestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.e.ServeHTTP(w, r)
+}
+
+// Close shuts down the embedded NATS server.
+func (h *TestHandler) Close() error {
+	return h.e.Close()
+}
+
+// SSEEvent is one server-sent event.
+type SSEEvent struct {
+	Event string   // event type, e.g. "datastar-patch-elements"
+	Data  []string // data lines, without the "data: " prefix
+}
+
+// String formats the event as it appeared on the wire.
+func (e SSEEvent) String() string {
+	var sb strings.Builder
+	if e.Event != "" {
+		fmt.Fprintf(&sb, "event: %s\n", e.Event)
+	}
+	for _, d := range e.Data {
+		fmt.Fprintf(&sb, "data: %s\n", d)
+	}
+	return sb.String()
+}
+
+// ReadSSEEvents reads n events from an SSE response body. Playground streams
+// stay open, so bound the request with a context deadline; ReadSSEEvents
+// returns the events read so far with an error if the stream ends early.
+func ReadSSEEvents(r io.Reader, n int) ([]SSEEvent, error) {
+	var events []SSEEvent
+	var cur SSEEvent
+	started := false
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for len(events) < n && sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if started {
+				events = append(events, cur)
+				cur, started = SSEEvent{}, false
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			cur.Event = value
+			started = true
+		case "data":
+			cur.Data = append(cur.Data, value)
+			started = true
+		}
+	}
+	if len(events) < n {
+		err := sc.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return events, fmt.Errorf("read %d of %d SSE events: %w", len(events), n, err)
+	}
+	return events, nil
+}
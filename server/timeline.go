@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var timelineTemplate = template.Must(template.ParseFS(templatesFS, "templates/timeline.html"))
+
+const (
+	debugPathPrefix = "/_debug"
+
+	// timelineSize is how many entries each session's timeline keeps.
+	timelineSize = 200
+
+	// maxTimelineSessions bounds how many sessions keep a timeline; the
+	// least recently active is dropped first.
+	maxTimelineSessions = 100
+)
+
+// Timeline records, per session, every signals payload the server received
+// and every patch it sent, for the /_debug/signals inspector. A nil
+// Timeline records nothing.
+type Timeline struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionTimeline
+}
+
+type sessionTimeline struct {
+	entries []TimelineEntry
+	updated time.Time
+}
+
+// TimelineEntry is one signals payload received ("signals") or one patch
+// sent ("patch").
+type TimelineEntry struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Transport string    `json:"transport"` // signals: http or ws; patches: html, sse or ws
+	Method    string    `json:"method,omitempty"`
+	URL       string    `json:"url"`
+	Signals   string    `json:"signals,omitempty"` // indented JSON
+	Selector  string    `json:"selector,omitempty"`
+	Mode      string    `json:"mode,omitempty"`
+	HTML      string    `json:"html,omitempty"`
+}
+
+func NewTimeline() *Timeline {
+	return &Timeline{sessions: make(map[string]*sessionTimeline)}
+}
+
+// Signals records signals received from the session.
+func (t *Timeline) Signals(sessionID, transport, method, url string, signals map[string]any) {
+	if t == nil {
+		return
+	}
+	pretty, err := json.MarshalIndent(signals, "", "  ")
+	if err != nil {
+		pretty = []byte(fmt.Sprint(signals))
+	}
+	t.add(sessionID, TimelineEntry{Kind: "signals", Transport: transport, Method: method, URL: url, Signals: string(pretty)})
+}
+
+// Patch records elements sent to the session.
+func (t *Timeline) Patch(sessionID, transport, url string, fm Frontmatter, html string) {
+	if t == nil {
+		return
+	}
+	t.add(sessionID, TimelineEntry{Kind: "patch", Transport: transport, URL: url, Selector: fm.Selector, Mode: fm.Mode, HTML: html})
+}
+
+func (t *Timeline) add(sessionID string, e TimelineEntry) {
+	e.Time = time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.sessions[sessionID]
+	if !ok {
+		if len(t.sessions) >= maxTimelineSessions {
+			oldest := ""
+			for id, s := range t.sessions {
+				if oldest == "" || s.updated.Before(t.sessions[oldest].updated) {
+					oldest = id
+				}
+			}
+			delete(t.sessions, oldest)
+		}
+		st = &sessionTimeline{}
+		t.sessions[sessionID] = st
+	}
+	st.entries = append(st.entries, e)
+	if n := len(st.entries); n > timelineSize {
+		st.entries = slices.Clone(st.entries[n-timelineSize:])
+	}
+	st.updated = e.Time
+}
+
+// Entries returns the session's timeline, newest first.
+func (t *Timeline) Entries(sessionID string) []TimelineEntry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	entries := slices.Clone(st.entries)
+	slices.Reverse(entries)
+	return entries
+}
+
+// Clear empties the session's timeline.
+func (t *Timeline) Clear(sessionID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// DebugHandler serves the debug-mode inspectors under /_debug.
+type DebugHandler struct {
+	timeline *Timeline
+	sessions *SessionManager
+}
+
+func NewDebugHandler(timeline *Timeline, sessions *SessionManager) *DebugHandler {
+	return &DebugHandler{timeline: timeline, sessions: sessions}
+}
+
+// timelinePage is the data rendered by templates/timeline.html.
+type timelinePage struct {
+	SessionID string
+	Entries   []TimelineEntry
+	CSRFToken string
+}
+
+// Routes mounts the debug endpoints on r:
+//
+//	GET  /_debug/signals       → your session's signals and patches, newest first
+//	GET  /_debug/signals.json  → the same as JSON
+//	POST /_debug/signals/clear → empty your session's timeline
+func (d *DebugHandler) Routes(r chi.Router) {
+	r.Get(debugPathPrefix+"/signals", d.signals)
+	r.Get(debugPathPrefix+"/signals.json", d.signalsJSON)
+	r.Post(debugPathPrefix+"/signals/clear", d.clearSignals)
+}
+
+func (d *DebugHandler) signals(w http.ResponseWriter, r *http.Request) {
+	sess, sd, err := d.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	sess.Save(r, w)
+	page := timelinePage{
+		SessionID: sd.SessionID,
+		Entries:   d.timeline.Entries(sd.SessionID),
+		CSRFToken: d.sessions.CSRFToken(sd.SessionID),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := timelineTemplate.Execute(w, page); err != nil {
+		log.Printf("Timeline template error: %v", err)
+	}
+}
+
+func (d *DebugHandler) signalsJSON(w http.ResponseWriter, r *http.Request) {
+	_, sd, err := d.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, d.timeline.Entries(sd.SessionID))
+}
+
+func (d *DebugHandler) clearSignals(w http.ResponseWriter, r *http.Request) {
+	_, sd, err := d.sessions.GetOrCreate(w, r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Session error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	d.timeline.Clear(sd.SessionID)
+	http.Redirect(w, r, basePath(r)+debugPathPrefix+"/signals", http.StatusSeeOther)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezone: must work on hosts without a zoneinfo database
+)
+
+// Sections can be limited to a time of day or a span of dates with the
+// during:, before: and after: options, evaluated against the playground
+// clock in the file's timezone: (the server's local zone by default):
+//
+//	=== during: Mon-Fri 09:00-17:00
+//	<p>We're open, chat with us!</p>
+//	=== before: 2026-12-24 18:00
+//	<p>Countdown to launch...</p>
+//	===
+//	<p>We've launched!</p>
+
+// timeWindow is a during: option: days of the week and a time of day,
+// either of which may be left out.
+type timeWindow struct {
+	days     [7]bool // by time.Weekday; all false = every day
+	from, to int     // minutes since midnight; to < from wraps past midnight
+	allDay   bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWindow reads "Mon-Fri 09:00-17:00", "Sat,Sun", "22:00-06:00", ...
+func parseWindow(value string) (*timeWindow, error) {
+	w := &timeWindow{allDay: true}
+	fields := strings.Fields(value)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("during: %q is not [days] [HH:MM-HH:MM]", value)
+	}
+	for _, f := range fields {
+		if strings.Contains(f, ":") {
+			from, to, ok := strings.Cut(f, "-")
+			if !ok {
+				return nil, fmt.Errorf("during: %q is not a HH:MM-HH:MM range", f)
+			}
+			var err error
+			if w.from, err = parseClock(from); err != nil {
+				return nil, err
+			}
+			if w.to, err = parseClock(to); err != nil {
+				return nil, err
+			}
+			w.allDay = false
+			continue
+		}
+		for _, part := range strings.Split(strings.ToLower(f), ",") {
+			switch part {
+			case "weekdays":
+				part = "mon-fri"
+			case "weekends":
+				part = "sat-sun"
+			}
+			first, last, isRange := strings.Cut(part, "-")
+			if !isRange {
+				last = first
+			}
+			d1, ok1 := weekdays[first]
+			d2, ok2 := weekdays[last]
+			if !ok1 || !ok2 {
+				return nil, fmt.Errorf("during: %q is not a day (Mon, Tue, ... or Mon-Fri)", part)
+			}
+			for d := d1; ; d = (d + 1) % 7 {
+				w.days[d] = true
+				if d == d2 {
+					break
+				}
+			}
+		}
+	}
+	return w, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("during: %q is not a HH:MM time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *timeWindow) contains(t time.Time) bool {
+	if w.days != [7]bool{} && !w.days[t.Weekday()] {
+		return false
+	}
+	if w.allDay {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.from <= w.to {
+		return m >= w.from && m < w.to
+	}
+	return m >= w.from || m < w.to
+}
+
+// wallTime is a before: or after: option. Without an explicit offset it
+// is read in the file's timezone when checked.
+type wallTime struct {
+	t     time.Time
+	zoned bool
+}
+
+var wallTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+func parseWallTime(key, value string) (*wallTime, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &wallTime{t: t, zoned: true}, nil
+	}
+	for _, layout := range wallTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &wallTime{t: t}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %q is not a date (2006-01-02), date and time (2006-01-02 15:04) or RFC 3339 time", key, value)
+}
+
+func (w *wallTime) in(loc *time.Location) time.Time {
+	if w.zoned {
+		return w.t
+	}
+	return time.Date(w.t.Year(), w.t.Month(), w.t.Day(), w.t.Hour(), w.t.Minute(), w.t.Second(), 0, loc)
+}
+
+// hasSchedule reports whether the section has any time options.
+func (o SectionOptions) hasSchedule() bool {
+	return o.During != nil || o.Before != nil || o.After != nil
+}
+
+// inSchedule reports whether now falls within the section's time options.
+func (o SectionOptions) inSchedule(now time.Time, loc *time.Location) bool {
+	now = now.In(loc)
+	if o.During != nil && !o.During.contains(now) {
+		return false
+	}
+	if o.Before != nil && !now.Before(o.Before.in(loc)) {
+		return false
+	}
+	if o.After != nil && now.Before(o.After.in(loc)) {
+		return false
+	}
+	return true
+}
+
+// location is the file's timezone:, or the server's local zone.
+func (fm Frontmatter) location() *time.Location {
+	if fm.Timezone == "" {
+		return time.Local
+	}
+	if loc, err := time.LoadLocation(fm.Timezone); err == nil {
+		return loc
+	}
+	return time.Local
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestTimeRules(t *testing.T) {
+	// A day and hours that stay in the future, and hours that stay current,
+	// even if the test runs over midnight
+	now := time.Now().UTC()
+	otherDay := now.AddDate(0, 0, 2).Weekday().String()[:3]
+	hours := func(from, to int) string {
+		return fmt.Sprintf("%02d:00-%02d:00", (now.Hour()+24+from)%24, (now.Hour()+24+to)%24)
+	}
+	page := func(option string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("---\ntimezone: UTC\n---\n=== " + option + "\nmatched\n===\nfallback")}
+	}
+	srv, c := newTestServer(t, fstest.MapFS{
+		"after/index.html":     page("after: 2000-01-01"),
+		"before/index.html":    page("before: 2000-01-01 09:00"),
+		"future/index.html":    page("after: 2999-12-31T00:00:00Z"),
+		"weekdays/index.html":  page("during: Mon-Sun"),
+		"other-day/index.html": page("during: " + otherDay),
+		"now/index.html":       page("during: " + hours(-2, 2)),
+		"later/index.html":     page("during: " + hours(3, 5)),
+		"wrong-day/index.html": page("during: " + otherDay + " " + hours(-2, 2)),
+	})
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/after/", "matched"},
+		{"/before/", "fallback"},
+		{"/future/", "fallback"},
+		{"/weekdays/", "matched"},
+		{"/other-day/", "fallback"},
+		{"/now/", "matched"},
+		{"/later/", "fallback"},
+		{"/wrong-day/", "fallback"},
+	}
+	for _, tt := range tests {
+		if status, body := get(t, c, srv.URL+tt.path); status != http.StatusOK || body != tt.want {
+			t.Errorf("GET %s = %d %q, want 200 %q", tt.path, status, body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// Clients without a cookie jar (curl, httpie, scripts) can name their session
+// with this header or query parameter instead. The query parameter is
+// ignored when the request has a session cookie, so a link can't move a
+// browser visitor into a session someone else chose.
+const (
+	sessionTokenHeader = "X-DSPlay-Session"
+	sessionTokenParam  = "session"
+	maxSessionToken    = 128
+	maxTokenSessions   = 10000 // token sessions kept at once; the least recently used go first
+)
+
+// sessionToken returns the client-chosen session token, or "" to use the
+// session cookie named cookie.
+func sessionToken(r *http.Request, cookie string) string {
+	token := r.Header.Get(sessionTokenHeader)
+	if token == "" {
+		if _, err := r.Cookie(cookie); err == nil {
+			return ""
+		}
+		token = r.URL.Query().Get(sessionTokenParam)
+	}
+	if len(token) > maxSessionToken {
+		return ""
+	}
+	return token
+}
+
+// tokenStore is a sessions.Store for token-identified sessions. Values are
+// kept in memory, gob-encoded so concurrent requests never share maps, and
+// dropped once unused for maxAge. Tokens are chosen by clients, so at most
+// maxTokenSessions are kept.
+type tokenStore struct {
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]tokenEntry
+	lastSweep time.Time
+}
+
+type tokenEntry struct {
+	values []byte
+	seen   time.Time
+}
+
+func newTokenStore(maxAge time.Duration) *tokenStore {
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge * time.Second
+	}
+	return &tokenStore{maxAge: maxAge, entries: make(map[string]tokenEntry)}
+}
+
+// Get returns the session for this request, cached for the request's lifetime.
+func (t *tokenStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(t, name)
+}
+
+// New loads the session for the request's token, or starts an empty one.
+func (t *tokenStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(t, name)
+	session.ID = sessionToken(r, name)
+	session.Options = &sessions.Options{}
+	session.IsNew = true
+
+	t.mu.Lock()
+	e, ok := t.entries[session.ID]
+	t.mu.Unlock()
+	if !ok {
+		return session, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(e.values)).Decode(&session.Values); err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save stores the session's values. No cookie is set.
+func (t *tokenStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, known := t.entries[session.ID]
+	if !known && len(t.entries) >= maxTokenSessions {
+		t.sweep(now)
+		if len(t.entries) >= maxTokenSessions {
+			t.evictOldest()
+		}
+	}
+	t.entries[session.ID] = tokenEntry{values: buf.Bytes(), seen: now}
+	if now.Sub(t.lastSweep) > time.Minute {
+		t.sweep(now)
+	}
+	return nil
+}
+
+// sweep drops sessions unused for maxAge. t.mu must be held.
+func (t *tokenStore) sweep(now time.Time) {
+	for id, e := range t.entries {
+		if now.Sub(e.seen) > t.maxAge {
+			delete(t.entries, id)
+		}
+	}
+	t.lastSweep = now
+}
+
+// evictOldest drops the least recently used session. t.mu must be held.
+func (t *tokenStore) evictOldest() {
+	var (
+		oldest string
+		seen   time.Time
+	)
+	for id, e := range t.entries {
+		if oldest == "" || e.seen.Before(seen) {
+			oldest, seen = id, e.seen
+		}
+	}
+	delete(t.entries, oldest)
+}
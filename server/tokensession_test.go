@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+func TestTokenStoreCap(t *testing.T) {
+	store := newTokenStore(time.Hour)
+	save := func(token string) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(sessionTokenHeader, token)
+		s := sessions.NewSession(store, "ds-play")
+		s.ID = token
+		if err := store.Save(r, httptest.NewRecorder(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range maxTokenSessions + 10 {
+		save(fmt.Sprintf("t%d", i))
+	}
+	if n := len(store.entries); n != maxTokenSessions {
+		t.Errorf("entries = %d, want %d", n, maxTokenSessions)
+	}
+	if _, ok := store.entries["t0"]; ok {
+		t.Error("least recently used token was kept")
+	}
+	if _, ok := store.entries[fmt.Sprintf("t%d", maxTokenSessions+9)]; !ok {
+		t.Error("newest token was dropped")
+	}
+}
+
+func TestSessionTokenIgnoresQueryWithCookie(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?session=planted", nil)
+	if got := sessionToken(r, "ds-play"); got != "planted" {
+		t.Errorf("without a cookie: token = %q, want %q", got, "planted")
+	}
+	r.AddCookie(&http.Cookie{Name: "ds-play", Value: "browser"})
+	if got := sessionToken(r, "ds-play"); got != "" {
+		t.Errorf("with a cookie: token = %q, want none", got)
+	}
+	r.Header.Set(sessionTokenHeader, "script")
+	if got := sessionToken(r, "ds-play"); got != "script" {
+		t.Errorf("header with a cookie: token = %q, want %q", got, "script")
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	transportHeader     = "X-Dsplay-Transport"  // client preference in, chosen transport out
+	transportsHeader    = "X-Dsplay-Transports" // transports the route offers
+	transportSignalName = "transport"
+)
+
+// preferredTransport returns the transport the client asked for with the
+// X-Dsplay-Transport header or the "transport" signal, if any.
+func preferredTransport(r *http.Request, signals map[string]any) string {
+	if v := r.Header.Get(transportHeader); v != "" {
+		return strings.ToLower(strings.TrimSpace(v))
+	}
+	if v, ok := signals[transportSignalName].(string); ok {
+		return strings.ToLower(v)
+	}
+	return ""
+}
+
+// wsSignals reads signals from the ?datastar= query parameter, which is
+// where Datastar puts them for GET requests and the only place a WebSocket
+// handshake can carry them.
+func wsSignals(r *http.Request) map[string]any {
+	signals := map[string]any{}
+	if q := r.URL.Query().Get("datastar"); q != "" {
+		if err := json.Unmarshal([]byte(q), &signals); err != nil {
+			log.Printf("Warning: failed to read websocket signals: %v", err)
+		}
+	}
+	return signals
+}
+
+// wsMessage is sent for every rendered section. Clients apply it the way
+// Datastar applies a patch-elements event.
+type wsMessage struct {
+	Elements string `json:"elements"`
+	Selector string `json:"selector,omitempty"`
+	Mode     string `json:"mode,omitempty"`
+}
+
+// handleWS streams a route's ws.html sections over a WebSocket. Timing
+// follows the same frontmatter as SSE (loop, interval, delay); messages from
+// the client are JSON signals, merged and broadcast like a Datastar POST.
+func (h *Handler) handleWS(w http.ResponseWriter, r *http.Request, files []*ParsedFile, sd *SessionData, td TemplateData, urlPath string) {
+	allSections := h.dropServed(sd.SessionID, collectSections(files))
+	if len(allSections) == 0 {
+		h.debugLog("  ws: every section already served once (204)")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	section := allSections[0]
+	conditional := hasConditions(allSections)
+
+	pick := func(current int) (int, bool) {
+		switch {
+		case conditional:
+			return h.firstMatch(allSections, td)
+		case allSections[current].isRandom():
+			return pickRandom(allSections), true
+		}
+		return current, true
+	}
+
+	pos, matched := pick(0)
+
+	if !h.limiter.Acquire(sd.SessionID, clientIP(r)) {
+		h.debugLog("  ws: connection limit reached (503)")
+		w.Header().Set("Retry-After", "5")
+		h.renderError(w, http.StatusServiceUnavailable, "Too many live connections, please try again shortly.", td)
+		return
+	}
+	defer h.limiter.Release(sd.SessionID, clientIP(r))
+
+	ws, err := acceptWebSocket(w, r, h.wsOrigins)
+	if err != nil {
+		h.debugLog("  ws: handshake failed: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	tabID, _ := td.Signals["tab_id"].(string)
+	ctx, cancel := h.conns.Open(context.Background(), ConnInfo{Transport: "ws", Route: urlPath, SessionID: sd.SessionID, TabID: tabID})
+	defer cancel()
+
+	natsCh := make(chan *nats.Msg, 16)
+	sub, err := h.nc.ChanSubscribe(h.subjects.session(sd.SessionID), natsCh)
+	if err != nil {
+		log.Printf("NATS subscribe error (session): %v", err)
+	} else {
+		defer sub.Unsubscribe()
+	}
+
+	// Client messages carry signals; publishing them re-renders every
+	// stream in the session, including this one.
+	go func() {
+		defer cancel()
+		for {
+			data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			signals := map[string]any{}
+			if err := json.Unmarshal(data, &signals); err != nil {
+				h.debugLog("  ws: ignoring non-JSON message: %v", err)
+				continue
+			}
+			h.timeline.Signals(td.SessionID, "ws", "", urlPath, signals)
+			h.publishSignals(TemplateData{SessionID: td.SessionID, Signals: signals})
+		}
+	}()
+
+	last := &lastPatch{}
+	send := func(pos int) bool {
+		td.GlobalHits = h.counters.GetGlobalHits()
+		td.URLHits = h.counters.GetURLHits(urlPath)
+		if err := h.sendWSSection(ws, last, allSections[pos], td); err != nil {
+			log.Printf("Error sending websocket message: %v", err)
+			return false
+		}
+		td.SSEMessageCount++
+		countMessage(ctx)
+		return true
+	}
+
+	td.SSEMessageCount = 1
+	if matched && !send(pos) {
+		return
+	}
+
+	if section.frontmatter.Loop && section.frontmatter.Interval > 0 {
+		pacer := newLoopPacer(section.frontmatter)
+		ticker := h.clock.NewTicker(pacer.first())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !pacer.steady() {
+					ticker.Reset(pacer.next())
+				}
+				next, ok := pick((pos + 1) % len(allSections))
+				if !ok {
+					continue
+				}
+				pos = next
+				td.LoopCounter++
+				td.LoopCounter0 = td.LoopCounter - 1
+				if !send(pos) {
+					return
+				}
+			case msg := <-natsCh:
+				h.mergeNATSSignals(msg.Data, &td)
+				if pos, matched = pick(pos); matched && !send(pos) {
+					return
+				}
+			}
+		}
+	}
+
+	// Sequential: remaining sections, each after its delay, unless a single
+	// section was picked by condition or at random
+	if !conditional && !section.isRandom() {
+		for pos+1 < len(allSections) {
+			if h.clock.Sleep(ctx, allSections[pos+1].delay()) != nil {
+				return
+			}
+			pos++
+			if !send(pos) {
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-natsCh:
+			h.mergeNATSSignals(msg.Data, &td)
+			if pos, matched = pick(pos); matched && !send(pos) {
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) sendWSSection(ws *wsConn, last *lastPatch, section sectionEntry, td TemplateData) error {
+	if section.content == "" || !h.claimOnce(td.SessionID, section) {
+		return nil
+	}
+	rendered, err := h.render(section, td)
+	if err != nil {
+		return err
+	}
+	if last.repeat(section, rendered) {
+		return nil
+	}
+	data, err := json.Marshal(wsMessage{
+		Elements: rendered,
+		Selector: section.frontmatter.Selector,
+		Mode:     section.frontmatter.Mode,
+	})
+	if err != nil {
+		return err
+	}
+	if err := ws.WriteText(string(data)); err != nil {
+		return err
+	}
+	h.timeline.Patch(td.SessionID, "ws", td.URL, section.frontmatter, rendered)
+	return nil
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	uploadsPathPrefix = "/_uploads"
+	uploadMemory      = 1 << 20   // multipart bytes held in memory before spilling to disk
+	uploadTTL         = time.Hour // uploads older than this are swept away
+	maxUploadName     = 100       // longest kept file name, in bytes
+)
+
+// Upload is a file posted in a multipart form, as templates see it in
+// .Uploads.
+type Upload struct {
+	Field       string // form field the file was posted in
+	Name        string // file name the browser sent, e.g. "cat.png"
+	Size        int64
+	ContentType string
+	Path        string // where the file was saved on the server
+	URL         string // where the visitor who posted it can fetch it back, relative to the playground root
+}
+
+// UploadStore saves uploaded files in one directory per session. Files are
+// temporary: they are swept an hour after upload, and when the store lives
+// in a directory it created, removed entirely by Close.
+type UploadStore struct {
+	dir  string
+	temp bool // dir was created by NewUploadStore
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewUploadStore returns a store saving into dir, or into a new temporary
+// directory when dir is empty.
+func NewUploadStore(dir string) (*UploadStore, error) {
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "dsplay-uploads-*")
+		if err != nil {
+			return nil, fmt.Errorf("creating upload directory: %w", err)
+		}
+		return &UploadStore{dir: tmp, temp: true}, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating upload directory: %w", err)
+	}
+	return &UploadStore{dir: dir}, nil
+}
+
+// Close removes the store's directory if it created it.
+func (u *UploadStore) Close() error {
+	if u == nil || !u.temp {
+		return nil
+	}
+	return os.RemoveAll(u.dir)
+}
+
+// sessionDir is where a session's uploads live. Session IDs come from
+// signed cookies, but are still kept to one safe path segment.
+func (u *UploadStore) sessionDir(sessionID string) string {
+	return filepath.Join(u.dir, safeFileName(sessionID))
+}
+
+// Save stores every file in form for the session and describes them.
+func (u *UploadStore) Save(sessionID string, form *multipart.Form) ([]Upload, error) {
+	if u == nil || form == nil || len(form.File) == 0 {
+		return nil, nil
+	}
+	u.sweep()
+	dir := u.sessionDir(sessionID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	var uploads []Upload
+	for field, headers := range form.File {
+		for _, fh := range headers {
+			if fh.Filename == "" && fh.Size == 0 {
+				continue // a file input left empty
+			}
+			up, err := u.save(dir, field, fh)
+			if err != nil {
+				return nil, fmt.Errorf("saving %s: %w", fh.Filename, err)
+			}
+			uploads = append(uploads, up)
+		}
+	}
+	return uploads, nil
+}
+
+func (u *UploadStore) save(dir, field string, fh *multipart.FileHeader) (Upload, error) {
+	src, err := fh.Open()
+	if err != nil {
+		return Upload{}, err
+	}
+	defer src.Close()
+
+	// A random prefix keeps uploads of the same name apart and unguessable
+	var prefix [8]byte
+	rand.Read(prefix[:])
+	name := hex.EncodeToString(prefix[:]) + "-" + safeFileName(fh.Filename)
+	path := filepath.Join(dir, name)
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return Upload{}, err
+	}
+	size, err := io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return Upload{}, err
+	}
+
+	contentType := fh.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return Upload{
+		Field:       field,
+		Name:        fh.Filename,
+		Size:        size,
+		ContentType: contentType,
+		Path:        path,
+		URL:         uploadsPathPrefix + "/" + name,
+	}, nil
+}
+
+// sweep removes uploads older than uploadTTL, at most once a minute.
+func (u *UploadStore) sweep() {
+	u.mu.Lock()
+	if time.Since(u.lastSweep) < time.Minute {
+		u.mu.Unlock()
+		return
+	}
+	u.lastSweep = time.Now()
+	u.mu.Unlock()
+
+	cutoff := time.Now().Add(-uploadTTL)
+	sessions, _ := os.ReadDir(u.dir)
+	for _, s := range sessions {
+		if !s.IsDir() {
+			continue
+		}
+		dir := filepath.Join(u.dir, s.Name())
+		files, _ := os.ReadDir(dir)
+		left := len(files)
+		for _, f := range files {
+			if info, err := f.Info(); err == nil && info.ModTime().Before(cutoff) {
+				if os.Remove(filepath.Join(dir, f.Name())) == nil {
+					left--
+				}
+			}
+		}
+		if left == 0 {
+			os.Remove(dir)
+		}
+	}
+}
+
+// safeFileName reduces name to a single path segment of letters, digits,
+// dots, dashes and underscores.
+func safeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	safe = strings.TrimLeft(safe, ".")
+	if len(safe) > maxUploadName {
+		safe = safe[len(safe)-maxUploadName:]
+	}
+	if safe == "" {
+		safe = "upload"
+	}
+	return safe
+}
+
+// isFormPost reports whether r carries an HTML form (including Datastar's
+// contentType: 'form' requests) rather than JSON signals.
+func isFormPost(r *http.Request) bool {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mt == "multipart/form-data" || mt == "application/x-www-form-urlencoded"
+}
+
+// UploadHandler serves uploaded files back to the session that posted them.
+type UploadHandler struct {
+	uploads  *UploadStore
+	sessions *SessionManager
+}
+
+func NewUploadHandler(uploads *UploadStore, sessions *SessionManager) *UploadHandler {
+	return &UploadHandler{uploads: uploads, sessions: sessions}
+}
+
+// Routes mounts the upload endpoint on r:
+//
+//	GET /_uploads/{name} → a file the visitor's session uploaded
+func (u *UploadHandler) Routes(r chi.Router) {
+	r.Get(uploadsPathPrefix+"/{name}", u.serve)
+}
+
+func (u *UploadHandler) serve(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	sessionID := u.sessions.sessionID(r)
+	if sessionID == "" || name != safeFileName(name) {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(filepath.Join(u.uploads.sessionDir(sessionID), name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	// Uploads are whatever visitors sent, so never let one run as a page
+	// on the playground's origin
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Upstream forwards a route to a real backend instead of rendering it:
+//
+//	proxy: http://localhost:9000/api/
+//
+// or, to set or remove request headers on the way:
+//
+//	proxy:
+//	  url: http://localhost:9000/api/
+//	  headers:
+//	    Authorization: Bearer dev-token
+//	    X-Debug: ""          # empty removes the header
+//
+// Under a catch-all ([...]) route the rest of the path is appended to url.
+// The upstream host must be allowlisted like fetchJSON's (--allow-fetch).
+type Upstream struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+func (u *Upstream) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*u = Upstream{URL: node.Value}
+		return nil
+	}
+	type plain Upstream
+	return node.Decode((*plain)(u))
+}
+
+// upstreamFile returns the file proxying requests of method to rf, if any.
+func upstreamFile(rf *RouteFiles, method string) *ParsedFile {
+	for _, files := range [][]*ParsedFile{rf.LookupHTML(method), rf.LookupSSE(method), rf.LookupWS(method)} {
+		for _, f := range files {
+			if f.Frontmatter.Proxy.URL != "" {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// serveUpstream forwards r to the upstream named by pf. Responses are
+// streamed as they arrive, so upstream SSE and WebSocket endpoints work.
+func (h *Handler) serveUpstream(w http.ResponseWriter, r *http.Request, pf *ParsedFile, wildcard string) {
+	up := pf.Frontmatter.Proxy
+	target, err := url.Parse(up.URL)
+	if err != nil {
+		h.renderError(w, http.StatusBadGateway, fmt.Sprintf("%s: bad proxy url: %v", pf.Path, err), TemplateData{URL: r.URL.Path, Method: r.Method, BasePath: basePath(r)})
+		return
+	}
+	if h.fetcher == nil || !h.fetcher.allowed(target) {
+		h.renderError(w, http.StatusBadGateway, fmt.Sprintf("%s: proxy host %q is not allowlisted (see --allow-fetch)", pf.Path, target.Hostname()), TemplateData{URL: r.URL.Path, Method: r.Method, BasePath: basePath(r)})
+		return
+	}
+	if wildcard != "" {
+		target = target.JoinPath(wildcard)
+		if strings.HasSuffix(r.URL.Path, "/") {
+			target.Path += "/"
+		}
+	}
+	target.RawQuery = r.URL.RawQuery
+	h.debugLog("  → proxy %s", target)
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL = target
+			pr.Out.Host = target.Host
+			pr.SetXForwarded()
+			dropCredentials(pr.Out, h.sessions.cookieName())
+			for name, value := range up.Headers {
+				if value == "" {
+					pr.Out.Header.Del(name)
+				} else {
+					pr.Out.Header.Set(name, value)
+				}
+			}
+		},
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Proxy %s → %s: %v", r.URL.Path, target, err)
+			h.renderError(w, http.StatusBadGateway, "The upstream server could not be reached.", TemplateData{URL: r.URL.Path, Method: r.Method, BasePath: basePath(r)})
+		},
+	}
+	rp.ServeHTTP(w, r)
+}
+
+// dropCredentials removes the visitor's playground credentials from a
+// request about to be sent upstream: the Authorization header protect
+// checks, the session token header and ?session= and ?token= parameters,
+// and every cookie dsplay sets for itself, so an allowlisted host never
+// sees them.
+func dropCredentials(r *http.Request, sessionCookie string) {
+	r.Header.Del("Authorization")
+	r.Header.Del(sessionTokenHeader)
+	if q := r.URL.Query(); q.Has(sessionTokenParam) || q.Has(protectTokenParam) {
+		q.Del(sessionTokenParam)
+		q.Del(protectTokenParam)
+		r.URL.RawQuery = q.Encode()
+	}
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, c := range cookies {
+		switch c.Name {
+		case sessionCookie, protectTokenCookie, adminTokenCookie:
+		default:
+			r.AddCookie(c)
+		}
+	}
+}
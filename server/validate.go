@@ -0,0 +1,216 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dataSPA/dataSPA-playground/expr"
+	"gopkg.in/yaml.v3"
+)
+
+// Signals is the signals: frontmatter, the signals a file expects
+// from Datastar requests, by name ("user.email" for nested signals):
+//
+//	signals:
+//	  email: {type: string, required: true, pattern: "^[^@]+@[^@]+$"}
+//	  age: {type: number, min: 18}
+//	  plan: {enum: [free, pro]}
+//	  notes: string          # just a type
+type Signals map[string]SignalRule
+
+// SignalRule constrains one signal.
+type SignalRule struct {
+	Type     string   `yaml:"type"`     // string, number, bool, list or object ("" = any)
+	Required bool     `yaml:"required"` // must be present and not empty
+	Min      *float64 `yaml:"min"`      // smallest number, or shortest string or list
+	Max      *float64 `yaml:"max"`      // largest number, or longest string or list
+	Pattern  string   `yaml:"pattern"`  // regular expression a string must match
+	Enum     []any    `yaml:"enum"`     // allowed values
+	Message  string   `yaml:"message"`  // shown instead of the generated error
+
+	re *regexp.Regexp
+}
+
+func (sr *SignalRule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*sr = SignalRule{Type: node.Value}
+	} else {
+		type plain SignalRule
+		if err := node.Decode((*plain)(sr)); err != nil {
+			return err
+		}
+	}
+	switch sr.Type {
+	case "", "string", "number", "bool", "list", "object":
+	default:
+		return fmt.Errorf("signals: unknown type %q (want string, number, bool, list or object)", sr.Type)
+	}
+	if sr.Pattern != "" {
+		re, err := regexp.Compile(sr.Pattern)
+		if err != nil {
+			return fmt.Errorf("signals: pattern: %w", err)
+		}
+		sr.re = re
+	}
+	return nil
+}
+
+// SignalErrors maps each signal that failed its schema to a message.
+type SignalErrors map[string]string
+
+// validate checks signals against the schema.
+func (s Signals) validate(signals map[string]any) SignalErrors {
+	errs := SignalErrors{}
+	for name, rule := range s {
+		if msg := rule.check(name, signalValue(signals, name)); msg != "" {
+			if rule.Message != "" {
+				msg = rule.Message
+			}
+			errs[name] = msg
+		}
+	}
+	return errs
+}
+
+// signalValue looks up a signal by dotted name.
+func signalValue(signals map[string]any, name string) any {
+	var v any = signals
+	for _, part := range strings.Split(name, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+// check returns why v breaks the rule, or "" if it doesn't.
+func (sr SignalRule) check(name string, v any) string {
+	var size float64 // the number, or the length of a string or list
+	unit := ""
+	switch v := v.(type) {
+	case nil:
+		if sr.Required {
+			return name + " is required"
+		}
+		return ""
+	case string:
+		if strings.TrimSpace(v) == "" && sr.Required {
+			return name + " is required"
+		}
+		if v == "" {
+			return ""
+		}
+		size, unit = float64(utf8.RuneCountInString(v)), " characters"
+		if sr.Type == "number" {
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return name + " must be a number"
+			}
+			size, unit = n, ""
+		}
+	case float64:
+		size = v
+	case []any:
+		if len(v) == 0 && sr.Required {
+			return name + " is required"
+		}
+		size, unit = float64(len(v)), " items"
+	}
+
+	switch sr.Type {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return name + " must be text"
+		}
+	case "number":
+		switch v.(type) {
+		case float64, string: // strings were parsed above
+		default:
+			return name + " must be a number"
+		}
+	case "bool":
+		if _, ok := v.(bool); !ok {
+			return name + " must be true or false"
+		}
+	case "list":
+		if _, ok := v.([]any); !ok {
+			return name + " must be a list"
+		}
+	case "object":
+		if _, ok := v.(map[string]any); !ok {
+			return name + " must be an object"
+		}
+	}
+
+	if sr.Min != nil && size < *sr.Min {
+		return fmt.Sprintf("%s must be at least %v%s", name, *sr.Min, unit)
+	}
+	if sr.Max != nil && size > *sr.Max {
+		return fmt.Sprintf("%s must be at most %v%s", name, *sr.Max, unit)
+	}
+	if s, ok := v.(string); ok && sr.re != nil && !sr.re.MatchString(s) {
+		return name + " is not in the expected format"
+	}
+	if len(sr.Enum) > 0 && !slices.ContainsFunc(sr.Enum, func(e any) bool { return expr.Equal(e, v) }) {
+		allowed := make([]string, len(sr.Enum))
+		for i, e := range sr.Enum {
+			allowed[i] = expr.ToString(e)
+		}
+		return fmt.Sprintf("%s must be one of %s", name, strings.Join(allowed, ", "))
+	}
+	return ""
+}
+
+// checkSignals validates a Datastar request's signals against the schema
+// in files' frontmatter. When they fail it answers the request with the
+// file's invalid: section, or a 422 listing the errors, and reports false.
+func (h *Handler) checkSignals(w http.ResponseWriter, files []*ParsedFile, td TemplateData) bool {
+	i := slices.IndexFunc(files, func(f *ParsedFile) bool { return len(f.Frontmatter.Signals) > 0 })
+	if i < 0 {
+		return true
+	}
+	f := files[i]
+	errs := f.Frontmatter.Signals.validate(td.Signals)
+	if len(errs) == 0 {
+		return true
+	}
+	h.debugLog("  signals failed the schema: %v", errs)
+	td.Errors = errs
+
+	// The invalid: section is sent as a plain HTML response, with 200 so
+	// Datastar morphs it into the page
+	if j := slices.IndexFunc(f.SectionOptions, func(o SectionOptions) bool { return o.Name == f.Frontmatter.Invalid }); f.Frontmatter.Invalid != "" && j >= 0 {
+		rendered, err := h.render(newSectionEntry(files, i, j), td)
+		if err != nil {
+			h.writeTemplateError(w, err)
+			return false
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(rendered))
+		return false
+	}
+
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var sb strings.Builder
+	sb.WriteString(`<ul id="signal-errors">`)
+	for _, name := range names {
+		sb.WriteString("<li>" + template.HTMLEscapeString(errs[name]) + "</li>")
+	}
+	sb.WriteString("</ul>\n")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write([]byte(sb.String()))
+	return false
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSignalSchema(t *testing.T) {
+	schema := "---\nsignals:\n  email: {type: string, required: true, pattern: \"^[^@]+@[^@]+$\", message: \"Enter a valid email\"}\n  age: {type: number, min: 18}\n"
+	srv, c := newTestServerWith(t, fstest.MapFS{
+		"signup/index.html":    {Data: []byte(schema + "---\nform")},
+		"signup/post_sse.html": {Data: []byte(schema + "invalid: errors\n---\n<p id=\"form\">Welcome {{.Signals.email}}</p>\n=== name: errors\n<ul id=\"form\">{{range $name, $msg := .Errors}}<li>{{$name}}: {{$msg}}</li>{{end}}</ul>")},
+		"bare/post_sse.html":   {Data: []byte(schema + "---\n<p id=\"form\">Welcome</p>")},
+	}, TestHandlerOptions{})
+
+	post := func(path, signals string) *http.Response {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+path, strings.NewReader(signals))
+		req.Header.Set("Datastar-Request", "true")
+		req.Header.Set("Content-Type", "application/json")
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { res.Body.Close() })
+		return res
+	}
+
+	// Valid signals get the file's normal response
+	res := post("/signup/", `{"email":"ada@example.com","age":"36"}`)
+	events, err := ReadSSEEvents(res.Body, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := events[0]; len(e.Data) != 1 || !strings.Contains(e.Data[0], "Welcome ada@example.com") {
+		t.Errorf("valid signals sent\n%s\nwant the welcome patch", e)
+	}
+
+	// Invalid ones get the invalid: section, with 200 so Datastar morphs it in
+	tests := []struct {
+		signals string
+		want    string
+	}{
+		{`{"age":36}`, "<li>email: Enter a valid email</li>"},
+		{`{"email":"ada"}`, "<li>email: Enter a valid email</li>"},
+		{`{"email":"ada@example.com","age":12}`, "<li>age: "},
+		{`{"email":"ada@example.com","age":"old"}`, "<li>age: "},
+	}
+	for _, tt := range tests {
+		res := post("/signup/", tt.signals)
+		body, _ := io.ReadAll(res.Body)
+		if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(body), tt.want) {
+			t.Errorf("POST /signup/ %s = %d %q, want 200 HTML containing %q", tt.signals, res.StatusCode, body, tt.want)
+		}
+	}
+
+	// Without an invalid: section the errors come back as a 422
+	res = post("/bare/", `{"email":"ada"}`)
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(string(body), "<li>Enter a valid email</li>") {
+		t.Errorf("POST /bare/ = %d %q, want 422 listing the error", res.StatusCode, body)
+	}
+
+	// Only Datastar requests are checked, so the page itself loads
+	if status, body := get(t, c, srv.URL+"/signup/"); status != http.StatusOK || body != "form" {
+		t.Errorf("GET /signup/ = %d %q, want 200 form", status, body)
+	}
+}
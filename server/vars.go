@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/dataSPA/dataSPA-playground/expr"
+	"gopkg.in/yaml.v3"
+)
+
+// Vars are computed values from the vars: frontmatter, each an expression
+// over the same names as section conditions:
+//
+//	vars:
+//	  subtotal: signals.price * signals.qty
+//	  total: vars.subtotal + 4.99
+//
+// They are evaluated in order, so each can use the ones before it.
+type Vars []Var
+
+// Var is one name: expression entry of Vars.
+type Var struct {
+	Name string
+	Expr *expr.Program
+}
+
+func (v *Vars) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("vars: want a map of name: expression")
+	}
+	vars := make(Vars, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return fmt.Errorf("vars: %s: want an expression", name)
+		}
+		prog, err := expr.Compile(value.Value)
+		if err != nil {
+			return fmt.Errorf("vars: %s: %w", name, err)
+		}
+		vars = append(vars, Var{Name: name, Expr: prog})
+	}
+	*v = vars
+	return nil
+}
+
+// withVars returns td with vars evaluated into .Vars, after any values the
+// route's handler.star returned.
+func withVars(vars Vars, td TemplateData) (TemplateData, error) {
+	if len(vars) == 0 {
+		return td, nil
+	}
+	values := make(map[string]any, len(td.Vars)+len(vars))
+	maps.Copy(values, td.Vars)
+	td.Vars = values
+	env := exprEnv(td)
+	for _, v := range vars {
+		value, err := v.Expr.Eval(env)
+		if err != nil {
+			return td, fmt.Errorf("vars: %s: %w", v.Name, err)
+		}
+		values[v.Name] = value
+	}
+	return td, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"time"
+)
+
+// watchPoll is how often watched playground files are checked for changes.
+const watchPoll = 2 * time.Second
+
+// watchFile calls start with the contents of name in fsys (nil when it
+// doesn't exist) now and whenever they change, until ctx is done. Whatever
+// start runs under its context is stopped when a later call succeeds; when
+// start fails, the error is logged and the previous run carries on.
+func watchFile(ctx context.Context, fsys fs.FS, name string, start func(ctx context.Context, data []byte) error) {
+	var (
+		current []byte
+		stop    context.CancelFunc = func() {}
+	)
+	defer func() { stop() }()
+
+	ticker := time.NewTicker(watchPoll)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		data, err := fs.ReadFile(fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			data, err = nil, nil
+		}
+		switch {
+		case err != nil:
+			log.Printf("Reading %s: %v", name, err)
+		case first || !bytes.Equal(data, current):
+			current = data
+			runCtx, cancel := context.WithCancel(ctx)
+			if err := start(runCtx, data); err != nil {
+				cancel()
+				log.Printf("%s: %v (keeping the previous version)", name, err)
+				break
+			}
+			stop()
+			stop = cancel
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
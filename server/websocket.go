@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	wsMaxMessage   = 1 << 20
+	wsWriteTimeout = 10 * time.Second
+)
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket protocol.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") &&
+		headerContains(r.Header, "Upgrade", "websocket")
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is a server-side WebSocket connection. Writes are safe for
+// concurrent use; reads must come from a single goroutine.
+type wsConn struct {
+	c *websocket.Conn
+}
+
+// acceptWebSocket completes the opening handshake and takes over the
+// connection. A browser page may only connect from the playground's own
+// host or one matching origins (path.Match patterns such as
+// "*.example.com"), so other sites can't open a socket with the visitor's
+// cookies.
+func acceptWebSocket(w http.ResponseWriter, r *http.Request, origins []string) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, fmt.Errorf("not a websocket upgrade request")
+	}
+	// The server's read/write timeouts would still apply to the hijacked
+	// connection
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+	c, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: origins})
+	if err != nil {
+		return nil, err
+	}
+	c.SetReadLimit(wsMaxMessage)
+	return &wsConn{c: c}, nil
+}
+
+// WriteText sends a single text message.
+func (c *wsConn) WriteText(msg string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+	defer cancel()
+	return c.c.Write(ctx, websocket.MessageText, []byte(msg))
+}
+
+// ReadMessage returns the next text or binary message. Pings and close
+// frames are answered along the way.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	_, msg, err := c.c.Read(context.Background())
+	return msg, err
+}
+
+func (c *wsConn) Close() error {
+	return c.c.Close(websocket.StatusNormalClosure, "")
+}
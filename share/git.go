@@ -0,0 +1,145 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitRemote shares playgrounds through any git repository the git command
+// can reach, keeping their directory layout. References are the
+// repository's URL with an optional #branch, like
+// https://git.example.com/team/demos.git#chat.
+type GitRemote struct {
+	URL    string // repository Save pushes to
+	Branch string // branch Save pushes to (default: the repository's default)
+}
+
+// Save replaces the contents of the remote branch with the playground and
+// pushes it as a new commit, with the description as its message.
+func (g GitRemote) Save(ctx context.Context, dir string, opts SaveOptions) (string, error) {
+	if g.URL == "" {
+		return "", fmt.Errorf("sharing to git needs a remote URL")
+	}
+	files, err := readDir(dir, opts.Exclude, opts.Progress)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp("", "dsplay-git-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := git(ctx, "", "clone", "--quiet", "--depth", "1", remoteURL(g.URL), tmp); err != nil {
+		return "", err
+	}
+	if g.Branch != "" {
+		// Check out the branch, or start it when the remote hasn't one
+		if err := git(ctx, tmp, "fetch", "--quiet", "--depth", "1", "origin", g.Branch); err == nil {
+			err = git(ctx, tmp, "checkout", "--quiet", "-B", g.Branch, "FETCH_HEAD")
+			if err != nil {
+				return "", err
+			}
+		} else if err := git(ctx, tmp, "checkout", "--quiet", "--orphan", g.Branch); err != nil {
+			return "", err
+		}
+	}
+
+	// The commit is the playground exactly: files it no longer has go
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.Name() != ".git" {
+			if err := os.RemoveAll(filepath.Join(tmp, e.Name())); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := writeFiles(tmp, files); err != nil {
+		return "", err
+	}
+	message := opts.Description
+	if message == "" {
+		message = "Update dsplay playground"
+	}
+	if err := git(ctx, tmp, "add", "--all"); err != nil {
+		return "", err
+	}
+	if err := git(ctx, tmp, "-c", "user.name="+gitIdentity(ctx, tmp, "user.name", "dsplay"),
+		"-c", "user.email="+gitIdentity(ctx, tmp, "user.email", "dsplay@localhost"),
+		"commit", "--quiet", "--allow-empty", "-m", message); err != nil {
+		return "", err
+	}
+	if err := git(ctx, tmp, "push", "--quiet", "origin", "HEAD"); err != nil {
+		return "", err
+	}
+
+	ref := g.URL
+	if g.Branch != "" {
+		ref += "#" + g.Branch
+	}
+	return ref, nil
+}
+
+// Load clones the branch ref names and reads its files.
+func (g GitRemote) Load(ctx context.Context, ref string) (map[string]string, error) {
+	repo, branch, _ := strings.Cut(ref, "#")
+	tmp, err := os.MkdirTemp("", "dsplay-git-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	if err := git(ctx, "", append(args, remoteURL(repo), tmp)...); err != nil {
+		return nil, err
+	}
+	return readDir(tmp, nil, nil)
+}
+
+// remoteURL drops the git+ that marks an http(s) URL as a repository.
+func remoteURL(ref string) string {
+	return strings.TrimPrefix(ref, "git+")
+}
+
+// gitIdentity returns the git config value key in dir, or fallback when
+// the user hasn't set one, so commits work on fresh machines.
+func gitIdentity(ctx context.Context, dir, key, fallback string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "config", key).Output()
+	if v := strings.TrimSpace(string(out)); err == nil && v != "" {
+		return v
+	}
+	return fallback
+}
+
+// git runs a git command in dir ("" for the current directory).
+func git(ctx context.Context, dir string, args ...string) error {
+	// Name the failing subcommand in errors, past any -c settings
+	sub := args[0]
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") && !strings.Contains(a, "=") {
+			sub = a
+			break
+		}
+	}
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", sub, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package share
+
+import (
+	"context"
+
+	"github.com/dataSPA/dataSPA-playground/gist"
+)
+
+// GitHubGists shares playgrounds as GitHub gists.
+type GitHubGists struct {
+	Client *gist.Client
+}
+
+// Save creates a gist holding the playground.
+func (g GitHubGists) Save(ctx context.Context, dir string, opts SaveOptions) (string, error) {
+	_, htmlURL, err := g.Client.SavePlayground(ctx, dir, gist.SaveOptions{
+		Public:      opts.Public,
+		Description: opts.Description,
+		Exclude:     opts.Exclude,
+		Progress:    opts.Progress,
+	})
+	return htmlURL, err
+}
+
+// Load fetches a gist by URL or ID, at the revision the URL pins if any.
+func (g GitHubGists) Load(ctx context.Context, ref string) (map[string]string, error) {
+	id, revision := gist.ParseGistRef(ref)
+	return g.Client.LoadPlayground(ctx, id, revision)
+}
@@ -0,0 +1,177 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/gist"
+)
+
+// DefaultGitLabURL is the GitLab instance snippets are created on unless
+// another is configured.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLabSnippets shares playgrounds as GitLab snippets. Snippet files are
+// flat, so paths are encoded as in gists (todos/index.html becomes
+// todos__index.html).
+type GitLabSnippets struct {
+	BaseURL string       // GitLab instance for new snippets (default DefaultGitLabURL)
+	Token   string       // personal access token with the api scope; "" reads public snippets only
+	HTTP    *http.Client // default http.DefaultClient
+}
+
+type snippetFile struct {
+	Path    string `json:"file_path,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type snippetRaw struct {
+	Path   string `json:"path"`
+	RawURL string `json:"raw_url"`
+}
+
+type snippet struct {
+	WebURL string `json:"web_url"`
+	// Files lists a snippet's files (GitLab 13.5 and later); older
+	// instances only report a single FileName and RawURL
+	Files    []snippetRaw `json:"files"`
+	FileName string       `json:"file_name"`
+	RawURL   string       `json:"raw_url"`
+}
+
+// Save creates a personal snippet holding the playground.
+func (g GitLabSnippets) Save(ctx context.Context, dir string, opts SaveOptions) (string, error) {
+	if g.Token == "" {
+		return "", fmt.Errorf("creating GitLab snippets needs a token with the api scope")
+	}
+	files, err := readDir(dir, opts.Exclude, opts.Progress)
+	if err != nil {
+		return "", err
+	}
+	title := opts.Description
+	if title == "" {
+		title = "dsplay playground"
+	}
+	visibility := "private"
+	if opts.Public {
+		visibility = "public"
+	}
+	body := struct {
+		Title       string        `json:"title"`
+		Description string        `json:"description,omitempty"`
+		Visibility  string        `json:"visibility"`
+		Files       []snippetFile `json:"files"`
+	}{Title: title, Description: opts.Description, Visibility: visibility}
+	for rel, content := range files {
+		body.Files = append(body.Files, snippetFile{Path: gist.EncodePath(rel), Content: content})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	var created snippet
+	if err := g.api(ctx, http.MethodPost, g.baseURL()+"/api/v4/snippets", bytes.NewReader(data), &created); err != nil {
+		return "", fmt.Errorf("creating snippet: %w", err)
+	}
+	return created.WebURL, nil
+}
+
+// Load fetches a snippet by web URL (https://gitlab.com/-/snippets/123,
+// or a project's .../-/snippets/123) or, on BaseURL, by ID.
+func (g GitLabSnippets) Load(ctx context.Context, ref string) (map[string]string, error) {
+	endpoint, err := g.snippetAPI(ref)
+	if err != nil {
+		return nil, err
+	}
+	var s snippet
+	if err := g.api(ctx, http.MethodGet, endpoint, nil, &s); err != nil {
+		return nil, fmt.Errorf("fetching snippet: %w", err)
+	}
+	if len(s.Files) == 0 && s.RawURL != "" {
+		s.Files = append(s.Files, snippetRaw{s.FileName, s.RawURL})
+	}
+
+	files := make(map[string]string, len(s.Files))
+	for _, f := range s.Files {
+		var content bytes.Buffer
+		if err := g.fetch(ctx, f.RawURL, &content); err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", f.Path, err)
+		}
+		files[gist.DecodePath(f.Path)] = content.String()
+	}
+	return files, nil
+}
+
+func (g GitLabSnippets) baseURL() string {
+	if g.BaseURL == "" {
+		return DefaultGitLabURL
+	}
+	return strings.TrimSuffix(g.BaseURL, "/")
+}
+
+// snippetAPI returns the API URL of the snippet ref names.
+func (g GitLabSnippets) snippetAPI(ref string) (string, error) {
+	before, id, ok := strings.Cut(ref, "/-/snippets/")
+	if !ok {
+		// A bare ID, on BaseURL
+		return g.baseURL() + "/api/v4/snippets/" + url.PathEscape(ref), nil
+	}
+	id, _, _ = strings.Cut(id, "/")
+	u, err := url.Parse(before)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("not a GitLab snippet URL: %s", ref)
+	}
+	base := u.Scheme + "://" + u.Host
+	if project := strings.Trim(u.Path, "/"); project != "" {
+		return base + "/api/v4/projects/" + url.PathEscape(project) + "/snippets/" + url.PathEscape(id), nil
+	}
+	return base + "/api/v4/snippets/" + url.PathEscape(id), nil
+}
+
+// api makes an API request and decodes the JSON reply into out.
+func (g GitLabSnippets) api(ctx context.Context, method, endpoint string, body io.Reader, out any) error {
+	var buf bytes.Buffer
+	if err := g.do(ctx, method, endpoint, body, &buf); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf.Bytes(), out)
+}
+
+func (g GitLabSnippets) fetch(ctx context.Context, rawURL string, w io.Writer) error {
+	return g.do(ctx, http.MethodGet, rawURL, nil, w)
+}
+
+func (g GitLabSnippets) do(ctx context.Context, method, endpoint string, body io.Reader, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.Token)
+	}
+	client := g.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s %s", method, endpoint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
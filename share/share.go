@@ -0,0 +1,129 @@
+// Package share publishes playgrounds to, and loads them from, the places
+// people keep code: GitHub gists, GitLab snippets and plain git
+// repositories. Each is a Provider; Detect picks one from a URL.
+package share
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dataSPA/dataSPA-playground/gist"
+)
+
+// Provider names, for --provider.
+const (
+	GitHub = "github" // GitHub gists
+	GitLab = "gitlab" // GitLab snippets
+	Git    = "git"    // any git remote
+)
+
+// Providers lists every provider name.
+var Providers = []string{GitHub, GitLab, Git}
+
+// SaveOptions controls how a playground is published.
+type SaveOptions struct {
+	Public      bool
+	Description string
+	Exclude     []string // glob patterns of files to leave out, see gist.Excluded
+	// Progress, if set, is called as each file is read, before the upload
+	Progress func(f gist.File, done, total int)
+}
+
+// Provider publishes and loads playgrounds.
+type Provider interface {
+	// Save publishes the playground in dir and returns the URL that
+	// Load, and dsplay serve, accept.
+	Save(ctx context.Context, dir string, opts SaveOptions) (string, error)
+	// Load returns the files of the playground at ref, by slash-separated
+	// path.
+	Load(ctx context.Context, ref string) (map[string]string, error)
+}
+
+// Detect returns the provider a playground URL belongs to, or "" for
+// anything else, such as a local path.
+func Detect(ref string) string {
+	switch {
+	case strings.Contains(ref, "gist.github.com"):
+		return GitHub
+	case strings.Contains(ref, "/-/snippets/"):
+		return GitLab
+	case strings.HasPrefix(ref, "git+"), strings.HasPrefix(ref, "git@"),
+		strings.HasPrefix(ref, "ssh://"), strings.HasPrefix(ref, "git://"):
+		return Git
+	}
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" && strings.HasSuffix(u.Path, ".git") {
+		return Git
+	}
+	return ""
+}
+
+// Valid reports whether name is a provider name.
+func Valid(name string) bool {
+	return slices.Contains(Providers, name)
+}
+
+// LoadToTempDir loads the playground at ref into a new temporary directory,
+// which the caller removes when done.
+func LoadToTempDir(ctx context.Context, p Provider, ref string) (string, error) {
+	files, err := p.Load(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files found at %s", ref)
+	}
+	tmpDir, err := os.MkdirTemp("", "dsplay-share-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	if err := writeFiles(tmpDir, files); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	return tmpDir, nil
+}
+
+// readDir returns the files in dir to publish, leaving out git metadata,
+// gist.LocalOnlyFiles and files matching exclude, and reports each to
+// progress if it isn't nil.
+func readDir(dir string, exclude []string, progress func(f gist.File, done, total int)) (map[string]string, error) {
+	list, err := gist.ListFiles(dir, exclude)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(list))
+	for i, f := range list {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, err
+		}
+		files[f.Path] = string(data)
+		if progress != nil {
+			progress(f, i+1, len(list))
+		}
+	}
+	return files, nil
+}
+
+// writeFiles writes files under dir. Shared playgrounds are untrusted, so
+// paths that would land outside dir are refused.
+func writeFiles(dir string, files map[string]string) error {
+	for rel, content := range files {
+		if err := gist.ValidatePath(rel); err != nil {
+			return fmt.Errorf("refusing file: %w", err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("creating dir for %s: %w", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", rel, err)
+		}
+	}
+	return nil
+}
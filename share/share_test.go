@@ -0,0 +1,155 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	for ref, want := range map[string]string{
+		"https://gist.github.com/you/abc123":          GitHub,
+		"https://gitlab.com/-/snippets/42":            GitLab,
+		"https://git.corp/team/demos/-/snippets/7":    GitLab,
+		"git@github.com:team/demos.git":               Git,
+		"https://git.example.com/team/demos.git#chat": Git,
+		"git+https://example.com/demos":               Git,
+		"./my-playground":                             "",
+		"https://example.com/page":                    "",
+	} {
+		if got := Detect(ref); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func writePlayground(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := writeFiles(dir, files); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGitLabRoundTrip(t *testing.T) {
+	stored := map[string]string{}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v4/snippets":
+			if r.Header.Get("PRIVATE-TOKEN") != "glpat" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var body struct {
+				Files []snippetFile `json:"files"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			for _, f := range body.Files {
+				stored[f.Path] = f.Content
+			}
+			fmt.Fprintf(w, `{"id":7,"web_url":%q}`, srv.URL+"/-/snippets/7")
+		case r.URL.Path == "/api/v4/snippets/7":
+			var s snippet
+			for name := range stored {
+				s.Files = append(s.Files, snippetRaw{name, srv.URL + "/-/snippets/7/raw/main/" + name})
+			}
+			json.NewEncoder(w).Encode(s)
+		default:
+			name := filepath.Base(r.URL.Path)
+			content, ok := stored[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(content))
+		}
+	}))
+	defer srv.Close()
+
+	dir := writePlayground(t, map[string]string{"index.html": "<h1>Home</h1>", "todos/index.html": "<ul></ul>", "dsplay.yaml": "secret: x"})
+	gl := GitLabSnippets{BaseURL: srv.URL, Token: "glpat"}
+	ref, err := gl.Save(context.Background(), dir, SaveOptions{Description: "Demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stored["todos__index.html"]; !ok || len(stored) != 2 {
+		t.Errorf("snippet files = %v, want index.html and todos__index.html", stored)
+	}
+	files, err := GitLabSnippets{}.Load(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files["todos/index.html"] != "<ul></ul>" || files["index.html"] != "<h1>Home</h1>" {
+		t.Errorf("loaded %v", files)
+	}
+}
+
+func TestGitRemoteRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	remote := filepath.Join(t.TempDir(), "demos.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	g := GitRemote{URL: remote, Branch: "chat"}
+
+	first := writePlayground(t, map[string]string{"index.html": "v1", "old/index.html": "gone soon"})
+	if _, err := g.Save(ctx, first, SaveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	second := writePlayground(t, map[string]string{"index.html": "v2", "todos/index.html": "<ul></ul>"})
+	ref, err := g.Save(ctx, second, SaveOptions{Description: "Second version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != remote+"#chat" {
+		t.Errorf("ref = %q, want %q", ref, remote+"#chat")
+	}
+
+	dir, err := LoadToTempDir(ctx, GitRemote{}, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if got, _ := os.ReadFile(filepath.Join(dir, "index.html")); string(got) != "v2" {
+		t.Errorf("index.html = %q, want v2", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old", "index.html")); err == nil {
+		t.Error("file removed from the playground is still in the repository")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "todos", "index.html")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShorten(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("url"); got != "https://gist.github.com/you/abc?x=1" {
+			http.Error(w, "Error: bad url "+got, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "https://is.gd/abc")
+	}))
+	defer srv.Close()
+
+	short, err := Shorten(context.Background(), srv.URL+"/create.php?format=simple&url=%s", "https://gist.github.com/you/abc?x=1")
+	if err != nil || short != "https://is.gd/abc" {
+		t.Errorf("Shorten = %q, %v; want https://is.gd/abc", short, err)
+	}
+	if _, err := Shorten(context.Background(), srv.URL+"/create.php?url=%s", "https://example.com/"); err == nil {
+		t.Error("Shorten succeeded on an error response")
+	}
+	if _, err := Shorten(context.Background(), srv.URL, "https://example.com/"); err == nil {
+		t.Error("Shorten accepted a service URL with no place for the link")
+	}
+}
@@ -0,0 +1,45 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultShortener registers short links with is.gd, which needs no
+// account. Shorten puts the URL to shorten in place of the %s.
+const DefaultShortener = "https://is.gd/create.php?format=simple&url=%s"
+
+// Shorten registers a short link to longURL with a link shortener. service
+// is a URL with a %s for the query-escaped long URL, answered with the
+// short link as plain text, as is.gd and similar services do.
+func Shorten(ctx context.Context, service, longURL string) (string, error) {
+	if !strings.Contains(service, "%s") {
+		return "", fmt.Errorf("shortener %q has no %%s for the URL", service)
+	}
+	endpoint := strings.Replace(service, "%s", url.QueryEscape(longURL), 1)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("shortening %s: %w", longURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if err != nil {
+		return "", fmt.Errorf("shortening %s: %w", longURL, err)
+	}
+	short := strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("shortening %s: %s: %s", longURL, resp.Status, short)
+	}
+	if u, err := url.Parse(short); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("shortening %s: the service answered %q, not a link", longURL, short)
+	}
+	return short, nil
+}
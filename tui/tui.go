@@ -0,0 +1,214 @@
+// Package tui draws a live, full-screen terminal dashboard of a running
+// playground: open streams, counters, recent requests, NATS traffic and log
+// output. It is a bubbletea program styled with lipgloss.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// State is one frame's worth of dashboard data.
+type State struct {
+	Title    string // e.g. the URL being served
+	Streams  []Stream
+	Counters []Counter
+	Requests []Request // newest last
+	NATS     []Message // newest last
+	Logs     []string  // newest last
+}
+
+// Stream is an open SSE or WebSocket connection.
+type Stream struct {
+	Route     string
+	Transport string
+	Session   string
+	Started   time.Time
+	Messages  int64
+}
+
+// Counter is a labelled number shown in the counters panel.
+type Counter struct {
+	Label string
+	Value int64
+}
+
+// Request is a completed HTTP request.
+type Request struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Status   int
+	Duration time.Duration
+}
+
+// Message is a NATS message published by the playground.
+type Message struct {
+	Time    time.Time
+	Subject string
+	Bytes   int
+}
+
+var (
+	boldStyle  = lipgloss.NewStyle().Bold(true)
+	dimStyle   = lipgloss.NewStyle().Faint(true)
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(1))
+	warnStyle  = lipgloss.NewStyle().Foreground(lipgloss.ANSIColor(3))
+)
+
+// Run shows the dashboard on the terminal, refreshing it from state every
+// interval, until ctx is done or q or Ctrl+C is pressed.
+func Run(ctx context.Context, interval time.Duration, state func() State) error {
+	m := model{state: state, interval: interval, s: state(), now: time.Now()}
+	_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// model is the bubbletea model behind Run.
+type model struct {
+	state    func() State
+	interval time.Duration
+
+	s             State
+	now           time.Time
+	width, height int
+}
+
+type tickMsg time.Time
+
+func (m model) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m model) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	case tickMsg:
+		m.s, m.now = m.state(), time.Time(msg)
+		return m, m.tick()
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	return Render(m.s, m.width, m.height, m.now)
+}
+
+// Render lays out s in a width×height terminal as of now. Lines never
+// exceed width columns; panels share the height, the logs getting whatever
+// is left.
+func Render(s State, width, height int, now time.Time) string {
+	width = max(width, 20)
+	height = max(height, 10)
+
+	var lines []string
+	add := func(line string) { lines = append(lines, line) }
+
+	header := fmt.Sprintf(" dsplay  %s", s.Title)
+	clock := now.Format("15:04:05") + " "
+	add(boldStyle.Render(pad(header, width-len(clock))) + clock)
+	counters := make([]string, 0, len(s.Counters))
+	for _, c := range s.Counters {
+		counters = append(counters, fmt.Sprintf("%s %d", c.Label, c.Value))
+	}
+	add(" " + fit(strings.Join(counters, " · "), width-1))
+
+	// Budget the panels: streams get up to a quarter of the screen, the
+	// rest is split between requests, NATS and logs
+	body := height - len(lines) - 4 // four panel titles
+	streamRows := min(len(s.Streams), max(body/4, 1))
+	rest := body - streamRows
+	requestRows := rest * 2 / 5
+	natsRows := rest / 4
+	logRows := rest - requestRows - natsRows
+
+	add(title(fmt.Sprintf("Streams (%d)", len(s.Streams)), width))
+	for _, st := range s.Streams[:streamRows] {
+		age := now.Sub(st.Started).Truncate(time.Second)
+		add(fit(fmt.Sprintf(" %-4s %-24s %8s %6d msgs  %s", st.Transport, st.Route, age, st.Messages, st.Session), width))
+	}
+	if len(s.Streams) == 0 {
+		add(dimStyle.Render(" none"))
+		logRows--
+	}
+
+	add(title("Requests", width))
+	for _, r := range tail(s.Requests, requestRows) {
+		status := fmt.Sprintf("%d", r.Status)
+		switch {
+		case r.Status >= 500:
+			status = errorStyle.Render(status)
+		case r.Status >= 400:
+			status = warnStyle.Render(status)
+		}
+		add(fit(fmt.Sprintf(" %s %-6s %s %s %s", r.Time.Format("15:04:05"), r.Method, status, fmtDuration(r.Duration), r.Path), width))
+	}
+
+	add(title("NATS", width))
+	for _, m := range tail(s.NATS, natsRows) {
+		add(fit(fmt.Sprintf(" %s %5dB %s", m.Time.Format("15:04:05"), m.Bytes, m.Subject), width))
+	}
+
+	add(title("Log", width))
+	for _, l := range tail(s.Logs, logRows) {
+		add(fit(" "+l, width))
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func title(name string, width int) string {
+	return dimStyle.Render("── ") + boldStyle.Render(name) + dimStyle.Render(" "+strings.Repeat("─", max(width-len(name)-4, 0)))
+}
+
+// tail returns up to the last n items of list.
+func tail[T any](list []T, n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	return list[max(len(list)-n, 0):]
+}
+
+// fit truncates text, styled or not, to width columns.
+func fit(s string, width int) string {
+	return ansi.Truncate(s, width, "…")
+}
+
+// pad right-pads (or truncates) s to width columns.
+func pad(s string, width int) string {
+	s = fit(s, width)
+	return s + strings.Repeat(" ", max(width-ansi.StringWidth(s), 0))
+}
+
+func fmtDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%5.1fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%4dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%4dµs", d.Microseconds())
+}
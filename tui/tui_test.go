@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;?]*[a-zA-Z]")
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	s := State{
+		Title:    "http://localhost:8080/",
+		Streams:  []Stream{{Route: "/clock/", Transport: "sse", Session: "s-1", Started: now.Add(-90 * time.Second), Messages: 42}},
+		Counters: []Counter{{"hits", 7}, {"streams", 1}},
+		Requests: []Request{
+			{Time: now, Method: "GET", Path: "/old/", Status: 200, Duration: time.Millisecond},
+			{Time: now, Method: "POST", Path: "/todos/" + strings.Repeat("x", 200), Status: 500, Duration: 2 * time.Second},
+		},
+		NATS: []Message{{Time: now, Subject: "dspen.session.s-1", Bytes: 18}},
+		Logs: []string{"first", "second"},
+	}
+
+	tests := []struct {
+		width, height int
+	}{
+		{80, 24},
+		{40, 12},
+		{120, 50},
+	}
+	for _, tt := range tests {
+		out := Render(s, tt.width, tt.height, now)
+		lines := strings.Split(out, "\n")
+		if len(lines) > tt.height {
+			t.Errorf("%dx%d: %d lines, want at most %d", tt.width, tt.height, len(lines), tt.height)
+		}
+		for _, line := range lines {
+			if n := utf8.RuneCountInString(escapes.ReplaceAllString(line, "")); n > tt.width {
+				t.Errorf("%dx%d: line is %d columns wide: %q", tt.width, tt.height, n, line)
+			}
+		}
+		plain := escapes.ReplaceAllString(out, "")
+		for _, want := range []string{"hits 7 · streams 1", "/clock/", "1m30s", "15:04:05"} {
+			if !strings.Contains(plain, want) {
+				t.Errorf("%dx%d: output lacks %q:\n%s", tt.width, tt.height, want, plain)
+			}
+		}
+	}
+
+	plain := escapes.ReplaceAllString(Render(s, 80, 40, now), "")
+	for _, want := range []string{"dspen.session.s-1", "second", "POST"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("output lacks %q:\n%s", want, plain)
+		}
+	}
+}